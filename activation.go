@@ -0,0 +1,201 @@
+package plugins
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ActivationOnEvent 清单中的按需激活模式：插件在第一个匹配的事件到达时才被打开和初始化
+const ActivationOnEvent = "on_event"
+
+// dormantPlugin 尚未打开的插件，元数据和订阅信息来自清单
+type dormantPlugin struct {
+	manifest *PluginManifest
+}
+
+func (d *dormantPlugin) Name() string                            { return d.manifest.Name }
+func (d *dormantPlugin) Version() string                         { return d.manifest.Version }
+func (d *dormantPlugin) Description() string                     { return d.manifest.Description }
+func (d *dormantPlugin) DefaultConfig() map[string]interface{}   { return nil }
+func (d *dormantPlugin) SetConfig(config map[string]interface{}) {}
+func (d *dormantPlugin) Init() error                             { return nil }
+func (d *dormantPlugin) Close() error                            { return nil }
+func (d *dormantPlugin) InterestedAPIs() []string                { return d.manifest.APIs }
+func (d *dormantPlugin) InterestedEvents() []EventType           { return d.manifest.Events }
+func (d *dormantPlugin) OnAPIEvent(ctx *gin.Context, event EventType, path string, statusCode int, requestBody interface{}, responseBody interface{}) error {
+	return nil
+}
+
+// registerDormant 按清单登记按需激活的插件而不打开插件文件，调用方需持有m.mutex
+func (m *Manager) registerDormant(ctx context.Context, pluginPath string, manifest *PluginManifest) (*PluginInfo, error) {
+	info, err := m.registerIndexed(pluginPath, manifest.Name, manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	m.logger.Printf("已登记按需激活插件: %s v%s", info.Name, info.Version)
+	if info.Enabled {
+		m.startAwaitingPlugins(ctx)
+	}
+	return info, nil
+}
+
+// registerIndexed 登记插件而不打开插件文件，元数据来自清单（可以为nil），配置和状态来自存储，调用方需持有m.mutex
+func (m *Manager) registerIndexed(pluginPath string, name string, manifest *PluginManifest) (*PluginInfo, error) {
+	if err := m.checkPolicy(name, pluginPath); err != nil {
+		return nil, err
+	}
+	var version string
+	if manifest != nil {
+		version = manifest.Version
+	}
+	if err := m.resolveNameConflict(name, version, pluginPath); err != nil {
+		return nil, err
+	}
+
+	stub := &PluginManifest{Name: name}
+	if manifest != nil {
+		stub = manifest
+	}
+
+	pluginDB, _ := m.store().GetPlugin(pluginPath)
+
+	var config map[string]interface{}
+	var reason string
+	if pluginDB != nil {
+		if pluginDB.Config != "" {
+			if err := json.Unmarshal([]byte(pluginDB.Config), &config); err != nil {
+				return nil, fmt.Errorf("解析插件配置失败: %v", err)
+			}
+		}
+		reason = pluginDB.StateReason
+	}
+	state, policyReason := startupState(m.startupPolicy, pluginDB)
+	if policyReason != "" {
+		reason = policyReason
+	}
+
+	info := &PluginInfo{
+		Name:        name,
+		Version:     stub.Version,
+		Description: stub.Description,
+		FilePath:    pluginPath,
+		Enabled:     state == StateEnabled,
+		State:       state,
+		StateReason: reason,
+		Config:      config,
+		Plugin:      &dormantPlugin{manifest: stub},
+		Manifest:    manifest,
+		Priority:    m.pluginPriority(name, manifest, nil),
+		dormant:     true,
+	}
+	info.applyManifestDetails()
+	m.awaitDependencies(info)
+	m.registerState(info, false)
+	m.plugins[info.Name] = info
+	return info, nil
+}
+
+// activatePlugin 打开并初始化按需激活的插件，替换清单占位实例
+func (m *Manager) activatePlugin(name string) (*PluginInfo, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	info, exists := m.plugins[name]
+	if !exists {
+		return nil, fmt.Errorf("插件不存在: %s", name)
+	}
+	if !info.dormant {
+		return info, nil
+	}
+
+	opened, err := m.openPlugin(info.FilePath)
+	if err != nil {
+		_ = m.setState(info, StateQuarantined, fmt.Sprintf("按需激活失败: %v", err))
+		return nil, err
+	}
+	instance := opened.instance
+	if err := info.Manifest.validateInstance(instance); err != nil {
+		_ = m.setState(info, StateQuarantined, fmt.Sprintf("插件与清单不一致: %v", err))
+		return nil, err
+	}
+	if err := m.validateSubscriptions(instance); err != nil {
+		_ = m.setState(info, StateQuarantined, err.Error())
+		return nil, err
+	}
+
+	config := info.Config
+	if config == nil {
+		config = instance.DefaultConfig()
+	}
+	m.deliverConfig(info.Name, instance, info.Manifest, config)
+
+	upgradeCtx, cancel := m.initContextLocked(context.Background())
+	initErr := m.runUpgrade(upgradeCtx, name, instance)
+	cancel()
+	if initErr == nil {
+		runWithPluginLabels(name, func() {
+			initErr = instance.Init()
+		})
+	}
+	if warmer, ok := instance.(Warmer); ok && initErr == nil {
+		if err := runWarmup(context.Background(), m.warmupTimeout, name, warmer); err != nil {
+			_ = instance.Close()
+			initErr = fmt.Errorf("预热失败: %v", err)
+		}
+	}
+	if initErr != nil {
+		_ = m.setState(info, StateInitFailed, fmt.Sprintf("按需激活失败: %v", initErr))
+		return nil, initErr
+	}
+
+	info.Plugin = instance
+	info.Version = instance.Version()
+	info.Description = instance.Description()
+	info.Config = config
+	info.APIVersion = opened.apiVersion
+	info.Manifest = opened.manifest
+	info.Build = opened.build
+	info.Priority = m.pluginPriority(name, opened.manifest, instance)
+	info.dormant = false
+	info.checkConfigDrift()
+
+	m.logger.Printf("已按需激活插件: %s v%s", info.Name, info.Version)
+	return info, nil
+}
+
+// registerPlaceholder 登记无法打开的插件（签名校验失败、与宿主不兼容等），不打开插件文件，
+// 元数据来自清单或文件名，便于在界面中展示原因。该状态不写入存储，问题修复后重新加载即可恢复，调用方需持有m.mutex
+func (m *Manager) registerPlaceholder(pluginPath string, manifest *PluginManifest, state PluginState, reason error) (*PluginInfo, error) {
+	placeholder := &PluginManifest{Name: artifactName(pluginPath)}
+	if manifest != nil && manifest.Name != "" {
+		placeholder.Name = manifest.Name
+		placeholder.Version = manifest.Version
+		placeholder.Description = manifest.Description
+	}
+
+	if err := m.resolveNameConflict(placeholder.Name, placeholder.Version, pluginPath); err != nil {
+		return nil, err
+	}
+
+	info := &PluginInfo{
+		Name:        placeholder.Name,
+		Version:     placeholder.Version,
+		Description: placeholder.Description,
+		FilePath:    pluginPath,
+		State:       state,
+		StateReason: reason.Error(),
+		Plugin:      &dormantPlugin{manifest: placeholder},
+		Manifest:    manifest,
+		Priority:    m.pluginPriority(placeholder.Name, manifest, nil),
+		dormant:     true,
+	}
+	info.applyManifestDetails()
+	m.registerState(info, false)
+	m.plugins[info.Name] = info
+
+	return info, fmt.Errorf("插件 %s 无法加载（%s）: %v", info.Name, state, reason)
+}
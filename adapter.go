@@ -0,0 +1,74 @@
+package plugins
+
+import (
+	"fmt"
+	"plugin"
+	"sort"
+	"sync"
+)
+
+// PluginAdapter 将插件导出的符号转换为当前版本的Plugin接口；
+// 新版本接口的适配器负责把事件和生命周期调用翻译为对应版本的调用
+type PluginAdapter func(sym plugin.Symbol) (Plugin, error)
+
+// adapterEntry 适配器登记项
+type adapterEntry struct {
+	symbol  string
+	version int
+	adapt   PluginAdapter
+}
+
+var (
+	adapters     = []adapterEntry{{symbol: "GetPlugin", version: 1, adapt: adaptV1}}
+	adapterMutex sync.RWMutex
+)
+
+// RegisterAdapter 登记插件接口适配器，symbol为插件导出的工厂符号名，version为接口版本；
+// 加载时按版本从高到低查找插件导出的符号
+func RegisterAdapter(symbol string, version int, adapt PluginAdapter) {
+	adapterMutex.Lock()
+	defer adapterMutex.Unlock()
+
+	for i, entry := range adapters {
+		if entry.symbol == symbol {
+			adapters[i] = adapterEntry{symbol: symbol, version: version, adapt: adapt}
+			return
+		}
+	}
+	adapters = append(adapters, adapterEntry{symbol: symbol, version: version, adapt: adapt})
+
+	sort.SliceStable(adapters, func(i, j int) bool {
+		return adapters[i].version > adapters[j].version
+	})
+}
+
+// adaptV1 适配v1接口：GetPlugin() Plugin
+func adaptV1(sym plugin.Symbol) (Plugin, error) {
+	getPlugin, ok := sym.(func() Plugin)
+	if !ok {
+		return nil, fmt.Errorf("GetPlugin函数签名不正确")
+	}
+	return getPlugin(), nil
+}
+
+// lookupPlugin 按已登记的适配器从插件中获取实例，返回接口版本
+func lookupPlugin(p *plugin.Plugin) (Plugin, int, error) {
+	adapterMutex.RLock()
+	entries := append([]adapterEntry(nil), adapters...)
+	adapterMutex.RUnlock()
+
+	for _, entry := range entries {
+		sym, err := p.Lookup(entry.symbol)
+		if err != nil {
+			continue
+		}
+
+		instance, err := entry.adapt(sym)
+		if err != nil {
+			return nil, 0, err
+		}
+		return instance, entry.version, nil
+	}
+
+	return nil, 0, fmt.Errorf("找不到GetPlugin函数")
+}
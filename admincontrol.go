@@ -0,0 +1,258 @@
+package plugins
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+var (
+	// ErrAdminControlNotGranted 插件未获得管理控制授权时AdminAPI返回的错误
+	ErrAdminControlNotGranted = errors.New("插件未获得管理控制授权")
+
+	// ErrAdminUnauthorized 操作者不在授权列表中时AdminAPI返回的错误
+	ErrAdminUnauthorized = errors.New("操作者未获授权")
+)
+
+// AdminPluginStatus 通过管理控制渠道查看的插件状态
+type AdminPluginStatus struct {
+	Name    string      `json:"name"`
+	Version string      `json:"version"`
+	State   PluginState `json:"state"`
+	Reason  string      `json:"reason,omitempty"`
+	Enabled bool        `json:"enabled"`
+}
+
+// AdminAPI 宿主向管理控制插件（如Telegram机器人）提供的受限管理接口。
+// 每次调用都需传入远程操作者的标识（如Telegram用户ID），只有宿主授权的操作者可以调用；
+// 不能操作插件自身和其他管理控制插件，避免管理渠道被误关闭。不要在Init中调用
+type AdminAPI interface {
+	// ListPlugins 列出所有插件的状态，按名称排序
+	ListPlugins(operator string) ([]AdminPluginStatus, error)
+
+	// PluginStatus 获取单个插件的状态
+	PluginStatus(operator, name string) (AdminPluginStatus, error)
+
+	// EnablePlugin 启用插件
+	EnablePlugin(operator, name string) error
+
+	// DisablePlugin 禁用插件
+	DisablePlugin(operator, name string) error
+}
+
+// AdminController 管理控制能力：插件作为远程管理渠道调用管理器操作，
+// 需在清单中声明admin权限并由宿主通过GrantAdminControl授权，加载时注入AdminAPI
+type AdminController interface {
+	SetAdminAPI(api AdminAPI)
+}
+
+// adminGrants 宿主授予的管理控制权限，只保存在内存中，宿主需在启动时设置
+type adminGrants struct {
+	operators map[string]map[string]bool // 插件名称 -> 授权的操作者
+	mutex     sync.RWMutex
+}
+
+func newAdminGrants() *adminGrants {
+	return &adminGrants{operators: make(map[string]map[string]bool)}
+}
+
+// GrantAdminControl 授权插件作为管理控制渠道，只有operators中的操作者可以通过该插件执行管理操作；
+// 插件还需在清单中声明admin权限。重复调用会替换该插件的操作者列表
+func (m *Manager) GrantAdminControl(plugin string, operators ...string) error {
+	if err := m.checkWritable(); err != nil {
+		return err
+	}
+
+	set := make(map[string]bool, len(operators))
+	for _, operator := range operators {
+		if operator != "" {
+			set[operator] = true
+		}
+	}
+	if len(set) == 0 {
+		return fmt.Errorf("至少需要授权一个操作者")
+	}
+
+	m.adminGrants.mutex.Lock()
+	defer m.adminGrants.mutex.Unlock()
+
+	m.adminGrants.operators[plugin] = set
+	m.logger.Printf("已授权插件 %s 作为管理控制渠道，操作者数量: %d", plugin, len(set))
+	return nil
+}
+
+// RevokeAdminControl 撤销插件的管理控制授权，立即生效；维护模式下返回ErrMaintenanceMode（此前无返回值）
+func (m *Manager) RevokeAdminControl(plugin string) error {
+	if err := m.checkWritable(); err != nil {
+		return err
+	}
+
+	m.adminGrants.mutex.Lock()
+	defer m.adminGrants.mutex.Unlock()
+
+	delete(m.adminGrants.operators, plugin)
+	return nil
+}
+
+// AdminControlGrants 获取管理控制授权，键为插件名称，值为授权的操作者
+func (m *Manager) AdminControlGrants() map[string][]string {
+	m.adminGrants.mutex.RLock()
+	defer m.adminGrants.mutex.RUnlock()
+
+	result := make(map[string][]string, len(m.adminGrants.operators))
+	for plugin, set := range m.adminGrants.operators {
+		operators := make([]string, 0, len(set))
+		for operator := range set {
+			operators = append(operators, operator)
+		}
+		sort.Strings(operators)
+		result[plugin] = operators
+	}
+	return result
+}
+
+// adminHost 单个管理控制插件的AdminAPI实现
+type adminHost struct {
+	m    *Manager
+	name string
+}
+
+// injectAdminAPI 向实现了AdminController的插件注入AdminAPI，授权在每次调用时检查
+func (m *Manager) injectAdminAPI(p Plugin) {
+	if controller, ok := p.(AdminController); ok {
+		controller.SetAdminAPI(&adminHost{m: m, name: p.Name()})
+	}
+}
+
+// authorize 检查插件的授权、清单权限和运行状态以及操作者身份
+func (h *adminHost) authorize(operator string) error {
+	h.m.adminGrants.mutex.RLock()
+	operators, granted := h.m.adminGrants.operators[h.name]
+	allowed := operators[operator]
+	h.m.adminGrants.mutex.RUnlock()
+
+	if !granted {
+		return ErrAdminControlNotGranted
+	}
+
+	h.m.mutex.RLock()
+	info, exists := h.m.plugins[h.name]
+	enabled := exists && info.Enabled
+	declared := exists && hasPermission(info.Manifest, PermissionAdmin)
+	h.m.mutex.RUnlock()
+
+	if !enabled {
+		return fmt.Errorf("%w: 插件 %s 未启用", ErrAdminControlNotGranted, h.name)
+	}
+	if !declared {
+		return fmt.Errorf("%w: 插件 %s 的清单未声明 %s 权限", ErrAdminControlNotGranted, h.name, PermissionAdmin)
+	}
+	if !allowed {
+		h.m.logger.Printf("管理控制插件 %s 拒绝了未授权的操作者: %s", h.name, operator)
+		return ErrAdminUnauthorized
+	}
+	return nil
+}
+
+// checkTarget 管理控制插件不能操作自身和其他管理控制插件
+func (h *adminHost) checkTarget(name string) error {
+	if name == h.name {
+		return fmt.Errorf("不能通过管理控制渠道操作插件自身")
+	}
+
+	h.m.mutex.RLock()
+	info, exists := h.m.plugins[name]
+	isController := false
+	if exists {
+		_, isController = info.Plugin.(AdminController)
+	}
+	h.m.mutex.RUnlock()
+
+	if !exists {
+		return fmt.Errorf("插件不存在: %s", name)
+	}
+	if isController {
+		return fmt.Errorf("不能通过管理控制渠道操作其他管理控制插件: %s", name)
+	}
+	return nil
+}
+
+func (h *adminHost) ListPlugins(operator string) ([]AdminPluginStatus, error) {
+	if err := h.authorize(operator); err != nil {
+		return nil, err
+	}
+
+	h.m.mutex.RLock()
+	result := make([]AdminPluginStatus, 0, len(h.m.plugins))
+	for _, info := range h.m.plugins {
+		result = append(result, adminStatus(info))
+	}
+	h.m.mutex.RUnlock()
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result, nil
+}
+
+func (h *adminHost) PluginStatus(operator, name string) (AdminPluginStatus, error) {
+	if err := h.authorize(operator); err != nil {
+		return AdminPluginStatus{}, err
+	}
+
+	h.m.mutex.RLock()
+	defer h.m.mutex.RUnlock()
+
+	info, exists := h.m.plugins[name]
+	if !exists {
+		return AdminPluginStatus{}, fmt.Errorf("插件不存在: %s", name)
+	}
+	return adminStatus(info), nil
+}
+
+func (h *adminHost) EnablePlugin(operator, name string) error {
+	if err := h.authorize(operator); err != nil {
+		return err
+	}
+	if err := h.checkTarget(name); err != nil {
+		return err
+	}
+
+	h.m.logger.Printf("操作者 %s 通过管理控制插件 %s 启用插件 %s", operator, h.name, name)
+	return h.m.EnablePlugin(name)
+}
+
+func (h *adminHost) DisablePlugin(operator, name string) error {
+	if err := h.authorize(operator); err != nil {
+		return err
+	}
+	if err := h.checkTarget(name); err != nil {
+		return err
+	}
+
+	h.m.logger.Printf("操作者 %s 通过管理控制插件 %s 禁用插件 %s", operator, h.name, name)
+	return h.m.DisablePlugin(name)
+}
+
+// adminStatus 生成插件状态，调用方需持有m.mutex
+func adminStatus(info *PluginInfo) AdminPluginStatus {
+	return AdminPluginStatus{
+		Name:    info.Name,
+		Version: info.Version,
+		State:   info.State,
+		Reason:  info.StateReason,
+		Enabled: info.Enabled,
+	}
+}
+
+// hasPermission 判断清单是否声明了权限
+func hasPermission(manifest *PluginManifest, permission string) bool {
+	if manifest == nil {
+		return false
+	}
+	for _, p := range manifest.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,147 @@
+package plugins
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// maxAlertKeyLength 聚合错误时错误信息参与比较的最大长度
+const maxAlertKeyLength = 256
+
+// alertDigitsPattern 错误信息中的数字（如ID、端口、耗时），聚合时忽略
+var alertDigitsPattern = regexp.MustCompile(`[0-9]+`)
+
+// AlertPolicy 插件错误告警策略
+type AlertPolicy struct {
+	// DedupWindow 去重窗口，同一插件的同一错误在窗口内只通知一次
+	DedupWindow time.Duration
+
+	// EscalationThresholds 升级阈值（升序），窗口内错误次数达到阈值时立即升级通知级别
+	EscalationThresholds []int
+}
+
+// DefaultAlertPolicy 默认告警策略
+var DefaultAlertPolicy = AlertPolicy{
+	DedupWindow:          10 * time.Minute,
+	EscalationThresholds: []int{10, 100},
+}
+
+// alertEntry 同一插件同一错误的聚合记录
+type alertEntry struct {
+	plugin    string
+	message   string
+	count     int // 当前窗口内的错误次数
+	level     int // 当前窗口内已达到的升级级别
+	firstSeen time.Time
+	notified  time.Time // 上次通知时间
+}
+
+// errorAlerter 插件错误聚合告警器
+type errorAlerter struct {
+	policy    AlertPolicy
+	entries   map[string]*alertEntry
+	lastSweep time.Time // 上次清理过期记录的时间
+	mutex     sync.Mutex
+}
+
+func newErrorAlerter(policy AlertPolicy) *errorAlerter {
+	return &errorAlerter{
+		policy:  policy,
+		entries: make(map[string]*alertEntry),
+	}
+}
+
+// SetAlertPolicy 设置插件错误告警策略
+func (m *Manager) SetAlertPolicy(policy AlertPolicy) {
+	m.alerts.mutex.Lock()
+	defer m.alerts.mutex.Unlock()
+
+	m.alerts.policy = policy
+}
+
+// reportPluginError 记录插件错误，按去重窗口和升级阈值聚合后通知管理员
+func (m *Manager) reportPluginError(name string, err error) {
+	n, ok := m.alerts.record(name, err.Error())
+	if !ok {
+		return
+	}
+
+	m.logger.Printf("%s", n.Body)
+	m.notifyAdmins(name, n)
+}
+
+// record 记录一次错误，需要发送通知时返回通知内容
+func (a *errorAlerter) record(name, message string) (Notification, bool) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	now := time.Now()
+	a.sweep(now)
+	key := name + "\x00" + alertKey(message)
+
+	entry, exists := a.entries[key]
+	if !exists || now.Sub(entry.firstSeen) > a.policy.DedupWindow {
+		entry = &alertEntry{plugin: name, message: message, firstSeen: now}
+		a.entries[key] = entry
+	}
+	entry.count++
+
+	// 达到升级阈值时立即通知
+	level := 0
+	for i, threshold := range a.policy.EscalationThresholds {
+		if entry.count >= threshold {
+			level = i + 1
+		}
+	}
+	escalated := level > entry.level
+	entry.level = level
+
+	if !entry.notified.IsZero() && !escalated && now.Sub(entry.notified) < a.policy.DedupWindow {
+		return Notification{}, false
+	}
+	entry.notified = now
+
+	severity := SeverityWarning
+	switch {
+	case level >= 2:
+		severity = SeverityCritical
+	case level == 1:
+		severity = SeverityError
+	}
+
+	return Notification{
+		Subject:  fmt.Sprintf("插件 %s 处理事件失败", name),
+		Body:     fmt.Sprintf("插件 %s 自 %s 起已失败 %d 次: %s", name, entry.firstSeen.Format(time.DateTime), entry.count, message),
+		Severity: severity,
+	}, true
+}
+
+// sweep 清理去重窗口已过且不再需要通知的记录，每个窗口最多执行一次
+func (a *errorAlerter) sweep(now time.Time) {
+	if now.Sub(a.lastSweep) < a.policy.DedupWindow {
+		return
+	}
+	a.lastSweep = now
+
+	for key, entry := range a.entries {
+		if now.Sub(entry.firstSeen) > a.policy.DedupWindow && now.Sub(entry.notified) >= a.policy.DedupWindow {
+			delete(a.entries, key)
+		}
+	}
+}
+
+// alertKey 错误信息的聚合键：忽略其中的数字并截断，使只有ID、耗时等不同的错误聚合到同一条记录
+func alertKey(message string) string {
+	key := alertDigitsPattern.ReplaceAllString(message, "#")
+	if len(key) <= maxAlertKeyLength {
+		return key
+	}
+	key = key[:maxAlertKeyLength]
+	for !utf8.ValidString(key) {
+		key = key[:len(key)-1]
+	}
+	return key
+}
@@ -0,0 +1,296 @@
+package plugins
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+const (
+	// archiveMetaFile 归档目录中的插件信息文件
+	archiveMetaFile = "archive.json"
+
+	// archivePackageDir 归档目录中存放插件包目录的子目录
+	archivePackageDir = "package"
+
+	// defaultArchiveRetention 卸载插件的默认保留时间
+	defaultArchiveRetention = 30 * 24 * time.Hour
+)
+
+// ArchivedPlugin 已卸载并归档的插件
+type ArchivedPlugin struct {
+	Name       string                 `json:"name"`
+	Version    string                 `json:"version"`
+	FileName   string                 `json:"file_name"`
+	Package    string                 `json:"package,omitempty"` // 通过插件包安装时为插件目录中的插件包目录名
+	Enabled    bool                   `json:"enabled"`
+	Config     map[string]interface{} `json:"config"`
+	Data       map[string]string      `json:"data,omitempty"` // 插件KV数据，存储支持时导出
+	ArchivedAt time.Time              `json:"archived_at"`
+}
+
+// SetArchive 设置卸载插件的归档目录和保留时间，dir为空时使用插件目录旁的 <插件目录>.archive，
+// retention<=0时使用默认的30天
+func (m *Manager) SetArchive(dir string, retention time.Duration) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if dir != "" {
+		dir = filepath.Clean(dir)
+	}
+	m.archiveDir = dir
+	m.archiveRetention = retention
+}
+
+// archiveRoot 归档目录，放在插件目录之外，避免被加载和监听，调用方需持有m.mutex
+func (m *Manager) archiveRoot() string {
+	if m.archiveDir != "" {
+		return m.archiveDir
+	}
+	return filepath.Clean(m.pluginDir) + ".archive"
+}
+
+// archiveTTL 归档保留时间，调用方需持有m.mutex
+func (m *Manager) archiveTTL() time.Duration {
+	if m.archiveRetention > 0 {
+		return m.archiveRetention
+	}
+	return defaultArchiveRetention
+}
+
+// archivePlugin 将插件文件、清单、签名及配置和数据移入归档目录，同名插件的旧归档会被替换，调用方需持有m.mutex
+func (m *Manager) archivePlugin(info *PluginInfo) error {
+	dir := filepath.Join(m.archiveRoot(), info.Name)
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("清理旧归档失败: %v", err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("创建归档目录失败: %v", err)
+	}
+
+	archived := ArchivedPlugin{
+		Name:       info.Name,
+		Version:    info.Version,
+		FileName:   filepath.Base(info.FilePath),
+		Package:    packageDirName(info.FilePath),
+		Enabled:    info.Enabled,
+		Config:     info.Config,
+		ArchivedAt: time.Now(),
+	}
+	if dataStorage, ok := m.store().(PluginDataStorage); ok {
+		data, err := dataStorage.ExportPluginData(info.Name)
+		if err != nil {
+			m.logger.Printf("导出插件 %s 数据失败: %v", info.Name, err)
+		}
+		archived.Data = data
+	}
+
+	meta, err := json.MarshalIndent(archived, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化归档信息失败: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, archiveMetaFile), meta, 0644); err != nil {
+		return fmt.Errorf("写入归档信息失败: %v", err)
+	}
+
+	// 通过插件包安装的插件连同静态资源整个目录一起归档
+	if pkgDir := filepath.Dir(info.FilePath); isPackageDir(pkgDir) {
+		if err := os.Rename(pkgDir, filepath.Join(dir, archivePackageDir)); err != nil {
+			return fmt.Errorf("移动插件包目录失败: %v", err)
+		}
+		return nil
+	}
+
+	for _, src := range pluginPackageFiles(info.FilePath) {
+		if err := moveFile(src, filepath.Join(dir, filepath.Base(src))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ArchivedPlugins 列出归档中可恢复的插件
+func (m *Manager) ArchivedPlugins() ([]ArchivedPlugin, error) {
+	m.mutex.RLock()
+	root := m.archiveRoot()
+	m.mutex.RUnlock()
+
+	entries, err := os.ReadDir(root)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取归档目录失败: %v", err)
+	}
+
+	var result []ArchivedPlugin
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		archived, err := readArchive(filepath.Join(root, entry.Name()))
+		if err != nil {
+			m.logger.Printf("读取归档 %s 失败: %v", entry.Name(), err)
+			continue
+		}
+		result = append(result, *archived)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ArchivedAt.After(result[j].ArchivedAt)
+	})
+	return result, nil
+}
+
+// RestorePlugin 从归档恢复已卸载的插件，恢复其文件、配置、启用状态和数据
+func (m *Manager) RestorePlugin(name string) (*PluginInfo, error) {
+	if err := m.checkWritable(); err != nil {
+		return nil, err
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if _, exists := m.plugins[name]; exists {
+		return nil, fmt.Errorf("插件已存在: %s", name)
+	}
+
+	dir := filepath.Join(m.archiveRoot(), name)
+	archived, err := readArchive(dir)
+	if err != nil {
+		return nil, fmt.Errorf("找不到插件 %s 的归档: %v", name, err)
+	}
+
+	if err := os.MkdirAll(m.pluginDir, 0755); err != nil {
+		return nil, fmt.Errorf("创建插件目录失败: %v", err)
+	}
+	var target string
+	if archived.Package != "" {
+		pkgDir := filepath.Join(m.pluginDir, archived.Package)
+		if _, err := os.Stat(pkgDir); err == nil {
+			return nil, fmt.Errorf("插件包目录已存在: %s", pkgDir)
+		}
+		if err := os.Rename(filepath.Join(dir, archivePackageDir), pkgDir); err != nil {
+			return nil, fmt.Errorf("移动插件包目录失败: %v", err)
+		}
+		target = filepath.Join(pkgDir, archived.FileName)
+	} else {
+		target = filepath.Join(m.pluginDir, archived.FileName)
+		if _, err := os.Stat(target); err == nil {
+			return nil, fmt.Errorf("插件文件已存在: %s", target)
+		}
+		for _, src := range pluginPackageFiles(filepath.Join(dir, archived.FileName)) {
+			if err := moveFile(src, filepath.Join(m.pluginDir, filepath.Base(src))); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := m.saveChecksum(target); err != nil {
+		return nil, err
+	}
+
+	// 恢复存储记录和数据，loadPlugin会据此恢复配置和启用状态
+	if err := m.store().SavePlugin(archived.Name, target, archived.Enabled, archived.Config); err != nil {
+		return nil, fmt.Errorf("恢复插件记录失败: %v", err)
+	}
+	if dataStorage, ok := m.store().(PluginDataStorage); ok && archived.Data != nil {
+		if err := dataStorage.ImportPluginData(archived.Name, archived.Data); err != nil {
+			m.logger.Printf("恢复插件 %s 数据失败: %v", archived.Name, err)
+		}
+	}
+
+	info, err := m.loadPlugin(target)
+	if err != nil {
+		return info, fmt.Errorf("加载插件失败: %v", err)
+	}
+
+	if err := os.RemoveAll(dir); err != nil {
+		m.logger.Printf("清理插件 %s 的归档失败: %v", name, err)
+	}
+	m.logger.Printf("已从归档恢复插件: %s", name)
+	return info, nil
+}
+
+// PurgeArchive 删除超过保留时间的归档，返回删除的插件名称；维护模式下返回ErrMaintenanceMode（此前只返回插件名称）
+func (m *Manager) PurgeArchive() ([]string, error) {
+	if err := m.checkWritable(); err != nil {
+		return nil, err
+	}
+
+	m.mutex.RLock()
+	root, ttl := m.archiveRoot(), m.archiveTTL()
+	m.mutex.RUnlock()
+
+	return purgeArchive(root, ttl, m.logger), nil
+}
+
+// purgeArchive 删除归档目录中过期的归档
+func purgeArchive(root string, ttl time.Duration, logger Logger) []string {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil
+	}
+
+	var purged []string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		dir := filepath.Join(root, entry.Name())
+		archived, err := readArchive(dir)
+		if err != nil || time.Since(archived.ArchivedAt) < ttl {
+			continue
+		}
+		if err := os.RemoveAll(dir); err != nil {
+			logger.Printf("删除过期归档 %s 失败: %v", entry.Name(), err)
+			continue
+		}
+		purged = append(purged, archived.Name)
+	}
+	return purged
+}
+
+// readArchive 读取归档信息
+func readArchive(dir string) (*ArchivedPlugin, error) {
+	data, err := os.ReadFile(filepath.Join(dir, archiveMetaFile))
+	if err != nil {
+		return nil, err
+	}
+	var archived ArchivedPlugin
+	if err := json.Unmarshal(data, &archived); err != nil {
+		return nil, err
+	}
+	return &archived, nil
+}
+
+// pluginPackageFiles 返回插件文件及其专属清单、签名文件中实际存在的文件
+func pluginPackageFiles(pluginPath string) []string {
+	files := []string{pluginPath}
+	for _, path := range []string{sidecarManifestPath(pluginPath), pluginPath + signatureSuffix} {
+		if _, err := os.Stat(path); err == nil {
+			files = append(files, path)
+		}
+	}
+	return files
+}
+
+// moveFile 移动文件，跨文件系统时改为复制后删除
+func moveFile(src, target string) error {
+	if err := os.Rename(src, target); err == nil {
+		return nil
+	}
+
+	stat, err := os.Stat(src)
+	if err != nil {
+		return fmt.Errorf("移动文件失败: %v", err)
+	}
+	if err := copyFile(src, target, stat.Mode().Perm()); err != nil {
+		return err
+	}
+	if err := os.Remove(src); err != nil {
+		return fmt.Errorf("删除原文件失败: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package plugins
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// 插件文件名中可识别的操作系统和架构，用于从 <名称>_<GOOS>_<GOARCH><扩展名> 中解析构件的目标平台
+var (
+	knownGOOS = map[string]bool{
+		"aix": true, "android": true, "darwin": true, "dragonfly": true, "freebsd": true, "illumos": true,
+		"ios": true, "js": true, "linux": true, "netbsd": true, "openbsd": true, "plan9": true,
+		"solaris": true, "wasip1": true, "windows": true,
+	}
+	knownGOARCH = map[string]bool{
+		"386": true, "amd64": true, "arm": true, "arm64": true, "loong64": true, "mips": true,
+		"mips64": true, "mips64le": true, "mipsle": true, "ppc64": true, "ppc64le": true,
+		"riscv64": true, "s390x": true, "wasm": true,
+	}
+)
+
+// artifactPlatform 解析插件文件名中的目标平台，返回去掉平台后缀的名称；
+// 文件名不带平台后缀（如WASM、脚本插件）时goos和goarch为空，表示与平台无关
+func artifactPlatform(pluginPath string) (stem, goos, goarch string) {
+	base := filepath.Base(pluginPath)
+	stem = strings.TrimSuffix(base, filepath.Ext(base))
+
+	parts := strings.Split(stem, "_")
+	if len(parts) < 3 {
+		return stem, "", ""
+	}
+	goos, goarch = parts[len(parts)-2], parts[len(parts)-1]
+	if !knownGOOS[goos] || !knownGOARCH[goarch] {
+		return stem, "", ""
+	}
+	return strings.Join(parts[:len(parts)-2], "_"), goos, goarch
+}
+
+// artifactName 插件文件去掉扩展名和平台后缀后的名称，没有清单时作为插件名称
+func artifactName(pluginPath string) string {
+	stem, _, _ := artifactPlatform(pluginPath)
+	return stem
+}
+
+// artifactSkipReason 同一目录中存在同名插件的多个平台构件时，只加载与当前平台匹配的构件：
+// 为其他平台构建的文件被跳过；与平台无关的文件（如WASM）只在没有匹配当前平台的构件时作为回退加载。
+// 文件应被跳过时返回原因，否则返回空字符串
+func artifactSkipReason(pluginPath string) string {
+	stem, goos, goarch := artifactPlatform(pluginPath)
+	if goos != "" {
+		if goos == runtime.GOOS && goarch == runtime.GOARCH {
+			return ""
+		}
+		return fmt.Sprintf("为 %s/%s 构建，当前平台为 %s/%s", goos, goarch, runtime.GOOS, runtime.GOARCH)
+	}
+
+	entries, err := os.ReadDir(filepath.Dir(pluginPath))
+	if err != nil {
+		return ""
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || isIgnoredName(entry.Name()) {
+			continue
+		}
+		sibling := filepath.Join(filepath.Dir(pluginPath), entry.Name())
+		if isPartialFile(sibling) || !isPluginFile(sibling) {
+			continue
+		}
+		siblingStem, siblingOS, siblingArch := artifactPlatform(sibling)
+		if siblingStem == stem && siblingOS == runtime.GOOS && siblingArch == runtime.GOARCH {
+			return fmt.Sprintf("已有适用于当前平台的构件 %s", entry.Name())
+		}
+	}
+	return ""
+}
@@ -0,0 +1,183 @@
+package plugins
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// breakerBuckets 失败比例统计窗口的桶数
+const breakerBuckets = 10
+
+// minBreakerWindow 失败比例统计窗口的最小值，窗口按桶均分，过小时桶的时长为0
+const minBreakerWindow = time.Second
+
+// BreakerPolicy 插件熔断策略：插件处理事件连续失败或窗口内失败比例过高时自动停用插件，零值字段不启用对应条件
+type BreakerPolicy struct {
+	ConsecutiveFailures int           // 连续失败次数阈值
+	FailureRate         float64       // 窗口内失败比例阈值，需在0到1之间
+	Window              time.Duration // 失败比例的统计窗口，默认5分钟，不能小于1秒
+	MinEvents           int           // 窗口内事件数达到该值后才按失败比例判断，默认20
+}
+
+// DefaultBreakerPolicy 默认熔断策略：连续失败20次时停用插件
+var DefaultBreakerPolicy = BreakerPolicy{
+	ConsecutiveFailures: 20,
+	Window:              5 * time.Minute,
+	MinEvents:           20,
+}
+
+// breakerBucket 一个时间桶内的事件数和失败数
+type breakerBucket struct {
+	start  int64
+	count  int
+	errors int
+}
+
+// breakerState 单个插件的熔断统计
+type breakerState struct {
+	consecutive int
+	buckets     [breakerBuckets]breakerBucket
+}
+
+// breakerTracker 插件熔断统计，插件重新启用时清零
+type breakerTracker struct {
+	policy  BreakerPolicy
+	plugins map[string]*breakerState
+	mutex   sync.Mutex
+}
+
+func newBreakerTracker() *breakerTracker {
+	return &breakerTracker{policy: DefaultBreakerPolicy, plugins: make(map[string]*breakerState)}
+}
+
+// SetBreakerPolicy 设置插件熔断策略，ConsecutiveFailures和FailureRate都为0时不自动停用插件
+func (m *Manager) SetBreakerPolicy(policy BreakerPolicy) error {
+	if policy.ConsecutiveFailures < 0 || policy.MinEvents < 0 || policy.Window < 0 {
+		return fmt.Errorf("熔断策略的阈值不能为负数")
+	}
+	if policy.FailureRate < 0 || policy.FailureRate > 1 {
+		return fmt.Errorf("失败比例阈值需在0到1之间")
+	}
+	if policy.Window == 0 {
+		policy.Window = DefaultBreakerPolicy.Window
+	}
+	if policy.Window < minBreakerWindow {
+		return fmt.Errorf("失败比例的统计窗口不能小于 %v", minBreakerWindow)
+	}
+	if policy.MinEvents == 0 {
+		policy.MinEvents = DefaultBreakerPolicy.MinEvents
+	}
+
+	m.breakers.mutex.Lock()
+	defer m.breakers.mutex.Unlock()
+
+	m.breakers.policy = policy
+	m.breakers.plugins = make(map[string]*breakerState)
+	return nil
+}
+
+// GetBreakerPolicy 获取插件熔断策略
+func (m *Manager) GetBreakerPolicy() BreakerPolicy {
+	m.breakers.mutex.Lock()
+	defer m.breakers.mutex.Unlock()
+
+	return m.breakers.policy
+}
+
+// record 记录插件一次事件处理的结果，达到熔断条件时返回原因并清零统计
+func (t *breakerTracker) record(name string, failed bool, now time.Time) (string, bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	policy := t.policy
+	if policy.ConsecutiveFailures == 0 && policy.FailureRate == 0 {
+		return "", false
+	}
+
+	state, exists := t.plugins[name]
+	if !exists {
+		state = &breakerState{}
+		t.plugins[name] = state
+	}
+
+	if failed {
+		state.consecutive++
+	} else {
+		state.consecutive = 0
+	}
+
+	span := int64(policy.Window / breakerBuckets)
+	start := now.UnixNano() / span * span
+	b := &state.buckets[(start/span)%breakerBuckets]
+	if b.start != start {
+		*b = breakerBucket{start: start}
+	}
+	b.count++
+	if failed {
+		b.errors++
+	}
+
+	var reason string
+	if policy.ConsecutiveFailures > 0 && state.consecutive >= policy.ConsecutiveFailures {
+		reason = fmt.Sprintf("连续失败 %d 次", state.consecutive)
+	} else if policy.FailureRate > 0 && failed {
+		var count, errors int
+		oldest := start - int64(breakerBuckets-1)*span
+		for _, bucket := range state.buckets {
+			if bucket.start >= oldest {
+				count += bucket.count
+				errors += bucket.errors
+			}
+		}
+		if count >= policy.MinEvents && float64(errors)/float64(count) >= policy.FailureRate {
+			reason = fmt.Sprintf("%v 内失败 %d/%d 次，失败比例超过 %.0f%%", policy.Window, errors, count, policy.FailureRate*100)
+		}
+	}
+	if reason == "" {
+		return "", false
+	}
+	delete(t.plugins, name)
+	return reason, true
+}
+
+// reset 插件重新启用时清零熔断统计
+func (t *breakerTracker) reset(name string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	delete(t.plugins, name)
+}
+
+// tripBreaker 熔断：停用插件并记录原因，推送站内通知并通知管理员。
+// 停用会等待插件正在执行的处理完成，需在事件处理之外的goroutine中调用
+func (m *Manager) tripBreaker(info *PluginInfo, reason string) {
+	m.mutex.RLock()
+	current, exists := m.plugins[info.Name]
+	running := exists && current == info && info.Enabled
+	m.mutex.RUnlock()
+	if !running {
+		return
+	}
+
+	reason = "自动停用: " + reason
+	if err := m.disablePlugin(info.Name, reason); err != nil {
+		m.logger.Printf("自动停用插件 %s 失败: %v", info.Name, err)
+		return
+	}
+	m.logger.Printf("插件 %s 处理事件失败过多，已%s", info.Name, reason)
+
+	if _, err := m.pushNotice(Notice{
+		Plugin:  info.Name,
+		Level:   SeverityWarning,
+		Title:   fmt.Sprintf("插件 %s 已被自动停用", info.Name),
+		Message: reason + "，排查问题后可重新启用",
+	}); err != nil {
+		m.logger.Printf("推送插件 %s 的停用通知失败: %v", info.Name, err)
+	}
+	m.notifyAdmins(info.Name, Notification{
+		Subject:  fmt.Sprintf("插件 %s 已被自动停用", info.Name),
+		Body:     fmt.Sprintf("插件 %s 处理事件失败过多，%s", info.Name, reason),
+		Severity: SeverityError,
+	})
+}
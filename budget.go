@@ -0,0 +1,113 @@
+package plugins
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// syncBudgetContextKey 在gin.Context中记录本次请求已消耗的同步时间预算
+const syncBudgetContextKey = "sublink_plugin_sync_spent"
+
+// errSyncBudgetExhausted 同步时间预算耗尽时记录在事件结果中的错误
+var errSyncBudgetExhausted = fmt.Errorf("本次请求的同步时间预算已耗尽，已跳过")
+
+// SyncBudgetStat 插件同步处理的时间预算统计
+type SyncBudgetStat struct {
+	Invocations int64         // 同步调用次数
+	TotalTime   time.Duration // 同步调用累计耗时
+	Skipped     int64         // 因预算耗尽被跳过的次数
+}
+
+// syncBudget 同步处理时间预算
+type syncBudget struct {
+	limit time.Duration // 每个请求的预算，0表示不限制
+	stats map[string]*SyncBudgetStat
+	mutex sync.Mutex
+}
+
+func newSyncBudget() *syncBudget {
+	return &syncBudget{stats: make(map[string]*SyncBudgetStat)}
+}
+
+// SetSyncBudget 设置每个请求所有同步处理的累计时间预算，0表示不限制
+func (m *Manager) SetSyncBudget(limit time.Duration) {
+	m.budget.mutex.Lock()
+	defer m.budget.mutex.Unlock()
+
+	m.budget.limit = limit
+}
+
+// SyncBudgetStats 获取每个插件的同步时间预算统计
+func (m *Manager) SyncBudgetStats() map[string]SyncBudgetStat {
+	m.budget.mutex.Lock()
+	defer m.budget.mutex.Unlock()
+
+	result := make(map[string]SyncBudgetStat, len(m.budget.stats))
+	for name, stat := range m.budget.stats {
+		result[name] = *stat
+	}
+	return result
+}
+
+// runSyncHooks 在时间预算内依次执行同步处理，预算耗尽后跳过剩余插件
+func (m *Manager) runSyncHooks(ctx *gin.Context, record *eventRecord, targets []*PluginInfo, requestBody interface{}, responseBody interface{}) {
+	m.budget.mutex.Lock()
+	limit := m.budget.limit
+	m.budget.mutex.Unlock()
+
+	var spent time.Duration
+	if ctx != nil {
+		if v, ok := ctx.Get(syncBudgetContextKey); ok {
+			spent, _ = v.(time.Duration)
+		}
+	}
+
+	for _, info := range targets {
+		if limit > 0 && spent >= limit {
+			m.accountSyncHook(info.Name, 0, true)
+			record.addResult(PluginResult{Plugin: info.Name, Error: errSyncBudgetExhausted.Error()})
+			continue
+		}
+
+		done, ok := m.beginWork(info.Name)
+		if !ok {
+			record.addResult(PluginResult{Plugin: info.Name, Error: errPluginDraining.Error()})
+			continue
+		}
+		start := time.Now()
+		runWithPluginLabels(info.Name, func() {
+			defer done()
+			m.invokeHandler(ctx, record, info, requestBody, responseBody)
+		})
+		elapsed := time.Since(start)
+
+		spent += elapsed
+		m.accountSyncHook(info.Name, elapsed, false)
+	}
+
+	if ctx != nil {
+		ctx.Set(syncBudgetContextKey, spent)
+	}
+}
+
+// accountSyncHook 记录插件同步处理的耗时或跳过
+func (m *Manager) accountSyncHook(name string, elapsed time.Duration, skipped bool) {
+	m.budget.mutex.Lock()
+	defer m.budget.mutex.Unlock()
+
+	stat, exists := m.budget.stats[name]
+	if !exists {
+		stat = &SyncBudgetStat{}
+		m.budget.stats[name] = stat
+	}
+
+	if skipped {
+		stat.Skipped++
+		return
+	}
+	stat.Invocations++
+	stat.TotalTime += elapsed
+}
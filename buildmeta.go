@@ -0,0 +1,55 @@
+package plugins
+
+import (
+	"debug/buildinfo"
+	"plugin"
+)
+
+// BuildInfoSymbol 插件导出构建信息的符号名称，可以是BuildInfo类型的变量或返回BuildInfo的函数：
+//
+//	var BuildInfo = plugins.BuildInfo{BuildTime: buildTime, GitCommit: gitCommit}
+const BuildInfoSymbol = "BuildInfo"
+
+// BuildInfo 插件的构建信息，用于在管理界面展示插件来源
+type BuildInfo struct {
+	BuildTime  string `json:"build_time,omitempty"`
+	GitCommit  string `json:"git_commit,omitempty"`
+	GoVersion  string `json:"go_version,omitempty"`
+	SDKVersion string `json:"sdk_version,omitempty"`
+}
+
+// readBuildMeta 读取插件的构建信息：优先使用插件导出的BuildInfo符号，
+// 缺失的字段从插件文件中嵌入的Go构建信息补全；p为nil时（如独立进程插件）只读取嵌入的构建信息
+func readBuildMeta(p *plugin.Plugin, pluginPath string) *BuildInfo {
+	meta := &BuildInfo{}
+
+	if p != nil {
+		if sym, err := p.Lookup(BuildInfoSymbol); err == nil {
+			switch v := sym.(type) {
+			case *BuildInfo:
+				*meta = *v
+			case func() BuildInfo:
+				*meta = v()
+			}
+		}
+	}
+
+	if embedded, err := buildinfo.ReadFile(pluginPath); err == nil {
+		if meta.GoVersion == "" {
+			meta.GoVersion = embedded.GoVersion
+		}
+		if meta.SDKVersion == "" {
+			meta.SDKVersion = moduleVersions(embedded)[sdkModulePath]
+		}
+		for _, setting := range embedded.Settings {
+			switch {
+			case setting.Key == "vcs.revision" && meta.GitCommit == "":
+				meta.GitCommit = setting.Value
+			case setting.Key == "vcs.time" && meta.BuildTime == "":
+				meta.BuildTime = setting.Value
+			}
+		}
+	}
+
+	return meta
+}
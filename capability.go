@@ -0,0 +1,149 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Capability 插件可选能力
+type Capability string
+
+const (
+	CapabilityRoutes       Capability = "routes"
+	CapabilityCron         Capability = "cron"
+	CapabilityNotifier     Capability = "notifier"
+	CapabilityConverter    Capability = "converter"
+	CapabilityAuthProvider Capability = "auth_provider"
+	CapabilityTransformer  Capability = "transformer"
+	CapabilityAdminControl Capability = "admin_control"
+	CapabilityWidgets      Capability = "widgets"
+)
+
+// RouteProvider 路由能力：插件向宿主注册自己的HTTP路由
+type RouteProvider interface {
+	// RegisterRoutes 在给定的路由组下注册路由
+	RegisterRoutes(group *gin.RouterGroup)
+}
+
+// CronJob 定时任务
+type CronJob struct {
+	Name     string
+	Schedule string // cron表达式
+	Run      func(ctx context.Context) error
+}
+
+// CronProvider 定时任务能力
+type CronProvider interface {
+	// CronJobs 返回插件需要宿主调度的定时任务
+	CronJobs() []CronJob
+}
+
+// Severity 通知级别
+type Severity string
+
+const (
+	SeverityInfo     Severity = "info"
+	SeverityWarning  Severity = "warning"
+	SeverityError    Severity = "error"
+	SeverityCritical Severity = "critical"
+)
+
+// Notification 通知内容
+type Notification struct {
+	Subject  string
+	Body     string // Markdown格式
+	Severity Severity
+}
+
+// Notifier 通知能力：插件作为通知渠道向管理员发送消息
+type Notifier interface {
+	// Notify 发送通知
+	Notify(ctx context.Context, n Notification) error
+}
+
+// Converter 转换能力：插件提供订阅格式转换
+type Converter interface {
+	// ConvertFormats 返回支持的目标格式
+	ConvertFormats() []string
+
+	// Convert 将输入转换为目标格式
+	Convert(ctx context.Context, format string, input []byte) ([]byte, error)
+}
+
+// AuthProvider 认证能力：插件提供额外的登录认证方式
+type AuthProvider interface {
+	// Authenticate 认证请求，返回用户名及是否认证成功
+	Authenticate(ctx *gin.Context) (string, bool, error)
+}
+
+// detectCapabilities 通过接口断言检测插件实现的能力
+func detectCapabilities(p Plugin) []Capability {
+	var caps []Capability
+
+	if _, ok := p.(RouteProvider); ok {
+		caps = append(caps, CapabilityRoutes)
+	}
+	if _, ok := p.(CronProvider); ok {
+		caps = append(caps, CapabilityCron)
+	}
+	if _, ok := p.(Notifier); ok {
+		caps = append(caps, CapabilityNotifier)
+	}
+	if _, ok := p.(Converter); ok {
+		caps = append(caps, CapabilityConverter)
+	}
+	if _, ok := p.(AuthProvider); ok {
+		caps = append(caps, CapabilityAuthProvider)
+	}
+	if _, ok := p.(Transformer); ok {
+		caps = append(caps, CapabilityTransformer)
+	}
+	if _, ok := p.(AdminController); ok {
+		caps = append(caps, CapabilityAdminControl)
+	}
+	if _, ok := p.(WidgetProvider); ok {
+		caps = append(caps, CapabilityWidgets)
+	}
+
+	return caps
+}
+
+// Capabilities 获取所有已加载插件实现的可选能力
+func (m *Manager) Capabilities() map[string][]Capability {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	result := make(map[string][]Capability, len(m.plugins))
+	for name, info := range m.plugins {
+		result[name] = detectCapabilities(info.Plugin)
+	}
+	return result
+}
+
+// PluginCapabilities 获取单个插件实现的可选能力
+func (m *Manager) PluginCapabilities(name string) ([]Capability, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	info, exists := m.plugins[name]
+	if !exists {
+		return nil, fmt.Errorf("插件不存在: %s", name)
+	}
+	return detectCapabilities(info.Plugin), nil
+}
+
+// HasCapability 判断插件是否实现了指定能力
+func (m *Manager) HasCapability(name string, capability Capability) bool {
+	caps, err := m.PluginCapabilities(name)
+	if err != nil {
+		return false
+	}
+	for _, c := range caps {
+		if c == capability {
+			return true
+		}
+	}
+	return false
+}
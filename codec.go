@@ -0,0 +1,222 @@
+package plugins
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ugorji/go/codec"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// BodyCodec 请求体和响应体解码器，将原始字节解析为插件可直接使用的结构化数据
+type BodyCodec interface {
+	// ContentTypes 处理的媒体类型（不含参数），如 application/json
+	ContentTypes() []string
+
+	// Decode 解析原始字节，返回值应为map、切片、字符串、数字等JSON兼容的类型
+	Decode(data []byte) (interface{}, error)
+}
+
+// codecRegistry 按媒体类型登记的解码器
+type codecRegistry struct {
+	codecs map[string]BodyCodec
+	mutex  sync.RWMutex
+}
+
+// newCodecRegistry 创建解码器登记表，默认支持JSON、表单和MessagePack
+func newCodecRegistry() *codecRegistry {
+	r := &codecRegistry{codecs: make(map[string]BodyCodec)}
+	for _, c := range []BodyCodec{jsonBodyCodec{}, formBodyCodec{}, newMsgpackBodyCodec()} {
+		for _, contentType := range c.ContentTypes() {
+			r.codecs[contentType] = c
+		}
+	}
+	return r
+}
+
+// RegisterBodyCodec 登记请求体和响应体解码器，同一媒体类型后登记的解码器覆盖先登记的
+func (m *Manager) RegisterBodyCodec(c BodyCodec) error {
+	types := c.ContentTypes()
+	if len(types) == 0 {
+		return fmt.Errorf("解码器未声明媒体类型")
+	}
+
+	m.codecs.mutex.Lock()
+	defer m.codecs.mutex.Unlock()
+
+	for _, contentType := range types {
+		m.codecs.codecs[strings.ToLower(contentType)] = c
+	}
+	return nil
+}
+
+// DecodeBody 按Content-Type解析原始字节，没有对应的解码器或解析失败时返回false。
+// Content-Type为空时尝试按JSON解析；application/*+json等结构化后缀按基础格式解析
+func (m *Manager) DecodeBody(contentType string, data []byte) (interface{}, bool) {
+	c := m.lookupCodec(contentType, data)
+	if c == nil {
+		return nil, false
+	}
+
+	value, err := c.Decode(data)
+	if err != nil {
+		return nil, false
+	}
+	return value, true
+}
+
+// lookupCodec 查找媒体类型对应的解码器
+func (m *Manager) lookupCodec(contentType string, data []byte) BodyCodec {
+	m.codecs.mutex.RLock()
+	defer m.codecs.mutex.RUnlock()
+
+	if contentType == "" {
+		if json.Valid(data) {
+			return m.codecs.codecs[gin.MIMEJSON]
+		}
+		return nil
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil
+	}
+	if c, exists := m.codecs.codecs[mediaType]; exists {
+		return c
+	}
+	if strings.HasSuffix(mediaType, "+json") {
+		return m.codecs.codecs[gin.MIMEJSON]
+	}
+	return nil
+}
+
+// decodeBodies 将[]byte形式的请求体和响应体按Content-Type解析为结构化数据，无法解析时保留原始字节；
+// 其他类型的数据原样传递
+func (m *Manager) decodeBodies(ctx *gin.Context, requestBody interface{}, responseBody interface{}) (interface{}, interface{}) {
+	if raw, ok := requestBody.([]byte); ok && len(raw) > 0 {
+		var contentType string
+		if ctx != nil && ctx.Request != nil {
+			contentType = ctx.Request.Header.Get("Content-Type")
+		}
+		if value, ok := m.DecodeBody(contentType, raw); ok {
+			requestBody = value
+		}
+	}
+
+	if raw, ok := responseBody.([]byte); ok && len(raw) > 0 {
+		var contentType string
+		if ctx != nil && ctx.Writer != nil {
+			contentType = ctx.Writer.Header().Get("Content-Type")
+		}
+		if value, ok := m.DecodeBody(contentType, raw); ok {
+			responseBody = value
+		}
+	}
+	return requestBody, responseBody
+}
+
+// jsonBodyCodec JSON解码器，数字解析为json.Number以保留精度
+type jsonBodyCodec struct{}
+
+func (jsonBodyCodec) ContentTypes() []string {
+	return []string{gin.MIMEJSON}
+}
+
+func (jsonBodyCodec) Decode(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// formBodyCodec 表单解码器，只有一个值的字段解析为字符串，多个值的字段解析为字符串切片
+type formBodyCodec struct{}
+
+func (formBodyCodec) ContentTypes() []string {
+	return []string{gin.MIMEPOSTForm}
+}
+
+func (formBodyCodec) Decode(data []byte) (interface{}, error) {
+	values, err := url.ParseQuery(string(data))
+	if err != nil {
+		return nil, err
+	}
+
+	form := make(map[string]interface{}, len(values))
+	for key, value := range values {
+		if len(value) == 1 {
+			form[key] = value[0]
+		} else {
+			form[key] = value
+		}
+	}
+	return form, nil
+}
+
+// msgpackBodyCodec MessagePack解码器，map解析为map[string]interface{}
+type msgpackBodyCodec struct {
+	handle *codec.MsgpackHandle
+}
+
+func newMsgpackBodyCodec() *msgpackBodyCodec {
+	handle := &codec.MsgpackHandle{}
+	handle.RawToString = true
+	handle.MapType = reflect.TypeOf(map[string]interface{}(nil))
+	return &msgpackBodyCodec{handle: handle}
+}
+
+func (c *msgpackBodyCodec) ContentTypes() []string {
+	return []string{"application/msgpack", "application/x-msgpack", "application/vnd.msgpack"}
+}
+
+func (c *msgpackBodyCodec) Decode(data []byte) (interface{}, error) {
+	var value interface{}
+	if err := codec.NewDecoderBytes(data, c.handle).Decode(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// protobufBodyCodec protobuf解码器，按消息类型解析后转换为与protojson一致的结构
+type protobufBodyCodec struct {
+	contentTypes []string
+	newMessage   func() proto.Message
+}
+
+// NewProtobufCodec 创建protobuf解码器。protobuf数据不自描述，需由宿主提供消息类型；
+// 不同消息类型应登记为不同的媒体类型，未指定媒体类型时使用 application/x-protobuf
+func NewProtobufCodec(newMessage func() proto.Message, contentTypes ...string) BodyCodec {
+	if len(contentTypes) == 0 {
+		contentTypes = []string{"application/x-protobuf", "application/protobuf"}
+	}
+	return &protobufBodyCodec{contentTypes: contentTypes, newMessage: newMessage}
+}
+
+func (c *protobufBodyCodec) ContentTypes() []string {
+	return c.contentTypes
+}
+
+func (c *protobufBodyCodec) Decode(data []byte) (interface{}, error) {
+	msg := c.newMessage()
+	if err := proto.Unmarshal(data, msg); err != nil {
+		return nil, err
+	}
+
+	encoded, err := protojson.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	return jsonBodyCodec{}.Decode(encoded)
+}
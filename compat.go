@@ -0,0 +1,151 @@
+package plugins
+
+import (
+	"debug/buildinfo"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sort"
+	"strings"
+)
+
+// sdkModulePath 插件SDK（本模块）的模块路径
+const sdkModulePath = "github.com/ZeroDeng01/sublinkPro-plugins"
+
+// DependencyMismatch 宿主与插件使用了不同版本的同一依赖
+type DependencyMismatch struct {
+	Path          string
+	HostVersion   string
+	PluginVersion string
+}
+
+// CompatibilityError 插件与宿主的构建环境不兼容，替代plugin.Open晦涩的错误信息
+type CompatibilityError struct {
+	Path             string
+	HostGoVersion    string
+	PluginGoVersion  string
+	HostSDKVersion   string
+	PluginSDKVersion string
+	Mismatches       []DependencyMismatch
+	Cause            error // plugin.Open的原始错误，预检发现问题时为nil
+}
+
+func (e *CompatibilityError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "插件 %s 与宿主不兼容", e.Path)
+	if e.HostGoVersion != e.PluginGoVersion {
+		fmt.Fprintf(&b, "; Go版本不一致: 宿主 %s, 插件 %s", e.HostGoVersion, e.PluginGoVersion)
+	}
+	if e.HostSDKVersion != e.PluginSDKVersion {
+		fmt.Fprintf(&b, "; SDK版本不一致: 宿主 %s, 插件 %s", e.HostSDKVersion, e.PluginSDKVersion)
+	}
+	for _, mismatch := range e.Mismatches {
+		if mismatch.Path == sdkModulePath {
+			continue
+		}
+		fmt.Fprintf(&b, "; 依赖 %s 版本不一致: 宿主 %s, 插件 %s", mismatch.Path, mismatch.HostVersion, mismatch.PluginVersion)
+	}
+	if e.Cause != nil {
+		fmt.Fprintf(&b, "; 原始错误: %v", e.Cause)
+	}
+	return b.String()
+}
+
+func (e *CompatibilityError) Unwrap() error {
+	return e.Cause
+}
+
+// isCompatibilityError 判断错误是否由插件与宿主不兼容引起
+func isCompatibilityError(err error) bool {
+	var compatErr *CompatibilityError
+	return errors.As(err, &compatErr)
+}
+
+// preflightCheck 打开插件前读取插件文件中的构建信息并与宿主比较，发现不兼容时返回*CompatibilityError；
+// 当前平台不支持Go原生插件时直接返回；无法读取构建信息时不做判断，由plugin.Open决定
+func preflightCheck(pluginPath string) error {
+	if !nativePluginsSupported {
+		return &CompatibilityError{Path: pluginPath, Cause: errNativeUnsupported}
+	}
+
+	compatErr := compareBuildInfo(pluginPath)
+	if compatErr == nil {
+		return nil
+	}
+	if compatErr.HostGoVersion == compatErr.PluginGoVersion && len(compatErr.Mismatches) == 0 {
+		return nil
+	}
+	return compatErr
+}
+
+// compatibilityFromOpenError 将plugin.Open的版本冲突错误转换为*CompatibilityError
+func compatibilityFromOpenError(pluginPath string, err error) error {
+	if !strings.Contains(err.Error(), "different version of package") {
+		return err
+	}
+
+	compatErr := compareBuildInfo(pluginPath)
+	if compatErr == nil {
+		compatErr = &CompatibilityError{Path: pluginPath}
+	}
+	compatErr.Cause = err
+	return compatErr
+}
+
+// compareBuildInfo 比较宿主与插件的构建信息，任一方无法读取时返回nil
+func compareBuildInfo(pluginPath string) *CompatibilityError {
+	host, ok := debug.ReadBuildInfo()
+	if !ok {
+		return nil
+	}
+	plug, err := buildinfo.ReadFile(pluginPath)
+	if err != nil {
+		return nil
+	}
+
+	hostDeps := moduleVersions(host)
+	plugDeps := moduleVersions(plug)
+
+	compatErr := &CompatibilityError{
+		Path:             pluginPath,
+		HostGoVersion:    host.GoVersion,
+		PluginGoVersion:  plug.GoVersion,
+		HostSDKVersion:   hostDeps[sdkModulePath],
+		PluginSDKVersion: plugDeps[sdkModulePath],
+	}
+
+	// 宿主与插件共同依赖的模块必须版本一致
+	for path, hostVersion := range hostDeps {
+		plugVersion, shared := plugDeps[path]
+		if shared && plugVersion != hostVersion {
+			compatErr.Mismatches = append(compatErr.Mismatches, DependencyMismatch{
+				Path:          path,
+				HostVersion:   hostVersion,
+				PluginVersion: plugVersion,
+			})
+		}
+	}
+	sort.Slice(compatErr.Mismatches, func(i, j int) bool {
+		return compatErr.Mismatches[i].Path < compatErr.Mismatches[j].Path
+	})
+	return compatErr
+}
+
+// moduleVersions 返回构建信息中的模块版本，包括主模块，replace后以替换模块的版本为准
+func moduleVersions(info *debug.BuildInfo) map[string]string {
+	versions := make(map[string]string, len(info.Deps)+1)
+	if info.Main.Path != "" {
+		versions[info.Main.Path] = info.Main.Version
+	}
+	for _, dep := range info.Deps {
+		version := dep.Version
+		if dep.Replace != nil {
+			version = dep.Replace.Version
+			if version == "" {
+				version = dep.Replace.Path
+			}
+		}
+		versions[dep.Path] = version
+	}
+	return versions
+}
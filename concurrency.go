@@ -0,0 +1,114 @@
+package plugins
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// OverflowPolicy 插件并发处理数达到上限后的处理方式
+type OverflowPolicy string
+
+const (
+	OverflowQueue OverflowPolicy = "queue" // 排队等待（默认）
+	OverflowDrop  OverflowPolicy = "drop"  // 直接丢弃
+)
+
+// errConcurrencyLimit 并发处理数达到上限被丢弃时记录在事件结果中的错误
+var errConcurrencyLimit = fmt.Errorf("插件并发处理数已达上限，已丢弃")
+
+// ConcurrencyStat 插件并发处理统计
+type ConcurrencyStat struct {
+	Max      int            // 最大并发数
+	Overflow OverflowPolicy // 超出上限后的处理方式
+	InFlight int            // 正在处理的事件数
+	Dropped  int64          // 被丢弃的事件数
+}
+
+// pluginSemaphore 单个插件的并发限制
+type pluginSemaphore struct {
+	slots    chan struct{}
+	overflow OverflowPolicy
+	dropped  atomic.Int64
+}
+
+// concurrencyLimiter 插件并发限制，未设置的插件不限制
+type concurrencyLimiter struct {
+	limits map[string]*pluginSemaphore
+	mutex  sync.RWMutex
+}
+
+func newConcurrencyLimiter() *concurrencyLimiter {
+	return &concurrencyLimiter{limits: make(map[string]*pluginSemaphore)}
+}
+
+// SetConcurrencyLimit 设置插件同时处理事件的最大数量，max<=0表示不限制；
+// 超出上限时按overflow排队或丢弃，避免慢速外部接口导致处理调用无限堆积
+func (m *Manager) SetConcurrencyLimit(name string, max int, overflow OverflowPolicy) error {
+	if err := m.checkWritable(); err != nil {
+		return err
+	}
+
+	switch overflow {
+	case "":
+		overflow = OverflowQueue
+	case OverflowQueue, OverflowDrop:
+	default:
+		return fmt.Errorf("未知的溢出处理方式: %s", overflow)
+	}
+
+	m.limiter.mutex.Lock()
+	defer m.limiter.mutex.Unlock()
+
+	if max <= 0 {
+		delete(m.limiter.limits, name)
+		return nil
+	}
+	m.limiter.limits[name] = &pluginSemaphore{
+		slots:    make(chan struct{}, max),
+		overflow: overflow,
+	}
+	return nil
+}
+
+// ConcurrencyStats 获取设置了并发限制的插件的统计
+func (m *Manager) ConcurrencyStats() map[string]ConcurrencyStat {
+	m.limiter.mutex.RLock()
+	defer m.limiter.mutex.RUnlock()
+
+	result := make(map[string]ConcurrencyStat, len(m.limiter.limits))
+	for name, sem := range m.limiter.limits {
+		result[name] = ConcurrencyStat{
+			Max:      cap(sem.slots),
+			Overflow: sem.overflow,
+			InFlight: len(sem.slots),
+			Dropped:  sem.dropped.Load(),
+		}
+	}
+	return result
+}
+
+// acquireSlot 获取插件的处理名额，返回释放函数；按丢弃策略无可用名额时返回false
+func (m *Manager) acquireSlot(name string) (func(), bool) {
+	m.limiter.mutex.RLock()
+	sem, limited := m.limiter.limits[name]
+	m.limiter.mutex.RUnlock()
+
+	if !limited {
+		return func() {}, true
+	}
+
+	if sem.overflow == OverflowDrop {
+		select {
+		case sem.slots <- struct{}{}:
+		default:
+			sem.dropped.Add(1)
+			return nil, false
+		}
+	} else {
+		sem.slots <- struct{}{}
+	}
+
+	// 修改限制后旧的名额仍归还到原来的信号量
+	return func() { <-sem.slots }, true
+}
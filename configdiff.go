@@ -0,0 +1,181 @@
+package plugins
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// maskedValue 敏感配置项在差异中的显示值
+const maskedValue = "******"
+
+// ErrConfirmRequired 配置变更包含破坏性修改但未确认
+var ErrConfirmRequired = errors.New("配置变更包含删除或清空的配置项，需要确认后才能应用")
+
+// secretKeyMarkers 配置项名称包含这些片段时视为敏感配置
+var secretKeyMarkers = []string{"password", "passwd", "secret", "token", "apikey", "api_key", "credential", "private"}
+
+// ConfigChangeType 配置项变更类型
+type ConfigChangeType string
+
+const (
+	ConfigAdded   ConfigChangeType = "added"
+	ConfigRemoved ConfigChangeType = "removed"
+	ConfigChanged ConfigChangeType = "changed"
+)
+
+// ConfigChange 单个配置项的变更，嵌套配置使用点号连接的路径
+type ConfigChange struct {
+	Key      string           `json:"key"`
+	Type     ConfigChangeType `json:"type"`
+	OldValue interface{}      `json:"old_value,omitempty"`
+	NewValue interface{}      `json:"new_value,omitempty"`
+	Secret   bool             `json:"secret,omitempty"` // 敏感配置项，新旧值已被遮盖
+}
+
+// ConfigDiff 配置变更
+type ConfigDiff struct {
+	Changes     []ConfigChange `json:"changes"`
+	Destructive bool           `json:"destructive"` // 是否删除或清空了配置项
+}
+
+// Empty 配置是否没有变化
+func (d *ConfigDiff) Empty() bool {
+	return len(d.Changes) == 0
+}
+
+// ConfigUpdateOptions 配置更新选项
+type ConfigUpdateOptions struct {
+	DryRun  bool // 只计算差异，不应用
+	Confirm bool // 确认应用破坏性修改
+}
+
+// PreviewPluginConfig 计算新配置与当前配置的差异，不应用修改
+func (m *Manager) PreviewPluginConfig(name string, config map[string]interface{}) (*ConfigDiff, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	plugin, exists := m.plugins[name]
+	if !exists {
+		return nil, fmt.Errorf("插件不存在: %s", name)
+	}
+	return diffConfig(plugin.Config, config), nil
+}
+
+// UpdatePluginConfigWithDiff 更新插件配置并返回配置差异；包含破坏性修改时必须设置Confirm，
+// 否则返回差异和ErrConfirmRequired，便于界面展示变更后再确认
+func (m *Manager) UpdatePluginConfigWithDiff(name string, config map[string]interface{}, opts ConfigUpdateOptions) (*ConfigDiff, error) {
+	if !opts.DryRun {
+		if err := m.checkWritable(); err != nil {
+			return nil, err
+		}
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	plugin, exists := m.plugins[name]
+	if !exists {
+		return nil, fmt.Errorf("插件不存在: %s", name)
+	}
+
+	diff := diffConfig(plugin.Config, config)
+	if opts.DryRun || diff.Empty() {
+		return diff, nil
+	}
+	if diff.Destructive && !opts.Confirm {
+		return diff, ErrConfirmRequired
+	}
+
+	if err := m.applyPluginConfig(plugin, config); err != nil {
+		return diff, err
+	}
+	return diff, nil
+}
+
+// diffConfig 计算配置差异，按配置项路径排序
+func diffConfig(oldConfig, newConfig map[string]interface{}) *ConfigDiff {
+	oldFlat := make(map[string]interface{})
+	newFlat := make(map[string]interface{})
+	flattenConfig("", oldConfig, oldFlat)
+	flattenConfig("", newConfig, newFlat)
+
+	diff := &ConfigDiff{Changes: []ConfigChange{}}
+	for key, oldValue := range oldFlat {
+		newValue, exists := newFlat[key]
+		switch {
+		case !exists:
+			diff.Changes = append(diff.Changes, ConfigChange{Key: key, Type: ConfigRemoved, OldValue: oldValue})
+			diff.Destructive = true
+		case !reflect.DeepEqual(oldValue, newValue):
+			diff.Changes = append(diff.Changes, ConfigChange{Key: key, Type: ConfigChanged, OldValue: oldValue, NewValue: newValue})
+			if isEmptyValue(newValue) && !isEmptyValue(oldValue) {
+				diff.Destructive = true
+			}
+		}
+	}
+	for key, newValue := range newFlat {
+		if _, exists := oldFlat[key]; !exists {
+			diff.Changes = append(diff.Changes, ConfigChange{Key: key, Type: ConfigAdded, NewValue: newValue})
+		}
+	}
+
+	for i := range diff.Changes {
+		change := &diff.Changes[i]
+		if isSecretKey(change.Key) {
+			change.Secret = true
+			if change.OldValue != nil {
+				change.OldValue = maskedValue
+			}
+			if change.NewValue != nil {
+				change.NewValue = maskedValue
+			}
+		}
+	}
+
+	sort.Slice(diff.Changes, func(i, j int) bool {
+		return diff.Changes[i].Key < diff.Changes[j].Key
+	})
+	return diff
+}
+
+// flattenConfig 将嵌套配置展开为点号连接的路径
+func flattenConfig(prefix string, config map[string]interface{}, out map[string]interface{}) {
+	for key, value := range config {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+		if nested, ok := value.(map[string]interface{}); ok && len(nested) > 0 {
+			flattenConfig(path, nested, out)
+			continue
+		}
+		out[path] = value
+	}
+}
+
+// isSecretKey 根据配置项名称判断是否为敏感配置
+func isSecretKey(key string) bool {
+	lower := strings.ToLower(key)
+	for _, marker := range secretKeyMarkers {
+		if strings.Contains(lower, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// isEmptyValue 判断配置值是否为空
+func isEmptyValue(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return v.Len() == 0
+	}
+	return false
+}
@@ -0,0 +1,154 @@
+package plugins
+
+import (
+	"fmt"
+	"sort"
+)
+
+// ConfigIssueType 配置漂移问题类型
+type ConfigIssueType string
+
+const (
+	ConfigIssueUnknown ConfigIssueType = "unknown" // 配置中有插件当前版本不再识别的配置项
+	ConfigIssueMissing ConfigIssueType = "missing" // 插件当前版本新增或要求的配置项在配置中缺失
+	ConfigIssueInvalid ConfigIssueType = "invalid" // 配置不符合清单中的config_schema
+)
+
+// ConfigIssue 插件配置与插件当前的默认配置和配置Schema不一致之处，嵌套配置使用点号连接的路径
+type ConfigIssue struct {
+	Key        string          `json:"key"`
+	Type       ConfigIssueType `json:"type"`
+	Default    interface{}     `json:"default,omitempty"` // 缺失配置项的默认值，敏感配置项不返回
+	Suggestion string          `json:"suggestion"`
+}
+
+// checkConfigDrift 对比配置与插件当前的默认配置和配置Schema，更新ConfigIssues，未打开的插件不检查
+func (info *PluginInfo) checkConfigDrift() {
+	info.ConfigIssues = nil
+	if info.dormant || info.Plugin == nil {
+		return
+	}
+
+	schema, _ := info.Manifest.configSchema()
+	issues, _ := driftConfig("", info.Config, info.Plugin.DefaultConfig(), schema)
+	if err := info.Manifest.validateConfig(info.Config); err != nil {
+		issues = append(issues, ConfigIssue{Key: "config", Type: ConfigIssueInvalid, Suggestion: err.Error()})
+	}
+	sort.SliceStable(issues, func(i, j int) bool {
+		return issues[i].Key < issues[j].Key
+	})
+	info.ConfigIssues = issues
+}
+
+// driftConfig 递归比较配置与默认配置和Schema，返回发现的问题以及删除未知配置项、补全缺失配置项后的建议配置
+func driftConfig(prefix string, config, defaults map[string]interface{}, schema *configSchema) ([]ConfigIssue, map[string]interface{}) {
+	known := make(map[string]bool)
+	for key := range defaults {
+		known[key] = true
+	}
+	if schema != nil {
+		for key := range schema.Properties {
+			known[key] = true
+		}
+	}
+
+	var issues []ConfigIssue
+	fixed := make(map[string]interface{}, len(config))
+	for key, value := range config {
+		path := joinConfigPath(prefix, key)
+		// 插件既没有默认配置也没有Schema时无法判断配置项是否仍被使用
+		if len(known) > 0 && !known[key] {
+			issues = append(issues, ConfigIssue{
+				Key:        path,
+				Type:       ConfigIssueUnknown,
+				Suggestion: "插件当前版本不再使用该配置项，可以删除",
+			})
+			continue
+		}
+
+		var childSchema *configSchema
+		if schema != nil {
+			childSchema = schema.Properties[key]
+		}
+		nested, isMap := value.(map[string]interface{})
+		defaultNested, defaultIsMap := defaults[key].(map[string]interface{})
+		if isMap && (defaultIsMap || childSchema != nil) {
+			childIssues, childFixed := driftConfig(path, nested, defaultNested, childSchema)
+			issues = append(issues, childIssues...)
+			fixed[key] = childFixed
+			continue
+		}
+		fixed[key] = copyConfigValue(value)
+	}
+
+	for key, value := range defaults {
+		if _, exists := config[key]; exists {
+			continue
+		}
+		path := joinConfigPath(prefix, key)
+		issue := ConfigIssue{Key: path, Type: ConfigIssueMissing}
+		if isSecretKey(path) {
+			issue.Suggestion = "插件新增了该配置项，请填写"
+		} else {
+			issue.Default = value
+			issue.Suggestion = fmt.Sprintf("插件新增了该配置项，可以使用默认值 %v", value)
+		}
+		issues = append(issues, issue)
+		fixed[key] = copyConfigValue(value)
+	}
+	if schema != nil {
+		for _, key := range schema.Required {
+			if _, exists := config[key]; exists {
+				continue
+			}
+			if _, hasDefault := defaults[key]; hasDefault {
+				continue
+			}
+			issues = append(issues, ConfigIssue{
+				Key:        joinConfigPath(prefix, key),
+				Type:       ConfigIssueMissing,
+				Suggestion: "清单要求填写该配置项",
+			})
+		}
+	}
+	return issues, fixed
+}
+
+// joinConfigPath 连接嵌套配置项的路径
+func joinConfigPath(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+// PluginConfigIssues 获取插件配置与插件当前版本的默认配置和配置Schema不一致之处，用于在插件升级后提示更新配置
+func (m *Manager) PluginConfigIssues(name string) ([]ConfigIssue, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	info, exists := m.plugins[name]
+	if !exists {
+		return nil, fmt.Errorf("插件不存在: %s", name)
+	}
+	return append([]ConfigIssue(nil), info.ConfigIssues...), nil
+}
+
+// SuggestConfigFix 生成修复配置漂移的建议配置：删除不再使用的配置项、以默认值补全缺失的配置项，
+// 返回建议配置及其与当前配置的差异，不应用修改；确认后可通过UpdatePluginConfigWithDiff应用
+func (m *Manager) SuggestConfigFix(name string) (map[string]interface{}, *ConfigDiff, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	info, exists := m.plugins[name]
+	if !exists {
+		return nil, nil, fmt.Errorf("插件不存在: %s", name)
+	}
+	if info.dormant || info.Plugin == nil {
+		return nil, nil, fmt.Errorf("插件 %s 尚未打开，无法检查配置", name)
+	}
+
+	schema, _ := info.Manifest.configSchema()
+	_, fixed := driftConfig("", info.Config, info.Plugin.DefaultConfig(), schema)
+	return fixed, diffConfig(info.Config, fixed), nil
+}
@@ -0,0 +1,136 @@
+package plugins
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// ConfigSnapshot 下发给插件的不可变配置快照。每次下发配置生成新的快照，Epoch单调递增，
+// 插件处理事件时持有的快照不会被后续的配置更新修改，比较Epoch即可判断配置是否变化
+type ConfigSnapshot struct {
+	epoch     uint64
+	values    map[string]interface{}
+	createdAt time.Time
+}
+
+// Epoch 配置版本号，同一宿主进程内单调递增，0表示插件尚未收到配置
+func (s *ConfigSnapshot) Epoch() uint64 {
+	if s == nil {
+		return 0
+	}
+	return s.epoch
+}
+
+// CreatedAt 快照生成时间
+func (s *ConfigSnapshot) CreatedAt() time.Time {
+	if s == nil {
+		return time.Time{}
+	}
+	return s.createdAt
+}
+
+// Get 获取配置项，返回的map和切片是副本，修改不影响快照
+func (s *ConfigSnapshot) Get(key string) (interface{}, bool) {
+	if s == nil {
+		return nil, false
+	}
+	v, ok := s.values[key]
+	return copyConfigValue(v), ok
+}
+
+// Keys 获取所有配置项名称，按名称排序
+func (s *ConfigSnapshot) Keys() []string {
+	if s == nil {
+		return nil
+	}
+	keys := make([]string, 0, len(s.values))
+	for k := range s.values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Values 获取完整配置的副本
+func (s *ConfigSnapshot) Values() map[string]interface{} {
+	if s == nil {
+		return nil
+	}
+	return copyConfigMap(s.values)
+}
+
+// configSnapshots 每个插件当前的配置快照
+type configSnapshots struct {
+	current map[string]*ConfigSnapshot
+	epoch   uint64
+	mutex   sync.RWMutex
+}
+
+func newConfigSnapshots() *configSnapshots {
+	return &configSnapshots{current: make(map[string]*ConfigSnapshot)}
+}
+
+// deliverConfig 生成插件的有效配置快照并下发：插件的SetConfig收到快照内容的独立副本，
+// 管理器和其他插件不会再修改该map；HostAPI.Config随后返回新快照，调用方需持有m.mutex
+func (m *Manager) deliverConfig(name string, instance Plugin, manifest *PluginManifest, config map[string]interface{}) {
+	values := copyConfigMap(m.effectiveConfig(name, manifest, config))
+
+	m.configs.mutex.Lock()
+	m.configs.epoch++
+	snapshot := &ConfigSnapshot{epoch: m.configs.epoch, values: values, createdAt: time.Now()}
+	m.configs.current[name] = snapshot
+	m.configs.mutex.Unlock()
+
+	instance.SetConfig(snapshot.Values())
+}
+
+// configSnapshot 获取插件当前的配置快照，未下发过配置时返回nil
+func (m *Manager) configSnapshot(name string) *ConfigSnapshot {
+	m.configs.mutex.RLock()
+	defer m.configs.mutex.RUnlock()
+
+	return m.configs.current[name]
+}
+
+// forgetConfigSnapshot 插件卸载时删除其配置快照
+func (m *Manager) forgetConfigSnapshot(name string) {
+	m.configs.mutex.Lock()
+	defer m.configs.mutex.Unlock()
+
+	delete(m.configs.current, name)
+}
+
+func (h *pluginHost) Config() *ConfigSnapshot {
+	return h.m.configSnapshot(h.name)
+}
+
+// copyConfigMap 深拷贝配置，嵌套的map和切片同样复制
+func copyConfigMap(config map[string]interface{}) map[string]interface{} {
+	if config == nil {
+		return nil
+	}
+	result := make(map[string]interface{}, len(config))
+	for k, v := range config {
+		result[k] = copyConfigValue(v)
+	}
+	return result
+}
+
+// copyConfigValue 深拷贝单个配置值
+func copyConfigValue(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		return copyConfigMap(value)
+	case []interface{}:
+		result := make([]interface{}, len(value))
+		for i, item := range value {
+			result[i] = copyConfigValue(item)
+		}
+		return result
+	case []string:
+		return append([]string(nil), value...)
+	default:
+		return v
+	}
+}
@@ -0,0 +1,212 @@
+package plugins
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrNameConflict 插件文件与已加载的插件同名而未被加载
+var ErrNameConflict = errors.New("插件名称冲突")
+
+// NameConflictPolicy 同一搜索目录中的不同插件文件返回相同名称时的处理策略。
+// 不同搜索目录中的同名插件始终以优先级更高的目录为准，不受该策略影响
+type NameConflictPolicy string
+
+const (
+	NameConflictKeepFirst           NameConflictPolicy = "keep_first"            // 保留先加载的插件，拒绝后加载的文件（默认）
+	NameConflictFail                NameConflictPolicy = "fail"                  // 同名的文件都不加载，移除多余的文件后重新加载插件
+	NameConflictPreferHigherVersion NameConflictPolicy = "prefer_higher_version" // 保留版本号更高的插件，版本相同时保留先加载的
+)
+
+// NameConflict 插件名称冲突记录，对应一个未被加载的插件文件
+type NameConflict struct {
+	Name    string             `json:"name"`
+	Path    string             `json:"path"` // 未被加载的插件文件
+	Version string             `json:"version,omitempty"`
+	Winner  string             `json:"winner,omitempty"` // 保留的插件文件，fail策略下为空
+	Policy  NameConflictPolicy `json:"policy"`
+	Reason  string             `json:"reason"`
+	Time    time.Time          `json:"time"`
+}
+
+// nameConflicts 名称冲突策略及冲突记录
+type nameConflicts struct {
+	policy  NameConflictPolicy
+	records map[string]NameConflict // 插件文件路径 -> 冲突记录
+	failed  map[string]bool         // fail策略下不再加载的插件名称
+	mutex   sync.RWMutex
+}
+
+func newNameConflicts() *nameConflicts {
+	return &nameConflicts{
+		policy:  NameConflictKeepFirst,
+		records: make(map[string]NameConflict),
+		failed:  make(map[string]bool),
+	}
+}
+
+// SetNameConflictPolicy 设置同一搜索目录中插件同名时的处理策略，需在LoadPlugins之前调用
+func (m *Manager) SetNameConflictPolicy(policy NameConflictPolicy) error {
+	if err := m.checkWritable(); err != nil {
+		return err
+	}
+
+	switch policy {
+	case NameConflictKeepFirst, NameConflictFail, NameConflictPreferHigherVersion:
+	default:
+		return fmt.Errorf("不支持的名称冲突策略: %s", policy)
+	}
+
+	m.conflicts.mutex.Lock()
+	defer m.conflicts.mutex.Unlock()
+
+	m.conflicts.policy = policy
+	return nil
+}
+
+// GetNameConflictPolicy 获取同一搜索目录中插件同名时的处理策略
+func (m *Manager) GetNameConflictPolicy() NameConflictPolicy {
+	m.conflicts.mutex.RLock()
+	defer m.conflicts.mutex.RUnlock()
+
+	return m.conflicts.policy
+}
+
+// NameConflicts 获取因名称冲突未被加载的插件文件，按名称和路径排序
+func (m *Manager) NameConflicts() []NameConflict {
+	m.conflicts.mutex.RLock()
+	defer m.conflicts.mutex.RUnlock()
+
+	result := make([]NameConflict, 0, len(m.conflicts.records))
+	for _, record := range m.conflicts.records {
+		result = append(result, record)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Name != result[j].Name {
+			return result[i].Name < result[j].Name
+		}
+		return result[i].Path < result[j].Path
+	})
+	return result
+}
+
+// resetNameConflicts 重新扫描插件目录前清空冲突记录
+func (m *Manager) resetNameConflicts() {
+	m.conflicts.mutex.Lock()
+	defer m.conflicts.mutex.Unlock()
+
+	m.conflicts.records = make(map[string]NameConflict)
+	m.conflicts.failed = make(map[string]bool)
+}
+
+// recordNameConflict 记录未被加载的插件文件并返回对应的错误
+func (m *Manager) recordNameConflict(conflict NameConflict) error {
+	conflict.Time = time.Now()
+
+	m.conflicts.mutex.Lock()
+	m.conflicts.records[filepath.Clean(conflict.Path)] = conflict
+	m.conflicts.mutex.Unlock()
+
+	m.logger.Printf("插件文件 %s 因名称冲突未加载: %s", conflict.Path, conflict.Reason)
+	return fmt.Errorf("%w: %s", ErrNameConflict, conflict.Reason)
+}
+
+// clearNameConflict 插件文件加载后删除其冲突记录
+func (m *Manager) clearNameConflict(pluginPath string) {
+	m.conflicts.mutex.Lock()
+	defer m.conflicts.mutex.Unlock()
+
+	delete(m.conflicts.records, filepath.Clean(pluginPath))
+}
+
+// resolveNameConflict 处理同名插件：不同搜索目录中优先级更高的目录中的插件替换已加载的插件，优先级更低时返回错误；
+// 同一搜索目录中按名称冲突策略处理，未被加载的文件记录原因。返回nil时调用方继续登记插件，调用方需持有m.mutex
+func (m *Manager) resolveNameConflict(name, version, pluginPath string) error {
+	m.conflicts.mutex.RLock()
+	policy, failed := m.conflicts.policy, m.conflicts.failed[name]
+	m.conflicts.mutex.RUnlock()
+
+	existing, exists := m.plugins[name]
+	if !exists || filepath.Clean(existing.FilePath) == filepath.Clean(pluginPath) {
+		if failed && !exists {
+			return m.recordNameConflict(NameConflict{
+				Name:    name,
+				Path:    pluginPath,
+				Version: version,
+				Policy:  NameConflictFail,
+				Reason:  fmt.Sprintf("插件 %s 存在多个同名文件，移除多余的文件后重新加载", name),
+			})
+		}
+		m.clearNameConflict(pluginPath)
+		return nil
+	}
+
+	newPriority, existingPriority := m.dirPriority(pluginPath), m.dirPriority(existing.FilePath)
+	if newPriority < existingPriority {
+		return fmt.Errorf("插件 %s 已由优先级更高的 %s 提供，忽略 %s", name, existing.FilePath, pluginPath)
+	}
+	if newPriority > existingPriority {
+		m.replaceConflicting(existing)
+		m.logger.Printf("插件 %s 由 %s 覆盖 %s", name, pluginPath, existing.FilePath)
+		m.clearNameConflict(pluginPath)
+		return nil
+	}
+
+	conflict := NameConflict{Name: name, Path: pluginPath, Version: version, Winner: existing.FilePath, Policy: policy}
+	switch policy {
+	case NameConflictFail:
+		// 已加载的同名插件同样移除，两个文件都记录冲突
+		m.replaceConflicting(existing)
+		m.conflicts.mutex.Lock()
+		m.conflicts.failed[name] = true
+		m.conflicts.mutex.Unlock()
+
+		reason := fmt.Sprintf("插件 %s 同时由 %s 和 %s 提供，移除多余的文件后重新加载", name, existing.FilePath, pluginPath)
+		_ = m.recordNameConflict(NameConflict{
+			Name:    name,
+			Path:    existing.FilePath,
+			Version: existing.Version,
+			Policy:  policy,
+			Reason:  reason,
+		})
+		conflict.Winner, conflict.Reason = "", reason
+		return m.recordNameConflict(conflict)
+
+	case NameConflictPreferHigherVersion:
+		if compareVersions(version, existing.Version) > 0 {
+			m.replaceConflicting(existing)
+			_ = m.recordNameConflict(NameConflict{
+				Name:    name,
+				Path:    existing.FilePath,
+				Version: existing.Version,
+				Winner:  pluginPath,
+				Policy:  policy,
+				Reason:  fmt.Sprintf("插件 %s 的 %s（v%s）版本更高，替换 v%s", name, pluginPath, version, existing.Version),
+			})
+			m.clearNameConflict(pluginPath)
+			return nil
+		}
+		conflict.Reason = fmt.Sprintf("插件 %s 已由 %s（v%s）提供，v%s 的版本不更高", name, existing.FilePath, existing.Version, version)
+		return m.recordNameConflict(conflict)
+
+	default:
+		conflict.Reason = fmt.Sprintf("插件 %s 已由先加载的 %s 提供", name, existing.FilePath)
+		return m.recordNameConflict(conflict)
+	}
+}
+
+// replaceConflicting 从内存中移除被替换的同名插件，保留其存储记录，移除替换它的插件文件后可重新生效，调用方需持有m.mutex
+func (m *Manager) replaceConflicting(existing *PluginInfo) {
+	if existing.Enabled {
+		if err := existing.Plugin.Close(); err != nil {
+			m.logger.Printf("关闭插件 %s 失败: %v", existing.Name, err)
+		}
+		m.checkLeaks(existing.Name)
+	}
+	delete(m.plugins, existing.Name)
+	m.forgetConfigSnapshot(existing.Name)
+}
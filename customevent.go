@@ -0,0 +1,177 @@
+package plugins
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CustomEventType 运行时登记的自定义事件类型，插件在InterestedEvents中声明即可接收，不受InterestedAPIs限制
+type CustomEventType struct {
+	Type        EventType       `json:"type"`
+	Description string          `json:"description,omitempty"`
+	Schema      json.RawMessage `json:"schema,omitempty"` // 事件数据的JSON Schema（常用子集），为空时不校验
+	Owner       string          `json:"owner,omitempty"`  // 登记的插件名称，宿主登记时为空
+}
+
+// customEvent 已登记的自定义事件类型及解析后的Schema
+type customEvent struct {
+	def    CustomEventType
+	schema *configSchema
+}
+
+// customEventRegistry 自定义事件类型
+type customEventRegistry struct {
+	events map[EventType]*customEvent
+	mutex  sync.RWMutex
+}
+
+func newCustomEventRegistry() *customEventRegistry {
+	return &customEventRegistry{events: make(map[EventType]*customEvent)}
+}
+
+// RegisterEventType 登记自定义事件类型，不能与内置的API事件和宿主业务事件同名；
+// 已由其他插件或宿主登记的类型不能重复登记，同一登记方重复登记时替换描述和Schema
+func (m *Manager) RegisterEventType(def CustomEventType) error {
+	if err := m.checkWritable(); err != nil {
+		return err
+	}
+
+	if def.Type == "" {
+		return fmt.Errorf("事件类型不能为空")
+	}
+	if isBuiltinEvent(def.Type) {
+		return fmt.Errorf("事件类型 %s 与内置事件同名", def.Type)
+	}
+
+	var schema *configSchema
+	if len(def.Schema) > 0 {
+		schema = &configSchema{}
+		if err := json.Unmarshal(def.Schema, schema); err != nil {
+			return fmt.Errorf("解析事件 %s 的Schema失败: %v", def.Type, err)
+		}
+	}
+	def.Schema = append(json.RawMessage(nil), def.Schema...)
+
+	m.customEvents.mutex.Lock()
+	defer m.customEvents.mutex.Unlock()
+
+	if existing, exists := m.customEvents.events[def.Type]; exists && existing.def.Owner != def.Owner {
+		owner := existing.def.Owner
+		if owner == "" {
+			owner = "宿主"
+		}
+		return fmt.Errorf("事件类型 %s 已被登记，登记方: %s", def.Type, owner)
+	}
+	m.customEvents.events[def.Type] = &customEvent{def: def, schema: schema}
+	return nil
+}
+
+// UnregisterEventType 注销自定义事件类型
+func (m *Manager) UnregisterEventType(event EventType) error {
+	if err := m.checkWritable(); err != nil {
+		return err
+	}
+
+	m.customEvents.mutex.Lock()
+	defer m.customEvents.mutex.Unlock()
+
+	if _, exists := m.customEvents.events[event]; !exists {
+		return fmt.Errorf("自定义事件类型不存在: %s", event)
+	}
+	delete(m.customEvents.events, event)
+	return nil
+}
+
+// CustomEventTypes 获取所有已登记的自定义事件类型，按类型名称排序
+func (m *Manager) CustomEventTypes() []CustomEventType {
+	m.customEvents.mutex.RLock()
+	defer m.customEvents.mutex.RUnlock()
+
+	result := make([]CustomEventType, 0, len(m.customEvents.events))
+	for _, event := range m.customEvents.events {
+		result = append(result, event.def)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Type < result[j].Type
+	})
+	return result
+}
+
+// TriggerCustomEvent 触发已登记的自定义事件，payload需可JSON序列化并符合登记的Schema；
+// 插件收到的requestBody为payload按JSON规范化后的值（对象为map[string]interface{}），ctx可以为nil
+func (m *Manager) TriggerCustomEvent(ctx *gin.Context, event EventType, payload interface{}) error {
+	m.customEvents.mutex.RLock()
+	custom, exists := m.customEvents.events[event]
+	m.customEvents.mutex.RUnlock()
+	if !exists {
+		return fmt.Errorf("自定义事件类型未登记: %s", event)
+	}
+
+	// 按JSON规范化，使各种运行时的插件看到同样的数据且与Schema中的类型一致
+	var normalized interface{}
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("序列化事件数据失败: %v", err)
+		}
+		if err := json.Unmarshal(data, &normalized); err != nil {
+			return fmt.Errorf("解析事件数据失败: %v", err)
+		}
+	}
+	if err := custom.schema.validate("payload", normalized); err != nil {
+		return fmt.Errorf("事件 %s 的数据不符合Schema: %v", event, err)
+	}
+
+	m.triggerEvent(ctx, newEventID(), event, "", 0, normalized, nil)
+	return nil
+}
+
+// isCustomEvent 判断事件是否为已登记的自定义事件
+func (m *Manager) isCustomEvent(event EventType) bool {
+	m.customEvents.mutex.RLock()
+	defer m.customEvents.mutex.RUnlock()
+
+	_, exists := m.customEvents.events[event]
+	return exists
+}
+
+// forgetEventTypes 插件卸载时注销其登记的自定义事件类型
+func (m *Manager) forgetEventTypes(name string) {
+	m.customEvents.mutex.Lock()
+	defer m.customEvents.mutex.Unlock()
+
+	for event, custom := range m.customEvents.events {
+		if custom.def.Owner == name {
+			delete(m.customEvents.events, event)
+		}
+	}
+}
+
+// builtinEvents 返回所有内置的API事件和宿主业务事件
+func builtinEvents() []EventType {
+	return append([]EventType{EventAPISuccess, EventAPIError, EventAPIBefore, EventAPIAfter}, DomainEvents()...)
+}
+
+// isBuiltinEvent 判断事件是否为内置的API事件或宿主业务事件
+func isBuiltinEvent(event EventType) bool {
+	switch event {
+	case EventAPISuccess, EventAPIError, EventAPIBefore, EventAPIAfter:
+		return true
+	}
+	return isDomainEvent(event)
+}
+
+// RegisterEventType 以当前插件的名义登记自定义事件类型，插件卸载时自动注销
+func (h *pluginHost) RegisterEventType(def CustomEventType) error {
+	def.Owner = h.name
+	return h.m.RegisterEventType(def)
+}
+
+// EmitEvent 触发已登记的自定义事件
+func (h *pluginHost) EmitEvent(event EventType, payload interface{}) error {
+	return h.m.TriggerCustomEvent(nil, event, payload)
+}
@@ -0,0 +1,364 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Dependency 插件依赖：依赖的插件名称及版本范围
+type Dependency struct {
+	Name string `json:"name"`
+
+	// Version 版本范围，为空或*表示任意版本；支持 >=1.2.0 <2.0.0 形式的比较组合、
+	// ^1.2.0、~1.2.0、1.x，以及 || 分隔的多个范围
+	Version string `json:"version,omitempty"`
+}
+
+// DependencyDeclarer 声明依赖的插件实现此接口（可选实现），与清单中的dependencies合并，同名时以此接口为准
+type DependencyDeclarer interface {
+	Dependencies() []Dependency
+}
+
+// dependencies 合并清单和插件实例声明的依赖，按名称排序
+func (info *PluginInfo) dependencies() []Dependency {
+	byName := make(map[string]Dependency)
+	if info.Manifest != nil {
+		for _, dep := range info.Manifest.Dependencies {
+			byName[dep.Name] = dep
+		}
+	}
+	if declarer, ok := info.Plugin.(DependencyDeclarer); ok {
+		for _, dep := range declarer.Dependencies() {
+			byName[dep.Name] = dep
+		}
+	}
+
+	deps := make([]Dependency, 0, len(byName))
+	for _, name := range sortedKeys(byName) {
+		deps = append(deps, byName[name])
+	}
+	return deps
+}
+
+// unmetDependencies 返回插件未满足的依赖说明，依赖的插件需已加载、已启用且版本满足范围，调用方需持有m.mutex
+func (m *Manager) unmetDependencies(info *PluginInfo) []string {
+	var unmet []string
+	for _, dep := range info.dependencies() {
+		target, exists := m.plugins[dep.Name]
+		switch {
+		case !exists:
+			unmet = append(unmet, fmt.Sprintf("%s 未加载", dep.Name))
+		case !target.Enabled:
+			unmet = append(unmet, fmt.Sprintf("%s 未启用", dep.Name))
+		default:
+			ok, err := versionSatisfies(target.Version, dep.Version)
+			if err != nil {
+				unmet = append(unmet, fmt.Sprintf("%s 的版本范围无效: %v", dep.Name, err))
+			} else if !ok {
+				unmet = append(unmet, fmt.Sprintf("%s 版本 %s 不满足 %s", dep.Name, target.Version, dep.Version))
+			}
+		}
+	}
+	return unmet
+}
+
+// DependencyOrder 按依赖关系返回已加载插件的启用顺序，被依赖的插件排在前面；存在循环依赖时返回错误
+func (m *Manager) DependencyOrder() ([]string, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	order, cyclic := sortByDependencies(m.dependencyGraph(), m.priorityMap())
+	if len(cyclic) > 0 {
+		return order, fmt.Errorf("插件存在循环依赖: %s", strings.Join(cyclic, ", "))
+	}
+	return order, nil
+}
+
+// Dependents 获取直接依赖指定插件的已加载插件，按名称排序
+func (m *Manager) Dependents(name string) []string {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	var dependents []string
+	for _, info := range m.plugins {
+		if dependsOn(info, name) {
+			dependents = append(dependents, info.Name)
+		}
+	}
+	sort.Strings(dependents)
+	return dependents
+}
+
+// dependencyGraph 返回已加载插件的依赖图（插件名称 -> 依赖的插件名称），调用方需持有m.mutex
+func (m *Manager) dependencyGraph() map[string][]string {
+	graph := make(map[string][]string, len(m.plugins))
+	for name, info := range m.plugins {
+		var deps []string
+		for _, dep := range info.dependencies() {
+			deps = append(deps, dep.Name)
+		}
+		graph[name] = deps
+	}
+	return graph
+}
+
+// dependsOn 判断插件是否直接依赖name
+func dependsOn(info *PluginInfo, name string) bool {
+	for _, dep := range info.dependencies() {
+		if dep.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// disableDependents 级联禁用依赖name的已启用插件，调用方需持有m.mutex
+func (m *Manager) disableDependents(name string) {
+	for _, info := range m.plugins {
+		if !info.Enabled || !dependsOn(info, name) {
+			continue
+		}
+
+		m.logger.Printf("警告: 插件 %s 依赖的插件 %s 已停止，级联禁用", info.Name, name)
+		if !info.dormant {
+			if err := info.Plugin.Close(); err != nil {
+				m.logger.Printf("关闭插件 %s 失败: %v", info.Name, err)
+			}
+			m.checkLeaks(info.Name)
+		}
+		_ = m.setState(info, StateDisabled, fmt.Sprintf("依赖的插件 %s 已停止", name))
+		m.clearReadiness(info.Name)
+
+		if err := m.store().SavePlugin(info.Name, info.FilePath, false, info.Config); err != nil {
+			m.logger.Printf("更新插件状态到存储失败: %v", err)
+		}
+
+		m.disableDependents(info.Name)
+	}
+}
+
+// awaitDependencies 加载时依赖未满足的已启用插件转为等待状态，不写入存储，调用方需持有m.mutex
+func (m *Manager) awaitDependencies(info *PluginInfo) {
+	if !info.Enabled {
+		return
+	}
+	unmet := m.unmetDependencies(info)
+	if len(unmet) == 0 {
+		return
+	}
+
+	reason := strings.Join(unmet, "; ")
+	info.Enabled, info.State = false, StateDisabled
+	info.StateReason = fmt.Sprintf("等待依赖: %s", reason)
+	info.awaitingDeps = true
+	m.logger.Printf("插件 %s 的依赖未满足，等待依赖启用: %s", info.Name, reason)
+}
+
+// startAwaitingPlugins 启动加载时因依赖未满足而等待的插件，直到没有插件可以启动为止，调用方需持有m.mutex
+func (m *Manager) startAwaitingPlugins(ctx context.Context) {
+	for progress := true; progress; {
+		progress = false
+
+		order, _ := sortByDependencies(m.dependencyGraph(), m.priorityMap())
+		for _, name := range order {
+			info := m.plugins[name]
+			if !info.awaitingDeps || len(m.unmetDependencies(info)) > 0 {
+				continue
+			}
+
+			info.awaitingDeps = false
+			if err := m.startLoadedPlugin(ctx, info); err != nil {
+				m.logger.Printf("初始化插件 %s 失败: %v", info.Name, err)
+				_ = m.setState(info, StateInitFailed, fmt.Sprintf("初始化失败: %v", err))
+				if err := m.store().SavePlugin(info.Name, info.FilePath, false, info.Config); err != nil {
+					m.logger.Printf("更新插件状态到存储失败: %v", err)
+				}
+				continue
+			}
+
+			_ = m.setState(info, StateEnabled, "")
+			m.logger.Printf("插件 %s 的依赖已满足，已启用", info.Name)
+			progress = true
+		}
+	}
+}
+
+// sortByDependencies 按依赖关系拓扑排序，同层按优先级从高到低、再按名称排序；依赖图之外的依赖忽略。
+// 循环依赖中的插件追加在末尾并通过cyclic返回
+func sortByDependencies(graph map[string][]string, priorities map[string]int) (order []string, cyclic []string) {
+	byPriority := func(names []string) {
+		sort.SliceStable(names, func(i, j int) bool {
+			if priorities[names[i]] != priorities[names[j]] {
+				return priorities[names[i]] > priorities[names[j]]
+			}
+			return names[i] < names[j]
+		})
+	}
+
+	indegree := make(map[string]int, len(graph))
+	dependents := make(map[string][]string, len(graph))
+	for name := range graph {
+		indegree[name] = 0
+	}
+	for name, deps := range graph {
+		for _, dep := range deps {
+			if _, exists := graph[dep]; !exists || dep == name {
+				continue
+			}
+			indegree[name]++
+			dependents[dep] = append(dependents[dep], name)
+		}
+	}
+
+	var ready []string
+	for name, degree := range indegree {
+		if degree == 0 {
+			ready = append(ready, name)
+		}
+	}
+	byPriority(ready)
+
+	for len(ready) > 0 {
+		name := ready[0]
+		ready = ready[1:]
+		order = append(order, name)
+
+		var next []string
+		for _, dependent := range dependents[name] {
+			indegree[dependent]--
+			if indegree[dependent] == 0 {
+				next = append(next, dependent)
+			}
+		}
+		ready = append(ready, next...)
+		byPriority(ready)
+	}
+
+	for name, degree := range indegree {
+		if degree > 0 {
+			cyclic = append(cyclic, name)
+		}
+	}
+	sort.Strings(cyclic)
+	return append(order, cyclic...), cyclic
+}
+
+// validateConstraint 校验版本范围格式
+func validateConstraint(constraint string) error {
+	_, err := versionSatisfies("0.0.0", constraint)
+	return err
+}
+
+// versionSatisfies 判断版本是否满足范围约束，约束为空或*时任意版本均满足
+func versionSatisfies(version string, constraint string) (bool, error) {
+	constraint = strings.TrimSpace(constraint)
+	if constraint == "" || constraint == "*" {
+		return true, nil
+	}
+
+	v, err := parseVersion(version)
+	if err != nil {
+		return false, err
+	}
+
+	satisfied := false
+	for _, alternative := range strings.Split(constraint, "||") {
+		comparators := strings.Fields(alternative)
+		if len(comparators) == 0 {
+			return false, fmt.Errorf("无效的版本范围: %q", constraint)
+		}
+
+		all := true
+		for _, comparator := range comparators {
+			ok, err := matchComparator(v, comparator)
+			if err != nil {
+				return false, err
+			}
+			all = all && ok
+		}
+		satisfied = satisfied || all
+	}
+	return satisfied, nil
+}
+
+// matchComparator 判断版本是否满足单个比较条件，如 >=1.2.0、^1.2、~1.2.3、1.x
+func matchComparator(v []int, comparator string) (bool, error) {
+	for _, op := range []string{">=", "<=", ">", "<", "="} {
+		if rest, ok := strings.CutPrefix(comparator, op); ok {
+			target, err := parseVersion(rest)
+			if err != nil {
+				return false, err
+			}
+			c := compareVersionParts(v, target)
+			switch op {
+			case ">=":
+				return c >= 0, nil
+			case "<=":
+				return c <= 0, nil
+			case ">":
+				return c > 0, nil
+			case "<":
+				return c < 0, nil
+			default:
+				return c == 0, nil
+			}
+		}
+	}
+
+	var lower, upper []int
+	switch {
+	case strings.HasPrefix(comparator, "^"):
+		target, err := parseVersion(comparator[1:])
+		if err != nil {
+			return false, err
+		}
+		// 不改变最左侧的非零版本段
+		lower = target
+		i := 0
+		for i < len(target)-1 && target[i] == 0 {
+			i++
+		}
+		upper = bumpVersion(target, i)
+	case strings.HasPrefix(comparator, "~"):
+		target, err := parseVersion(comparator[1:])
+		if err != nil {
+			return false, err
+		}
+		// ~1.2.3 允许补丁版本变化，~1 允许次版本变化
+		lower = target
+		upper = bumpVersion(target, min(1, len(target)-1))
+	default:
+		// 1.x、1.2.* 通配，其余为精确匹配
+		parts := strings.Split(strings.TrimPrefix(comparator, "v"), ".")
+		wildcard := len(parts)
+		for i, part := range parts {
+			if part == "x" || part == "X" || part == "*" {
+				wildcard = i
+				break
+			}
+		}
+		target, err := parseVersion(strings.Join(parts[:max(wildcard, 1)], "."))
+		if err != nil {
+			return false, err
+		}
+		if wildcard == len(parts) {
+			return compareVersionParts(v, target) == 0, nil
+		}
+		if wildcard == 0 {
+			return true, nil
+		}
+		lower = target
+		upper = bumpVersion(target, wildcard-1)
+	}
+
+	return compareVersionParts(v, lower) >= 0 && compareVersionParts(v, upper) < 0, nil
+}
+
+// bumpVersion 将第i段版本号加1并截断之后的版本段
+func bumpVersion(version []int, i int) []int {
+	bumped := append([]int{}, version[:i+1]...)
+	bumped[i]++
+	return bumped
+}
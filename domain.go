@@ -0,0 +1,116 @@
+package plugins
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 宿主业务事件：由宿主在业务操作完成后通过TriggerDomainEvent触发，不对应API路径，
+// 插件在InterestedEvents中声明即可接收而不受InterestedAPIs限制；事件数据以requestBody传给插件，类型见domainEvents
+const (
+	EventNodeCreated           EventType = "node_created"           // 节点已创建，数据为NodeEvent
+	EventNodeUpdated           EventType = "node_updated"           // 节点已修改，数据为NodeEvent，Previous为修改前的节点
+	EventNodeDeleted           EventType = "node_deleted"           // 节点已删除，数据为NodeEvent
+	EventSubscriptionGenerated EventType = "subscription_generated" // 订阅已生成，数据为SubscriptionEvent
+	EventUserLogin             EventType = "user_login"             // 用户登录（包括失败的登录），数据为UserLoginEvent
+	EventTemplateChanged       EventType = "template_changed"       // 模板已创建、修改或删除，数据为TemplateEvent
+)
+
+// NodeInfo 节点信息
+type NodeInfo struct {
+	ID     int    `json:"id"`
+	Name   string `json:"name"`
+	Link   string `json:"link"`
+	Group  string `json:"group,omitempty"`
+	Source string `json:"source,omitempty"` // 节点来源，如手动添加或机场订阅名称
+}
+
+// NodeEvent 节点事件的数据
+type NodeEvent struct {
+	Node     NodeInfo  `json:"node"`
+	Previous *NodeInfo `json:"previous,omitempty"` // 修改前的节点，只在node_updated中提供
+}
+
+// SubscriptionEvent 订阅生成事件的数据
+type SubscriptionEvent struct {
+	Name      string `json:"name"`
+	Client    string `json:"client"` // 客户端类型，如 clash、surge、v2ray
+	NodeCount int    `json:"node_count"`
+	ClientIP  string `json:"client_ip,omitempty"`
+	UserAgent string `json:"user_agent,omitempty"`
+}
+
+// UserLoginEvent 用户登录事件的数据
+type UserLoginEvent struct {
+	Username  string `json:"username"`
+	Success   bool   `json:"success"`
+	Reason    string `json:"reason,omitempty"` // 登录失败的原因
+	ClientIP  string `json:"client_ip,omitempty"`
+	UserAgent string `json:"user_agent,omitempty"`
+}
+
+// TemplateAction 模板变更的操作
+type TemplateAction string
+
+const (
+	TemplateCreated TemplateAction = "created"
+	TemplateUpdated TemplateAction = "updated"
+	TemplateDeleted TemplateAction = "deleted"
+)
+
+// TemplateEvent 模板变更事件的数据
+type TemplateEvent struct {
+	Name   string         `json:"name"`
+	Action TemplateAction `json:"action"`
+}
+
+// domainEvents 宿主业务事件及其数据类型
+var domainEvents = map[EventType]reflect.Type{
+	EventNodeCreated:           reflect.TypeOf(NodeEvent{}),
+	EventNodeUpdated:           reflect.TypeOf(NodeEvent{}),
+	EventNodeDeleted:           reflect.TypeOf(NodeEvent{}),
+	EventSubscriptionGenerated: reflect.TypeOf(SubscriptionEvent{}),
+	EventUserLogin:             reflect.TypeOf(UserLoginEvent{}),
+	EventTemplateChanged:       reflect.TypeOf(TemplateEvent{}),
+}
+
+// DomainEvents 返回所有宿主业务事件类型
+func DomainEvents() []EventType {
+	events := make([]EventType, 0, len(domainEvents))
+	for event := range domainEvents {
+		events = append(events, event)
+	}
+	sort.Slice(events, func(i, j int) bool {
+		return events[i] < events[j]
+	})
+	return events
+}
+
+// isDomainEvent 判断事件是否为宿主业务事件
+func isDomainEvent(event EventType) bool {
+	_, ok := domainEvents[event]
+	return ok
+}
+
+// TriggerDomainEvent 触发宿主业务事件，payload需为事件对应的数据类型（值或指针），插件收到的requestBody为该类型的值，
+// 如 requestBody.(plugins.NodeEvent)；独立进程和脚本插件收到其JSON。ctx可以为nil，在请求处理中触发时传入当前请求
+func (m *Manager) TriggerDomainEvent(ctx *gin.Context, event EventType, payload interface{}) error {
+	expected, ok := domainEvents[event]
+	if !ok {
+		return fmt.Errorf("不是宿主业务事件: %s", event)
+	}
+
+	v := reflect.ValueOf(payload)
+	if v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+	if !v.IsValid() || v.Type() != expected {
+		return fmt.Errorf("事件 %s 的数据类型应为 %s，实际为 %T", event, expected.Name(), payload)
+	}
+
+	m.triggerEvent(ctx, newEventID(), event, "", 0, v.Interface(), nil)
+	return nil
+}
@@ -0,0 +1,137 @@
+package plugins
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// defaultDrainTimeout 停用插件前等待已分发事件处理完成的默认时间
+const defaultDrainTimeout = 10 * time.Second
+
+// errPluginDraining 插件正在停用时记录在事件结果中的错误
+var errPluginDraining = fmt.Errorf("插件正在停用，不再接收新事件")
+
+// pluginWork 单个插件已分发尚未完成的事件处理，包括等待并发名额的处理
+type pluginWork struct {
+	pending  int
+	draining bool
+	idle     chan struct{} // 停用等待期间pending降为0时关闭
+}
+
+// drainTracker 插件事件处理计数，停用插件时据此等待处理完成
+type drainTracker struct {
+	work    map[string]*pluginWork
+	timeout time.Duration
+	mutex   sync.Mutex
+}
+
+func newDrainTracker() *drainTracker {
+	return &drainTracker{work: make(map[string]*pluginWork), timeout: defaultDrainTimeout}
+}
+
+// SetDrainTimeout 设置停用插件前等待已分发事件处理完成的最长时间，超时后仍然关闭插件；d<=0时恢复默认值
+func (m *Manager) SetDrainTimeout(d time.Duration) {
+	m.drains.mutex.Lock()
+	defer m.drains.mutex.Unlock()
+
+	if d <= 0 {
+		d = defaultDrainTimeout
+	}
+	m.drains.timeout = d
+}
+
+// PendingEvents 获取插件已分发尚未处理完成的事件数，包括等待并发名额的事件
+func (m *Manager) PendingEvents(name string) int {
+	m.drains.mutex.Lock()
+	defer m.drains.mutex.Unlock()
+
+	if w, exists := m.drains.work[name]; exists {
+		return w.pending
+	}
+	return 0
+}
+
+// beginWork 登记一次分发给插件的事件处理，返回处理完成时调用的函数；插件正在停用时返回false
+func (m *Manager) beginWork(name string) (func(), bool) {
+	t := m.drains
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	w, exists := t.work[name]
+	if !exists {
+		w = &pluginWork{}
+		t.work[name] = w
+	}
+	if w.draining {
+		return nil, false
+	}
+	w.pending++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			t.mutex.Lock()
+			defer t.mutex.Unlock()
+
+			w.pending--
+			if w.pending > 0 {
+				return
+			}
+			if w.idle != nil {
+				close(w.idle)
+				w.idle = nil
+			}
+			if !w.draining && t.work[name] == w {
+				delete(t.work, name)
+			}
+		})
+	}, true
+}
+
+// drainPlugin 停止向插件分发新事件，并在超时时间内等待已分发的处理完成；完成停用后需调用endDrain
+func (m *Manager) drainPlugin(name string) error {
+	t := m.drains
+	t.mutex.Lock()
+	w, exists := t.work[name]
+	if !exists {
+		w = &pluginWork{}
+		t.work[name] = w
+	}
+	w.draining = true
+	if w.pending == 0 {
+		t.mutex.Unlock()
+		return nil
+	}
+	if w.idle == nil {
+		w.idle = make(chan struct{})
+	}
+	idle, pending, timeout := w.idle, w.pending, t.timeout
+	t.mutex.Unlock()
+
+	m.logger.Printf("等待插件 %s 的 %d 个事件处理完成", name, pending)
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-idle:
+		return nil
+	case <-timer.C:
+		return fmt.Errorf("等待事件处理完成超时（%v），仍有 %d 个未完成", timeout, m.PendingEvents(name))
+	}
+}
+
+// endDrain 插件停用完成后恢复分发，未完成的处理继续计数直到结束
+func (m *Manager) endDrain(name string) {
+	t := m.drains
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	w, exists := t.work[name]
+	if !exists {
+		return
+	}
+	w.draining = false
+	if w.pending == 0 {
+		delete(t.work, name)
+	}
+}
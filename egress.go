@@ -0,0 +1,55 @@
+package plugins
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// defaultHTTPTimeout HostAPI HTTP客户端默认超时时间
+const defaultHTTPTimeout = 30 * time.Second
+
+// egressTransport 按插件清单的出站白名单限制请求
+type egressTransport struct {
+	plugin  string
+	allowed []string // nil表示不限制
+	base    http.RoundTripper
+	logger  Logger
+}
+
+func (t *egressTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.allowed != nil && !hostAllowed(req.URL.Hostname(), t.allowed) {
+		t.logger.Printf("插件 %s 访问未授权的主机被拒绝: %s", t.plugin, req.URL.Host)
+		return nil, fmt.Errorf("插件 %s 未被允许访问主机 %s", t.plugin, req.URL.Hostname())
+	}
+	return t.base.RoundTrip(req)
+}
+
+// hostAllowed 判断主机是否在白名单中，支持 *.example.com 通配子域名
+func hostAllowed(host string, allowed []string) bool {
+	host = strings.ToLower(host)
+	for _, pattern := range allowed {
+		pattern = strings.ToLower(pattern)
+		if pattern == host {
+			return true
+		}
+		if strings.HasPrefix(pattern, "*.") && strings.HasSuffix(host, pattern[1:]) {
+			return true
+		}
+	}
+	return false
+}
+
+// HTTPClient 返回受插件出站白名单约束的HTTP客户端，重定向目标同样受约束
+func (h *pluginHost) HTTPClient() *http.Client {
+	return &http.Client{
+		Timeout: defaultHTTPTimeout,
+		Transport: &egressTransport{
+			plugin:  h.name,
+			allowed: h.egress,
+			base:    http.DefaultTransport,
+			logger:  h.m.logger,
+		},
+	}
+}
@@ -0,0 +1,193 @@
+package plugins
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// PluginSecretEnv 用于加密插件环境变量的密钥所在的环境变量
+const PluginSecretEnv = "SUBLINK_PLUGIN_SECRET"
+
+// PluginEnvStorage 插件环境变量存储扩展接口（可选实现），保存的是加密后的数据
+type PluginEnvStorage interface {
+	// SavePluginEnv 保存插件的加密环境变量，data为nil时删除
+	SavePluginEnv(name string, data []byte) error
+
+	// LoadPluginEnv 读取插件的加密环境变量，不存在时返回nil
+	LoadPluginEnv(name string) ([]byte, error)
+}
+
+// envVault 插件环境变量加解密
+type envVault struct {
+	key   []byte
+	mutex sync.RWMutex
+}
+
+func newEnvVault() *envVault {
+	v := &envVault{}
+	if secret := os.Getenv(PluginSecretEnv); secret != "" {
+		v.setSecret(secret)
+	}
+	return v
+}
+
+func (v *envVault) setSecret(secret string) {
+	sum := sha256.Sum256([]byte(secret))
+	v.key = sum[:]
+}
+
+// SetPluginSecret 设置用于加密插件环境变量的密钥，未设置时从SUBLINK_PLUGIN_SECRET环境变量读取
+func (m *Manager) SetPluginSecret(secret string) error {
+	if err := m.checkWritable(); err != nil {
+		return err
+	}
+
+	if secret == "" {
+		return fmt.Errorf("密钥不能为空")
+	}
+
+	m.env.mutex.Lock()
+	defer m.env.mutex.Unlock()
+
+	m.env.setSecret(secret)
+	return nil
+}
+
+// SetPluginEnv 设置传递给独立进程插件的环境变量（如凭据、语言、代理），加密后保存，在插件进程下次启动时生效
+func (m *Manager) SetPluginEnv(name string, env map[string]string) error {
+	if err := m.checkWritable(); err != nil {
+		return err
+	}
+
+	envStorage, ok := m.store().(PluginEnvStorage)
+	if !ok {
+		return fmt.Errorf("当前存储不支持保存插件环境变量")
+	}
+
+	for key := range env {
+		if key == "" || strings.ContainsAny(key, "=\x00") {
+			return fmt.Errorf("无效的环境变量名: %q", key)
+		}
+	}
+
+	if len(env) == 0 {
+		if err := envStorage.SavePluginEnv(name, nil); err != nil {
+			return fmt.Errorf("删除插件环境变量失败: %v", err)
+		}
+		return nil
+	}
+
+	plain, err := json.Marshal(env)
+	if err != nil {
+		return fmt.Errorf("序列化插件环境变量失败: %v", err)
+	}
+
+	data, err := m.env.seal(plain)
+	if err != nil {
+		return err
+	}
+
+	if err := envStorage.SavePluginEnv(name, data); err != nil {
+		return fmt.Errorf("保存插件环境变量失败: %v", err)
+	}
+	return nil
+}
+
+// GetPluginEnv 获取插件的环境变量
+func (m *Manager) GetPluginEnv(name string) (map[string]string, error) {
+	envStorage, ok := m.store().(PluginEnvStorage)
+	if !ok {
+		return nil, nil
+	}
+
+	data, err := envStorage.LoadPluginEnv(name)
+	if err != nil {
+		return nil, fmt.Errorf("读取插件环境变量失败: %v", err)
+	}
+	if data == nil {
+		return nil, nil
+	}
+
+	plain, err := m.env.open(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var env map[string]string
+	if err := json.Unmarshal(plain, &env); err != nil {
+		return nil, fmt.Errorf("解析插件环境变量失败: %v", err)
+	}
+	return env, nil
+}
+
+// pluginEnviron 以KEY=VALUE形式返回插件进程的环境变量，按名称排序
+func (m *Manager) pluginEnviron(name string) ([]string, error) {
+	env, err := m.GetPluginEnv(name)
+	if err != nil {
+		return nil, err
+	}
+
+	environ := make([]string, 0, len(env))
+	for key, value := range env {
+		environ = append(environ, key+"="+value)
+	}
+	sort.Strings(environ)
+	return environ, nil
+}
+
+// seal 使用AES-GCM加密
+func (v *envVault) seal(plain []byte) ([]byte, error) {
+	gcm, err := v.cipher()
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("生成随机数失败: %v", err)
+	}
+	return gcm.Seal(nonce, nonce, plain, nil), nil
+}
+
+// open 使用AES-GCM解密
+func (v *envVault) open(data []byte) ([]byte, error) {
+	gcm, err := v.cipher()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < gcm.NonceSize() {
+		return nil, fmt.Errorf("插件环境变量数据已损坏")
+	}
+	nonce, sealed := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+
+	plain, err := gcm.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return nil, fmt.Errorf("解密插件环境变量失败，密钥可能已变更: %v", err)
+	}
+	return plain, nil
+}
+
+func (v *envVault) cipher() (cipher.AEAD, error) {
+	v.mutex.RLock()
+	key := v.key
+	v.mutex.RUnlock()
+
+	if key == nil {
+		return nil, fmt.Errorf("未设置插件环境变量密钥，请调用SetPluginSecret或设置%s环境变量", PluginSecretEnv)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("创建加密器失败: %v", err)
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,313 @@
+package plugins
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// GroupStatus 插件组的健康汇总状态
+type GroupStatus string
+
+const (
+	GroupHealthy  GroupStatus = "healthy"  // 所有成员都正常运行
+	GroupDegraded GroupStatus = "degraded" // 部分成员未运行或不健康
+	GroupDown     GroupStatus = "down"     // 没有正常运行的成员
+)
+
+// GroupMemberHealth 插件组成员的健康状态
+type GroupMemberHealth struct {
+	Name  string
+	State PluginState
+	Error string // 健康检查失败或就绪状态降级的原因，正常时为空
+}
+
+// GroupHealth 插件组的健康汇总
+type GroupHealth struct {
+	Group   string
+	Status  GroupStatus
+	Healthy int
+	Members []GroupMemberHealth
+}
+
+// groupRegistry 插件组：显式加入的成员和组级配置覆盖。插件清单中的tags同样作为组成员
+type groupRegistry struct {
+	members   map[string]map[string]bool        // 组名 -> 显式加入的插件名称
+	overrides map[string]map[string]interface{} // 组名 -> 组级配置覆盖
+	mutex     trackedRWMutex
+}
+
+func newGroupRegistry(locks *lockTracker) *groupRegistry {
+	return &groupRegistry{
+		members:   make(map[string]map[string]bool),
+		overrides: make(map[string]map[string]interface{}),
+		mutex:     trackedRWMutex{name: "groups", tracker: locks},
+	}
+}
+
+// AddToGroup 将插件显式加入插件组，插件可以同时属于多个组
+func (m *Manager) AddToGroup(group string, names ...string) error {
+	if err := m.checkWritable(); err != nil {
+		return err
+	}
+	if group == "" {
+		return fmt.Errorf("插件组名称不能为空")
+	}
+
+	m.groups.mutex.Lock()
+	members, exists := m.groups.members[group]
+	if !exists {
+		members = make(map[string]bool)
+		m.groups.members[group] = members
+	}
+	for _, name := range names {
+		members[name] = true
+	}
+	m.groups.mutex.Unlock()
+
+	m.reapplyGroupConfig(group)
+	return nil
+}
+
+// RemoveFromGroup 将插件移出插件组，通过插件清单tags加入的成员不受影响
+func (m *Manager) RemoveFromGroup(group string, names ...string) error {
+	if err := m.checkWritable(); err != nil {
+		return err
+	}
+
+	m.groups.mutex.Lock()
+	members := m.groups.members[group]
+	for _, name := range names {
+		delete(members, name)
+	}
+	if len(members) == 0 {
+		delete(m.groups.members, group)
+	}
+	m.groups.mutex.Unlock()
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	// 移出的插件不再受组级配置覆盖
+	for _, name := range names {
+		if info, exists := m.plugins[name]; exists {
+			m.deliverConfig(info.Name, info.Plugin, info.Manifest, info.Config)
+		}
+	}
+	return nil
+}
+
+// Groups 获取所有插件组及其成员，包括插件清单tags形成的组
+func (m *Manager) Groups() map[string][]string {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	seen := make(map[string]map[string]bool)
+	add := func(group, name string) {
+		if seen[group] == nil {
+			seen[group] = make(map[string]bool)
+		}
+		seen[group][name] = true
+	}
+
+	m.groups.mutex.RLock()
+	for group, members := range m.groups.members {
+		for name := range members {
+			add(group, name)
+		}
+	}
+	m.groups.mutex.RUnlock()
+
+	for name, info := range m.plugins {
+		if info.Manifest != nil {
+			for _, tag := range info.Manifest.Tags {
+				add(tag, name)
+			}
+		}
+	}
+
+	result := make(map[string][]string, len(seen))
+	for group, members := range seen {
+		result[group] = sortedKeys(members)
+	}
+	return result
+}
+
+// GroupMembers 获取插件组的成员，按名称排序
+func (m *Manager) GroupMembers(group string) []string {
+	return m.Groups()[group]
+}
+
+// EnableGroup 启用插件组中的所有插件，单个插件失败不影响其他插件，返回合并的错误
+func (m *Manager) EnableGroup(group string) error {
+	members := m.GroupMembers(group)
+	if len(members) == 0 {
+		return fmt.Errorf("插件组不存在或没有成员: %s", group)
+	}
+
+	var errs []error
+	for _, name := range members {
+		if err := m.EnablePlugin(name); err != nil {
+			errs = append(errs, fmt.Errorf("启用插件 %s 失败: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// DisableGroup 禁用插件组中的所有插件，单个插件失败不影响其他插件，返回合并的错误
+func (m *Manager) DisableGroup(group string) error {
+	members := m.GroupMembers(group)
+	if len(members) == 0 {
+		return fmt.Errorf("插件组不存在或没有成员: %s", group)
+	}
+
+	var errs []error
+	for _, name := range members {
+		if err := m.DisablePlugin(name); err != nil {
+			errs = append(errs, fmt.Errorf("禁用插件 %s 失败: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// SetGroupConfig 设置组级配置覆盖，覆盖插件自身配置中的同名顶层配置项，并立即下发给组内插件；
+// overrides为nil时删除组级配置。组级配置不写入插件的存储记录
+func (m *Manager) SetGroupConfig(group string, overrides map[string]interface{}) error {
+	if err := m.checkWritable(); err != nil {
+		return err
+	}
+	if group == "" {
+		return fmt.Errorf("插件组名称不能为空")
+	}
+
+	m.groups.mutex.Lock()
+	if overrides == nil {
+		delete(m.groups.overrides, group)
+	} else {
+		copied := make(map[string]interface{}, len(overrides))
+		for k, v := range overrides {
+			copied[k] = v
+		}
+		m.groups.overrides[group] = copied
+	}
+	m.groups.mutex.Unlock()
+
+	m.reapplyGroupConfig(group)
+	return nil
+}
+
+// GetGroupConfig 获取组级配置覆盖
+func (m *Manager) GetGroupConfig(group string) map[string]interface{} {
+	m.groups.mutex.RLock()
+	defer m.groups.mutex.RUnlock()
+
+	overrides := m.groups.overrides[group]
+	if overrides == nil {
+		return nil
+	}
+	result := make(map[string]interface{}, len(overrides))
+	for k, v := range overrides {
+		result[k] = v
+	}
+	return result
+}
+
+// GroupHealth 汇总插件组的健康状态：未运行、健康检查失败或自报降级的成员视为不健康
+func (m *Manager) GroupHealth(ctx context.Context, group string) (*GroupHealth, error) {
+	members := m.GroupMembers(group)
+	if len(members) == 0 {
+		return nil, fmt.Errorf("插件组不存在或没有成员: %s", group)
+	}
+
+	report := &GroupHealth{Group: group}
+	for _, name := range members {
+		member := GroupMemberHealth{Name: name}
+
+		info, exists := m.GetPlugin(name)
+		switch {
+		case !exists:
+			member.Error = "插件未加载"
+		case !info.Enabled:
+			member.State = info.State
+			member.Error = fmt.Sprintf("插件未运行: %s", info.State)
+		default:
+			member.State = info.State
+			if checker, ok := info.Plugin.(HealthChecker); ok {
+				if err := checker.Healthy(ctx); err != nil {
+					member.Error = err.Error()
+				}
+			}
+			if member.Error == "" {
+				if readiness := m.GetPluginReadiness(name); readiness.Readiness != ReadinessReady {
+					member.Error = fmt.Sprintf("%s: %s", readiness.Readiness, readiness.Reason)
+				}
+			}
+		}
+
+		if member.Error == "" {
+			report.Healthy++
+		}
+		report.Members = append(report.Members, member)
+	}
+
+	switch report.Healthy {
+	case len(report.Members):
+		report.Status = GroupHealthy
+	case 0:
+		report.Status = GroupDown
+	default:
+		report.Status = GroupDegraded
+	}
+	return report, nil
+}
+
+// reapplyGroupConfig 将组级配置重新下发给组内已加载的插件
+func (m *Manager) reapplyGroupConfig(group string) {
+	members := m.GroupMembers(group)
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for _, name := range members {
+		if info, exists := m.plugins[name]; exists {
+			m.deliverConfig(info.Name, info.Plugin, info.Manifest, info.Config)
+		}
+	}
+}
+
+// effectiveConfig 返回下发给插件的配置：按组名顺序叠加组级配置覆盖并替换占位符，原配置保持不变，调用方需持有m.mutex
+func (m *Manager) effectiveConfig(name string, manifest *PluginManifest, config map[string]interface{}) map[string]interface{} {
+	m.groups.mutex.RLock()
+	var groups []string
+	for group, members := range m.groups.members {
+		if members[name] {
+			groups = append(groups, group)
+		}
+	}
+	if manifest != nil {
+		groups = append(groups, manifest.Tags...)
+	}
+	sort.Strings(groups)
+
+	merged, copied := config, false
+	for i, group := range groups {
+		overrides := m.groups.overrides[group]
+		if len(overrides) == 0 || (i > 0 && groups[i-1] == group) {
+			continue
+		}
+		if !copied {
+			merged = make(map[string]interface{}, len(config)+len(overrides))
+			for k, v := range config {
+				merged[k] = v
+			}
+			copied = true
+		}
+		for k, v := range overrides {
+			merged[k] = v
+		}
+	}
+	m.groups.mutex.RUnlock()
+
+	return m.resolveConfig(merged)
+}
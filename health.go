@@ -0,0 +1,288 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// defaultHealthCheckTimeout 单个插件健康检查的默认超时时间
+const defaultHealthCheckTimeout = 5 * time.Second
+
+// HealthCheckPolicy 定时健康检查策略
+type HealthCheckPolicy struct {
+	Interval     time.Duration // 检查间隔，必须大于0
+	Timeout      time.Duration // 单个插件健康检查的超时时间，默认5秒
+	RestartAfter int           // 连续不健康达到该次数时重启插件（Close后重新Init），0表示不自动重启
+}
+
+// HealthStatus 插件最近的健康检查结果
+type HealthStatus struct {
+	Name                string    `json:"name"`
+	Healthy             bool      `json:"healthy"`
+	LastCheck           time.Time `json:"last_check"`
+	LastError           string    `json:"last_error,omitempty"`
+	Duration            string    `json:"duration"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	Checks              uint64    `json:"checks"`
+	Failures            uint64    `json:"failures"`
+	Restarts            int       `json:"restarts"`
+	LastRestart         time.Time `json:"last_restart"`
+}
+
+// healthMonitor 定时健康检查的状态和结果
+type healthMonitor struct {
+	policy   HealthCheckPolicy
+	stop     chan struct{} // 定时检查未开启时为nil
+	statuses map[string]*HealthStatus
+	mutex    sync.Mutex
+	run      sync.Mutex // 同一时间只执行一轮检查
+}
+
+func newHealthMonitor() *healthMonitor {
+	return &healthMonitor{statuses: make(map[string]*HealthStatus)}
+}
+
+// StartHealthChecks 按策略定时检查已启用且实现了HealthChecker的插件，记录检查结果，
+// RestartAfter大于0时重启连续不健康达到该次数的插件
+func (m *Manager) StartHealthChecks(policy HealthCheckPolicy) error {
+	if policy.Interval <= 0 {
+		return fmt.Errorf("健康检查间隔必须大于0")
+	}
+	if policy.Timeout < 0 || policy.RestartAfter < 0 {
+		return fmt.Errorf("健康检查的超时时间和重启阈值不能为负数")
+	}
+	if policy.Timeout == 0 {
+		policy.Timeout = defaultHealthCheckTimeout
+	}
+
+	m.health.mutex.Lock()
+	defer m.health.mutex.Unlock()
+
+	if m.health.stop != nil {
+		return fmt.Errorf("定时健康检查已开启")
+	}
+	stop := make(chan struct{})
+	m.health.stop = stop
+	m.health.policy = policy
+
+	go func() {
+		ticker := time.NewTicker(policy.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				if m.IsMaintenanceMode() || m.IsShuttingDown() {
+					continue
+				}
+				m.RunHealthChecks(context.Background())
+			}
+		}
+	}()
+
+	m.logger.Printf("已开启插件定时健康检查，间隔 %v", policy.Interval)
+	return nil
+}
+
+// StopHealthChecks 停止定时健康检查，正在执行的检查会继续完成
+func (m *Manager) StopHealthChecks() {
+	m.health.mutex.Lock()
+	defer m.health.mutex.Unlock()
+
+	if m.health.stop == nil {
+		return
+	}
+	close(m.health.stop)
+	m.health.stop = nil
+	m.logger.Printf("已停止插件定时健康检查")
+}
+
+// RunHealthChecks 立即并发检查一轮已启用且实现了HealthChecker的插件，按当前策略记录结果并重启连续不健康的插件，
+// 返回按名称排序的检查结果。未开启定时检查时使用默认超时时间且不重启插件
+func (m *Manager) RunHealthChecks(ctx context.Context) []HealthStatus {
+	m.health.run.Lock()
+	defer m.health.run.Unlock()
+
+	m.health.mutex.Lock()
+	policy := m.health.policy
+	if m.health.stop == nil {
+		policy = HealthCheckPolicy{Timeout: defaultHealthCheckTimeout}
+	}
+	m.health.mutex.Unlock()
+
+	m.mutex.RLock()
+	var targets []*PluginInfo
+	for _, info := range m.plugins {
+		if _, ok := info.Plugin.(HealthChecker); ok && info.Enabled {
+			targets = append(targets, info)
+		}
+	}
+	m.mutex.RUnlock()
+
+	results := make([]error, len(targets))
+	durations := make([]time.Duration, len(targets))
+	var wg sync.WaitGroup
+	for i, info := range targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start := time.Now()
+			results[i] = checkHealth(ctx, info, policy.Timeout)
+			durations[i] = time.Since(start)
+		}()
+	}
+	wg.Wait()
+
+	var restart []*PluginInfo
+	checked := make(map[string]bool, len(targets))
+	m.health.mutex.Lock()
+	now := time.Now()
+	for i, info := range targets {
+		checked[info.Name] = true
+		status, exists := m.health.statuses[info.Name]
+		if !exists {
+			status = &HealthStatus{Name: info.Name}
+			m.health.statuses[info.Name] = status
+		}
+		status.LastCheck = now
+		status.Duration = durations[i].String()
+		status.Checks++
+		if err := results[i]; err != nil {
+			status.Healthy = false
+			status.LastError = err.Error()
+			status.ConsecutiveFailures++
+			status.Failures++
+			if policy.RestartAfter > 0 && status.ConsecutiveFailures >= policy.RestartAfter {
+				restart = append(restart, info)
+			}
+		} else {
+			status.Healthy = true
+			status.LastError = ""
+			status.ConsecutiveFailures = 0
+		}
+	}
+	// 只保留仍在检查的插件的结果
+	for name := range m.health.statuses {
+		if !checked[name] {
+			delete(m.health.statuses, name)
+		}
+	}
+	m.health.mutex.Unlock()
+
+	for _, info := range restart {
+		m.restartUnhealthy(info)
+	}
+	return m.HealthStatuses()
+}
+
+// checkHealth 在超时时间内执行插件的健康检查，插件未响应或panic时视为不健康
+func checkHealth(ctx context.Context, info *PluginInfo, timeout time.Duration) error {
+	checker := info.Plugin.(HealthChecker)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go runWithPluginLabels(info.Name, func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("健康检查时发生panic: %v", r)
+			}
+		}()
+		done <- checker.Healthy(ctx)
+	})
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("健康检查超时: %v", ctx.Err())
+	}
+}
+
+// restartUnhealthy 重启连续不健康的插件：停止分发并等待已分发的处理完成后调用Close再重新Init，
+// 重新初始化失败时插件进入init_failed状态
+func (m *Manager) restartUnhealthy(info *PluginInfo) {
+	m.health.mutex.Lock()
+	status := m.health.statuses[info.Name]
+	failures := 0
+	if status != nil {
+		failures = status.ConsecutiveFailures
+	}
+	m.health.mutex.Unlock()
+
+	if err := m.drainPlugin(info.Name); err != nil {
+		m.logger.Printf("插件 %s %v，继续重启", info.Name, err)
+	}
+	defer m.endDrain(info.Name)
+
+	m.mutex.Lock()
+	current, exists := m.plugins[info.Name]
+	if !exists || current != info || !info.Enabled || info.enabling {
+		m.mutex.Unlock()
+		return
+	}
+	info.enabling = true
+	if err := info.Plugin.Close(); err != nil {
+		m.logger.Printf("关闭插件 %s 失败: %v", info.Name, err)
+	}
+	m.clearReadiness(info.Name)
+	m.mutex.Unlock()
+
+	ctx, cancel := m.initContext(context.Background())
+	err := m.runInit(ctx, info.Name, info.Plugin)
+	cancel()
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	info.enabling = false
+	m.health.mutex.Lock()
+	if status := m.health.statuses[info.Name]; status != nil {
+		status.Restarts++
+		status.LastRestart = time.Now()
+		if err == nil {
+			status.ConsecutiveFailures = 0
+		}
+	}
+	m.health.mutex.Unlock()
+
+	if err != nil {
+		_ = m.setState(info, StateInitFailed, fmt.Sprintf("健康检查连续失败 %d 次后重启失败: %v", failures, err))
+		m.checkLeaks(info.Name)
+		m.disableDependents(info.Name)
+		m.logger.Printf("插件 %s 健康检查连续失败 %d 次，重启失败: %v", info.Name, failures, err)
+		return
+	}
+	m.logger.Printf("插件 %s 健康检查连续失败 %d 次，已重启", info.Name, failures)
+}
+
+// PluginHealth 获取插件最近的健康检查结果，插件未被检查过时返回false
+func (m *Manager) PluginHealth(name string) (HealthStatus, bool) {
+	m.health.mutex.Lock()
+	defer m.health.mutex.Unlock()
+
+	status, exists := m.health.statuses[name]
+	if !exists {
+		return HealthStatus{}, false
+	}
+	return *status, true
+}
+
+// HealthStatuses 获取所有插件最近的健康检查结果，按名称排序
+func (m *Manager) HealthStatuses() []HealthStatus {
+	m.health.mutex.Lock()
+	defer m.health.mutex.Unlock()
+
+	result := make([]HealthStatus, 0, len(m.health.statuses))
+	for _, status := range m.health.statuses {
+		result = append(result, *status)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
@@ -0,0 +1,195 @@
+package plugins
+
+import (
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Clock 时钟抽象，便于在测试和模拟中控制时间
+type Clock interface {
+	// Now 返回当前时间
+	Now() time.Time
+
+	// After 在d之后向返回的通道发送当前时间
+	After(d time.Duration) <-chan time.Time
+
+	// Sleep 暂停d
+	Sleep(d time.Duration)
+}
+
+// Rand 随机数抽象，便于在测试和模拟中得到确定的结果
+type Rand interface {
+	Int63n(n int64) int64
+	Intn(n int) int
+	Float64() float64
+}
+
+// HostAPI 宿主向插件提供的服务
+type HostAPI interface {
+	// PluginName 当前插件名称
+	PluginName() string
+
+	// Clock 获取时钟
+	Clock() Clock
+
+	// Rand 获取插件专属的随机数生成器
+	Rand() Rand
+
+	// NotifyAdmin 通过宿主配置的渠道向管理员发送模板化通知，受每个插件的节流限制
+	NotifyAdmin(n AdminNotification) error
+
+	// HTTPClient 返回受插件清单出站白名单约束的HTTP客户端
+	HTTPClient() *http.Client
+
+	// ReportReadiness 上报插件运行时状态（就绪、降级及原因、恢复中），降级的插件不参与同步处理
+	ReportReadiness(readiness Readiness, reason string) error
+
+	// Routes 获取宿主的命名路由表
+	Routes() RouteCatalog
+
+	// EmitAfter 在d之后投递事件，存储支持时重启后继续投递，返回延迟事件ID
+	EmitAfter(d time.Duration, event ScheduledEvent) (string, error)
+
+	// PushNotice 向宿主站内通知中心推送通知（info/warning），已读状态由管理器维护，返回通知ID
+	PushNotice(notice Notice) (string, error)
+
+	// MarkSeen 检查并记录去重键（如事件ID），键在ttl内已记录过时返回true，用于事件重试和重放时去重
+	MarkSeen(key string, ttl time.Duration) (bool, error)
+
+	// ForgetSeen 删除去重键，处理失败需要重试时调用
+	ForgetSeen(key string) error
+
+	// Config 获取插件当前生效的配置快照，处理事件时取一次并在整个处理过程中使用，避免看到更新到一半的配置
+	Config() *ConfigSnapshot
+
+	// RenderTemplate 以宿主提供的辅助函数渲染用户可自定义的模板（text或html格式），受模板大小、输出大小和渲染时间限制
+	RenderTemplate(format TemplateFormat, text string, data interface{}) (string, error)
+
+	// ValidateTemplate 检查模板能否解析，用于保存用户自定义的模板前校验
+	ValidateTemplate(format TemplateFormat, text string) error
+
+	// RegisterEventType 登记自定义事件类型，插件卸载时自动注销
+	RegisterEventType(def CustomEventType) error
+
+	// EmitEvent 触发已登记的自定义事件，数据需符合登记的Schema
+	EmitEvent(event EventType, payload interface{}) error
+}
+
+// HostAware 需要使用宿主服务的插件实现此接口（可选实现），在加载时注入HostAPI
+type HostAware interface {
+	SetHostAPI(host HostAPI)
+}
+
+// systemClock 基于系统时间的时钟
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+func (systemClock) Sleep(d time.Duration)                  { time.Sleep(d) }
+
+// lockedRand 并发安全的随机数生成器
+type lockedRand struct {
+	rnd   *rand.Rand
+	mutex sync.Mutex
+}
+
+func newLockedRand(seed int64) *lockedRand {
+	return &lockedRand{rnd: rand.New(rand.NewSource(seed))}
+}
+
+func (r *lockedRand) Int63n(n int64) int64 {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.rnd.Int63n(n)
+}
+
+func (r *lockedRand) Intn(n int) int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.rnd.Intn(n)
+}
+
+func (r *lockedRand) Float64() float64 {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.rnd.Float64()
+}
+
+// defaultRandFactory 默认随机数工厂，以当前时间为种子
+func defaultRandFactory(string) Rand {
+	return newLockedRand(time.Now().UnixNano())
+}
+
+// pluginHost 单个插件的HostAPI实现
+type pluginHost struct {
+	m      *Manager
+	name   string
+	egress []string // 出站白名单，nil表示不限制
+
+	rnd     Rand
+	rndOnce sync.Once
+}
+
+func (h *pluginHost) PluginName() string {
+	return h.name
+}
+
+func (h *pluginHost) Clock() Clock {
+	h.m.hostMutex.RLock()
+	defer h.m.hostMutex.RUnlock()
+
+	return h.m.clock
+}
+
+func (h *pluginHost) Rand() Rand {
+	h.rndOnce.Do(func() {
+		h.m.hostMutex.RLock()
+		factory := h.m.randFactory
+		h.m.hostMutex.RUnlock()
+
+		h.rnd = factory(h.name)
+	})
+	return h.rnd
+}
+
+// SetClock 设置提供给插件的时钟，nil表示恢复系统时钟
+func (m *Manager) SetClock(clock Clock) {
+	m.hostMutex.Lock()
+	defer m.hostMutex.Unlock()
+
+	if clock == nil {
+		clock = systemClock{}
+	}
+	m.clock = clock
+}
+
+// SetRandFactory 设置插件随机数生成器工厂，按插件名称创建，nil表示恢复默认实现；
+// 仅影响之后首次获取随机数生成器的插件
+func (m *Manager) SetRandFactory(factory func(plugin string) Rand) {
+	m.hostMutex.Lock()
+	defer m.hostMutex.Unlock()
+
+	if factory == nil {
+		factory = defaultRandFactory
+	}
+	m.randFactory = factory
+}
+
+// newPluginHost 创建插件的HostAPI
+func (m *Manager) newPluginHost(name string, manifest *PluginManifest) *pluginHost {
+	host := &pluginHost{m: m, name: name}
+	if manifest != nil && manifest.Egress != nil {
+		host.egress = append([]string{}, manifest.Egress...)
+	}
+	return host
+}
+
+// injectHostAPI 向实现了HostAware的插件注入HostAPI，向管理控制插件注入AdminAPI
+func (m *Manager) injectHostAPI(p Plugin, manifest *PluginManifest) {
+	if aware, ok := p.(HostAware); ok {
+		aware.SetHostAPI(m.newPluginHost(p.Name(), manifest))
+	}
+	m.injectAdminAPI(p)
+}
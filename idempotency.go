@@ -0,0 +1,140 @@
+package plugins
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// seenKeySweepInterval 内存去重键过期清理的最小间隔
+const seenKeySweepInterval = time.Minute
+
+// IdempotentHandler 需要事件ID的事件处理接口（可选实现），实现后将代替ResultHandler和OnAPIEvent被调用；
+// 事件重试或重放时事件ID保持不变，插件可以配合HostAPI的MarkSeen去重
+type IdempotentHandler interface {
+	OnAPIEventWithID(ctx *gin.Context, eventID string, event EventType, path string, statusCode int, requestBody interface{}, responseBody interface{}) (*EventResult, error)
+}
+
+// SeenKeyStorage 去重键存储扩展接口（可选实现），实现后去重键在重启后保留并可在多个实例间共享
+type SeenKeyStorage interface {
+	// MarkSeenKey 原子地检查并记录去重键，键已存在且未过期时返回true
+	MarkSeenKey(plugin string, key string, expiresAt time.Time) (bool, error)
+
+	// DeleteSeenKey 删除去重键
+	DeleteSeenKey(plugin string, key string) error
+}
+
+// seenKeyStore 内存去重键，按插件隔离，键为插件名称和去重键
+type seenKeyStore struct {
+	keys      map[string]map[string]time.Time // 插件名称 -> 去重键 -> 过期时间
+	lastSweep time.Time
+	mutex     sync.Mutex
+}
+
+func newSeenKeyStore() *seenKeyStore {
+	return &seenKeyStore{keys: make(map[string]map[string]time.Time)}
+}
+
+// MarkSeen 检查并记录去重键，键在ttl内已记录过时返回true，表示事件已处理过应跳过；
+// 去重键按插件隔离，ttl<=0时返回错误
+func (h *pluginHost) MarkSeen(key string, ttl time.Duration) (bool, error) {
+	return h.m.markSeen(h.name, key, ttl)
+}
+
+// ForgetSeen 删除去重键，处理失败需要重试时调用，使同一事件可以再次处理
+func (h *pluginHost) ForgetSeen(key string) error {
+	return h.m.forgetSeen(h.name, key)
+}
+
+// markSeen 检查并记录插件的去重键
+func (m *Manager) markSeen(plugin string, key string, ttl time.Duration) (bool, error) {
+	if key == "" {
+		return false, fmt.Errorf("去重键不能为空")
+	}
+	if ttl <= 0 {
+		return false, fmt.Errorf("去重键有效期必须大于0")
+	}
+
+	m.hostMutex.RLock()
+	now := m.clock.Now()
+	m.hostMutex.RUnlock()
+
+	if seenStorage, ok := m.store().(SeenKeyStorage); ok {
+		seen, err := seenStorage.MarkSeenKey(plugin, key, now.Add(ttl))
+		if err != nil {
+			return false, fmt.Errorf("记录去重键失败: %v", err)
+		}
+		return seen, nil
+	}
+
+	store := m.seenKeys
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if now.Sub(store.lastSweep) >= seenKeySweepInterval {
+		store.sweep(now)
+	}
+
+	keys, exists := store.keys[plugin]
+	if !exists {
+		keys = make(map[string]time.Time)
+		store.keys[plugin] = keys
+	}
+	if expiresAt, exists := keys[key]; exists && now.Before(expiresAt) {
+		return true, nil
+	}
+	keys[key] = now.Add(ttl)
+	return false, nil
+}
+
+// forgetSeen 删除插件的去重键
+func (m *Manager) forgetSeen(plugin string, key string) error {
+	if seenStorage, ok := m.store().(SeenKeyStorage); ok {
+		if err := seenStorage.DeleteSeenKey(plugin, key); err != nil {
+			return fmt.Errorf("删除去重键失败: %v", err)
+		}
+		return nil
+	}
+
+	m.seenKeys.mutex.Lock()
+	defer m.seenKeys.mutex.Unlock()
+
+	delete(m.seenKeys.keys[plugin], key)
+	return nil
+}
+
+// forgetPluginSeenKeys 清除插件的所有内存去重键，卸载插件时调用
+func (m *Manager) forgetPluginSeenKeys(plugin string) {
+	m.seenKeys.mutex.Lock()
+	defer m.seenKeys.mutex.Unlock()
+
+	delete(m.seenKeys.keys, plugin)
+}
+
+// sweep 清除过期的去重键，调用方需持有s.mutex
+func (s *seenKeyStore) sweep(now time.Time) {
+	for plugin, keys := range s.keys {
+		for key, expiresAt := range keys {
+			if !now.Before(expiresAt) {
+				delete(keys, key)
+			}
+		}
+		if len(keys) == 0 {
+			delete(s.keys, plugin)
+		}
+	}
+	s.lastSweep = now
+}
+
+// newEventID 生成事件ID
+func newEventID() string {
+	id, err := newRandomID()
+	if err != nil {
+		// 随机数不可用时退化为时间戳，仍可在单个实例内区分事件
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+	return id
+}
@@ -0,0 +1,300 @@
+package plugins
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// downloadTimeout 下载插件文件的超时时间
+const downloadTimeout = 5 * time.Minute
+
+// InstallPlugin 将插件文件复制到插件目录并加载，同时复制插件清单，返回加载后的插件信息。
+// 插件包（.splug）解压到插件目录中的同名子目录。插件目录中已存在同名文件或目录时返回错误
+func (m *Manager) InstallPlugin(srcPath string) (*PluginInfo, error) {
+	if err := m.checkWritable(); err != nil {
+		return nil, err
+	}
+
+	if !isPluginFile(srcPath) && !isPackageFile(srcPath) {
+		return nil, fmt.Errorf("不支持的插件文件类型: %s", srcPath)
+	}
+	stat, err := os.Stat(srcPath)
+	if err != nil {
+		return nil, fmt.Errorf("无法访问插件文件: %v", err)
+	}
+	if !stat.Mode().IsRegular() {
+		return nil, fmt.Errorf("插件文件不是普通文件: %s", srcPath)
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if isPackageFile(srcPath) {
+		return m.installPackage(srcPath)
+	}
+
+	if err := os.MkdirAll(m.pluginDir, 0755); err != nil {
+		return nil, fmt.Errorf("创建插件目录失败: %v", err)
+	}
+
+	target := filepath.Join(m.pluginDir, filepath.Base(srcPath))
+	if _, err := os.Stat(target); err == nil {
+		return nil, fmt.Errorf("插件文件已存在: %s", target)
+	}
+
+	if err := copyFile(srcPath, target, 0755); err != nil {
+		return nil, err
+	}
+
+	// 插件清单随插件文件一起安装，目录级清单安装为插件专属清单，避免影响插件目录中的其他插件
+	var manifestTarget string
+	if src := manifestPath(srcPath); src != "" {
+		manifestTarget = sidecarManifestPath(target)
+		if err := copyFile(src, manifestTarget, 0644); err != nil {
+			os.Remove(target)
+			return nil, err
+		}
+	}
+
+	// 签名文件随插件文件一起安装
+	var sigTarget string
+	if _, err := os.Stat(srcPath + signatureSuffix); err == nil {
+		sigTarget = target + signatureSuffix
+		if err := copyFile(srcPath+signatureSuffix, sigTarget, 0644); err != nil {
+			os.Remove(target)
+			if manifestTarget != "" {
+				os.Remove(manifestTarget)
+			}
+			return nil, err
+		}
+	}
+
+	if err := m.saveChecksum(target); err != nil {
+		os.Remove(target)
+		return nil, err
+	}
+
+	info, err := m.loadPlugin(target)
+	if err != nil {
+		// 安装失败时清理已复制的文件和存储记录
+		m.discardInstall(info, target)
+		if manifestTarget != "" {
+			os.Remove(manifestTarget)
+		}
+		if sigTarget != "" {
+			os.Remove(sigTarget)
+		}
+		return nil, fmt.Errorf("加载插件失败: %v", err)
+	}
+	return info, nil
+}
+
+// copyFile 通过临时文件复制文件，避免目标目录中出现不完整的文件
+func copyFile(src, target string, perm os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("打开文件失败: %v", err)
+	}
+	defer in.Close()
+
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".install-*")
+	if err != nil {
+		return fmt.Errorf("创建临时文件失败: %v", err)
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+
+	if _, err := io.Copy(tmp, in); err != nil {
+		tmp.Close()
+		return fmt.Errorf("复制文件失败: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("复制文件失败: %v", err)
+	}
+
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		return fmt.Errorf("设置文件权限失败: %v", err)
+	}
+	if err := os.Rename(tmpPath, target); err != nil {
+		return fmt.Errorf("移动文件失败: %v", err)
+	}
+	return nil
+}
+
+// UninstallPlugin 卸载插件，并将插件文件、清单、签名及配置和数据移入归档区，可通过RestorePlugin恢复；
+// keepConfig为false时同时删除存储中的插件记录，为true时保留。只能卸载插件目录中的插件，其他搜索目录中的插件只能禁用
+func (m *Manager) UninstallPlugin(name string, keepConfig bool) error {
+	if err := m.checkWritable(); err != nil {
+		return err
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	info, exists := m.plugins[name]
+	if !exists {
+		return fmt.Errorf("插件不存在: %s", name)
+	}
+	if m.dirPriority(info.FilePath) != len(m.searchDirs) {
+		return fmt.Errorf("插件 %s 不在插件目录中，不能卸载: %s", name, info.FilePath)
+	}
+
+	// 归档记录卸载前的启用状态
+	archived := *info
+
+	if err := m.unloadPlugin(name); err != nil {
+		return err
+	}
+	m.forgetPluginSeenKeys(name)
+
+	if err := m.archivePlugin(&archived); err != nil {
+		return fmt.Errorf("归档插件失败: %v", err)
+	}
+
+	if !keepConfig {
+		if lister, ok := m.store().(PluginListStorage); ok {
+			if err := lister.DeletePlugin(info.FilePath); err != nil {
+				return fmt.Errorf("删除插件记录失败: %v", err)
+			}
+		}
+	}
+
+	for _, purged := range purgeArchive(m.archiveRoot(), m.archiveTTL(), m.logger) {
+		m.logger.Printf("已删除过期的插件归档: %s", purged)
+	}
+
+	m.logger.Printf("已卸载并归档插件: %s", name)
+	return nil
+}
+
+// InstallFromURL 通过HTTPS下载插件文件，校验SHA-256后放入插件目录并加载
+func (m *Manager) InstallFromURL(rawURL, expectedSHA256 string) (*PluginInfo, error) {
+	if err := m.checkWritable(); err != nil {
+		return nil, err
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("无效的下载地址: %v", err)
+	}
+	if u.Scheme != "https" {
+		return nil, fmt.Errorf("只支持HTTPS下载地址: %s", rawURL)
+	}
+	if sum, err := hex.DecodeString(expectedSHA256); err != nil || len(sum) != sha256.Size {
+		return nil, fmt.Errorf("无效的SHA-256校验和: %s", expectedSHA256)
+	}
+
+	fileName := path.Base(u.Path)
+	if !isPluginFile(fileName) {
+		return nil, fmt.Errorf("下载地址不是支持的插件文件: %s", rawURL)
+	}
+
+	client := &http.Client{Timeout: downloadTimeout}
+	resp, err := client.Get(u.String())
+	if err != nil {
+		return nil, fmt.Errorf("下载插件失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("下载插件失败: HTTP %d", resp.StatusCode)
+	}
+
+	// 下载和校验不持有m.mutex，先写入插件目录中的隐藏临时文件（加载和监听会忽略）
+	m.mutex.RLock()
+	dir := m.pluginDir
+	m.mutex.RUnlock()
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("创建插件目录失败: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, fileName)); err == nil {
+		return nil, fmt.Errorf("插件文件已存在: %s", filepath.Join(dir, fileName))
+	}
+	tmpPath, err := stageVerifiedFile(resp.Body, dir, expectedSHA256)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmpPath)
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.installStaged(tmpPath, dir, fileName)
+}
+
+// installStaged 将已校验的临时文件移入插件目录并加载，失败时清理文件和记录，调用方需持有m.mutex
+func (m *Manager) installStaged(tmpPath, dir, fileName string) (*PluginInfo, error) {
+	if dir != m.pluginDir {
+		return nil, fmt.Errorf("下载期间插件目录已变更: %s", m.pluginDir)
+	}
+	target := filepath.Join(dir, fileName)
+	if _, err := os.Stat(target); err == nil {
+		return nil, fmt.Errorf("插件文件已存在: %s", target)
+	}
+	if err := os.Rename(tmpPath, target); err != nil {
+		return nil, fmt.Errorf("移动插件文件失败: %v", err)
+	}
+	if err := m.saveChecksum(target); err != nil {
+		os.Remove(target)
+		return nil, err
+	}
+
+	info, err := m.loadPlugin(target)
+	if err != nil {
+		m.discardInstall(info, target)
+		return nil, fmt.Errorf("加载插件失败: %v", err)
+	}
+	return info, nil
+}
+
+// discardInstall 安装的插件加载失败时删除插件文件、登记、存储记录和校验和记录，调用方需持有m.mutex
+func (m *Manager) discardInstall(info *PluginInfo, target string) {
+	if info != nil && m.plugins[info.Name] == info {
+		delete(m.plugins, info.Name)
+	}
+	if lister, ok := m.store().(PluginListStorage); ok {
+		_ = lister.DeletePlugin(target)
+	}
+	m.forgetChecksum(target)
+	os.Remove(target)
+}
+
+// stageVerifiedFile 将内容写入dir中的隐藏临时文件并校验SHA-256，返回临时文件路径，由调用方移动或删除
+func stageVerifiedFile(r io.Reader, dir, expectedSHA256 string) (string, error) {
+	tmp, err := os.CreateTemp(dir, ".download-*")
+	if err != nil {
+		return "", fmt.Errorf("创建临时文件失败: %v", err)
+	}
+	tmpPath := tmp.Name()
+
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(tmp, h), r); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return "", fmt.Errorf("写入临时文件失败: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return "", fmt.Errorf("写入临时文件失败: %v", err)
+	}
+
+	if sum := hex.EncodeToString(h.Sum(nil)); !strings.EqualFold(sum, expectedSHA256) {
+		os.Remove(tmpPath)
+		return "", fmt.Errorf("校验和不匹配: 期望 %s, 实际 %s", expectedSHA256, sum)
+	}
+	if err := os.Chmod(tmpPath, 0755); err != nil {
+		os.Remove(tmpPath)
+		return "", fmt.Errorf("设置文件权限失败: %v", err)
+	}
+	return tmpPath, nil
+}
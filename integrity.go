@@ -0,0 +1,111 @@
+package plugins
+
+import (
+	"fmt"
+)
+
+// PluginChecksumStorage 插件文件校验和存储扩展接口（可选实现），用于发现被篡改或未复制完整的插件文件
+type PluginChecksumStorage interface {
+	// GetPluginChecksum 获取插件文件的SHA-256，未记录时返回空字符串
+	GetPluginChecksum(path string) (string, error)
+
+	// SavePluginChecksum 保存插件文件的SHA-256，sum为空字符串时清除记录
+	SavePluginChecksum(path string, sum string) error
+}
+
+// SetAllowModifiedPlugins 设置是否允许加载校验和与首次安装时不一致的插件文件，
+// 允许时会以新文件的校验和为准；维护模式下返回ErrMaintenanceMode（此前无返回值）
+func (m *Manager) SetAllowModifiedPlugins(allow bool) error {
+	if err := m.checkWritable(); err != nil {
+		return err
+	}
+
+	m.allowModified.Store(allow)
+	return nil
+}
+
+// TrustPluginFile 以插件文件当前内容更新记录的校验和，用于有意替换插件文件后重新加载
+func (m *Manager) TrustPluginFile(pluginPath string) error {
+	if err := m.checkWritable(); err != nil {
+		return err
+	}
+	return m.saveChecksum(pluginPath)
+}
+
+// verifyChecksum 加载前校验插件文件：首次加载时记录校验和，之后文件变化时拒绝加载
+func (m *Manager) verifyChecksum(pluginPath string) error {
+	checksumStorage, ok := m.store().(PluginChecksumStorage)
+	if !ok {
+		return nil
+	}
+
+	sum, err := fileSHA256(pluginPath)
+	if err != nil {
+		return fmt.Errorf("计算插件文件校验和失败: %v", err)
+	}
+
+	expected, err := checksumStorage.GetPluginChecksum(pluginPath)
+	if err != nil {
+		return fmt.Errorf("读取插件文件校验和失败: %v", err)
+	}
+
+	switch {
+	case expected == "":
+	case expected == sum:
+		return nil
+	case m.allowModified.Load():
+		m.logger.Printf("插件文件 %s 已被修改，按配置允许加载", pluginPath)
+	default:
+		return fileFailure(fmt.Errorf("插件文件校验和与安装时不一致，文件可能被篡改或未复制完整: %s", pluginPath))
+	}
+
+	if err := checksumStorage.SavePluginChecksum(pluginPath, sum); err != nil {
+		return fmt.Errorf("保存插件文件校验和失败: %v", err)
+	}
+	return nil
+}
+
+// saveChecksum 记录插件文件当前的校验和，安装插件时调用
+func (m *Manager) saveChecksum(pluginPath string) error {
+	checksumStorage, ok := m.store().(PluginChecksumStorage)
+	if !ok {
+		return nil
+	}
+
+	sum, err := fileSHA256(pluginPath)
+	if err != nil {
+		return fmt.Errorf("计算插件文件校验和失败: %v", err)
+	}
+	if err := checksumStorage.SavePluginChecksum(pluginPath, sum); err != nil {
+		return fmt.Errorf("保存插件文件校验和失败: %v", err)
+	}
+	return nil
+}
+
+// forgetChecksum 清除插件文件记录的校验和，安装失败时调用
+func (m *Manager) forgetChecksum(pluginPath string) {
+	if checksumStorage, ok := m.store().(PluginChecksumStorage); ok {
+		if err := checksumStorage.SavePluginChecksum(pluginPath, ""); err != nil {
+			m.logger.Printf("清除插件文件 %s 的校验和失败: %v", pluginPath, err)
+		}
+	}
+}
+
+// checksumModified 判断插件文件是否与记录的校验和不一致，不修改记录
+func (m *Manager) checksumModified(pluginPath string) (bool, error) {
+	checksumStorage, ok := m.store().(PluginChecksumStorage)
+	if !ok {
+		return false, nil
+	}
+
+	expected, err := checksumStorage.GetPluginChecksum(pluginPath)
+	if err != nil || expected == "" {
+		return false, err
+	}
+
+	sum, err := fileSHA256(pluginPath)
+	if err != nil {
+		return false, err
+	}
+	return sum != expected, nil
+}
@@ -1,6 +1,9 @@
 package plugins
 
 import (
+	"context"
+	"time"
+
 	"github.com/gin-gonic/gin"
 )
 
@@ -40,20 +43,49 @@ type Plugin interface {
 	// OnAPIEvent 处理API事件
 	OnAPIEvent(ctx *gin.Context, event EventType, path string, statusCode int, requestBody interface{}, responseBody interface{}) error
 
-	// InterestedAPIs 获取感兴趣的API路径
+	// InterestedAPIs 获取感兴趣的API：prefix:<路径前缀>、route:<路由名称>、glob:<通配符>（如 glob:/api/v1/*/nodes）、
+	// regex:<正则表达式> 或匹配所有路径的 *，可在前面加HTTP方法限定，如 "POST prefix:/api/subscription"；
+	// 直接返回路径前缀（如 /api/subscription）的旧格式按前缀匹配兼容
 	InterestedAPIs() []string
 
 	// InterestedEvents 获取感兴趣的事件类型
 	InterestedEvents() []EventType
 }
 
+// HealthChecker 插件健康检查接口（可选实现）
+type HealthChecker interface {
+	// Healthy 检查插件是否健康，返回nil表示健康
+	Healthy(ctx context.Context) error
+}
+
 // PluginInfo 插件信息
 type PluginInfo struct {
-	Name        string
-	Version     string
-	Description string
-	FilePath    string
-	Enabled     bool
-	Config      map[string]interface{}
-	Plugin      Plugin
+	Name           string
+	Version        string
+	Description    string
+	FilePath       string
+	Enabled        bool        // 是否已启用，与State == StateEnabled一致
+	State          PluginState // 插件状态
+	StateReason    string      // 状态原因，用于向界面解释插件未运行的原因
+	StateChangedAt time.Time   // 最近一次状态迁移的时间
+	Config         map[string]interface{}
+	Plugin         Plugin
+	APIVersion     int             // 插件接口版本
+	Manifest       *PluginManifest // 插件清单，没有清单时为nil
+	Build          *BuildInfo      // 插件构建信息，尚未打开的插件为nil
+	Author         string          // 插件作者，来自清单
+	Homepage       string          // 插件主页，来自清单
+	Permissions    []string        // 插件需要的权限，来自清单
+	Priority       int             // 插件优先级，越大越先初始化和接收事件
+	ConfigIssues   []ConfigIssue   // 配置与插件当前版本的默认配置和配置Schema不一致之处，插件升级后提示更新配置
+
+	enabling bool // 是否正在启用中
+	dormant  bool // 是否为尚未激活的按需加载插件
+
+	awaitingDeps bool // 加载时因依赖未满足而等待，依赖启用后自动初始化
+	lazy         bool // 是否为延迟加载模式下只索引、尚未打开的插件
+
+	enabledSeq uint64 // 最近一次启用的顺序号，关闭时按逆序关闭
+
+	history []StateTransition // 最近的状态迁移记录
 }
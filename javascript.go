@@ -0,0 +1,238 @@
+package plugins
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dop251/goja"
+	"github.com/gin-gonic/gin"
+)
+
+// JSPluginExt JavaScript脚本插件的文件扩展名
+const JSPluginExt = ".js"
+
+// defaultJSTimeout 单次执行JavaScript脚本的默认超时时间，脚本可通过plugin.timeout（毫秒）修改
+const defaultJSTimeout = 5 * time.Second
+
+func init() {
+	loaders[JSPluginExt] = builtinLoader(loadJSPlugin)
+}
+
+// loadJSPlugin 加载JavaScript脚本插件。脚本通过全局对象和函数描述插件：
+//
+//	var plugin = { name: "hello", version: "1.0.0", description: "示例插件", timeout: 3000 };
+//
+//	function defaultConfig() { return { webhook: "" }; }
+//	function interestedAPIs() { return ["/api/subscription"]; }
+//	function interestedEvents() { return ["api_success"]; }      // 可选，缺省时接收所有事件
+//	function init(config) {}                                      // 可选，启用时调用
+//	function onAPIEvent(event, config) {                          // event包含type、path、statusCode、requestBody、responseBody
+//	    console.log("收到事件: " + event.path);
+//	}
+//	function close() {}                                           // 可选，禁用时调用
+//
+// 未定义interestedEvents时接收所有内置的API事件和宿主业务事件。
+// 处理函数通过throw返回错误，每次调用的执行时间受plugin.timeout限制，console.log输出到管理器的日志
+func loadJSPlugin(path string, logger Logger) (Plugin, error) {
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取脚本失败: %v", err)
+	}
+
+	p := &jsPlugin{vm: goja.New(), timeout: defaultJSTimeout}
+	p.vm.SetFieldNameMapper(goja.TagFieldNameMapper("json", true))
+
+	console := p.vm.NewObject()
+	_ = console.Set("log", func(call goja.FunctionCall) goja.Value {
+		args := make([]string, 0, len(call.Arguments))
+		for _, arg := range call.Arguments {
+			args = append(args, arg.String())
+		}
+		logger.Printf("[js] %s", strings.Join(args, " "))
+		return goja.Undefined()
+	})
+	_ = p.vm.Set("console", console)
+
+	if err := p.run(func() error {
+		_, err := p.vm.RunScript(filepath.Base(path), string(source))
+		return err
+	}); err != nil {
+		return nil, fmt.Errorf("执行JavaScript脚本失败: %v", err)
+	}
+
+	p.name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	if meta := p.vm.Get("plugin"); meta != nil && !goja.IsUndefined(meta) && !goja.IsNull(meta) {
+		obj := meta.ToObject(p.vm)
+		if name := jsString(obj, "name"); name != "" {
+			p.name = name
+		}
+		p.version = jsString(obj, "version")
+		p.description = jsString(obj, "description")
+		if timeout := obj.Get("timeout"); timeout != nil && !goja.IsUndefined(timeout) {
+			if ms := timeout.ToInteger(); ms > 0 {
+				p.timeout = time.Duration(ms) * time.Millisecond
+			}
+		}
+	}
+
+	if _, ok := goja.AssertFunction(p.vm.Get("onAPIEvent")); !ok {
+		return nil, fmt.Errorf("JavaScript脚本未定义onAPIEvent函数: %s", path)
+	}
+
+	if err := p.export("defaultConfig", &p.defaultConfig); err != nil {
+		return nil, err
+	}
+	if err := p.export("interestedAPIs", &p.apis); err != nil {
+		return nil, err
+	}
+	if _, ok := goja.AssertFunction(p.vm.Get("interestedEvents")); !ok {
+		p.events = builtinEvents()
+		return p, nil
+	}
+	var events []string
+	if err := p.export("interestedEvents", &events); err != nil {
+		return nil, err
+	}
+	for _, event := range events {
+		p.events = append(p.events, EventType(event))
+	}
+	return p, nil
+}
+
+// jsPlugin JavaScript脚本插件，goja虚拟机不支持并发调用，所有调用串行执行
+type jsPlugin struct {
+	name          string
+	version       string
+	description   string
+	apis          []string
+	events        []EventType
+	defaultConfig map[string]interface{}
+	config        map[string]interface{}
+	timeout       time.Duration
+
+	vm    *goja.Runtime
+	mutex sync.Mutex
+}
+
+func (p *jsPlugin) Name() string {
+	return p.name
+}
+
+func (p *jsPlugin) Version() string {
+	return p.version
+}
+
+func (p *jsPlugin) Description() string {
+	return p.description
+}
+
+func (p *jsPlugin) DefaultConfig() map[string]interface{} {
+	config := make(map[string]interface{}, len(p.defaultConfig))
+	for k, v := range p.defaultConfig {
+		config[k] = v
+	}
+	return config
+}
+
+func (p *jsPlugin) SetConfig(config map[string]interface{}) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.config = config
+}
+
+func (p *jsPlugin) Init() error {
+	return p.invoke("init")
+}
+
+func (p *jsPlugin) Close() error {
+	return p.invoke("close")
+}
+
+func (p *jsPlugin) OnAPIEvent(ctx *gin.Context, event EventType, path string, statusCode int, requestBody interface{}, responseBody interface{}) error {
+	return p.invoke("onAPIEvent", map[string]interface{}{
+		"type":         string(event),
+		"path":         path,
+		"statusCode":   statusCode,
+		"requestBody":  requestBody,
+		"responseBody": responseBody,
+	})
+}
+
+func (p *jsPlugin) InterestedAPIs() []string {
+	return p.apis
+}
+
+func (p *jsPlugin) InterestedEvents() []EventType {
+	return p.events
+}
+
+// invoke 调用脚本中的全局函数，函数未定义时忽略；配置作为最后一个参数传入
+func (p *jsPlugin) invoke(name string, args ...interface{}) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	fn, ok := goja.AssertFunction(p.vm.Get(name))
+	if !ok {
+		return nil
+	}
+
+	values := make([]goja.Value, 0, len(args)+1)
+	for _, arg := range args {
+		values = append(values, p.vm.ToValue(arg))
+	}
+	values = append(values, p.vm.ToValue(p.config))
+
+	return p.run(func() error {
+		_, err := fn(goja.Undefined(), values...)
+		return err
+	})
+}
+
+// export 调用脚本中无参数的全局函数并将返回值导出到target，函数未定义时忽略
+func (p *jsPlugin) export(name string, target interface{}) error {
+	fn, ok := goja.AssertFunction(p.vm.Get(name))
+	if !ok {
+		return nil
+	}
+
+	var result goja.Value
+	if err := p.run(func() error {
+		var err error
+		result, err = fn(goja.Undefined())
+		return err
+	}); err != nil {
+		return fmt.Errorf("调用%s失败: %v", name, err)
+	}
+
+	if err := p.vm.ExportTo(result, target); err != nil {
+		return fmt.Errorf("%s返回值无效: %v", name, err)
+	}
+	return nil
+}
+
+// run 在超时限制内执行脚本，超时后中断虚拟机
+func (p *jsPlugin) run(fn func() error) error {
+	timer := time.AfterFunc(p.timeout, func() {
+		p.vm.Interrupt(fmt.Sprintf("执行超时（%v）", p.timeout))
+	})
+	defer func() {
+		timer.Stop()
+		p.vm.ClearInterrupt()
+	}()
+
+	return fn()
+}
+
+// jsString 读取对象中的字符串属性
+func jsString(obj *goja.Object, key string) string {
+	value := obj.Get(key)
+	if value == nil || goja.IsUndefined(value) || goja.IsNull(value) {
+		return ""
+	}
+	return value.String()
+}
@@ -0,0 +1,248 @@
+package plugins
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// defaultJournalLimit 内存事件日志保留的最大记录数
+const defaultJournalLimit = 10000
+
+// ExportFormat 事件导出格式
+type ExportFormat string
+
+const (
+	ExportNDJSON ExportFormat = "ndjson"
+	ExportCSV    ExportFormat = "csv"
+)
+
+// EventFilter 事件过滤条件，零值字段表示不过滤
+type EventFilter struct {
+	From   time.Time   // 起始时间（含）
+	To     time.Time   // 结束时间（不含）
+	Events []EventType // 事件类型
+	Plugin string      // 插件名称，只保留该插件的处理结果
+}
+
+// EventJournalStorage 事件日志存储扩展接口（可选实现），实现后事件日志写入存储而不是内存
+type EventJournalStorage interface {
+	// AppendEventRecord 追加事件记录
+	AppendEventRecord(record EventRecord) error
+
+	// QueryEventRecords 按条件查询事件记录
+	QueryEventRecords(filter EventFilter) ([]EventRecord, error)
+}
+
+// JournalOptions 事件日志选项
+type JournalOptions struct {
+	CaptureBodies bool           // 是否记录请求体和响应体
+	Payload       PayloadOptions // 请求体和响应体的压缩和大小上限
+}
+
+// eventJournal 事件日志
+type eventJournal struct {
+	enabled bool
+	options JournalOptions
+	records []EventRecord
+	mutex   sync.Mutex
+}
+
+// EnableEventJournal 开启或关闭事件日志
+func (m *Manager) EnableEventJournal(enabled bool) {
+	m.journal.mutex.Lock()
+	defer m.journal.mutex.Unlock()
+
+	m.journal.enabled = enabled
+	if !enabled {
+		m.journal.records = nil
+	}
+}
+
+// SetJournalOptions 设置事件日志选项
+func (m *Manager) SetJournalOptions(options JournalOptions) error {
+	switch options.Payload.Compression {
+	case CompressionNone, CompressionGzip, CompressionZstd:
+	default:
+		return fmt.Errorf("不支持的压缩方式: %s", options.Payload.Compression)
+	}
+
+	m.journal.mutex.Lock()
+	defer m.journal.mutex.Unlock()
+
+	m.journal.options = options
+	return nil
+}
+
+// captureBodies 按事件日志选项将请求体和响应体编码后保存到事件记录
+func (m *Manager) captureBodies(record *eventRecord, requestBody interface{}, responseBody interface{}) {
+	m.journal.mutex.Lock()
+	capture := m.journal.enabled && m.journal.options.CaptureBodies
+	opts := m.journal.options.Payload
+	m.journal.mutex.Unlock()
+
+	if !capture {
+		return
+	}
+
+	reqPayload, err := EncodePayload(requestBody, opts)
+	if err != nil {
+		m.logger.Printf("记录请求体失败: %v", err)
+	}
+	respPayload, err := EncodePayload(responseBody, opts)
+	if err != nil {
+		m.logger.Printf("记录响应体失败: %v", err)
+	}
+
+	record.mutex.Lock()
+	record.RequestBody = reqPayload
+	record.ResponseBody = respPayload
+	record.mutex.Unlock()
+}
+
+// IsEventJournalEnabled 事件日志是否开启
+func (m *Manager) IsEventJournalEnabled() bool {
+	m.journal.mutex.Lock()
+	defer m.journal.mutex.Unlock()
+
+	return m.journal.enabled
+}
+
+// appendJournal 将处理完成的事件记录写入事件日志
+func (m *Manager) appendJournal(record EventRecord) {
+	m.journal.mutex.Lock()
+	defer m.journal.mutex.Unlock()
+
+	if !m.journal.enabled {
+		return
+	}
+
+	if journalStorage, ok := m.store().(EventJournalStorage); ok {
+		if err := journalStorage.AppendEventRecord(record); err != nil {
+			m.logger.Printf("写入事件日志失败: %v", err)
+		}
+		return
+	}
+
+	m.journal.records = append(m.journal.records, record)
+	if over := len(m.journal.records) - defaultJournalLimit; over > 0 {
+		m.journal.records = m.journal.records[over:]
+	}
+}
+
+// queryJournal 按条件查询事件日志
+func (m *Manager) queryJournal(filter EventFilter) ([]EventRecord, error) {
+	m.journal.mutex.Lock()
+	enabled := m.journal.enabled
+	records := append([]EventRecord(nil), m.journal.records...)
+	m.journal.mutex.Unlock()
+
+	if !enabled {
+		return nil, fmt.Errorf("事件日志未开启")
+	}
+
+	if journalStorage, ok := m.store().(EventJournalStorage); ok {
+		return journalStorage.QueryEventRecords(filter)
+	}
+
+	var result []EventRecord
+	for _, record := range records {
+		if filtered, ok := filter.apply(record); ok {
+			result = append(result, filtered)
+		}
+	}
+	return result, nil
+}
+
+// apply 判断记录是否满足过滤条件，按插件过滤时只保留该插件的结果
+func (f EventFilter) apply(record EventRecord) (EventRecord, bool) {
+	if !f.From.IsZero() && record.Time.Before(f.From) {
+		return record, false
+	}
+	if !f.To.IsZero() && !record.Time.Before(f.To) {
+		return record, false
+	}
+
+	if len(f.Events) > 0 {
+		matched := false
+		for _, event := range f.Events {
+			if event == record.Event {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return record, false
+		}
+	}
+
+	if f.Plugin != "" {
+		var results []PluginResult
+		for _, result := range record.Results {
+			if result.Plugin == f.Plugin {
+				results = append(results, result)
+			}
+		}
+		if len(results) == 0 {
+			return record, false
+		}
+		record.Results = results
+	}
+
+	return record, true
+}
+
+// ExportEvents 按条件导出事件日志，NDJSON每行一个事件，CSV每行一个插件处理结果
+func (m *Manager) ExportEvents(w io.Writer, format ExportFormat, filter EventFilter) error {
+	records, err := m.queryJournal(filter)
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case ExportNDJSON:
+		enc := json.NewEncoder(w)
+		for _, record := range records {
+			if err := enc.Encode(record); err != nil {
+				return fmt.Errorf("导出事件失败: %v", err)
+			}
+		}
+		return nil
+	case ExportCSV:
+		return writeEventsCSV(w, records)
+	default:
+		return fmt.Errorf("不支持的导出格式: %s", format)
+	}
+}
+
+// writeEventsCSV 以CSV格式写出事件记录
+func writeEventsCSV(w io.Writer, records []EventRecord) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"time", "event", "path", "status_code", "plugin", "duration_ms", "error"}); err != nil {
+		return fmt.Errorf("导出事件失败: %v", err)
+	}
+
+	for _, record := range records {
+		for _, result := range record.Results {
+			row := []string{
+				record.Time.Format(time.RFC3339Nano),
+				string(record.Event),
+				record.Path,
+				strconv.Itoa(record.StatusCode),
+				result.Plugin,
+				strconv.FormatFloat(float64(result.Duration)/float64(time.Millisecond), 'f', 3, 64),
+				result.Error,
+			}
+			if err := cw.Write(row); err != nil {
+				return fmt.Errorf("导出事件失败: %v", err)
+			}
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,76 @@
+package plugins
+
+import (
+	"strings"
+)
+
+// SyncHandler 同步处理接口（可选实现），Synchronous返回true时事件在请求处理流程中同步分发
+type SyncHandler interface {
+	Synchronous() bool
+}
+
+// LatencyPolicy 延迟敏感路由策略
+type LatencyPolicy struct {
+	// CriticalRoutes 延迟敏感的路由前缀（如订阅下发），这些路由上只有白名单插件可以同步执行
+	CriticalRoutes []string
+
+	// SyncAllowlist 允许在延迟敏感路由上同步执行的插件名称
+	SyncAllowlist []string
+}
+
+// SetLatencyPolicy 设置延迟敏感路由策略
+func (m *Manager) SetLatencyPolicy(policy LatencyPolicy) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.latencyPolicy = LatencyPolicy{
+		CriticalRoutes: append([]string(nil), policy.CriticalRoutes...),
+		SyncAllowlist:  append([]string(nil), policy.SyncAllowlist...),
+	}
+}
+
+// GetLatencyPolicy 获取延迟敏感路由策略
+func (m *Manager) GetLatencyPolicy() LatencyPolicy {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	return LatencyPolicy{
+		CriticalRoutes: append([]string(nil), m.latencyPolicy.CriticalRoutes...),
+		SyncAllowlist:  append([]string(nil), m.latencyPolicy.SyncAllowlist...),
+	}
+}
+
+// deliverSync 判断事件是否应同步分发给插件，调用方需持有m.mutex
+func (m *Manager) deliverSync(info *PluginInfo, path string) bool {
+	handler, ok := info.Plugin.(SyncHandler)
+	if !ok || !handler.Synchronous() {
+		return false
+	}
+
+	// 降级的插件改为异步分发，避免拖慢请求
+	if m.isDegraded(info.Name) {
+		return false
+	}
+
+	if !m.isLatencyCritical(path) {
+		return true
+	}
+
+	// 延迟敏感路由上非白名单插件强制异步
+	for _, name := range m.latencyPolicy.SyncAllowlist {
+		if name == info.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// isLatencyCritical 判断路径是否属于延迟敏感路由，调用方需持有m.mutex
+func (m *Manager) isLatencyCritical(path string) bool {
+	for _, route := range m.latencyPolicy.CriticalRoutes {
+		if strings.HasPrefix(path, route) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,98 @@
+package plugins
+
+import (
+	"fmt"
+)
+
+// SetLazyLoading 设置延迟加载模式：开启后LoadPlugins对未启用的插件只索引文件并读取清单，
+// 插件文件在首次启用时才打开，以减少插件较多时的启动时间和内存占用；只影响之后加载的插件
+func (m *Manager) SetLazyLoading(enabled bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.lazyLoading = enabled
+}
+
+// IsLazyLoading 是否开启了延迟加载模式
+func (m *Manager) IsLazyLoading() bool {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	return m.lazyLoading
+}
+
+// shouldIndexOnly 判断插件是否只需索引：延迟加载模式下启动策略不会启用的插件，调用方需持有m.mutex
+func (m *Manager) shouldIndexOnly(pluginPath string) bool {
+	if !m.lazyLoading {
+		return false
+	}
+
+	pluginDB, _ := m.store().GetPlugin(pluginPath)
+	state, _ := startupState(m.startupPolicy, pluginDB)
+	return state != StateEnabled
+}
+
+// registerLazy 索引未启用的插件而不打开插件文件，名称来自清单，没有清单时使用文件名，调用方需持有m.mutex
+func (m *Manager) registerLazy(pluginPath string, manifest *PluginManifest) (*PluginInfo, error) {
+	name := artifactName(pluginPath)
+	if manifest != nil && manifest.Name != "" {
+		name = manifest.Name
+	}
+
+	info, err := m.registerIndexed(pluginPath, name, manifest)
+	if err != nil {
+		return nil, err
+	}
+	info.lazy = true
+
+	m.logger.Printf("已索引插件: %s（延迟加载）", info.Name)
+	return info, nil
+}
+
+// openLazy 打开延迟加载的插件文件，替换索引时的占位实例，调用方需持有m.mutex
+func (m *Manager) openLazy(info *PluginInfo) error {
+	opened, err := m.openPlugin(info.FilePath)
+	if err != nil {
+		if isCompatibilityError(err) {
+			_ = m.setState(info, StateIncompatible, err.Error())
+		}
+		return err
+	}
+	instance := opened.instance
+
+	if instance.Name() != info.Name {
+		return fmt.Errorf("插件名称 %s 与索引名称 %s 不一致，请在插件清单中声明名称", instance.Name(), info.Name)
+	}
+	if err := m.checkPolicy(instance.Name(), info.FilePath); err != nil {
+		return err
+	}
+	if err := opened.manifest.validateInstance(instance); err != nil {
+		return err
+	}
+	if err := m.validateSubscriptions(instance); err != nil {
+		return err
+	}
+
+	if info.Config == nil {
+		info.Config = instance.DefaultConfig()
+	}
+	m.deliverConfig(info.Name, instance, opened.manifest, info.Config)
+
+	info.Plugin = instance
+	info.Version = instance.Version()
+	info.Description = instance.Description()
+	info.APIVersion = opened.apiVersion
+	info.Manifest = opened.manifest
+	info.Build = opened.build
+	info.Priority = m.pluginPriority(info.Name, opened.manifest, instance)
+	info.applyManifestDetails()
+	info.dormant, info.lazy = false, false
+	info.checkConfigDrift()
+
+	for _, warning := range m.subscriptionWarnings(info) {
+		m.logger.Printf("插件 %s 订阅检查: %s", info.Name, warning)
+	}
+
+	m.logger.Printf("成功加载插件: %s v%s", info.Name, info.Version)
+	return nil
+}
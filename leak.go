@@ -0,0 +1,126 @@
+package plugins
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"regexp"
+	"runtime/pprof"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// pluginLabelKey 标记插件goroutine的pprof标签键
+	pluginLabelKey = "sublink_plugin"
+
+	// leakGracePeriod 插件关闭后等待goroutine退出的宽限期
+	leakGracePeriod = 5 * time.Second
+)
+
+// pluginLabelPattern 匹配goroutine profile中的插件标签
+var pluginLabelPattern = regexp.MustCompile(`"` + pluginLabelKey + `":("(?:[^"\\]|\\.)*")`)
+
+// LeakReport 插件goroutine泄漏报告
+type LeakReport struct {
+	Plugin     string
+	Goroutines int       // 关闭后仍存活的goroutine数量
+	DetectedAt time.Time // 检测时间
+}
+
+// runWithPluginLabels 以插件标签执行fn，fn中创建的goroutine会继承该标签
+func runWithPluginLabels(name string, fn func()) {
+	pprof.Do(context.Background(), pprof.Labels(pluginLabelKey, name), func(context.Context) {
+		fn()
+	})
+}
+
+// goUnlabeled 在清除了插件标签的goroutine中执行fn。宿主自身的后台任务可能在插件标签下被触发，
+// 继承标签会被GoroutineStats计入插件，导致误报泄漏
+func goUnlabeled(fn func()) {
+	go func() {
+		pprof.SetGoroutineLabels(context.Background())
+		fn()
+	}()
+}
+
+// GoroutineStats 获取每个插件当前存活的goroutine数量
+func (m *Manager) GoroutineStats() map[string]int {
+	var buf bytes.Buffer
+	if err := pprof.Lookup("goroutine").WriteTo(&buf, 1); err != nil {
+		m.logger.Printf("读取goroutine信息失败: %v", err)
+		return nil
+	}
+
+	stats := make(map[string]int)
+	count := 0
+
+	scanner := bufio.NewScanner(&buf)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		// 每组堆栈以 "N @ 0x..." 开头，随后可能跟随标签行
+		if idx := strings.Index(line, " @ "); idx > 0 {
+			if n, err := strconv.Atoi(line[:idx]); err == nil {
+				count = n
+			}
+			continue
+		}
+
+		if !strings.HasPrefix(line, "# labels:") {
+			continue
+		}
+		match := pluginLabelPattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		if name, err := strconv.Unquote(match[1]); err == nil {
+			stats[name] += count
+		}
+	}
+
+	return stats
+}
+
+// GetLeakReports 获取检测到的goroutine泄漏报告
+func (m *Manager) GetLeakReports() []LeakReport {
+	m.leakMutex.Lock()
+	defer m.leakMutex.Unlock()
+
+	reports := make([]LeakReport, 0, len(m.leaks))
+	for _, report := range m.leaks {
+		reports = append(reports, *report)
+	}
+	return reports
+}
+
+// checkLeaks 在宽限期后检查已关闭插件是否仍有存活的goroutine
+func (m *Manager) checkLeaks(name string) {
+	goUnlabeled(func() {
+		time.Sleep(leakGracePeriod)
+
+		// 检查期间插件可能已被重新启用
+		if info, exists := m.GetPlugin(name); exists && info.Enabled {
+			return
+		}
+
+		alive := m.GoroutineStats()[name]
+
+		m.leakMutex.Lock()
+		defer m.leakMutex.Unlock()
+
+		if alive == 0 {
+			delete(m.leaks, name)
+			return
+		}
+
+		m.leaks[name] = &LeakReport{
+			Plugin:     name,
+			Goroutines: alive,
+			DetectedAt: time.Now(),
+		}
+		m.logger.Printf("插件 %s 关闭后仍有 %d 个goroutine存活，可能存在泄漏", name, alive)
+	})
+}
@@ -0,0 +1,127 @@
+package plugins
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// goPluginExt Go原生插件（plugin.Open）的文件扩展名
+const goPluginExt = ".so"
+
+// PluginLoader 插件加载器，按文件扩展名为非Go原生插件（脚本、WASM等）提供运行时
+type PluginLoader interface {
+	// Load 加载插件文件并返回插件实例
+	Load(path string) (Plugin, error)
+}
+
+// PluginLoaderFunc 函数形式的插件加载器
+type PluginLoaderFunc func(path string) (Plugin, error)
+
+func (f PluginLoaderFunc) Load(path string) (Plugin, error) {
+	return f(path)
+}
+
+// loggingLoader 内置加载器实现此接口（可选实现），使插件脚本输出的日志写入管理器配置的日志
+type loggingLoader interface {
+	loadWithLogger(path string, logger Logger) (Plugin, error)
+}
+
+// builtinLoader 内置的脚本插件加载器，通过Load调用时日志写入标准库默认日志
+type builtinLoader func(path string, logger Logger) (Plugin, error)
+
+func (f builtinLoader) Load(path string) (Plugin, error) {
+	return f(path, log.Default())
+}
+
+func (f builtinLoader) loadWithLogger(path string, logger Logger) (Plugin, error) {
+	return f(path, logger)
+}
+
+var (
+	loaders     = make(map[string]PluginLoader)
+	loaderMutex sync.RWMutex
+)
+
+// RegisterLoader 为文件扩展名（如 ".wasm"）登记插件加载器，之后LoadPlugins、目录监听和安装都会识别该扩展名的文件。
+// 内置的.lua、.js和.wasm加载器可以被替换或注销（loader为nil）；.so始终由Go原生插件加载
+func RegisterLoader(ext string, loader PluginLoader) error {
+	ext = strings.ToLower(ext)
+	if !strings.HasPrefix(ext, ".") || len(ext) < 2 {
+		return fmt.Errorf("无效的扩展名: %s", ext)
+	}
+	if ext == goPluginExt {
+		return fmt.Errorf("%s 由Go原生插件加载，不能替换", goPluginExt)
+	}
+	if ext == SubprocessPluginExt {
+		return fmt.Errorf("%s 由独立进程插件加载，不能替换", SubprocessPluginExt)
+	}
+
+	loaderMutex.Lock()
+	defer loaderMutex.Unlock()
+
+	if loader == nil {
+		delete(loaders, ext)
+		return nil
+	}
+	loaders[ext] = loader
+	return nil
+}
+
+// PluginExtensions 返回所有可加载的插件文件扩展名
+func PluginExtensions() []string {
+	loaderMutex.RLock()
+	defer loaderMutex.RUnlock()
+
+	exts := []string{goPluginExt, SubprocessPluginExt}
+	for ext := range loaders {
+		exts = append(exts, ext)
+	}
+	sort.Strings(exts[2:])
+	return exts
+}
+
+// isPluginFile 判断文件是否为可加载的插件文件
+func isPluginFile(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext == goPluginExt || isSubprocessPlugin(path) {
+		return true
+	}
+	_, ok := loaderFor(path)
+	return ok
+}
+
+// loaderFor 查找文件扩展名对应的加载器
+func loaderFor(path string) (PluginLoader, bool) {
+	loaderMutex.RLock()
+	defer loaderMutex.RUnlock()
+
+	loader, ok := loaders[strings.ToLower(filepath.Ext(path))]
+	return loader, ok
+}
+
+// openWithLoader 使用登记的加载器打开非Go原生插件
+func (m *Manager) openWithLoader(loader PluginLoader, pluginPath string) (*openedPlugin, error) {
+	var instance Plugin
+	var err error
+	if l, ok := loader.(loggingLoader); ok {
+		instance, err = l.loadWithLogger(pluginPath, m.logger)
+	} else {
+		instance, err = loader.Load(pluginPath)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("加载插件失败: %w", err)
+	}
+
+	manifest, err := loadManifest(pluginPath)
+	if err != nil {
+		_ = instance.Close()
+		return nil, err
+	}
+	m.injectHostAPI(instance, manifest)
+
+	return &openedPlugin{instance: instance, apiVersion: 1, manifest: manifest}, nil
+}
@@ -0,0 +1,114 @@
+package plugins
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// LoaderFilter 扫描插件目录时的文件过滤规则，条目为glob模式（如 notify-*.so、*-beta.so）。
+// 不含 / 的模式匹配文件名，含 / 的模式匹配相对搜索目录的路径（如 staging/*.so）。
+// 包含规则为空表示不限制；同时命中时以排除规则为准。只影响LoadPlugins、PlanLoad和目录监听，
+// 不影响InstallPlugin等显式操作
+type LoaderFilter struct {
+	Include []string `json:"include" yaml:"include"`
+	Exclude []string `json:"exclude" yaml:"exclude"`
+}
+
+// loaderFilter 生效中的文件过滤规则
+type loaderFilter struct {
+	filter LoaderFilter
+	mutex  sync.RWMutex
+}
+
+// SetLoaderFilter 设置扫描插件目录时的文件过滤规则，已加载的插件不受影响
+func (m *Manager) SetLoaderFilter(filter LoaderFilter) error {
+	if err := m.checkWritable(); err != nil {
+		return err
+	}
+
+	normalized := LoaderFilter{}
+	for _, list := range []struct {
+		src []string
+		dst *[]string
+	}{{filter.Include, &normalized.Include}, {filter.Exclude, &normalized.Exclude}} {
+		for _, pattern := range list.src {
+			pattern = strings.TrimSpace(filepath.ToSlash(pattern))
+			if pattern == "" {
+				continue
+			}
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("无效的过滤规则 %q: %v", pattern, err)
+			}
+			*list.dst = append(*list.dst, pattern)
+		}
+	}
+
+	m.loadFilter.mutex.Lock()
+	defer m.loadFilter.mutex.Unlock()
+
+	m.loadFilter.filter = normalized
+	return nil
+}
+
+// GetLoaderFilter 获取扫描插件目录时的文件过滤规则
+func (m *Manager) GetLoaderFilter() LoaderFilter {
+	m.loadFilter.mutex.RLock()
+	defer m.loadFilter.mutex.RUnlock()
+
+	return LoaderFilter{
+		Include: append([]string(nil), m.loadFilter.filter.Include...),
+		Exclude: append([]string(nil), m.loadFilter.filter.Exclude...),
+	}
+}
+
+// filterReason 按过滤规则检查搜索目录root中的插件文件，被过滤时返回原因，否则返回空字符串
+func (m *Manager) filterReason(root, pluginPath string) string {
+	m.loadFilter.mutex.RLock()
+	filter := m.loadFilter.filter
+	m.loadFilter.mutex.RUnlock()
+
+	if len(filter.Include) == 0 && len(filter.Exclude) == 0 {
+		return ""
+	}
+
+	rel, err := filepath.Rel(root, pluginPath)
+	if err != nil {
+		rel = filepath.Base(pluginPath)
+	}
+	rel = filepath.ToSlash(rel)
+
+	if pattern, ok := filterMatches(filter.Exclude, rel); ok {
+		return fmt.Sprintf("匹配排除规则 %q", pattern)
+	}
+	if len(filter.Include) > 0 {
+		if _, ok := filterMatches(filter.Include, rel); !ok {
+			return "不匹配任何包含规则"
+		}
+	}
+	return ""
+}
+
+// filterMatches 查找匹配相对路径的模式
+func filterMatches(patterns []string, rel string) (string, bool) {
+	for _, pattern := range patterns {
+		target := rel
+		if !strings.Contains(pattern, "/") {
+			target = path.Base(rel)
+		}
+		if ok, _ := path.Match(pattern, target); ok {
+			return pattern, true
+		}
+	}
+	return "", false
+}
+
+// searchDirOf 返回插件文件所在的搜索目录，不在任何搜索目录中时返回文件所在目录，调用方需持有m.mutex
+func (m *Manager) searchDirOf(pluginPath string) string {
+	if i := m.dirPriority(pluginPath); i >= 0 {
+		return m.pluginDirs()[i]
+	}
+	return filepath.Dir(pluginPath)
+}
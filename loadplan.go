@@ -0,0 +1,234 @@
+package plugins
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LoadDecision 预演加载时对插件文件的判断
+type LoadDecision string
+
+const (
+	LoadWouldLoad LoadDecision = "load"   // 将被加载
+	LoadSkip      LoadDecision = "skip"   // 将被跳过，如已加载或被同名插件覆盖
+	LoadReject    LoadDecision = "reject" // 将被拒绝，如签名无效、被策略禁止或与宿主不兼容
+)
+
+// LoadPlanEntry 单个插件文件的预演结果
+type LoadPlanEntry struct {
+	Path     string       `json:"path"`
+	Name     string       `json:"name"`              // 来自清单，没有清单时为文件名，仅供参考
+	Version  string       `json:"version,omitempty"` // 来自清单
+	Decision LoadDecision `json:"decision"`
+	Reason   string       `json:"reason,omitempty"`
+	Enabled  bool         `json:"enabled"` // 加载后是否启用
+	Dormant  bool         `json:"dormant"` // 是否为按需激活插件
+}
+
+// LoadReport LoadPlugins的预演报告
+type LoadReport struct {
+	Dirs    []string        `json:"dirs"`
+	Entries []LoadPlanEntry `json:"entries"`
+}
+
+// Count 统计指定判断的插件数量
+func (r *LoadReport) Count(decision LoadDecision) int {
+	n := 0
+	for _, entry := range r.Entries {
+		if entry.Decision == decision {
+			n++
+		}
+	}
+	return n
+}
+
+// String 生成适合写入启动日志的摘要
+func (r *LoadReport) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "插件加载预演: %d 个加载, %d 个跳过, %d 个拒绝",
+		r.Count(LoadWouldLoad), r.Count(LoadSkip), r.Count(LoadReject))
+	for _, entry := range r.Entries {
+		fmt.Fprintf(&b, "\n  [%s] %s (%s)", entry.Decision, entry.Name, entry.Path)
+		if entry.Reason != "" {
+			fmt.Fprintf(&b, ": %s", entry.Reason)
+		}
+	}
+	return b.String()
+}
+
+// PlanLoad 扫描插件搜索目录，报告LoadPlugins将加载、跳过或拒绝哪些插件及原因，不打开任何插件文件。
+// 插件名称在打开前只能从清单获得，因此名称相关的判断（覆盖、允许列表）仅在有清单时准确
+func (m *Manager) PlanLoad() (*LoadReport, error) {
+	m.mutex.RLock()
+	dirs, opts := m.pluginDirs(), m.walkOptions()
+	loaded := make(map[string]bool, len(m.plugins))
+	for _, info := range m.plugins {
+		loaded[filepath.Clean(info.FilePath)] = true
+	}
+	m.mutex.RUnlock()
+
+	report := &LoadReport{Dirs: dirs}
+	byName := make(map[string]int) // 插件名称 -> 将被加载的条目下标
+	entryDir := make(map[int]int)  // 条目下标 -> 搜索目录下标
+	policy := m.GetNameConflictPolicy()
+
+	for i, dir := range dirs {
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			continue
+		}
+
+		err := walkPluginTree(dir, opts, nil, func(path string) error {
+			if reason := m.filterReason(dir, path); reason != "" {
+				report.Entries = append(report.Entries, LoadPlanEntry{
+					Path:     path,
+					Name:     artifactName(path),
+					Decision: LoadSkip,
+					Reason:   "被加载过滤规则排除: " + reason,
+				})
+				return nil
+			}
+			if reason := artifactSkipReason(path); reason != "" {
+				report.Entries = append(report.Entries, LoadPlanEntry{
+					Path:     path,
+					Name:     artifactName(path),
+					Decision: LoadSkip,
+					Reason:   reason,
+				})
+				return nil
+			}
+
+			entry := m.planEntry(path, loaded[filepath.Clean(path)])
+
+			// 后面目录中的同名插件覆盖前面的，同一目录中按名称冲突策略处理
+			if entry.Decision == LoadWouldLoad {
+				prev, exists := byName[entry.Name]
+				switch {
+				case !exists:
+					byName[entry.Name] = len(report.Entries)
+				case entryDir[prev] != i:
+					report.Entries[prev].Decision = LoadSkip
+					report.Entries[prev].Reason = fmt.Sprintf("被 %s 覆盖", path)
+					byName[entry.Name] = len(report.Entries)
+				default:
+					if planNameConflict(policy, &report.Entries[prev], &entry) {
+						byName[entry.Name] = len(report.Entries)
+					}
+				}
+			}
+
+			entryDir[len(report.Entries)] = i
+			report.Entries = append(report.Entries, entry)
+			return nil
+		})
+		if err != nil {
+			return report, fmt.Errorf("扫描插件目录失败: %v", err)
+		}
+	}
+
+	return report, nil
+}
+
+// planNameConflict 按名称冲突策略判断同一目录中的同名插件，entry取代prev成为将被加载的插件时返回true
+func planNameConflict(policy NameConflictPolicy, prev, entry *LoadPlanEntry) bool {
+	switch policy {
+	case NameConflictFail:
+		reason := fmt.Sprintf("插件 %s 同时由 %s 和 %s 提供", entry.Name, prev.Path, entry.Path)
+		if prev.Decision == LoadWouldLoad {
+			prev.Decision, prev.Reason = LoadReject, reason
+		}
+		entry.Decision, entry.Reason = LoadReject, reason
+		return false
+
+	case NameConflictPreferHigherVersion:
+		if prev.Decision == LoadWouldLoad && compareVersions(entry.Version, prev.Version) > 0 {
+			prev.Decision = LoadReject
+			prev.Reason = fmt.Sprintf("同名的 %s（v%s）版本更高", entry.Path, entry.Version)
+			return true
+		}
+		entry.Decision = LoadReject
+		entry.Reason = fmt.Sprintf("插件 %s 已由 %s 提供，版本不更高", entry.Name, prev.Path)
+		return false
+
+	default:
+		entry.Decision = LoadReject
+		entry.Reason = fmt.Sprintf("插件 %s 已由先加载的 %s 提供", entry.Name, prev.Path)
+		return false
+	}
+}
+
+// planEntry 判断单个插件文件的加载结果
+func (m *Manager) planEntry(pluginPath string, loaded bool) LoadPlanEntry {
+	entry := LoadPlanEntry{
+		Path: pluginPath,
+		Name: artifactName(pluginPath),
+	}
+
+	manifest, err := loadManifest(pluginPath)
+	if err != nil {
+		entry.Decision, entry.Reason = LoadReject, err.Error()
+		return entry
+	}
+	if manifest != nil && manifest.Name != "" {
+		entry.Name = manifest.Name
+		entry.Version = manifest.Version
+		entry.Dormant = manifest.Activation == ActivationOnEvent
+	}
+
+	if loaded {
+		entry.Decision, entry.Reason = LoadSkip, "已加载"
+		return entry
+	}
+
+	if err := m.checkHostVersion(manifest); err != nil {
+		entry.Decision, entry.Reason = LoadReject, err.Error()
+		return entry
+	}
+
+	if modified, err := m.checksumModified(pluginPath); err != nil {
+		entry.Decision, entry.Reason = LoadReject, fmt.Sprintf("无法校验文件: %v", err)
+		return entry
+	} else if modified && !m.allowModified.Load() {
+		entry.Decision, entry.Reason = LoadReject, "文件校验和与安装时不一致"
+		return entry
+	}
+
+	policyName := ""
+	if manifest != nil {
+		policyName = manifest.Name
+	}
+	if err := m.checkPolicy(policyName, pluginPath); err != nil {
+		entry.Decision, entry.Reason = LoadReject, err.Error()
+		return entry
+	}
+
+	// 按需激活的插件在激活时才校验签名和兼容性
+	if !entry.Dormant {
+		if err := m.verifySignature(pluginPath); err != nil {
+			entry.Decision, entry.Reason = LoadReject, err.Error()
+			if m.GetSignaturePolicy() == SignatureQuarantine {
+				entry.Reason += "，将被隔离"
+			}
+			return entry
+		}
+		// 非Go原生插件不需要构建信息预检
+		if _, custom := loaderFor(pluginPath); !custom && !isSubprocessPlugin(pluginPath) {
+			if err := preflightCheck(pluginPath); err != nil {
+				entry.Decision, entry.Reason = LoadReject, err.Error()
+				return entry
+			}
+		}
+	}
+
+	record, _ := m.store().GetPlugin(pluginPath)
+	state, _ := startupState(m.GetStartupPolicy(), record)
+	entry.Decision = LoadWouldLoad
+	entry.Enabled = state == StateEnabled
+	if record == nil {
+		entry.Reason = "新插件"
+	} else if state != StateEnabled {
+		entry.Reason = fmt.Sprintf("状态: %s", state)
+	}
+	return entry
+}
@@ -0,0 +1,415 @@
+package plugins
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// LockDebugEnv 开启锁检测模式的环境变量，值为持有时间阈值（如 200ms），为 1 或 true 时使用默认阈值
+const LockDebugEnv = "SUBLINK_PLUGIN_LOCK_DEBUG"
+
+const (
+	// defaultLockHoldThreshold 锁检测模式默认的持有和等待时间阈值
+	defaultLockHoldThreshold = 100 * time.Millisecond
+
+	// maxLockIssues 保留的锁问题数量，相同的问题只保留一条并累加次数
+	maxLockIssues = 100
+)
+
+// LockIssueKind 锁问题类型
+type LockIssueKind string
+
+const (
+	LockIssueReentrant      LockIssueKind = "reentrant"       // 同一goroutine重复获取已持有的锁，写锁必然死锁，读锁在有写者等待时死锁
+	LockIssueOrderInversion LockIssueKind = "order_inversion" // 两个锁以相反的顺序被获取，并发时可能死锁
+	LockIssuePluginCallback LockIssueKind = "plugin_callback" // 插件goroutine等待管理器正在持有的锁，通常是插件在Init等调用中回调管理器
+	LockIssueLongWait       LockIssueKind = "long_wait"       // 等待锁的时间超过阈值
+	LockIssueLongHold       LockIssueKind = "long_hold"       // 持有锁的时间超过阈值
+)
+
+// LockIssue 锁检测模式发现的问题
+type LockIssue struct {
+	Kind      LockIssueKind
+	Locks     []string      // 涉及的锁，顺序倒置时为先持有的锁和后获取的锁
+	Site      string        // 获取锁的位置
+	Detail    string        // 问题说明
+	Duration  time.Duration // 持有或等待的时间，同类问题取最大值
+	Stack     string        // 首次发现时的调用栈
+	Count     int           // 发现次数
+	FirstSeen time.Time
+	LastSeen  time.Time
+}
+
+// heldLock 当前被持有的锁
+type heldLock struct {
+	name  string
+	gid   uint64
+	write bool
+	site  string
+	since time.Time
+}
+
+// lockTracker 锁检测模式的状态，每个管理器各有一个，只记录该管理器的锁；检测模式关闭时锁的开销只有一次原子读
+type lockTracker struct {
+	enabled   atomic.Bool
+	threshold atomic.Int64
+	logger    Logger // 创建管理器时设置为管理器的日志输出
+
+	held   []*heldLock                  // 当前被持有的锁
+	edges  map[string]map[string]string // 锁的获取顺序：先持有的锁 -> 后获取的锁 -> 首次出现的位置
+	issues map[string]*LockIssue        // 发现的问题，键为类型、锁和位置
+	mutex  sync.Mutex
+}
+
+func newLockTracker() *lockTracker {
+	return &lockTracker{
+		logger: log.Default(),
+		edges:  make(map[string]map[string]string),
+		issues: make(map[string]*LockIssue),
+	}
+}
+
+// enableLockDebugFromEnv 按环境变量开启锁检测模式
+func (m *Manager) enableLockDebugFromEnv() {
+	value := strings.TrimSpace(os.Getenv(LockDebugEnv))
+	switch strings.ToLower(value) {
+	case "", "0", "false":
+		return
+	case "1", "true":
+		m.lockDebug.enable(defaultLockHoldThreshold)
+		return
+	}
+
+	threshold, err := time.ParseDuration(value)
+	if err != nil || threshold <= 0 {
+		m.logger.Printf("环境变量 %s 无效，使用默认阈值 %v: %s", LockDebugEnv, defaultLockHoldThreshold, value)
+		threshold = defaultLockHoldThreshold
+	}
+	m.lockDebug.enable(threshold)
+}
+
+// SetLockDebug 开启或关闭当前管理器的锁检测模式。开启后记录管理器各个锁的获取顺序、持有时间和等待时间，
+// 发现重复获取、顺序倒置、插件回调等待和超过阈值的持有或等待时写入日志并通过LockIssues查询。
+// 检测有额外开销，只应在排查问题时开启；threshold不大于0时使用默认阈值
+func (m *Manager) SetLockDebug(enabled bool, threshold time.Duration) {
+	if !enabled {
+		m.lockDebug.enabled.Store(false)
+		return
+	}
+	if threshold <= 0 {
+		threshold = defaultLockHoldThreshold
+	}
+	m.lockDebug.enable(threshold)
+}
+
+// IsLockDebugEnabled 是否开启了锁检测模式
+func (m *Manager) IsLockDebugEnabled() bool {
+	return m.lockDebug.enabled.Load()
+}
+
+// LockIssues 获取锁检测模式发现的问题，按最近发现时间从新到旧排列
+func (m *Manager) LockIssues() []LockIssue {
+	m.lockDebug.mutex.Lock()
+	defer m.lockDebug.mutex.Unlock()
+
+	issues := make([]LockIssue, 0, len(m.lockDebug.issues))
+	for _, issue := range m.lockDebug.issues {
+		copied := *issue
+		copied.Locks = append([]string(nil), issue.Locks...)
+		issues = append(issues, copied)
+	}
+	sort.Slice(issues, func(i, j int) bool {
+		return issues[i].LastSeen.After(issues[j].LastSeen)
+	})
+	return issues
+}
+
+// ClearLockIssues 清空锁检测模式发现的问题和记录的获取顺序
+func (m *Manager) ClearLockIssues() {
+	m.lockDebug.mutex.Lock()
+	defer m.lockDebug.mutex.Unlock()
+
+	m.lockDebug.edges = make(map[string]map[string]string)
+	m.lockDebug.issues = make(map[string]*LockIssue)
+}
+
+func (t *lockTracker) enable(threshold time.Duration) {
+	t.threshold.Store(int64(threshold))
+	if !t.enabled.Swap(true) {
+		// 开启前获取的锁没有记录，清空残留的持有记录避免误报
+		t.mutex.Lock()
+		t.held = nil
+		t.mutex.Unlock()
+		t.logger.Printf("已开启锁检测模式，阈值 %v", threshold)
+	}
+}
+
+// beforeAcquire 获取锁之前检查重复获取和获取顺序
+func (t *lockTracker) beforeAcquire(name string, gid uint64, write bool, site string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	for _, h := range t.held {
+		if h.gid != gid {
+			continue
+		}
+		if h.name == name {
+			if write || h.write {
+				t.report(LockIssueReentrant, []string{name}, site, 0,
+					fmt.Sprintf("goroutine在持有锁 %s（%s）时再次获取该锁，将会死锁", name, h.site))
+			} else {
+				t.report(LockIssueReentrant, []string{name}, site, 0,
+					fmt.Sprintf("goroutine在持有读锁 %s（%s）时再次获取读锁，有写者等待时将会死锁", name, h.site))
+			}
+			continue
+		}
+
+		if first, exists := t.edges[name][h.name]; exists {
+			t.report(LockIssueOrderInversion, []string{h.name, name}, site, 0,
+				fmt.Sprintf("持有 %s 时获取 %s，而 %s 处以相反的顺序获取，并发时可能死锁", h.name, name, first))
+		}
+		if t.edges[h.name] == nil {
+			t.edges[h.name] = make(map[string]string)
+		}
+		if _, exists := t.edges[h.name][name]; !exists {
+			t.edges[h.name][name] = site
+		}
+	}
+}
+
+// acquired 记录获取到的锁
+func (t *lockTracker) acquired(name string, gid uint64, write bool, site string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.held = append(t.held, &heldLock{name: name, gid: gid, write: write, site: site, since: time.Now()})
+}
+
+// released 移除释放的锁的持有记录，持有时间超过阈值时报告。解锁的goroutine可能不是加锁的goroutine，
+// 找不到当前goroutine的记录时移除同名同模式的最早记录
+func (t *lockTracker) released(name string, write bool) {
+	gid := goroutineID()
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	index := -1
+	for i, h := range t.held {
+		if h.name != name || h.write != write {
+			continue
+		}
+		if h.gid == gid {
+			index = i
+			break
+		}
+		if index < 0 {
+			index = i
+		}
+	}
+	if index < 0 {
+		return
+	}
+
+	h := t.held[index]
+	t.held = append(t.held[:index], t.held[index+1:]...)
+
+	if elapsed := time.Since(h.since); elapsed > time.Duration(t.threshold.Load()) {
+		t.report(LockIssueLongHold, []string{name}, h.site, elapsed,
+			fmt.Sprintf("持有锁 %s %v，超过阈值", name, elapsed.Round(time.Millisecond)))
+	}
+}
+
+// waiting 获取锁需要等待时启动定时检查，超过阈值仍未获取到时报告等待的goroutine和当前持有者
+func (t *lockTracker) waiting(name string, site string) func() {
+	stack := currentStack()
+	start := time.Now()
+	timer := time.AfterFunc(time.Duration(t.threshold.Load()), func() {
+		t.mutex.Lock()
+		defer t.mutex.Unlock()
+
+		var holders []string
+		for _, h := range t.held {
+			if h.name == name {
+				holders = append(holders, fmt.Sprintf("%s（已持有 %v）", h.site, time.Since(h.since).Round(time.Millisecond)))
+			}
+		}
+		elapsed := time.Since(start)
+		if strings.Contains(stack, "runWithPluginLabels") {
+			t.reportStack(LockIssuePluginCallback, []string{name}, site, elapsed, stack,
+				fmt.Sprintf("插件goroutine等待锁 %s 超过 %v，持有者: %s；插件在管理器持有锁期间回调管理器会导致死锁或初始化超时",
+					name, elapsed.Round(time.Millisecond), strings.Join(holders, ", ")))
+			return
+		}
+		t.reportStack(LockIssueLongWait, []string{name}, site, elapsed, stack,
+			fmt.Sprintf("等待锁 %s 超过 %v，持有者: %s", name, elapsed.Round(time.Millisecond), strings.Join(holders, ", ")))
+	})
+	return func() { timer.Stop() }
+}
+
+// report 记录问题，调用方需持有t.mutex
+func (t *lockTracker) report(kind LockIssueKind, locks []string, site string, duration time.Duration, detail string) {
+	t.reportStack(kind, locks, site, duration, "", detail)
+}
+
+// reportStack 记录问题并写入日志，相同的问题只在首次发现时写入日志，调用方需持有t.mutex
+func (t *lockTracker) reportStack(kind LockIssueKind, locks []string, site string, duration time.Duration, stack string, detail string) {
+	now := time.Now()
+	key := string(kind) + "|" + strings.Join(locks, ",") + "|" + site
+	if issue, exists := t.issues[key]; exists {
+		issue.Count++
+		issue.LastSeen = now
+		issue.Detail = detail
+		if duration > issue.Duration {
+			issue.Duration = duration
+		}
+		return
+	}
+
+	t.logger.Printf("锁检测: [%s] %s（%s）", kind, detail, site)
+	if len(t.issues) >= maxLockIssues {
+		return
+	}
+	if stack == "" {
+		stack = currentStack()
+	}
+	t.issues[key] = &LockIssue{
+		Kind:      kind,
+		Locks:     locks,
+		Site:      site,
+		Detail:    detail,
+		Duration:  duration,
+		Stack:     stack,
+		Count:     1,
+		FirstSeen: now,
+		LastSeen:  now,
+	}
+}
+
+// trackedRWMutex 锁检测模式下记录获取顺序和持有时间的读写锁，tracker为nil时不记录
+type trackedRWMutex struct {
+	sync.RWMutex
+	name    string
+	tracker *lockTracker
+}
+
+func (l *trackedRWMutex) Lock() {
+	if l.tracker == nil || !l.tracker.enabled.Load() {
+		l.RWMutex.Lock()
+		return
+	}
+
+	gid, site := goroutineID(), callerSite()
+	l.tracker.beforeAcquire(l.name, gid, true, site)
+	if !l.RWMutex.TryLock() {
+		stop := l.tracker.waiting(l.name, site)
+		l.RWMutex.Lock()
+		stop()
+	}
+	l.tracker.acquired(l.name, gid, true, site)
+}
+
+func (l *trackedRWMutex) Unlock() {
+	if l.tracker != nil && l.tracker.enabled.Load() {
+		l.tracker.released(l.name, true)
+	}
+	l.RWMutex.Unlock()
+}
+
+func (l *trackedRWMutex) RLock() {
+	if l.tracker == nil || !l.tracker.enabled.Load() {
+		l.RWMutex.RLock()
+		return
+	}
+
+	gid, site := goroutineID(), callerSite()
+	l.tracker.beforeAcquire(l.name, gid, false, site)
+	if !l.RWMutex.TryRLock() {
+		stop := l.tracker.waiting(l.name, site)
+		l.RWMutex.RLock()
+		stop()
+	}
+	l.tracker.acquired(l.name, gid, false, site)
+}
+
+func (l *trackedRWMutex) RUnlock() {
+	if l.tracker != nil && l.tracker.enabled.Load() {
+		l.tracker.released(l.name, false)
+	}
+	l.RWMutex.RUnlock()
+}
+
+// trackedMutex 锁检测模式下记录获取顺序和持有时间的互斥锁，tracker为nil时不记录
+type trackedMutex struct {
+	sync.Mutex
+	name    string
+	tracker *lockTracker
+}
+
+func (l *trackedMutex) Lock() {
+	if l.tracker == nil || !l.tracker.enabled.Load() {
+		l.Mutex.Lock()
+		return
+	}
+
+	gid, site := goroutineID(), callerSite()
+	l.tracker.beforeAcquire(l.name, gid, true, site)
+	if !l.Mutex.TryLock() {
+		stop := l.tracker.waiting(l.name, site)
+		l.Mutex.Lock()
+		stop()
+	}
+	l.tracker.acquired(l.name, gid, true, site)
+}
+
+func (l *trackedMutex) Unlock() {
+	if l.tracker != nil && l.tracker.enabled.Load() {
+		l.tracker.released(l.name, true)
+	}
+	l.Mutex.Unlock()
+}
+
+// goroutineID 从调用栈的第一行解析当前goroutine的ID，只在锁检测模式下使用
+func goroutineID() uint64 {
+	var buf [64]byte
+	n := runtime.Stack(buf[:], false)
+	field := bytes.Fields(bytes.TrimPrefix(buf[:n], []byte("goroutine ")))
+	if len(field) == 0 {
+		return 0
+	}
+	id, _ := strconv.ParseUint(string(field[0]), 10, 64)
+	return id
+}
+
+// callerSite 返回加锁方法调用方的位置，格式为 文件:行号 函数名
+func callerSite() string {
+	pc, file, line, ok := runtime.Caller(2)
+	if !ok {
+		return "unknown"
+	}
+	site := fmt.Sprintf("%s:%d", filepath.Base(file), line)
+	if fn := runtime.FuncForPC(pc); fn != nil {
+		name := fn.Name()
+		if i := strings.LastIndex(name, "/"); i >= 0 {
+			name = name[i+1:]
+		}
+		site += " " + name
+	}
+	return site
+}
+
+// currentStack 返回当前goroutine的调用栈
+func currentStack() string {
+	buf := make([]byte, 8192)
+	n := runtime.Stack(buf, false)
+	return string(buf[:n])
+}
@@ -0,0 +1,303 @@
+package plugins
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// LuaPluginExt Lua脚本插件的文件扩展名
+const LuaPluginExt = ".lua"
+
+// luaCallTimeout 单次执行Lua脚本的超时时间
+const luaCallTimeout = 5 * time.Second
+
+func init() {
+	loaders[LuaPluginExt] = builtinLoader(loadLuaPlugin)
+}
+
+// loadLuaPlugin 加载Lua脚本插件。脚本需定义全局表plugin：
+//
+//	plugin = {
+//	    name = "hello",                 -- 插件名称，缺省时使用文件名
+//	    version = "1.0.0",
+//	    description = "示例插件",
+//	    apis = { "/api/subscription" }, -- 感兴趣的API路径
+//	    events = { "api_success" },     -- 感兴趣的事件类型
+//	    default_config = { webhook = "" },
+//	}
+//
+//	function plugin.init(config) end           -- 可选，启用时调用
+//	function plugin.on_event(event, config)    -- event包含type、path、status_code、request_body、response_body
+//	    sublink.log("收到事件: " .. event.path)
+//	end
+//	function plugin.close() end                -- 可选，禁用时调用
+//
+// 处理函数通过error()返回错误，sublink.log输出到管理器的日志。脚本只能使用base、table、string、math库，每次调用的执行时间受luaCallTimeout限制
+func loadLuaPlugin(path string, logger Logger) (Plugin, error) {
+	L := newLuaState(logger)
+	p := &luaPlugin{state: L}
+
+	if err := p.call(func() error { return L.DoFile(path) }); err != nil {
+		L.Close()
+		return nil, fmt.Errorf("执行Lua脚本失败: %v", err)
+	}
+
+	table, ok := L.GetGlobal("plugin").(*lua.LTable)
+	if !ok {
+		L.Close()
+		return nil, fmt.Errorf("Lua脚本未定义plugin表: %s", path)
+	}
+	p.table = table
+
+	p.name = luaString(table, "name")
+	if p.name == "" {
+		p.name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	p.version = luaString(table, "version")
+	p.description = luaString(table, "description")
+
+	if apis, ok := fromLua(table.RawGetString("apis")).([]interface{}); ok {
+		for _, api := range apis {
+			if s, ok := api.(string); ok {
+				p.apis = append(p.apis, s)
+			}
+		}
+	}
+	if events, ok := fromLua(table.RawGetString("events")).([]interface{}); ok {
+		for _, event := range events {
+			if s, ok := event.(string); ok {
+				p.events = append(p.events, EventType(s))
+			}
+		}
+	}
+	if config, ok := fromLua(table.RawGetString("default_config")).(map[string]interface{}); ok {
+		p.defaultConfig = config
+	}
+
+	if _, ok := table.RawGetString("on_event").(*lua.LFunction); !ok {
+		L.Close()
+		return nil, fmt.Errorf("Lua脚本未定义plugin.on_event函数: %s", path)
+	}
+	return p, nil
+}
+
+// newLuaState 创建只开放安全标准库的Lua虚拟机，sublink.log写入logger
+func newLuaState(logger Logger) *lua.LState {
+	L := lua.NewState(lua.Options{SkipOpenLibs: true})
+
+	for _, lib := range []struct {
+		name string
+		open lua.LGFunction
+	}{
+		{lua.BaseLibName, lua.OpenBase},
+		{lua.TabLibName, lua.OpenTable},
+		{lua.StringLibName, lua.OpenString},
+		{lua.MathLibName, lua.OpenMath},
+	} {
+		L.Push(L.NewFunction(lib.open))
+		L.Push(lua.LString(lib.name))
+		L.Call(1, 0)
+	}
+
+	// 禁止脚本读取其他文件
+	for _, name := range []string{"dofile", "loadfile"} {
+		L.SetGlobal(name, lua.LNil)
+	}
+
+	sublink := L.NewTable()
+	L.SetField(sublink, "log", L.NewFunction(func(L *lua.LState) int {
+		logger.Printf("[lua] %s", L.CheckString(1))
+		return 0
+	}))
+	L.SetGlobal("sublink", sublink)
+	return L
+}
+
+// luaPlugin Lua脚本插件，Lua虚拟机不支持并发调用，所有调用串行执行
+type luaPlugin struct {
+	name          string
+	version       string
+	description   string
+	apis          []string
+	events        []EventType
+	defaultConfig map[string]interface{}
+	config        map[string]interface{}
+
+	state *lua.LState
+	table *lua.LTable
+	mutex sync.Mutex
+}
+
+func (p *luaPlugin) Name() string {
+	return p.name
+}
+
+func (p *luaPlugin) Version() string {
+	return p.version
+}
+
+func (p *luaPlugin) Description() string {
+	return p.description
+}
+
+func (p *luaPlugin) DefaultConfig() map[string]interface{} {
+	config := make(map[string]interface{}, len(p.defaultConfig))
+	for k, v := range p.defaultConfig {
+		config[k] = v
+	}
+	return config
+}
+
+func (p *luaPlugin) SetConfig(config map[string]interface{}) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.config = config
+}
+
+func (p *luaPlugin) Init() error {
+	return p.invoke("init")
+}
+
+func (p *luaPlugin) Close() error {
+	return p.invoke("close")
+}
+
+func (p *luaPlugin) OnAPIEvent(ctx *gin.Context, event EventType, path string, statusCode int, requestBody interface{}, responseBody interface{}) error {
+	return p.invoke("on_event", map[string]interface{}{
+		"type":          string(event),
+		"path":          path,
+		"status_code":   statusCode,
+		"request_body":  requestBody,
+		"response_body": responseBody,
+	})
+}
+
+func (p *luaPlugin) InterestedAPIs() []string {
+	return p.apis
+}
+
+func (p *luaPlugin) InterestedEvents() []EventType {
+	return p.events
+}
+
+// invoke 调用plugin表中的函数，函数未定义时忽略；配置作为最后一个参数传入
+func (p *luaPlugin) invoke(name string, args ...interface{}) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	fn, ok := p.table.RawGetString(name).(*lua.LFunction)
+	if !ok {
+		return nil
+	}
+
+	values := make([]lua.LValue, 0, len(args)+1)
+	for _, arg := range args {
+		values = append(values, toLua(p.state, arg))
+	}
+	values = append(values, toLua(p.state, p.config))
+
+	return p.call(func() error {
+		return p.state.CallByParam(lua.P{Fn: fn, NRet: 0, Protect: true}, values...)
+	})
+}
+
+// call 在超时限制内执行Lua代码
+func (p *luaPlugin) call(fn func() error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), luaCallTimeout)
+	defer cancel()
+
+	p.state.SetContext(ctx)
+	defer p.state.RemoveContext()
+
+	return fn()
+}
+
+// luaString 读取表中的字符串字段
+func luaString(table *lua.LTable, key string) string {
+	if s, ok := table.RawGetString(key).(lua.LString); ok {
+		return string(s)
+	}
+	return ""
+}
+
+// toLua 将Go值转换为Lua值，结构体等类型先按JSON规范化
+func toLua(L *lua.LState, v interface{}) lua.LValue {
+	switch value := v.(type) {
+	case nil:
+		return lua.LNil
+	case bool:
+		return lua.LBool(value)
+	case string:
+		return lua.LString(value)
+	case int:
+		return lua.LNumber(value)
+	case int64:
+		return lua.LNumber(value)
+	case float64:
+		return lua.LNumber(value)
+	case []interface{}:
+		table := L.NewTable()
+		for _, item := range value {
+			table.Append(toLua(L, item))
+		}
+		return table
+	case map[string]interface{}:
+		table := L.NewTable()
+		for k, item := range value {
+			table.RawSetString(k, toLua(L, item))
+		}
+		return table
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return lua.LString(fmt.Sprint(v))
+	}
+	var normalized interface{}
+	if err := json.Unmarshal(data, &normalized); err != nil {
+		return lua.LString(string(data))
+	}
+	return toLua(L, normalized)
+}
+
+// fromLua 将Lua值转换为Go值，键为连续整数的表转换为切片，其他表转换为map
+func fromLua(v lua.LValue) interface{} {
+	switch value := v.(type) {
+	case lua.LBool:
+		return bool(value)
+	case lua.LString:
+		return string(value)
+	case lua.LNumber:
+		return float64(value)
+	case *lua.LTable:
+		if n := value.MaxN(); n > 0 && n == luaTableLen(value) {
+			items := make([]interface{}, 0, n)
+			for i := 1; i <= n; i++ {
+				items = append(items, fromLua(value.RawGetInt(i)))
+			}
+			return items
+		}
+		result := make(map[string]interface{})
+		value.ForEach(func(key, item lua.LValue) {
+			result[key.String()] = fromLua(item)
+		})
+		return result
+	}
+	return nil
+}
+
+// luaTableLen 返回表中键值对的数量
+func luaTableLen(table *lua.LTable) int {
+	n := 0
+	table.ForEach(func(lua.LValue, lua.LValue) { n++ })
+	return n
+}
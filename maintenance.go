@@ -0,0 +1,31 @@
+package plugins
+
+import (
+	"errors"
+)
+
+// ErrMaintenanceMode 维护模式下拒绝修改操作时返回的错误。
+// 为了返回该错误，以下方法由无返回值改为返回error（PurgeArchive由返回[]string改为返回([]string, error)），
+// 升级后调用方需处理新增的返回值：RemovePipeline、PurgeArchive、SetHostSettings、RevokeAdminControl、
+// SetAllowModifiedPlugins、RegisterNotifyChannel
+var ErrMaintenanceMode = errors.New("插件子系统处于维护模式，禁止执行修改操作")
+
+// SetMaintenanceMode 开启或关闭维护模式，维护模式下事件分发照常进行，但所有修改操作都会被拒绝
+func (m *Manager) SetMaintenanceMode(enabled bool) {
+	if m.maintenance.Swap(enabled) != enabled {
+		m.logger.Printf("插件子系统维护模式: %v", enabled)
+	}
+}
+
+// IsMaintenanceMode 是否处于维护模式
+func (m *Manager) IsMaintenanceMode() bool {
+	return m.maintenance.Load()
+}
+
+// checkWritable 检查当前是否允许执行修改操作
+func (m *Manager) checkWritable() error {
+	if m.maintenance.Load() {
+		return ErrMaintenanceMode
+	}
+	return nil
+}
@@ -1,23 +1,159 @@
 package plugins
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"os"
-	"path/filepath"
 	"plugin"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"golang.org/x/sync/errgroup"
 )
 
 // Manager 插件管理器
 type Manager struct {
-	plugins   map[string]*PluginInfo
-	pluginDir string
-	mutex     sync.RWMutex
+	plugins     map[string]*PluginInfo
+	pluginDir   string   // 可写的插件目录，优先级最高
+	searchDirs  []string // 其他插件搜索目录，按优先级从低到高排列
+	mutex       trackedRWMutex
+	lockDebug   *lockTracker  // 锁检测模式，记录本管理器各个锁的获取顺序和持有时间
+	initTimeout time.Duration // 插件初始化超时时间，0表示不限制
+	inits       *initTracker  // 正在执行的插件初始化
+
+	warmupTimeout time.Duration // 插件预热超时时间，0表示不限制
+
+	operations map[string]*Operation
+	opMutex    trackedMutex
+
+	leaks     map[string]*LeakReport
+	leakMutex sync.Mutex
+
+	hostSettings map[string]string // 宿主设置，用于解析配置占位符
+	maintenance  atomic.Bool       // 维护模式
+
+	alerts *errorAlerter
+
+	clock       Clock
+	randFactory func(plugin string) Rand
+	hostVersion string // 宿主版本，用于校验插件清单中的最低宿主版本
+	hostMutex   trackedRWMutex
+
+	missing map[string]*MissingPlugin // 文件已丢失的插件记录，键为文件路径
+	routes  []string                  // 宿主登记的路由表
+
+	routeCatalog RouteCatalog // 宿主的命名路由表
+
+	records     []*eventRecord // 最近的事件记录
+	recordLimit int
+	recordMutex trackedMutex
+
+	latencyPolicy LatencyPolicy // 延迟敏感路由策略
+
+	notifyChannels map[string]NotifyChannel
+	notifyThrottle *notifyThrottle
+	notifyMutex    sync.Mutex
+
+	watcher    *pluginWatcher // 插件目录监听器，未开启时为nil
+	watchMutex sync.Mutex
+
+	stats   *statsTracker  // 插件计数器
+	windows *windowTracker // 插件滚动窗口统计
+	budget  *syncBudget    // 同步处理时间预算
+
+	journal eventJournal // 事件日志
+
+	env *envVault // 插件环境变量加解密
+
+	readiness *readinessTracker // 插件自报的就绪状态
+
+	scheduler *eventScheduler // 延迟事件调度
+
+	allowModified atomic.Bool // 是否允许加载校验和变化的插件文件
+
+	followSymlinks atomic.Bool // 遍历插件目录时是否进入指向目录的符号链接
+
+	pipelines *pipelineRegistry // 插件处理管道
+
+	signature signatureVerifier // 插件签名校验
+
+	policy pluginPolicy // 插件允许/禁止列表
+
+	loadFilter loaderFilter // 扫描插件目录时的文件过滤规则
+
+	archiveDir       string        // 卸载插件的归档目录，为空时使用插件目录旁的默认目录
+	archiveRetention time.Duration // 归档保留时间，0表示使用默认值
+
+	keptVersions int // 每个插件保留的历史版本数量，0表示使用默认值
+
+	limiter *concurrencyLimiter // 插件并发处理限制
+
+	startupPolicy StartupPolicy // 加载插件时的启用策略
+
+	groups *groupRegistry // 插件组
+
+	notices *noticeCenter // 站内通知
+
+	seenKeys *seenKeyStore // 插件事件去重键
+
+	priorities map[string]int // 管理员设置的插件优先级，键为插件名称
+
+	codecs *codecRegistry // 请求体和响应体解码器
+
+	lazyLoading     bool // 延迟加载模式，未启用的插件只索引不打开
+	loadConcurrency int  // LoadPlugins并发打开插件文件的数量
+
+	shutdown     *shutdownSettings // 关闭阶段的超时时间和钩子
+	shuttingDown atomic.Bool       // 是否正在关闭，关闭期间不再分发新事件
+	inflight     sync.WaitGroup    // 正在执行的异步事件处理
+
+	stateObservers *stateObservers // 插件状态变化回调
+
+	shedder *loadShedder // 按宿主压力削减异步分发
+
+	versions *versionStore // 插件上次启用时的版本，存储未实现PluginVersionStorage时使用
+
+	adminGrants *adminGrants // 管理控制插件的授权
+
+	matchers *matcherCache // 编译后的API订阅条目
+
+	configs *configSnapshots // 下发给插件的配置快照
+
+	conflicts *nameConflicts // 同名插件的处理策略和冲突记录
+
+	widgetTimeout time.Duration // 单个仪表盘组件获取数据的超时时间
+
+	reconciler *reconciler // 存储与内存状态的定时同步
+
+	drains *drainTracker // 插件事件处理计数，停用插件时等待处理完成
+
+	enableSeq atomic.Uint64 // 插件启用顺序号
+
+	templates *templateSettings // 插件模板渲染限制和辅助函数
+
+	restarting   atomic.Bool  // 是否正在重启插件子系统
+	dispatchGate sync.RWMutex // 重启替换插件集合期间暂停事件分发
+
+	taps *tapRegistry // 调试用的事件镜像
+
+	breakers *breakerTracker // 插件熔断统计
+
+	health *healthMonitor // 插件定时健康检查
+
+	customEvents *customEventRegistry // 运行时登记的自定义事件类型
+
+	planMutex sync.Mutex // 保证同一时刻只有一个管理计划在执行
+
+	storage PluginStorage // 通过WithStorage指定的存储，为nil时使用默认存储
+	logger  Logger        // 日志输出
+
+	optionWarnings []string // 应用选项时产生的警告，所有选项生效后写入日志
 }
 
 var (
@@ -25,102 +161,342 @@ var (
 	once    sync.Once
 )
 
-// GetManager 获取插件管理器实例（单例）
+// GetManager 获取插件管理器实例（单例），首次调用时以环境变量中的配置创建
 func GetManager() *Manager {
 	once.Do(func() {
-		manager = &Manager{
-			plugins:   make(map[string]*PluginInfo),
-			pluginDir: "./plugins",
-		}
+		manager = NewManager()
+		manager.enableLockDebugFromEnv()
 	})
 	return manager
 }
 
-// LoadPlugins 加载所有插件
-func (m *Manager) LoadPlugins() error {
+// NewManager 创建独立的插件管理器，用于在同一进程中嵌入多个实例或在测试中使用；未指定插件目录时与GetManager一样从环境变量读取。
+// 存储和插件加载器仍在进程内共享；Go原生插件（.so）同一文件在进程中只能打开一次，多个管理器加载同一文件时共享插件实例
+func NewManager(opts ...Option) *Manager {
+	dirs := pluginDirsFromEnv()
+	locks := newLockTracker()
+	m := &Manager{
+		mutex:           trackedRWMutex{name: "manager", tracker: locks},
+		lockDebug:       locks,
+		opMutex:         trackedMutex{name: "operations", tracker: locks},
+		hostMutex:       trackedRWMutex{name: "host", tracker: locks},
+		recordMutex:     trackedMutex{name: "records", tracker: locks},
+		plugins:         make(map[string]*PluginInfo),
+		pluginDir:       dirs[len(dirs)-1],
+		searchDirs:      dirs[:len(dirs)-1],
+		initTimeout:     defaultInitTimeout,
+		inits:           newInitTracker(),
+		warmupTimeout:   defaultWarmupTimeout,
+		operations:      make(map[string]*Operation),
+		leaks:           make(map[string]*LeakReport),
+		alerts:          newErrorAlerter(DefaultAlertPolicy),
+		clock:           systemClock{},
+		randFactory:     defaultRandFactory,
+		missing:         make(map[string]*MissingPlugin),
+		recordLimit:     defaultEventRecordLimit,
+		notifyChannels:  make(map[string]NotifyChannel),
+		notifyThrottle:  newNotifyThrottle(defaultNotifyLimit, defaultNotifyWindow),
+		stats:           newStatsTracker(),
+		windows:         newWindowTracker(),
+		budget:          newSyncBudget(),
+		env:             newEnvVault(),
+		readiness:       newReadinessTracker(),
+		scheduler:       newEventScheduler(locks),
+		pipelines:       newPipelineRegistry(),
+		limiter:         newConcurrencyLimiter(),
+		startupPolicy:   StartupRespectStorage,
+		groups:          newGroupRegistry(locks),
+		notices:         newNoticeCenter(),
+		seenKeys:        newSeenKeyStore(),
+		priorities:      make(map[string]int),
+		codecs:          newCodecRegistry(),
+		shutdown:        newShutdownSettings(),
+		stateObservers:  newStateObservers(),
+		shedder:         newLoadShedder(),
+		adminGrants:     newAdminGrants(),
+		matchers:        newMatcherCache(),
+		configs:         newConfigSnapshots(),
+		conflicts:       newNameConflicts(),
+		widgetTimeout:   defaultWidgetTimeout,
+		reconciler:      newReconciler(),
+		drains:          newDrainTracker(),
+		templates:       newTemplateSettings(),
+		taps:            newTapRegistry(),
+		breakers:        newBreakerTracker(),
+		health:          newHealthMonitor(),
+		customEvents:    newCustomEventRegistry(),
+		logger:          log.Default(),
+		versions:        newVersionStore(),
+		loadConcurrency: defaultLoadConcurrency,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	// WithLogger可以出现在任何位置，选项产生的警告在所有选项生效后再写入日志
+	for _, warning := range m.optionWarnings {
+		m.logger.Printf("%s", warning)
+	}
+	m.optionWarnings = nil
+	locks.logger = m.logger
+	return m
+}
+
+// defaultLoadConcurrency LoadPlugins默认并发打开插件文件的数量
+const defaultLoadConcurrency = 4
+
+// LoadPlugins 加载所有插件，按优先级从低到高依次遍历插件搜索目录；
+// 单个插件加载失败不影响其他插件，返回合并的错误，插件文件本身有问题的插件被移入隔离目录。每个插件的初始化受初始化超时时间约束，
+// 超时的插件进入init_failed状态，需在超时的初始化返回后重新启用；ctx取消后不再加载剩余的插件
+func (m *Manager) LoadPlugins(ctx context.Context) error {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 
-	// 确保插件目录存在
+	if err := m.ensurePluginDir(); err != nil {
+		return err
+	}
+
+	// 遍历插件目录，加载完成后恢复未投递的延迟事件
+	m.resetNameConflicts()
+	defer m.restoreScheduledEvents()
+	defer m.detectMissingPlugins()
+
+	prepared, walkErr := m.preparePluginDirs(ctx)
+	if err := m.registerPrepared(ctx, prepared); err != nil {
+		return errors.Join(err, walkErr)
+	}
+	return walkErr
+}
+
+// ensurePluginDir 确保可写的插件目录存在，调用方需持有m.mutex
+func (m *Manager) ensurePluginDir() error {
 	if _, err := os.Stat(m.pluginDir); os.IsNotExist(err) {
 		if err := os.MkdirAll(m.pluginDir, 0755); err != nil {
 			return fmt.Errorf("创建插件目录失败: %v", err)
 		}
-		log.Printf("创建插件目录: %s", m.pluginDir)
-		return nil
+		m.logger.Printf("创建插件目录: %s", m.pluginDir)
 	}
 
-	// 遍历插件目录
-	return filepath.Walk(m.pluginDir, func(path string, info os.FileInfo, err error) error {
+	if !nativePluginsSupported {
+		m.logger.Printf("%v；.so 文件将被标记为不兼容", errNativeUnsupported)
+	}
+	return nil
+}
+
+// preparePluginDirs 遍历插件搜索目录并并发准备插件文件，按目录优先级从低到高、同一目录中按插件优先级排列；
+// 遍历目录失败时返回已准备的插件和错误。调用方需持有m.mutex（读锁即可）
+func (m *Manager) preparePluginDirs(ctx context.Context) ([]*preparedLoad, error) {
+	var all []*preparedLoad
+	for _, dir := range m.pluginDirs() {
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			m.logger.Printf("插件搜索目录不存在，跳过: %s", dir)
+			continue
+		}
+
+		// 只加载插件文件（编译后的.so或已登记加载器的扩展名）
+		var paths []string
+		err := walkPluginTree(dir, m.walkOptions(), nil, func(path string) error {
+			if reason := m.filterReason(dir, path); reason != "" {
+				m.logger.Printf("插件文件 %s 被加载过滤规则跳过: %s", path, reason)
+				return nil
+			}
+			if reason := artifactSkipReason(path); reason != "" {
+				m.logger.Printf("跳过插件文件 %s: %s", path, reason)
+				return nil
+			}
+			paths = append(paths, path)
+			return nil
+		})
 		if err != nil {
-			return err
+			return all, err
+		}
+
+		// 打开插件文件等准备工作并发执行，同一目录中的插件再按优先级依次登记和初始化
+		m.sortPathsByPriority(paths)
+		prepared := make([]*preparedLoad, len(paths))
+		var g errgroup.Group
+		g.SetLimit(m.loadConcurrency)
+		for i, path := range paths {
+			g.Go(func() error {
+				if err := ctx.Err(); err != nil {
+					prepared[i] = &preparedLoad{path: path, err: err}
+					return nil
+				}
+				prepared[i] = m.prepareLoad(path)
+				return nil
+			})
 		}
+		_ = g.Wait()
+		all = append(all, prepared...)
+	}
+	return all, nil
+}
 
-		// 只加载.so文件（编译后的插件）
-		if strings.HasSuffix(path, ".so") {
-			if err := m.loadPlugin(path); err != nil {
-				log.Printf("加载插件失败 %s: %v", path, err)
-				// 继续加载其他插件
+// registerPrepared 依次登记并初始化已准备的插件，返回合并的错误，调用方需持有m.mutex
+func (m *Manager) registerPrepared(ctx context.Context, prepared []*preparedLoad) error {
+	var errs []error
+	for _, p := range prepared {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, fmt.Errorf("加载插件已取消: %w", err))
+			return errors.Join(errs...)
+		}
+		if _, err := m.loadPrepared(ctx, p); err != nil {
+			m.logger.Printf("加载插件失败 %s: %v", p.path, err)
+			if isFileFailure(err) {
+				m.quarantineFile(p.path, err)
 			}
+			// 继续加载其他插件
+			errs = append(errs, fmt.Errorf("加载插件失败 %s: %w", p.path, err))
 		}
+	}
+	return errors.Join(errs...)
+}
 
-		return nil
-	})
+// SetLoadConcurrency 设置LoadPlugins并发打开插件文件的数量，n<=0时恢复默认值
+func (m *Manager) SetLoadConcurrency(n int) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if n <= 0 {
+		n = defaultLoadConcurrency
+	}
+	m.loadConcurrency = n
 }
 
-// loadPlugin 加载单个插件
-func (m *Manager) loadPlugin(pluginPath string) error {
+// preparedLoad 加载插件的准备结果：完整性校验、清单解析和打开插件文件，这些工作不需要持有m.mutex
+type preparedLoad struct {
+	path     string
+	manifest *PluginManifest
+	opened   *openedPlugin // 不需要打开插件文件时为nil
+	openErr  error         // 打开插件文件失败的原因
+	err      error         // 准备失败的原因，插件不会被登记
+}
 
+// loadPlugin 加载单个插件，调用方需持有m.mutex
+func (m *Manager) loadPlugin(pluginPath string) (*PluginInfo, error) {
+	return m.loadPrepared(context.Background(), m.prepareLoad(pluginPath))
+}
 
-	p, err := plugin.Open(pluginPath)
-	if err != nil {
-		fmt.Printf("插件加载失败，详细错误: %v\n", err)
-		return fmt.Errorf("打开插件失败: %w", err)
+// prepareLoad 校验插件文件、解析清单并按需打开插件文件，可以并发执行；
+// 调用方需持有m.mutex或保证期间没有其他goroutine修改管理器配置
+func (m *Manager) prepareLoad(pluginPath string) *preparedLoad {
+	p := &preparedLoad{path: pluginPath}
+
+	// 校验插件文件完整性
+	if p.err = m.verifyChecksum(pluginPath); p.err != nil {
+		return p
+	}
+
+	// 打开插件文件前先按文件哈希检查禁止列表
+	if p.err = m.checkPolicy("", pluginPath); p.err != nil {
+		return p
 	}
 
-	// 查找GetPlugin函数
-	symGetPlugin, err := p.Lookup("GetPlugin")
+	// 打开插件文件前先解析清单
+	if p.manifest, p.err = loadManifest(pluginPath); p.err != nil {
+		// 插件专属清单无效时隔离插件文件，目录级清单无效时不隔离同目录的其他插件
+		if manifestPath(pluginPath) == sidecarManifestPath(pluginPath) {
+			p.err = fileFailure(p.err)
+		}
+		return p
+	}
+
+	if m.checkHostVersion(p.manifest) == nil && !isOnEvent(p.manifest) && !m.shouldIndexOnly(pluginPath) {
+		p.opened, p.openErr = m.openPlugin(pluginPath)
+	}
+	return p
+}
+
+// isOnEvent 判断清单是否声明了按需激活
+func isOnEvent(manifest *PluginManifest) bool {
+	return manifest != nil && manifest.Activation == ActivationOnEvent && manifest.Name != ""
+}
+
+// loadPrepared 按准备结果登记并初始化插件，调用方需持有m.mutex
+func (m *Manager) loadPrepared(ctx context.Context, p *preparedLoad) (*PluginInfo, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	pluginPath, manifest := p.path, p.manifest
+
+	if err := m.checkHostVersion(manifest); err != nil {
+		return m.registerPlaceholder(pluginPath, manifest, StateIncompatible, err)
+	}
+
+	// 声明按需激活的插件只登记清单，不打开插件文件
+	if isOnEvent(manifest) {
+		return m.registerDormant(ctx, pluginPath, manifest)
+	}
+
+	// 延迟加载模式下未启用的插件只索引，首次启用时再打开
+	if m.shouldIndexOnly(pluginPath) {
+		return m.registerLazy(pluginPath, manifest)
+	}
+
+	opened, err := p.opened, p.openErr
+	if opened == nil && err == nil {
+		opened, err = m.openPlugin(pluginPath)
+	}
 	if err != nil {
-		return fmt.Errorf("找不到GetPlugin函数: %v", err)
+		if isSignatureError(err) && m.GetSignaturePolicy() == SignatureQuarantine {
+			return m.registerPlaceholder(pluginPath, manifest, StateQuarantined, err)
+		}
+		if isCompatibilityError(err) {
+			return m.registerPlaceholder(pluginPath, manifest, StateIncompatible, err)
+		}
+		// 只有文件格式损坏等由openPlugin标记的问题才隔离文件，签名策略为跳过时的签名错误和进程启动失败等只跳过插件
+		return nil, err
+	}
+	pluginInstance := opened.instance
+
+	// 按插件名称检查允许/禁止列表
+	if err := m.checkPolicy(pluginInstance.Name(), pluginPath); err != nil {
+		return nil, err
 	}
 
-	// 类型断言为函数
-	getPlugin, ok := symGetPlugin.(func() Plugin)
-	if !ok {
-		return fmt.Errorf("GetPlugin函数签名不正确")
+	// 校验清单与插件实例一致
+	if err := opened.manifest.validateInstance(pluginInstance); err != nil {
+		return nil, err
+	}
+	if err := m.validateSubscriptions(pluginInstance); err != nil {
+		return nil, err
 	}
 
-	// 获取插件实例
-	pluginInstance := getPlugin()
+	// 处理同名插件
+	if err := m.resolveNameConflict(pluginInstance.Name(), pluginInstance.Version(), pluginPath); err != nil {
+		return nil, err
+	}
 
 	// 从存储中获取插件信息
-	pluginDB, _ := storage.GetPlugin(pluginPath)
+	pluginDB, _ := m.store().GetPlugin(pluginPath)
 
 	if pluginDB == nil {
-		log.Printf("插件 %s 未在数据库中注册", pluginPath)
+		m.logger.Printf("插件 %s 未在数据库中注册", pluginPath)
 	}
 
 	// 初始化配置
 	var config map[string]interface{}
-	var enable bool
+	var reason string
 
 	if pluginDB != nil {
 		if pluginDB.Config != "" {
 			err := json.Unmarshal([]byte(pluginDB.Config), &config)
 			if err != nil {
-				return fmt.Errorf("解析插件配置失败: %v", err)
+				return nil, fmt.Errorf("解析插件配置失败: %v", err)
 			}
 		}
 
-		enable = pluginDB.Enabled
+		reason = pluginDB.StateReason
 	} else {
 		// 如果数据库中没有配置,则使用默认配置
 		config = pluginInstance.DefaultConfig()
-		enable = false
+	}
+	state, policyReason := startupState(m.startupPolicy, pluginDB)
+	if policyReason != "" {
+		reason = policyReason
 	}
 
 	// 设置配置到插件
-	pluginInstance.SetConfig(config)
+	m.deliverConfig(pluginInstance.Name(), pluginInstance, opened.manifest, config)
 
 	// 创建插件信息
 	info := &PluginInfo{
@@ -128,37 +504,158 @@ func (m *Manager) loadPlugin(pluginPath string) error {
 		Version:     pluginInstance.Version(),
 		Description: pluginInstance.Description(),
 		FilePath:    pluginPath,
-		Enabled:     enable,
+		Enabled:     state == StateEnabled,
+		State:       state,
+		StateReason: reason,
 		Config:      config,
 		Plugin:      pluginInstance,
+		APIVersion:  opened.apiVersion,
+		Manifest:    opened.manifest,
+		Build:       opened.build,
+		Priority:    m.pluginPriority(pluginInstance.Name(), opened.manifest, pluginInstance),
+	}
+	info.applyManifestDetails()
+
+	// 依赖未满足的插件暂不初始化，等待依赖的插件加载并启用
+	m.awaitDependencies(info)
+	m.registerState(info, true)
+
+	// 插件升级后存储中的配置可能与新版本不一致
+	info.checkConfigDrift()
+	if len(info.ConfigIssues) > 0 {
+		m.logger.Printf("插件 %s 的配置与当前版本有 %d 处不一致，请检查配置", info.Name, len(info.ConfigIssues))
 	}
 
 	// 如果插件已启用，则初始化插件
 	if info.Enabled {
-		if err := pluginInstance.Init(); err != nil {
-			log.Printf("初始化插件 %s 失败: %v", info.Name, err)
-			info.Enabled = false
+		if err := m.startLoadedPlugin(ctx, info); err != nil {
+			m.logger.Printf("初始化插件 %s 失败: %v", info.Name, err)
+
+			// 标记为初始化失败并保留在列表中，以便界面展示失败原因
+			_ = m.setState(info, StateInitFailed, fmt.Sprintf("初始化失败: %v", err))
+			m.plugins[info.Name] = info
 
 			// 同步插件状态到存储
-			if err := storage.SavePlugin(info.Name, pluginPath, false, info.Config); err != nil {
-				log.Printf("更新插件状态到存储失败: %v", err)
+			if err := m.store().SavePlugin(info.Name, pluginPath, false, info.Config); err != nil {
+				m.logger.Printf("更新插件状态到存储失败: %v", err)
 			}
 
 			// 初始化失败，跳过当前插件加载
-			return fmt.Errorf("初始化插件 %s 失败，跳过加载", info.Name)
+			return info, fmt.Errorf("初始化插件 %s 失败，需重新启用", info.Name)
 		}
 	}
 
 	// 存储插件
 	m.plugins[info.Name] = info
 
-	// 同步插件信息到存储
-	if err := storage.SavePlugin(info.Name, pluginPath, info.Enabled, info.Config); err != nil {
-		log.Printf("保存插件信息到存储失败: %v", err)
+	// 同步插件信息到存储，等待依赖的插件保留启用意图
+	if err := m.store().SavePlugin(info.Name, pluginPath, info.Enabled || info.awaitingDeps, info.Config); err != nil {
+		m.logger.Printf("保存插件信息到存储失败: %v", err)
 	}
 
-	log.Printf("成功加载插件: %s v%s", info.Name, info.Version)
-	return nil
+	// 新加载的插件可能是其他插件等待的依赖
+	if info.Enabled {
+		m.startAwaitingPlugins(ctx)
+	}
+
+	for _, warning := range m.subscriptionWarnings(info) {
+		m.logger.Printf("插件 %s 订阅检查: %s", info.Name, warning)
+	}
+
+	m.logger.Printf("成功加载插件: %s v%s", info.Name, info.Version)
+	return info, nil
+}
+
+// startLoadedPlugin 初始化并预热加载时已启用的插件，初始化受ctx和初始化超时时间约束，
+// 超时的插件视为初始化失败而不会阻塞加载，调用方需持有m.mutex
+func (m *Manager) startLoadedPlugin(ctx context.Context, info *PluginInfo) error {
+	initCtx, cancel := m.initContextLocked(ctx)
+	defer cancel()
+
+	// 版本变化时先迁移数据，与初始化共用超时时间
+	if err := m.runUpgrade(initCtx, info.Name, info.Plugin); err != nil {
+		return err
+	}
+
+	initErr := m.runInit(initCtx, info.Name, info.Plugin)
+	if initErr != nil && initCtx.Err() != nil {
+		initErr = fmt.Errorf("初始化超时或已取消: %v", initErr)
+	}
+
+	// 预热插件
+	if warmer, ok := info.Plugin.(Warmer); ok && initErr == nil {
+		if err := runWarmup(ctx, m.warmupTimeout, info.Name, warmer); err != nil {
+			_ = info.Plugin.Close()
+			initErr = fmt.Errorf("预热失败: %v", err)
+		}
+	}
+	if initErr == nil {
+		info.enabledSeq = m.enableSeq.Add(1)
+	}
+	return initErr
+}
+
+// openedPlugin 打开插件文件得到的插件实例及元数据
+type openedPlugin struct {
+	instance   Plugin
+	apiVersion int
+	manifest   *PluginManifest
+	build      *BuildInfo
+}
+
+// openPlugin 打开插件文件，通过接口适配器获取插件实例并注入HostAPI
+func (m *Manager) openPlugin(pluginPath string) (*openedPlugin, error) {
+	// 打开插件文件会执行其中的代码，需先校验签名
+	if err := m.verifySignature(pluginPath); err != nil {
+		return nil, err
+	}
+
+	// 独立进程插件通过gRPC调用，不需要与宿主的依赖版本一致
+	if isSubprocessPlugin(pluginPath) {
+		return m.openSubprocess(pluginPath)
+	}
+
+	// 非Go原生插件交给对应扩展名的加载器
+	if loader, ok := loaderFor(pluginPath); ok {
+		return m.openWithLoader(loader, pluginPath)
+	}
+
+	// 预检构建信息，给出比plugin.Open更易懂的不兼容原因
+	if err := preflightCheck(pluginPath); err != nil {
+		return nil, err
+	}
+
+	p, err := plugin.Open(pluginPath)
+	if err != nil {
+		m.logger.Printf("插件加载失败，详细错误: %v", err)
+		if compatErr := compatibilityFromOpenError(pluginPath, err); compatErr != err {
+			return nil, compatErr
+		}
+		if isMalformedBinary(err) {
+			return nil, fileFailure(fmt.Errorf("打开插件失败: %w", err))
+		}
+		return nil, fmt.Errorf("打开插件失败: %w", err)
+	}
+
+	// 通过接口适配器获取插件实例，缺少导出符号说明文件不是有效的插件
+	pluginInstance, apiVersion, err := lookupPlugin(p)
+	if err != nil {
+		return nil, fileFailure(err)
+	}
+
+	// 读取插件清单
+	manifest, err := loadManifest(pluginPath)
+	if err != nil {
+		return nil, err
+	}
+	m.injectHostAPI(pluginInstance, manifest)
+
+	return &openedPlugin{
+		instance:   pluginInstance,
+		apiVersion: apiVersion,
+		manifest:   manifest,
+		build:      readBuildMeta(p, pluginPath),
+	}, nil
 }
 
 // GetPlugin 获取插件
@@ -184,30 +681,141 @@ func (m *Manager) GetAllPlugins() map[string]*PluginInfo {
 
 // EnablePlugin 启用插件
 func (m *Manager) EnablePlugin(name string) error {
-	m.mutex.Lock()
-	defer m.mutex.Unlock()
+	if err := m.checkWritable(); err != nil {
+		return err
+	}
+
+	ctx, cancel := m.initContext(context.Background())
+	defer cancel()
+
+	return m.enablePlugin(ctx, name, nil)
+}
 
+// enablePlugin 启用插件，初始化过程不持有全局锁，op不为nil时上报进度
+func (m *Manager) enablePlugin(ctx context.Context, name string, op *Operation) error {
+	m.mutex.Lock()
 	plugin, exists := m.plugins[name]
 	if !exists {
+		m.mutex.Unlock()
 		return fmt.Errorf("插件不存在: %s", name)
 	}
 
 	// 如果插件已经启用，则不需要重复操作
 	if plugin.Enabled {
+		m.mutex.Unlock()
 		return nil
 	}
 
+	if plugin.State == StateIncompatible {
+		m.mutex.Unlock()
+		return fmt.Errorf("插件 %s 与宿主不兼容: %s", name, plugin.StateReason)
+	}
+
+	if err := m.checkPolicy(name, plugin.FilePath); err != nil {
+		m.mutex.Unlock()
+		return err
+	}
+
+	if unmet := m.unmetDependencies(plugin); len(unmet) > 0 {
+		m.mutex.Unlock()
+		return fmt.Errorf("插件 %s 的依赖未满足: %s", name, strings.Join(unmet, "; "))
+	}
+
+	if plugin.enabling {
+		m.mutex.Unlock()
+		return fmt.Errorf("插件 %s 正在启用中", name)
+	}
+
+	if err := m.inits.check(name); err != nil {
+		m.mutex.Unlock()
+		return err
+	}
+
+	// 延迟加载的插件在首次启用时打开
+	if plugin.lazy {
+		op.setStage(StageLoading)
+		if err := m.openLazy(plugin); err != nil {
+			m.mutex.Unlock()
+			return fmt.Errorf("打开插件失败: %v", err)
+		}
+	}
+	plugin.enabling = true
+	oldState, oldReason := plugin.State, plugin.StateReason
+	warmupTimeout := m.warmupTimeout
+	m.mutex.Unlock()
+
+	defer func() {
+		m.mutex.Lock()
+		plugin.enabling = false
+		m.mutex.Unlock()
+	}()
+
+	// 版本变化时先迁移数据
+	op.setStage(StageInitializing)
+	if err := m.runUpgrade(ctx, plugin.Name, plugin.Plugin); err != nil {
+		m.mutex.Lock()
+		_ = m.setState(plugin, StateInitFailed, err.Error())
+		m.mutex.Unlock()
+		return err
+	}
+
 	// 初始化插件
-	if err := plugin.Plugin.Init(); err != nil {
+	if err := m.runInit(ctx, plugin.Name, plugin.Plugin); err != nil {
+		m.mutex.Lock()
+		_ = m.setState(plugin, StateInitFailed, fmt.Sprintf("初始化失败: %v", err))
+		m.mutex.Unlock()
 		return fmt.Errorf("初始化插件失败: %v", err)
 	}
 
-	plugin.Enabled = true
+	// 预热插件，预热期间插件处于warming状态，不接收事件
+	if warmer, ok := plugin.Plugin.(Warmer); ok {
+		op.setStage(StageWarming)
+		m.mutex.Lock()
+		_ = m.setState(plugin, StateWarming, "预热中")
+		m.mutex.Unlock()
+
+		if err := runWarmup(ctx, warmupTimeout, plugin.Name, warmer); err != nil {
+			_ = plugin.Plugin.Close()
+			m.mutex.Lock()
+			_ = m.setState(plugin, StateInitFailed, fmt.Sprintf("预热失败: %v", err))
+			m.mutex.Unlock()
+			return fmt.Errorf("插件预热失败: %v", err)
+		}
+	}
+
+	// 健康检查
+	op.setStage(StageHealthChecking)
+	if checker, ok := plugin.Plugin.(HealthChecker); ok {
+		if err := checker.Healthy(ctx); err != nil {
+			_ = plugin.Plugin.Close()
+			m.mutex.Lock()
+			_ = m.setState(plugin, StateInitFailed, fmt.Sprintf("健康检查失败: %v", err))
+			m.mutex.Unlock()
+			return fmt.Errorf("插件健康检查失败: %v", err)
+		}
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	// 操作在健康检查后被取消
+	if err := ctx.Err(); err != nil {
+		_ = plugin.Plugin.Close()
+		_ = m.setState(plugin, StateInitFailed, fmt.Sprintf("启用已取消: %v", err))
+		return fmt.Errorf("启用插件已取消: %v", err)
+	}
+
+	if err := m.setState(plugin, StateEnabled, ""); err != nil {
+		_ = plugin.Plugin.Close()
+		return err
+	}
+	plugin.awaitingDeps = false
 
 	// 同步写入存储
-	if err := storage.SavePlugin(plugin.Name, plugin.FilePath, true, plugin.Config); err != nil {
+	if err := m.store().SavePlugin(plugin.Name, plugin.FilePath, true, plugin.Config); err != nil {
 		// 如果存储更新失败，回滚内存状态并关闭已初始化的插件
-		plugin.Enabled = false
+		plugin.State, plugin.StateReason, plugin.Enabled = oldState, oldReason, false
+		m.notifyStateChange(plugin.Name, StateEnabled, oldState, oldReason)
 		_ = plugin.Plugin.Close() // 忽略关闭错误，因为已经有更严重的存储错误
 		return fmt.Errorf("更新插件状态到存储失败: %v", err)
 	}
@@ -215,33 +823,76 @@ func (m *Manager) EnablePlugin(name string) error {
 	return nil
 }
 
-// DisablePlugin 禁用插件
+// DisablePlugin 禁用插件：先停止向插件分发新事件，在停用等待时间内等待已分发的处理完成后再关闭插件
 func (m *Manager) DisablePlugin(name string) error {
-	m.mutex.Lock()
-	defer m.mutex.Unlock()
+	if err := m.checkWritable(); err != nil {
+		return err
+	}
 
+	return m.disablePlugin(name, "用户禁用")
+}
+
+// disablePlugin 停用插件并记录原因
+func (m *Manager) disablePlugin(name, reason string) error {
+	m.mutex.Lock()
 	plugin, exists := m.plugins[name]
 	if !exists {
+		m.mutex.Unlock()
 		return fmt.Errorf("插件不存在: %s", name)
 	}
 
+	// 用户禁用后不再等待依赖自动启用
+	plugin.awaitingDeps = false
+
 	// 如果插件已经禁用，则不需要重复操作
-	if !plugin.Enabled {
+	if plugin.State == StateDisabled {
+		m.mutex.Unlock()
 		return nil
 	}
 
+	// 未运行的插件（隔离、不兼容等）只需切换状态
+	if !plugin.Enabled {
+		defer m.mutex.Unlock()
+		return m.setState(plugin, StateDisabled, reason)
+	}
+	m.mutex.Unlock()
+
+	// 停止分发新事件，等待已分发的处理完成后再关闭插件，等待期间不持有全局锁
+	if err := m.drainPlugin(name); err != nil {
+		m.logger.Printf("插件 %s %v，继续停用", name, err)
+	}
+	defer m.endDrain(name)
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	// 等待期间插件可能已被卸载或切换状态
+	plugin, exists = m.plugins[name]
+	if !exists {
+		return fmt.Errorf("插件不存在: %s", name)
+	}
+	if !plugin.Enabled {
+		if plugin.State == StateDisabled {
+			return nil
+		}
+		return m.setState(plugin, StateDisabled, reason)
+	}
+
 	// 关闭插件
 	if err := plugin.Plugin.Close(); err != nil {
 		// 即使关闭失败，我们也要将插件标记为禁用
-		log.Printf("关闭插件 %s 失败: %v", name, err)
+		m.logger.Printf("关闭插件 %s 失败: %v", name, err)
 	}
 
-	plugin.Enabled = false
+	_ = m.setState(plugin, StateDisabled, reason)
+	m.clearReadiness(name)
+	m.checkLeaks(name)
+	m.disableDependents(name)
 
 	// 同步写入存储
-	if err := storage.SavePlugin(plugin.Name, plugin.FilePath, false, plugin.Config); err != nil {
+	if err := m.store().SavePlugin(plugin.Name, plugin.FilePath, false, plugin.Config); err != nil {
 		// 如果存储更新失败，记录错误但不回滚状态（插件已经被关闭）
-		log.Printf("更新插件状态到存储失败: %v", err)
+		m.logger.Printf("更新插件状态到存储失败: %v", err)
 		// 仍然返回成功，因为插件已成功禁用，只是存储同步失败
 	}
 
@@ -250,6 +901,10 @@ func (m *Manager) DisablePlugin(name string) error {
 
 // UpdatePluginConfig 更新插件配置
 func (m *Manager) UpdatePluginConfig(name string, config map[string]interface{}) error {
+	if err := m.checkWritable(); err != nil {
+		return err
+	}
+
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 
@@ -258,6 +913,15 @@ func (m *Manager) UpdatePluginConfig(name string, config map[string]interface{})
 		return fmt.Errorf("插件不存在: %s", name)
 	}
 
+	return m.applyPluginConfig(plugin, config)
+}
+
+// applyPluginConfig 更新插件配置并写入存储，失败时回滚，调用方需持有m.mutex
+func (m *Manager) applyPluginConfig(plugin *PluginInfo, config map[string]interface{}) error {
+	if err := plugin.Manifest.validateConfig(config); err != nil {
+		return fmt.Errorf("插件配置无效: %v", err)
+	}
+
 	// 先备份旧配置，以便回滚
 	oldConfig := plugin.Config
 
@@ -265,24 +929,87 @@ func (m *Manager) UpdatePluginConfig(name string, config map[string]interface{})
 	plugin.Config = config
 
 	// 更新插件内部配置
-	plugin.Plugin.SetConfig(config)
+	m.deliverConfig(plugin.Name, plugin.Plugin, plugin.Manifest, config)
 
 	// 同步写入存储
-	if err := storage.SavePlugin(plugin.Name, plugin.FilePath, plugin.Enabled, config); err != nil {
+	if err := m.store().SavePlugin(plugin.Name, plugin.FilePath, plugin.Enabled, config); err != nil {
 		// 如果存储更新失败，回滚内存配置
 		plugin.Config = oldConfig
-		plugin.Plugin.SetConfig(oldConfig) // 尝试回滚插件内部配置
+		m.deliverConfig(plugin.Name, plugin.Plugin, plugin.Manifest, oldConfig) // 尝试回滚插件内部配置
 		return fmt.Errorf("更新插件配置到存储失败: %v", err)
 	}
 
+	plugin.checkConfigDrift()
 	return nil
 }
 
-// TriggerEvent 触发事件
+// UnloadPlugin 卸载插件：关闭已启用的插件并从管理器中移除，同时更新存储
+func (m *Manager) UnloadPlugin(name string) error {
+	if err := m.checkWritable(); err != nil {
+		return err
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.unloadPlugin(name)
+}
+
+// unloadPlugin 卸载插件，调用方需持有m.mutex
+func (m *Manager) unloadPlugin(name string) error {
+	plugin, exists := m.plugins[name]
+	if !exists {
+		return fmt.Errorf("插件不存在: %s", name)
+	}
+
+	// 关闭已启用的插件
+	if plugin.Enabled {
+		if err := plugin.Plugin.Close(); err != nil {
+			m.logger.Printf("关闭插件 %s 失败: %v", name, err)
+		}
+		m.checkLeaks(name)
+	}
+
+	m.clearReadiness(name)
+	m.forgetConfigSnapshot(name)
+	m.forgetEventTypes(name)
+	delete(m.plugins, name)
+
+	// 同步写入存储，卸载后的插件在下次加载前保持禁用
+	if err := m.store().SavePlugin(plugin.Name, plugin.FilePath, false, plugin.Config); err != nil {
+		m.logger.Printf("更新插件状态到存储失败: %v", err)
+	}
+
+	m.logger.Printf("已卸载插件: %s", name)
+	return nil
+}
+
+// TriggerEvent 触发事件，每次触发生成新的事件ID
 func (m *Manager) TriggerEvent(ctx *gin.Context, event EventType, path string, statusCode int, requestBody interface{}, responseBody interface{}) {
-	m.mutex.RLock()
-	defer m.mutex.RUnlock()
+	m.triggerEvent(ctx, newEventID(), event, path, statusCode, requestBody, responseBody)
+}
 
+// triggerEvent 以指定的事件ID触发事件
+func (m *Manager) triggerEvent(ctx *gin.Context, id string, event EventType, path string, statusCode int, requestBody interface{}, responseBody interface{}) {
+	if m.shuttingDown.Load() {
+		return
+	}
+
+	// 重启插件子系统期间等待新的插件集合就绪后再分发
+	m.dispatchGate.RLock()
+	defer m.dispatchGate.RUnlock()
+
+	// 延迟投递的事件没有请求上下文，不检查订阅条目限定的请求方法
+	var method string
+	if ctx != nil && ctx.Request != nil {
+		method = ctx.Request.Method
+	}
+
+	// 宿主业务事件和自定义事件不对应API路径
+	pathless := isDomainEvent(event) || m.isCustomEvent(event)
+
+	m.mutex.RLock()
+	var targets, syncTargets, dormant []*PluginInfo
 	for _, pluginInfo := range m.plugins {
 		if !pluginInfo.Enabled {
 			continue
@@ -303,10 +1030,9 @@ func (m *Manager) TriggerEvent(ctx *gin.Context, event EventType, path string, s
 		}
 
 		// 检查插件是否对这个API路径感兴趣
-		interestedAPIs := pluginInfo.Plugin.InterestedAPIs()
-		apiInterested := false
-		for _, interestedAPI := range interestedAPIs {
-			if strings.HasPrefix(path, interestedAPI) {
+		apiInterested := pathless
+		for _, interestedAPI := range interestedAPIs(pluginInfo.Plugin) {
+			if m.apiMatches(pluginInfo.Name, method, path, interestedAPI) {
 				apiInterested = true
 				break
 			}
@@ -316,25 +1042,69 @@ func (m *Manager) TriggerEvent(ctx *gin.Context, event EventType, path string, s
 			continue
 		}
 
-		// 执行插件事件处理
-		go func(p Plugin, name string) {
-			if err := p.OnAPIEvent(ctx, event, path, statusCode, requestBody, responseBody); err != nil {
-				log.Printf("插件 %s 处理事件失败: %v", name, err)
-			}
-		}(pluginInfo.Plugin, pluginInfo.Name)
+		if pluginInfo.dormant {
+			dormant = append(dormant, pluginInfo)
+		} else if m.deliverSync(pluginInfo, path) {
+			syncTargets = append(syncTargets, pluginInfo)
+		} else {
+			targets = append(targets, pluginInfo)
+		}
 	}
-}
 
-// Shutdown 关闭所有插件
-func (m *Manager) Shutdown() {
-	m.mutex.Lock()
-	defer m.mutex.Unlock()
+	// 按优先级分发，同步处理严格按此顺序执行，异步处理按此顺序启动
+	sortByPriority(targets)
+	sortByPriority(syncTargets)
+	sortByPriority(dormant)
+	m.mutex.RUnlock()
 
-	for _, pluginInfo := range m.plugins {
-		if err := pluginInfo.Plugin.Close(); err != nil {
-			log.Printf("关闭插件失败 %s: %v", pluginInfo.Name, err)
+	// 按需激活第一次匹配到事件的插件
+	for _, info := range dormant {
+		activated, err := m.activatePlugin(info.Name)
+		if err != nil {
+			m.reportPluginError(info.Name, fmt.Errorf("按需激活失败: %v", err))
+			continue
+		}
+		targets = append(targets, activated)
+	}
+	if len(dormant) > 0 {
+		// 激活的插件按优先级插入异步分发顺序
+		m.mutex.RLock()
+		sortByPriority(targets)
+		m.mutex.RUnlock()
+	}
+
+	if len(targets)+len(syncTargets) == 0 {
+		return
+	}
+
+	// 按Content-Type将原始字节解析为结构化数据
+	requestBody, responseBody = m.decodeBodies(ctx, requestBody, responseBody)
+
+	record := m.newEventRecord(id, event, path, statusCode, len(targets)+len(syncTargets))
+	m.captureBodies(record, requestBody, responseBody)
+	m.mirrorEvent(record, method, requestBody, responseBody, syncTargets, targets)
+
+	// 执行插件事件处理，宿主压力过高时跳过优先级低的插件
+	for _, pluginInfo := range targets {
+		if m.shouldShed(pluginInfo.Name) {
+			record.addResult(PluginResult{Plugin: pluginInfo.Name, Error: errLoadShed.Error()})
+			continue
+		}
+		done, ok := m.beginWork(pluginInfo.Name)
+		if !ok {
+			record.addResult(PluginResult{Plugin: pluginInfo.Name, Error: errPluginDraining.Error()})
+			continue
 		}
+		m.inflight.Add(1)
+		go func(info *PluginInfo) {
+			defer m.inflight.Done()
+			defer done()
+			runWithPluginLabels(info.Name, func() {
+				m.invokeHandler(ctx, record, info, requestBody, responseBody)
+			})
+		}(pluginInfo)
 	}
 
-	m.plugins = make(map[string]*PluginInfo)
+	// 同步处理在请求流程中依次执行，受每个请求的时间预算约束
+	m.runSyncHooks(ctx, record, syncTargets, requestBody, responseBody)
 }
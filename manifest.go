@@ -0,0 +1,382 @@
+package plugins
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// manifestFileName 目录级插件清单文件名
+const manifestFileName = "plugin.json"
+
+// PluginManifest 插件清单，与插件文件放在一起
+type PluginManifest struct {
+	Name        string `json:"name"`
+	Version     string `json:"version"`
+	Description string `json:"description"`
+
+	// Egress 允许插件通过HostAPI访问的主机，支持 *.example.com 形式的通配；
+	// 为nil表示不限制，空列表表示禁止所有外部访问
+	Egress []string `json:"egress"`
+
+	// Activation 激活模式，on_event表示在第一个匹配的事件到达时才加载插件
+	Activation string `json:"activation"`
+
+	// Events 插件订阅的事件类型，按需激活时用于匹配事件
+	Events []EventType `json:"events"`
+
+	// APIs 插件订阅的API路径前缀，按需激活时用于匹配事件
+	APIs []string `json:"apis"`
+
+	// Tags 插件标签，同名插件组自动包含带有该标签的插件
+	Tags []string `json:"tags"`
+
+	// Author 插件作者
+	Author string `json:"author"`
+
+	// Homepage 插件主页
+	Homepage string `json:"homepage"`
+
+	// Icon 插件图标，相对清单所在目录的路径，通过插件包安装时由AssetHandler提供
+	Icon string `json:"icon,omitempty"`
+
+	// MinHostVersion 插件要求的最低宿主版本，如 1.2.0，宿主版本通过SetHostVersion设置
+	MinHostVersion string `json:"min_host_version"`
+
+	// Permissions 插件需要的权限，供管理员在启用前审阅
+	Permissions []string `json:"permissions"`
+
+	// Priority 插件优先级，优先级高的插件先初始化、先接收事件，未设置时使用插件自身声明的优先级
+	Priority *int `json:"priority,omitempty"`
+
+	// Dependencies 插件依赖的其他插件及版本范围，依赖未启用时插件不能启用
+	Dependencies []Dependency `json:"dependencies"`
+
+	// ConfigSchema 插件配置的JSON Schema，支持type、properties、required、enum和items，
+	// 加载时校验插件的默认配置，更新配置时校验新配置
+	ConfigSchema json.RawMessage `json:"config_schema,omitempty"`
+}
+
+// 插件清单中可声明的权限
+const (
+	PermissionNetwork  = "network"  // 通过HostAPI访问外部网络
+	PermissionNotify   = "notify"   // 向管理员发送通知和推送站内通知
+	PermissionSchedule = "schedule" // 投递延迟事件
+	PermissionStorage  = "storage"  // 读写插件数据
+	PermissionAdmin    = "admin"    // 作为管理控制渠道启用、禁用其他插件，需宿主另行授权
+)
+
+// knownPermissions 可声明的权限
+var knownPermissions = map[string]bool{
+	PermissionNetwork:  true,
+	PermissionNotify:   true,
+	PermissionSchedule: true,
+	PermissionStorage:  true,
+	PermissionAdmin:    true,
+}
+
+// sidecarManifestPath 插件文件专属的清单路径 <文件名>.plugin.json
+func sidecarManifestPath(pluginPath string) string {
+	return strings.TrimSuffix(pluginPath, filepath.Ext(pluginPath)) + ".plugin.json"
+}
+
+// manifestPath 查找插件文件对应的清单：优先 <文件名>.plugin.json，其次各平台构件共用的 <去掉平台后缀的名称>.plugin.json，
+// 最后是同目录下的 plugin.json
+func manifestPath(pluginPath string) string {
+	candidate := sidecarManifestPath(pluginPath)
+	if _, err := os.Stat(candidate); err == nil {
+		return candidate
+	}
+
+	if stem, goos, _ := artifactPlatform(pluginPath); goos != "" {
+		candidate = filepath.Join(filepath.Dir(pluginPath), stem+".plugin.json")
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate
+		}
+	}
+
+	candidate = filepath.Join(filepath.Dir(pluginPath), manifestFileName)
+	if _, err := os.Stat(candidate); err == nil {
+		return candidate
+	}
+	return ""
+}
+
+// loadManifest 读取插件清单，不存在时返回nil
+func loadManifest(pluginPath string) (*PluginManifest, error) {
+	path := manifestPath(pluginPath)
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取插件清单失败: %v", err)
+	}
+
+	var manifest PluginManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("解析插件清单 %s 失败: %v", path, err)
+	}
+	if err := manifest.validate(); err != nil {
+		return nil, fmt.Errorf("插件清单 %s 无效: %v", path, err)
+	}
+	return &manifest, nil
+}
+
+// validate 校验清单自身的字段格式
+func (pm *PluginManifest) validate() error {
+	if pm.MinHostVersion != "" {
+		if _, err := parseVersion(pm.MinHostVersion); err != nil {
+			return fmt.Errorf("min_host_version: %v", err)
+		}
+	}
+	for _, permission := range pm.Permissions {
+		if !knownPermissions[permission] {
+			return fmt.Errorf("未知的权限: %s", permission)
+		}
+	}
+	for _, dep := range pm.Dependencies {
+		if dep.Name == "" {
+			return fmt.Errorf("dependencies: 依赖的插件名称不能为空")
+		}
+		if err := validateConstraint(dep.Version); err != nil {
+			return fmt.Errorf("dependencies: %s: %v", dep.Name, err)
+		}
+	}
+	if _, err := pm.configSchema(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// configSchema 解析配置Schema，未声明时返回nil
+func (pm *PluginManifest) configSchema() (*configSchema, error) {
+	if pm == nil || len(pm.ConfigSchema) == 0 {
+		return nil, nil
+	}
+
+	var schema configSchema
+	if err := json.Unmarshal(pm.ConfigSchema, &schema); err != nil {
+		return nil, fmt.Errorf("config_schema: %v", err)
+	}
+	if schema.Type != "" && schema.Type != "object" {
+		return nil, fmt.Errorf("config_schema的类型必须为object")
+	}
+	return &schema, nil
+}
+
+// validateInstance 校验清单与已打开的插件实例一致，并用配置Schema校验插件的默认配置
+func (pm *PluginManifest) validateInstance(p Plugin) error {
+	if pm == nil {
+		return nil
+	}
+	if pm.Name != "" && pm.Name != p.Name() {
+		return fmt.Errorf("插件名称 %s 与清单 %s 不一致", p.Name(), pm.Name)
+	}
+	if pm.Version != "" && pm.Version != p.Version() {
+		return fmt.Errorf("插件版本 %s 与清单 %s 不一致", p.Version(), pm.Version)
+	}
+	if err := pm.validateConfig(p.DefaultConfig()); err != nil {
+		return fmt.Errorf("默认配置不符合清单中的config_schema: %v", err)
+	}
+	return nil
+}
+
+// validateConfig 用清单中的配置Schema校验配置，未声明Schema时不校验
+func (pm *PluginManifest) validateConfig(config map[string]interface{}) error {
+	schema, err := pm.configSchema()
+	if err != nil || schema == nil {
+		return err
+	}
+
+	// 按JSON规范化，使Go类型与Schema中的类型一致
+	data, err := json.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("序列化配置失败: %v", err)
+	}
+	var normalized interface{}
+	if err := json.Unmarshal(data, &normalized); err != nil {
+		return fmt.Errorf("解析配置失败: %v", err)
+	}
+	if normalized == nil {
+		normalized = map[string]interface{}{}
+	}
+	return schema.validate("config", normalized)
+}
+
+// configSchema 配置Schema，实现JSON Schema的常用子集
+type configSchema struct {
+	Type       string                   `json:"type"`
+	Properties map[string]*configSchema `json:"properties"`
+	Required   []string                 `json:"required"`
+	Enum       []interface{}            `json:"enum"`
+	Items      *configSchema            `json:"items"`
+}
+
+// validate 校验JSON规范化后的值
+func (s *configSchema) validate(path string, value interface{}) error {
+	if s == nil {
+		return nil
+	}
+
+	if s.Type != "" && !schemaTypeMatches(s.Type, value) {
+		return fmt.Errorf("%s 的类型应为 %s", path, s.Type)
+	}
+
+	if len(s.Enum) > 0 {
+		matched := false
+		for _, allowed := range s.Enum {
+			if fmt.Sprint(allowed) == fmt.Sprint(value) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return fmt.Errorf("%s 的值不在允许的范围内: %v", path, value)
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, key := range s.Required {
+			if _, ok := v[key]; !ok {
+				return fmt.Errorf("缺少必填项 %s.%s", path, key)
+			}
+		}
+		for key, item := range v {
+			if err := s.Properties[key].validate(path+"."+key, item); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			if err := s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// schemaTypeMatches 判断值是否符合JSON Schema类型
+func schemaTypeMatches(schemaType string, value interface{}) bool {
+	switch schemaType {
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		n, ok := value.(float64)
+		return ok && n == math.Trunc(n)
+	case "null":
+		return value == nil
+	}
+	return true
+}
+
+// applyManifestDetails 将清单中的作者、主页和权限复制到插件信息
+func (info *PluginInfo) applyManifestDetails() {
+	if info.Manifest == nil {
+		return
+	}
+	info.Author = info.Manifest.Author
+	info.Homepage = info.Manifest.Homepage
+	info.Permissions = info.Manifest.Permissions
+}
+
+// SetHostVersion 设置宿主版本，用于校验插件清单中的min_host_version；未设置时不校验
+func (m *Manager) SetHostVersion(version string) error {
+	if _, err := parseVersion(version); err != nil {
+		return err
+	}
+
+	m.hostMutex.Lock()
+	defer m.hostMutex.Unlock()
+
+	m.hostVersion = version
+	return nil
+}
+
+// checkHostVersion 检查宿主版本是否满足插件清单的最低要求
+func (m *Manager) checkHostVersion(manifest *PluginManifest) error {
+	if manifest == nil || manifest.MinHostVersion == "" {
+		return nil
+	}
+
+	m.hostMutex.RLock()
+	hostVersion := m.hostVersion
+	m.hostMutex.RUnlock()
+
+	if hostVersion == "" {
+		return nil
+	}
+	if compareVersions(hostVersion, manifest.MinHostVersion) < 0 {
+		return fmt.Errorf("插件要求宿主版本不低于 %s，当前宿主版本 %s", manifest.MinHostVersion, hostVersion)
+	}
+	return nil
+}
+
+// parseVersion 解析 1.2.3 或 v1.2.3 形式的版本号，忽略预发布和构建后缀
+func parseVersion(version string) ([]int, error) {
+	core := strings.TrimPrefix(strings.TrimSpace(version), "v")
+	if i := strings.IndexAny(core, "-+"); i >= 0 {
+		core = core[:i]
+	}
+	if core == "" {
+		return nil, fmt.Errorf("无效的版本号: %q", version)
+	}
+
+	parts := strings.Split(core, ".")
+	numbers := make([]int, len(parts))
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("无效的版本号: %q", version)
+		}
+		numbers[i] = n
+	}
+	return numbers, nil
+}
+
+// compareVersions 比较两个版本号，无法解析的版本视为0
+func compareVersions(a, b string) int {
+	va, _ := parseVersion(a)
+	vb, _ := parseVersion(b)
+	return compareVersionParts(va, vb)
+}
+
+// compareVersionParts 逐段比较解析后的版本号，缺少的版本段视为0
+func compareVersionParts(va, vb []int) int {
+	for i := 0; i < len(va) || i < len(vb); i++ {
+		var x, y int
+		if i < len(va) {
+			x = va[i]
+		}
+		if i < len(vb) {
+			y = vb[i]
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,270 @@
+package plugins
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// PrefixMatch 按路径前缀匹配的订阅条目前缀，如 prefix:/api/subscription。
+// 订阅条目可以在前面加HTTP方法限定请求方法，如 "POST prefix:/api/subscription"、"GET route:subscription.list"
+const PrefixMatch = "prefix:"
+
+// GlobMatch 按通配符匹配整个路径的订阅条目前缀，如 glob:/api/v1/*/nodes。
+// * 匹配一个路径段中的任意字符，** 匹配任意多个路径段，? 和 [...] 的含义与path.Match相同
+const GlobMatch = "glob:"
+
+// RegexMatch 按正则表达式匹配整个路径的订阅条目前缀，如 regex:/api/v1/subscribe/[0-9a-f]{32}，语法同regexp包
+const RegexMatch = "regex:"
+
+// AllAPIs 匹配所有请求路径的订阅条目，可以加HTTP方法限定，如 "POST *"
+const AllAPIs = "*"
+
+// errInvalidAPIRegex 订阅条目中的正则表达式无法编译，插件加载时被拒绝
+var errInvalidAPIRegex = errors.New("订阅条目的正则表达式无效")
+
+// errInvalidAPIGlob 订阅条目中的通配符格式错误，插件加载时被拒绝
+var errInvalidAPIGlob = errors.New("订阅条目的通配符无效")
+
+// RegexSubscriber 以正则表达式订阅API路径的插件接口（可选实现）
+type RegexSubscriber interface {
+	// InterestedAPIRegex 获取感兴趣的API路径正则表达式，匹配整个路径，等同于在InterestedAPIs中返回 regex:<表达式>
+	InterestedAPIRegex() []string
+}
+
+// matchKind 订阅条目的匹配方式
+type matchKind int
+
+const (
+	matchPrefix matchKind = iota // 路径前缀
+	matchRoute                   // 宿主路由名称
+	matchGlob                    // 通配符
+	matchRegex                   // 正则表达式
+	matchAll                     // 所有路径
+)
+
+// apiMatcher 编译后的API订阅条目
+type apiMatcher struct {
+	method   string // 限定的请求方法，为空表示任意方法
+	kind     matchKind
+	pattern  string         // 前缀匹配时为路径前缀，路由匹配时为路由名称，通配符匹配时为通配符
+	segments []string       // 通配符按路径段拆分的结果
+	regex    *regexp.Regexp // 编译后的正则表达式
+	legacy   bool           // 旧格式的裸路径前缀
+}
+
+// compileAPIMatcher 解析订阅条目。旧插件直接返回路径前缀（如 /api/subscription），
+// 按 prefix:/api/subscription 处理并标记为旧格式；裸路径中的 * ? [ 不作为通配符，按通配符匹配需使用 glob: 前缀
+func compileAPIMatcher(api string) (*apiMatcher, error) {
+	matcher := &apiMatcher{}
+	entry := strings.TrimSpace(api)
+	if method, rest, ok := strings.Cut(entry, " "); ok && isHTTPMethod(method) {
+		matcher.method = method
+		entry = strings.TrimSpace(rest)
+	}
+
+	switch {
+	case entry == AllAPIs:
+		matcher.kind = matchAll
+	case strings.HasPrefix(entry, PrefixMatch):
+		matcher.kind, matcher.pattern = matchPrefix, strings.TrimPrefix(entry, PrefixMatch)
+	case strings.HasPrefix(entry, RouteRefPrefix):
+		matcher.kind, matcher.pattern = matchRoute, strings.TrimPrefix(entry, RouteRefPrefix)
+	case strings.HasPrefix(entry, GlobMatch):
+		matcher.kind, matcher.pattern = matchGlob, strings.TrimPrefix(entry, GlobMatch)
+	case strings.HasPrefix(entry, RegexMatch):
+		matcher.kind, matcher.pattern = matchRegex, strings.TrimPrefix(entry, RegexMatch)
+	case strings.HasPrefix(entry, "/") || entry == "":
+		matcher.kind, matcher.pattern, matcher.legacy = matchPrefix, entry, true
+	default:
+		return nil, fmt.Errorf("无法识别的订阅条目 %q", api)
+	}
+	if matcher.kind == matchRoute && matcher.pattern == "" {
+		return nil, fmt.Errorf("订阅条目 %q 缺少路由名称", api)
+	}
+	if matcher.kind == matchGlob {
+		if matcher.pattern == "" {
+			return nil, fmt.Errorf("%w: %q 缺少通配符", errInvalidAPIGlob, api)
+		}
+		matcher.segments = splitPath(matcher.pattern)
+		for _, seg := range matcher.segments {
+			if _, err := path.Match(seg, ""); err != nil {
+				return nil, fmt.Errorf("%w: %q: %v", errInvalidAPIGlob, api, err)
+			}
+		}
+	}
+	if matcher.kind == matchRegex {
+		if matcher.pattern == "" {
+			return nil, fmt.Errorf("%w: %q 缺少表达式", errInvalidAPIRegex, api)
+		}
+		re, err := regexp.Compile("^(?:" + matcher.pattern + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q: %v", errInvalidAPIRegex, api, err)
+		}
+		matcher.regex = re
+	}
+	return matcher, nil
+}
+
+// interestedAPIs 插件的全部订阅条目，包括RegexSubscriber声明的正则表达式
+func interestedAPIs(p Plugin) []string {
+	apis := p.InterestedAPIs()
+	if subscriber, ok := p.(RegexSubscriber); ok {
+		apis = apis[:len(apis):len(apis)]
+		for _, expr := range subscriber.InterestedAPIRegex() {
+			apis = append(apis, RegexMatch+expr)
+		}
+	}
+	return apis
+}
+
+// validateSubscriptions 加载插件时编译订阅条目，正则表达式或通配符无效时拒绝加载插件，其他无效条目只在订阅检查中提示
+func (m *Manager) validateSubscriptions(p Plugin) error {
+	for _, api := range interestedAPIs(p) {
+		if _, err := m.matchers.precompile(api); errors.Is(err, errInvalidAPIRegex) || errors.Is(err, errInvalidAPIGlob) {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitPath 将路径按/拆分为路径段，忽略首尾的/
+func splitPath(p string) []string {
+	return strings.Split(strings.Trim(p, "/"), "/")
+}
+
+// globMatch 逐段匹配通配符，** 匹配任意多个路径段
+func globMatch(pattern, segs []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := len(segs); i >= 0; i-- {
+				if globMatch(pattern[1:], segs[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(segs) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], segs[0]); !ok {
+			return false
+		}
+		pattern, segs = pattern[1:], segs[1:]
+	}
+	return len(segs) == 0
+}
+
+// isHTTPMethod 判断是否为HTTP方法
+func isHTTPMethod(s string) bool {
+	switch s {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
+
+// canonical 订阅条目的新格式写法，用于提示旧插件迁移
+func (a *apiMatcher) canonical() string {
+	var entry string
+	switch a.kind {
+	case matchRoute:
+		entry = RouteRefPrefix + a.pattern
+	case matchGlob:
+		entry = GlobMatch + a.pattern
+	case matchRegex:
+		entry = RegexMatch + a.pattern
+	case matchAll:
+		entry = AllAPIs
+	default:
+		entry = PrefixMatch + a.pattern
+	}
+	if a.method != "" {
+		entry = a.method + " " + entry
+	}
+	return entry
+}
+
+// matcherCache 缓存编译后的订阅条目，并记录已提示过的旧格式条目
+type matcherCache struct {
+	matchers map[string]*apiMatcher
+	invalid  map[string]error
+	guided   map[string]bool // 插件名称|订阅条目
+	mutex    sync.RWMutex
+}
+
+func newMatcherCache() *matcherCache {
+	return &matcherCache{
+		matchers: make(map[string]*apiMatcher),
+		invalid:  make(map[string]error),
+		guided:   make(map[string]bool),
+	}
+}
+
+// compile 获取订阅条目编译后的结果，插件第一次使用旧格式条目或无效条目时guide为true，由调用方记录迁移提示
+func (c *matcherCache) compile(plugin, api string) (matcher *apiMatcher, guide bool, err error) {
+	c.mutex.RLock()
+	matcher, ok := c.matchers[api]
+	err = c.invalid[api]
+	guided := c.guided[plugin+"|"+api]
+	c.mutex.RUnlock()
+
+	if !ok && err == nil {
+		matcher, err = c.precompile(api)
+	}
+
+	if !guided && (err != nil || matcher.legacy) {
+		c.mutex.Lock()
+		guide = !c.guided[plugin+"|"+api]
+		c.guided[plugin+"|"+api] = true
+		c.mutex.Unlock()
+	}
+	return matcher, guide, err
+}
+
+// precompile 编译订阅条目并缓存结果，插件加载时调用，使分发事件时不再解析条目
+func (c *matcherCache) precompile(api string) (*apiMatcher, error) {
+	c.mutex.RLock()
+	matcher, ok := c.matchers[api]
+	err := c.invalid[api]
+	c.mutex.RUnlock()
+	if ok || err != nil {
+		return matcher, err
+	}
+
+	matcher, err = compileAPIMatcher(api)
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if err != nil {
+		c.invalid[api] = err
+	} else {
+		c.matchers[api] = matcher
+	}
+	return matcher, err
+}
+
+// matches 判断请求是否匹配订阅条目，method为空（如延迟投递的事件）时不检查请求方法，调用方需持有m.mutex
+func (m *Manager) matches(matcher *apiMatcher, method, path string) bool {
+	if matcher.method != "" && method != "" && matcher.method != method {
+		return false
+	}
+	switch matcher.kind {
+	case matchRoute:
+		route := m.routeCatalog.Path(matcher.pattern)
+		return route != "" && routeMatchesPath(route, path)
+	case matchGlob:
+		return globMatch(matcher.segments, splitPath(path))
+	case matchRegex:
+		return matcher.regex.MatchString(path)
+	case matchAll:
+		return true
+	default:
+		return strings.HasPrefix(path, matcher.pattern)
+	}
+}
@@ -0,0 +1,190 @@
+package plugins
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// metricsContentType Prometheus文本格式
+const metricsContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// MetricsHandler 返回以Prometheus文本格式输出插件子系统指标的Gin处理函数，
+// 宿主只需注册一个路由，如 engine.GET("/metrics", manager.MetricsHandler())
+func (m *Manager) MetricsHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Status(http.StatusOK)
+		c.Header("Content-Type", metricsContentType)
+		m.WriteMetrics(c.Writer)
+	}
+}
+
+// WriteMetrics 以Prometheus文本格式写出插件子系统指标
+func (m *Manager) WriteMetrics(w io.Writer) {
+	mw := &metricsWriter{w: w}
+
+	// 插件状态
+	m.mutex.RLock()
+	states := make(map[PluginState]int)
+	for _, info := range m.plugins {
+		states[info.State]++
+	}
+	m.mutex.RUnlock()
+
+	mw.header("sublink_plugins", "gauge", "按状态统计的插件数量")
+	for _, state := range sortedKeys(states) {
+		mw.sample("sublink_plugins", labels("state", string(state)), float64(states[PluginState(state)]))
+	}
+
+	// 插件事件计数
+	stats := m.AllPluginStats()
+	mw.header("sublink_plugin_events_total", "counter", "插件处理的事件数")
+	for _, name := range sortedKeys(stats) {
+		mw.sample("sublink_plugin_events_total", labels("plugin", name), float64(stats[name].EventsHandled))
+	}
+	mw.header("sublink_plugin_errors_total", "counter", "插件处理失败的事件数")
+	for _, name := range sortedKeys(stats) {
+		mw.sample("sublink_plugin_errors_total", labels("plugin", name), float64(stats[name].Errors))
+	}
+
+	// 滚动窗口内的耗时分位数和错误率
+	windows := m.AllPluginWindowStats()
+	mw.header("sublink_plugin_latency_seconds", "gauge", "插件在滚动窗口内的处理耗时分位数")
+	for _, name := range sortedKeys(windows) {
+		for _, w := range windows[name] {
+			window := w.Window.String()
+			mw.sample("sublink_plugin_latency_seconds", labels("plugin", name, "window", window, "quantile", "0.5"), w.P50.Seconds())
+			mw.sample("sublink_plugin_latency_seconds", labels("plugin", name, "window", window, "quantile", "0.9"), w.P90.Seconds())
+			mw.sample("sublink_plugin_latency_seconds", labels("plugin", name, "window", window, "quantile", "0.99"), w.P99.Seconds())
+		}
+	}
+	mw.header("sublink_plugin_error_rate", "gauge", "插件在滚动窗口内的处理失败比例")
+	for _, name := range sortedKeys(windows) {
+		for _, w := range windows[name] {
+			mw.sample("sublink_plugin_error_rate", labels("plugin", name, "window", w.Window.String()), w.ErrorRate)
+		}
+	}
+
+	// 同步处理时间预算
+	budget := m.SyncBudgetStats()
+	mw.header("sublink_plugin_sync_invocations_total", "counter", "插件同步处理次数")
+	for _, name := range sortedKeys(budget) {
+		mw.sample("sublink_plugin_sync_invocations_total", labels("plugin", name), float64(budget[name].Invocations))
+	}
+	mw.header("sublink_plugin_sync_seconds_total", "counter", "插件同步处理累计耗时")
+	for _, name := range sortedKeys(budget) {
+		mw.sample("sublink_plugin_sync_seconds_total", labels("plugin", name), budget[name].TotalTime.Seconds())
+	}
+	mw.header("sublink_plugin_sync_skipped_total", "counter", "因时间预算耗尽跳过的同步处理次数")
+	for _, name := range sortedKeys(budget) {
+		mw.sample("sublink_plugin_sync_skipped_total", labels("plugin", name), float64(budget[name].Skipped))
+	}
+
+	// 并发限制
+	concurrency := m.ConcurrencyStats()
+	mw.header("sublink_plugin_inflight", "gauge", "设置了并发限制的插件正在处理的事件数")
+	for _, name := range sortedKeys(concurrency) {
+		mw.sample("sublink_plugin_inflight", labels("plugin", name), float64(concurrency[name].InFlight))
+	}
+	mw.header("sublink_plugin_dropped_total", "counter", "因并发数达到上限被丢弃的事件数")
+	for _, name := range sortedKeys(concurrency) {
+		mw.sample("sublink_plugin_dropped_total", labels("plugin", name), float64(concurrency[name].Dropped))
+	}
+
+	// 宿主压力削减
+	pressure, shedRatio := m.LoadPressure()
+	mw.header("sublink_plugin_host_pressure", "gauge", "最近一次读取的宿主压力值")
+	mw.sample("sublink_plugin_host_pressure", "", pressure)
+	mw.header("sublink_plugin_shed_ratio", "gauge", "当前按优先级削减异步分发的插件比例")
+	mw.sample("sublink_plugin_shed_ratio", "", shedRatio)
+	shed := m.ShedStats()
+	mw.header("sublink_plugin_shed_total", "counter", "因宿主压力过高被跳过的异步事件数")
+	for _, name := range sortedKeys(shed) {
+		mw.sample("sublink_plugin_shed_total", labels("plugin", name), float64(shed[name]))
+	}
+
+	// 管道阶段
+	type stageSample struct {
+		labels string
+		stat   StageStats
+	}
+	var stages []stageSample
+	for _, p := range m.Pipelines() {
+		stageStats, err := m.PipelineStats(p.Name)
+		if err != nil {
+			continue
+		}
+		for i, stat := range stageStats {
+			stages = append(stages, stageSample{labels("pipeline", p.Name, "stage", fmt.Sprint(i), "plugin", stat.Plugin), stat})
+		}
+	}
+	sort.Slice(stages, func(i, j int) bool { return stages[i].labels < stages[j].labels })
+	mw.header("sublink_pipeline_stage_runs_total", "counter", "管道阶段执行次数")
+	for _, s := range stages {
+		mw.sample("sublink_pipeline_stage_runs_total", s.labels, float64(s.stat.Runs))
+	}
+	mw.header("sublink_pipeline_stage_errors_total", "counter", "管道阶段失败次数")
+	for _, s := range stages {
+		mw.sample("sublink_pipeline_stage_errors_total", s.labels, float64(s.stat.Errors))
+	}
+
+	// goroutine泄漏
+	leaks := make(map[string]int)
+	for _, report := range m.GetLeakReports() {
+		leaks[report.Plugin] = report.Goroutines
+	}
+	mw.header("sublink_plugin_leaked_goroutines", "gauge", "插件关闭后仍存活的goroutine数量")
+	for _, name := range sortedKeys(leaks) {
+		mw.sample("sublink_plugin_leaked_goroutines", labels("plugin", name), float64(leaks[name]))
+	}
+
+	// 延迟事件
+	mw.header("sublink_scheduled_events", "gauge", "尚未投递的延迟事件数")
+	mw.sample("sublink_scheduled_events", "", float64(len(m.ScheduledEvents())))
+
+	mw.header("sublink_plugin_maintenance_mode", "gauge", "是否处于维护模式")
+	maintenance := 0.0
+	if m.IsMaintenanceMode() {
+		maintenance = 1
+	}
+	mw.sample("sublink_plugin_maintenance_mode", "", maintenance)
+}
+
+// metricsWriter Prometheus文本格式写入
+type metricsWriter struct {
+	w io.Writer
+}
+
+func (mw *metricsWriter) header(name, kind, help string) {
+	fmt.Fprintf(mw.w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+func (mw *metricsWriter) sample(name, labels string, value float64) {
+	fmt.Fprintf(mw.w, "%s%s %g\n", name, labels, value)
+}
+
+// labelEscaper 按Prometheus文本格式转义标签值
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// labels 生成标签字符串，参数为键值对
+func labels(pairs ...string) string {
+	parts := make([]string, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		parts = append(parts, pairs[i]+`="`+labelEscaper.Replace(pairs[i+1])+`"`)
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+// sortedKeys 返回排序后的键，保证输出稳定
+func sortedKeys[K ~string, V any](m map[K]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, string(k))
+	}
+	sort.Strings(keys)
+	return keys
+}
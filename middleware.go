@@ -0,0 +1,104 @@
+package plugins
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxCapturedBody 中间件为插件事件保留的请求体和响应体最大字节数，超过时不传递该部分
+const maxCapturedBody = 1 << 20
+
+// bodyCaptureWriter 在写出响应的同时保留响应体
+type bodyCaptureWriter struct {
+	gin.ResponseWriter
+	body     bytes.Buffer
+	overflow bool
+}
+
+func (w *bodyCaptureWriter) capture(data []byte) {
+	if w.overflow {
+		return
+	}
+	if w.body.Len()+len(data) > maxCapturedBody {
+		w.overflow = true
+		w.body.Reset()
+		return
+	}
+	w.body.Write(data)
+}
+
+func (w *bodyCaptureWriter) Write(data []byte) (int, error) {
+	w.capture(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *bodyCaptureWriter) WriteString(s string) (int, error) {
+	w.capture([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+// responseSnapshot 请求结束后的响应快照，传递给异步处理的上下文副本读取响应头和状态码；
+// 响应已经写出，写入方法不可用
+type responseSnapshot struct {
+	gin.ResponseWriter
+	header http.Header
+	status int
+	size   int
+}
+
+func (w *responseSnapshot) Header() http.Header {
+	return w.header
+}
+
+func (w *responseSnapshot) Status() int {
+	return w.status
+}
+
+func (w *responseSnapshot) Size() int {
+	return w.size
+}
+
+func (w *responseSnapshot) Written() bool {
+	return true
+}
+
+// Middleware 返回在请求处理完成后触发插件事件的Gin中间件：状态码小于400时触发api_success，否则触发api_error。
+// 请求体和响应体以原始字节传递并按Content-Type解析，超过1MB的部分不传递；路径为请求的URL路径
+func (m *Manager) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var requestBody []byte
+		if c.Request.Body != nil && c.Request.Body != http.NoBody {
+			// 读取后放回请求，处理函数仍能读到完整的请求体
+			head, err := io.ReadAll(io.LimitReader(c.Request.Body, maxCapturedBody+1))
+			c.Request.Body = struct {
+				io.Reader
+				io.Closer
+			}{io.MultiReader(bytes.NewReader(head), c.Request.Body), c.Request.Body}
+			if err == nil && len(head) <= maxCapturedBody {
+				requestBody = head
+			}
+		}
+
+		writer := &bodyCaptureWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+
+		var responseBody []byte
+		if !writer.overflow {
+			responseBody = writer.body.Bytes()
+		}
+
+		status := c.Writer.Status()
+		event := EventAPISuccess
+		if status >= http.StatusBadRequest {
+			event = EventAPIError
+		}
+		// 异步处理在请求结束后仍会使用上下文，传递副本，副本通过响应快照读取响应头
+		ctx := c.Copy()
+		ctx.Writer = &responseSnapshot{header: c.Writer.Header().Clone(), status: status, size: c.Writer.Size()}
+		m.TriggerEvent(ctx, event, c.Request.URL.Path, status, requestBody, responseBody)
+	}
+}
@@ -0,0 +1,129 @@
+package plugins
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// MissingPlugin 存储中存在记录但文件已丢失的插件
+type MissingPlugin struct {
+	Name    string
+	Path    string
+	Enabled bool
+	Config  map[string]interface{}
+}
+
+// detectMissingPlugins 对比存储记录与磁盘文件，找出文件已丢失的插件，调用方需持有m.mutex
+func (m *Manager) detectMissingPlugins() {
+	lister, ok := m.store().(PluginListStorage)
+	if !ok {
+		return
+	}
+
+	records, err := lister.ListPlugins()
+	if err != nil {
+		m.logger.Printf("读取插件记录失败: %v", err)
+		return
+	}
+
+	loaded := make(map[string]bool, len(m.plugins))
+	for _, info := range m.plugins {
+		loaded[info.FilePath] = true
+	}
+
+	m.missing = make(map[string]*MissingPlugin)
+	for _, record := range records {
+		if loaded[record.Path] {
+			continue
+		}
+		if _, err := os.Stat(record.Path); !os.IsNotExist(err) {
+			continue
+		}
+
+		missing := &MissingPlugin{
+			Name:    record.Name,
+			Path:    record.Path,
+			Enabled: record.Enabled,
+		}
+		if record.Config != "" {
+			if err := json.Unmarshal([]byte(record.Config), &missing.Config); err != nil {
+				m.logger.Printf("解析插件 %s 配置失败: %v", record.Name, err)
+			}
+		}
+
+		m.missing[record.Path] = missing
+		m.logger.Printf("插件 %s 的文件已丢失: %s", record.Name, record.Path)
+	}
+}
+
+// GetMissingPlugins 获取文件已丢失的插件记录
+func (m *Manager) GetMissingPlugins() []*MissingPlugin {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	result := make([]*MissingPlugin, 0, len(m.missing))
+	for _, missing := range m.missing {
+		result = append(result, missing)
+	}
+	return result
+}
+
+// RelocatePlugin 为文件已丢失的插件指定新的文件位置，保留原有启用状态和配置并加载插件
+func (m *Manager) RelocatePlugin(oldPath, newPath string) (*PluginInfo, error) {
+	if err := m.checkWritable(); err != nil {
+		return nil, err
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	missing, exists := m.missing[oldPath]
+	if !exists {
+		return nil, fmt.Errorf("不存在丢失的插件记录: %s", oldPath)
+	}
+	if _, err := os.Stat(newPath); err != nil {
+		return nil, fmt.Errorf("新的插件文件不可用: %v", err)
+	}
+
+	// 将原有状态迁移到新路径，loadPlugin会按存储中的状态初始化插件
+	if err := m.store().SavePlugin(missing.Name, newPath, missing.Enabled, missing.Config); err != nil {
+		return nil, fmt.Errorf("保存插件记录失败: %v", err)
+	}
+
+	info, err := m.loadPlugin(newPath)
+	if err != nil {
+		return info, err
+	}
+	if info.Name != missing.Name {
+		m.logger.Printf("重新定位的插件名称 %s 与原记录 %s 不一致", info.Name, missing.Name)
+	}
+
+	if err := m.store().(PluginListStorage).DeletePlugin(oldPath); err != nil {
+		m.logger.Printf("删除旧插件记录失败: %v", err)
+	}
+	delete(m.missing, oldPath)
+
+	return info, nil
+}
+
+// PurgeMissingPlugin 删除文件已丢失的插件记录
+func (m *Manager) PurgeMissingPlugin(path string) error {
+	if err := m.checkWritable(); err != nil {
+		return err
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if _, exists := m.missing[path]; !exists {
+		return fmt.Errorf("不存在丢失的插件记录: %s", path)
+	}
+
+	if err := m.store().(PluginListStorage).DeletePlugin(path); err != nil {
+		return fmt.Errorf("删除插件记录失败: %v", err)
+	}
+	delete(m.missing, path)
+
+	return nil
+}
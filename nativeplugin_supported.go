@@ -0,0 +1,6 @@
+//go:build cgo && (linux || darwin || freebsd)
+
+package plugins
+
+// nativePluginsSupported 当前平台是否支持Go原生插件（plugin.Open）
+const nativePluginsSupported = true
@@ -0,0 +1,243 @@
+package plugins
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// defaultNoticeLimit 内存中保留的站内通知数量上限
+const defaultNoticeLimit = 1000
+
+// Notice 插件推送到宿主站内通知中心的通知
+type Notice struct {
+	ID        string     `json:"id"`
+	Plugin    string     `json:"plugin"`
+	Level     Severity   `json:"level"` // 只支持info和warning
+	Title     string     `json:"title"`
+	Message   string     `json:"message"`
+	Link      string     `json:"link,omitempty"` // 点击通知后跳转的宿主页面
+	CreatedAt time.Time  `json:"created_at"`
+	ReadAt    *time.Time `json:"read_at,omitempty"` // 为nil表示未读
+}
+
+// NoticeStorage 站内通知存储扩展接口（可选实现），实现后通知及已读状态在重启后保留
+type NoticeStorage interface {
+	// SaveNotice 保存通知，ID相同时覆盖
+	SaveNotice(notice Notice) error
+
+	// ListNotices 按创建时间从新到旧列出通知
+	ListNotices() ([]Notice, error)
+
+	// DeleteNotice 删除通知
+	DeleteNotice(id string) error
+}
+
+// noticeCenter 站内通知，存储未实现NoticeStorage时保存在内存中
+type noticeCenter struct {
+	notices  []Notice // 从旧到新
+	handlers []func(Notice)
+	mutex    sync.Mutex
+}
+
+func newNoticeCenter() *noticeCenter {
+	return &noticeCenter{}
+}
+
+// PushNotice 向宿主站内通知中心推送通知，返回通知ID，受每个插件的通知节流限制
+func (h *pluginHost) PushNotice(notice Notice) (string, error) {
+	h.m.notifyMutex.Lock()
+	throttle := h.m.notifyThrottle
+	h.m.notifyMutex.Unlock()
+
+	if !throttle.allow("notice:"+h.name, time.Now()) {
+		return "", ErrNotifyThrottled
+	}
+
+	notice.Plugin = h.name
+	return h.m.pushNotice(notice)
+}
+
+// OnNotice 注册新通知回调，宿主可以借此将通知实时推送到界面
+func (m *Manager) OnNotice(handler func(Notice)) {
+	m.notices.mutex.Lock()
+	defer m.notices.mutex.Unlock()
+
+	m.notices.handlers = append(m.notices.handlers, handler)
+}
+
+// pushNotice 保存通知并通知回调
+func (m *Manager) pushNotice(notice Notice) (string, error) {
+	if notice.Level == "" {
+		notice.Level = SeverityInfo
+	}
+	if notice.Level != SeverityInfo && notice.Level != SeverityWarning {
+		return "", fmt.Errorf("不支持的通知级别: %s", notice.Level)
+	}
+	if notice.Title == "" {
+		return "", fmt.Errorf("通知标题不能为空")
+	}
+
+	id, err := newRandomID()
+	if err != nil {
+		return "", err
+	}
+	notice.ID = id
+	notice.CreatedAt = time.Now()
+	notice.ReadAt = nil
+
+	m.notices.mutex.Lock()
+	if noticeStorage, ok := m.store().(NoticeStorage); ok {
+		if err := noticeStorage.SaveNotice(notice); err != nil {
+			m.notices.mutex.Unlock()
+			return "", fmt.Errorf("保存通知失败: %v", err)
+		}
+	} else {
+		m.notices.notices = append(m.notices.notices, notice)
+		if over := len(m.notices.notices) - defaultNoticeLimit; over > 0 {
+			m.notices.notices = m.notices.notices[over:]
+		}
+	}
+	handlers := append([]func(Notice){}, m.notices.handlers...)
+	m.notices.mutex.Unlock()
+
+	for _, handler := range handlers {
+		handler(notice)
+	}
+	return id, nil
+}
+
+// Notices 按创建时间从新到旧获取站内通知，unreadOnly为true时只返回未读通知
+func (m *Manager) Notices(unreadOnly bool) ([]Notice, error) {
+	m.notices.mutex.Lock()
+	defer m.notices.mutex.Unlock()
+
+	all, err := m.listNotices()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]Notice, 0, len(all))
+	for _, notice := range all {
+		if unreadOnly && notice.ReadAt != nil {
+			continue
+		}
+		result = append(result, notice)
+	}
+	return result, nil
+}
+
+// UnreadNoticeCount 获取未读通知数量
+func (m *Manager) UnreadNoticeCount() (int, error) {
+	unread, err := m.Notices(true)
+	if err != nil {
+		return 0, err
+	}
+	return len(unread), nil
+}
+
+// MarkNoticeRead 将通知标记为已读
+func (m *Manager) MarkNoticeRead(id string) error {
+	if err := m.checkWritable(); err != nil {
+		return err
+	}
+
+	m.notices.mutex.Lock()
+	defer m.notices.mutex.Unlock()
+
+	all, err := m.listNotices()
+	if err != nil {
+		return err
+	}
+	for _, notice := range all {
+		if notice.ID == id {
+			if notice.ReadAt == nil {
+				return m.markRead(notice, time.Now())
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("通知不存在: %s", id)
+}
+
+// MarkAllNoticesRead 将所有通知标记为已读
+func (m *Manager) MarkAllNoticesRead() error {
+	m.notices.mutex.Lock()
+	defer m.notices.mutex.Unlock()
+
+	all, err := m.listNotices()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, notice := range all {
+		if notice.ReadAt == nil {
+			if err := m.markRead(notice, now); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// DeleteNotice 删除通知
+func (m *Manager) DeleteNotice(id string) error {
+	if err := m.checkWritable(); err != nil {
+		return err
+	}
+
+	m.notices.mutex.Lock()
+	defer m.notices.mutex.Unlock()
+
+	if noticeStorage, ok := m.store().(NoticeStorage); ok {
+		if err := noticeStorage.DeleteNotice(id); err != nil {
+			return fmt.Errorf("删除通知失败: %v", err)
+		}
+		return nil
+	}
+
+	for i, notice := range m.notices.notices {
+		if notice.ID == id {
+			m.notices.notices = append(m.notices.notices[:i], m.notices.notices[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("通知不存在: %s", id)
+}
+
+// listNotices 按创建时间从新到旧返回所有通知，调用方需持有m.notices.mutex
+func (m *Manager) listNotices() ([]Notice, error) {
+	if noticeStorage, ok := m.store().(NoticeStorage); ok {
+		notices, err := noticeStorage.ListNotices()
+		if err != nil {
+			return nil, fmt.Errorf("读取通知失败: %v", err)
+		}
+		return notices, nil
+	}
+
+	notices := make([]Notice, 0, len(m.notices.notices))
+	for i := len(m.notices.notices) - 1; i >= 0; i-- {
+		notices = append(notices, m.notices.notices[i])
+	}
+	return notices, nil
+}
+
+// markRead 保存通知的已读时间，调用方需持有m.notices.mutex
+func (m *Manager) markRead(notice Notice, at time.Time) error {
+	notice.ReadAt = &at
+
+	if noticeStorage, ok := m.store().(NoticeStorage); ok {
+		if err := noticeStorage.SaveNotice(notice); err != nil {
+			return fmt.Errorf("保存通知失败: %v", err)
+		}
+		return nil
+	}
+
+	for i := range m.notices.notices {
+		if m.notices.notices[i].ID == notice.ID {
+			m.notices.notices[i].ReadAt = &at
+			break
+		}
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+package plugins
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// notifyTimeout 单次通知发送超时时间
+	notifyTimeout = 10 * time.Second
+
+	// defaultNotifyLimit 默认每个插件在节流窗口内允许发送的通知数量
+	defaultNotifyLimit = 10
+
+	// defaultNotifyWindow 默认节流窗口
+	defaultNotifyWindow = time.Minute
+)
+
+// ErrNotifyThrottled 插件通知过于频繁被节流时返回的错误
+var ErrNotifyThrottled = errors.New("通知发送过于频繁，已被节流")
+
+// AdminNotification 插件发送给管理员的通知，Subject和Body为text/template模板，以Data渲染，可使用RenderTemplate的辅助函数
+type AdminNotification struct {
+	Subject  string
+	Body     string // Markdown格式
+	Severity Severity
+	Data     map[string]interface{}
+}
+
+// NotifyChannel 宿主配置的通知渠道
+type NotifyChannel interface {
+	Send(ctx context.Context, n Notification) error
+}
+
+// NotifyChannelFunc 函数形式的通知渠道
+type NotifyChannelFunc func(ctx context.Context, n Notification) error
+
+func (f NotifyChannelFunc) Send(ctx context.Context, n Notification) error {
+	return f(ctx, n)
+}
+
+// notifyThrottle 按插件的固定窗口节流器
+type notifyThrottle struct {
+	limit  int
+	window time.Duration
+	counts map[string]*throttleWindow
+	mutex  sync.Mutex
+}
+
+type throttleWindow struct {
+	start time.Time
+	count int
+}
+
+func newNotifyThrottle(limit int, window time.Duration) *notifyThrottle {
+	return &notifyThrottle{
+		limit:  limit,
+		window: window,
+		counts: make(map[string]*throttleWindow),
+	}
+}
+
+// allow 判断插件当前是否允许发送通知
+func (t *notifyThrottle) allow(name string, now time.Time) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	w, exists := t.counts[name]
+	if !exists || now.Sub(w.start) >= t.window {
+		w = &throttleWindow{start: now}
+		t.counts[name] = w
+	}
+	if w.count >= t.limit {
+		return false
+	}
+	w.count++
+	return true
+}
+
+// RegisterNotifyChannel 注册通知渠道，同名渠道会被覆盖，ch为nil表示移除；维护模式下返回ErrMaintenanceMode（此前无返回值）
+func (m *Manager) RegisterNotifyChannel(name string, ch NotifyChannel) error {
+	if err := m.checkWritable(); err != nil {
+		return err
+	}
+
+	m.notifyMutex.Lock()
+	defer m.notifyMutex.Unlock()
+
+	if ch == nil {
+		delete(m.notifyChannels, name)
+		return nil
+	}
+	m.notifyChannels[name] = ch
+	return nil
+}
+
+// SetNotifyThrottle 设置每个插件在窗口内允许发送的通知数量
+func (m *Manager) SetNotifyThrottle(limit int, window time.Duration) {
+	m.notifyMutex.Lock()
+	defer m.notifyMutex.Unlock()
+
+	m.notifyThrottle = newNotifyThrottle(limit, window)
+}
+
+// NotifyAdmin 渲染模板并通过已配置的渠道向管理员发送通知
+func (h *pluginHost) NotifyAdmin(n AdminNotification) error {
+	h.m.notifyMutex.Lock()
+	throttle := h.m.notifyThrottle
+	h.m.notifyMutex.Unlock()
+
+	if !throttle.allow(h.name, time.Now()) {
+		return ErrNotifyThrottled
+	}
+
+	subject, err := h.renderNotifyTemplate("subject", n.Subject, n.Data)
+	if err != nil {
+		return err
+	}
+	body, err := h.renderNotifyTemplate("body", n.Body, n.Data)
+	if err != nil {
+		return err
+	}
+
+	severity := n.Severity
+	if severity == "" {
+		severity = SeverityInfo
+	}
+
+	h.m.notifyAdmins(h.name, Notification{
+		Subject:  fmt.Sprintf("[%s] %s", h.name, subject),
+		Body:     body,
+		Severity: severity,
+	})
+	return nil
+}
+
+// renderNotifyTemplate 以插件模板渲染服务渲染通知模板，可使用相同的辅助函数
+func (h *pluginHost) renderNotifyTemplate(name, text string, data map[string]interface{}) (string, error) {
+	result, err := h.m.renderTemplate(TemplateText, name, text, data, h.Clock())
+	if err != nil {
+		return "", fmt.Errorf("通知模板 %s: %w", name, err)
+	}
+	return result, nil
+}
+
+// notifyAdmins 通过宿主渠道和所有已启用的通知插件发送通知，exclude为发起通知的插件本身
+func (m *Manager) notifyAdmins(exclude string, n Notification) {
+	targets := make(map[string]NotifyChannel)
+
+	m.notifyMutex.Lock()
+	for name, ch := range m.notifyChannels {
+		targets["channel:"+name] = ch
+	}
+	m.notifyMutex.Unlock()
+
+	m.mutex.RLock()
+	for _, info := range m.plugins {
+		if !info.Enabled || info.Name == exclude {
+			continue
+		}
+		if notifier, ok := info.Plugin.(Notifier); ok {
+			targets["plugin:"+info.Name] = NotifyChannelFunc(notifier.Notify)
+		}
+	}
+	m.mutex.RUnlock()
+
+	for name, ch := range targets {
+		go func(name string, ch NotifyChannel) {
+			ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+			defer cancel()
+
+			if err := ch.Send(ctx, n); err != nil {
+				m.logger.Printf("通知渠道 %s 发送失败: %v", name, err)
+			}
+		}(name, ch)
+	}
+}
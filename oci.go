@@ -0,0 +1,306 @@
+package plugins
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	// ociSchemePrefix OCI制品引用前缀
+	ociSchemePrefix = "oci://"
+
+	// OCIPluginMediaType 插件制品层的媒体类型
+	OCIPluginMediaType = "application/vnd.sublink.plugin.v1+so"
+
+	// ociManifestMediaType OCI镜像清单媒体类型
+	ociManifestMediaType = "application/vnd.oci.image.manifest.v1+json"
+
+	// ociTitleAnnotation 层文件名注解
+	ociTitleAnnotation = "org.opencontainers.image.title"
+
+	// ociDownloadTimeout 拉取制品超时时间
+	ociDownloadTimeout = 5 * time.Minute
+
+	// maxOCIManifestSize OCI清单的最大字节数
+	maxOCIManifestSize = 4 << 20
+)
+
+// bearerParamPattern 解析WWW-Authenticate头中的参数
+var bearerParamPattern = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// ociReference 解析后的OCI制品引用
+type ociReference struct {
+	Registry   string
+	Repository string
+	Reference  string // 标签或摘要
+}
+
+// ociDescriptor OCI内容描述符
+type ociDescriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// ociManifest OCI镜像清单
+type ociManifest struct {
+	MediaType string          `json:"mediaType"`
+	Layers    []ociDescriptor `json:"layers"`
+}
+
+// parseOCIReference 解析 oci://registry/ns/plugin:tag 或 oci://registry/ns/plugin@sha256:...
+func parseOCIReference(ref string) (*ociReference, error) {
+	if !strings.HasPrefix(ref, ociSchemePrefix) {
+		return nil, fmt.Errorf("无效的OCI引用: %s", ref)
+	}
+	rest := strings.TrimPrefix(ref, ociSchemePrefix)
+
+	slash := strings.Index(rest, "/")
+	if slash <= 0 {
+		return nil, fmt.Errorf("OCI引用缺少仓库地址: %s", ref)
+	}
+	result := &ociReference{Registry: rest[:slash]}
+	repo := rest[slash+1:]
+
+	if at := strings.Index(repo, "@"); at >= 0 {
+		result.Repository, result.Reference = repo[:at], repo[at+1:]
+	} else if colon := strings.LastIndex(repo, ":"); colon >= 0 {
+		result.Repository, result.Reference = repo[:colon], repo[colon+1:]
+	} else {
+		result.Repository, result.Reference = repo, "latest"
+	}
+
+	if result.Repository == "" || result.Reference == "" {
+		return nil, fmt.Errorf("无效的OCI引用: %s", ref)
+	}
+	return result, nil
+}
+
+// InstallFromOCI 从OCI仓库拉取插件制品，校验摘要后放入插件目录并加载。
+// 插件层按媒体类型或文件名注解选取，文件名注解为已注册加载器支持的扩展名（如 .so、.lua、.js）时使用该文件名
+func (m *Manager) InstallFromOCI(ref string) (*PluginInfo, error) {
+	if err := m.checkWritable(); err != nil {
+		return nil, err
+	}
+
+	oref, err := parseOCIReference(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &ociClient{http: &http.Client{Timeout: ociDownloadTimeout}, ref: oref}
+
+	manifest, err := client.fetchManifest()
+	if err != nil {
+		return nil, err
+	}
+
+	layer, err := selectPluginLayer(manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	fileName := path.Base(layer.Annotations[ociTitleAnnotation])
+	if fileName == "." || fileName == "/" || !isPluginFile(fileName) {
+		fileName = path.Base(oref.Repository) + goPluginExt
+	}
+
+	// 下载和校验不持有m.mutex，先写入插件目录中的隐藏临时文件（加载和监听会忽略）
+	m.mutex.RLock()
+	dir := m.pluginDir
+	m.mutex.RUnlock()
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("创建插件目录失败: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, fileName)); err == nil {
+		return nil, fmt.Errorf("插件文件已存在: %s", filepath.Join(dir, fileName))
+	}
+	tmpPath, err := client.downloadBlob(layer, dir)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmpPath)
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.installStaged(tmpPath, dir, fileName)
+}
+
+// selectPluginLayer 从清单中选出插件层：优先插件媒体类型，其次文件名注解为插件文件的层
+func selectPluginLayer(manifest *ociManifest) (*ociDescriptor, error) {
+	for i, layer := range manifest.Layers {
+		if layer.MediaType == OCIPluginMediaType {
+			return &manifest.Layers[i], nil
+		}
+	}
+	for i, layer := range manifest.Layers {
+		if title := layer.Annotations[ociTitleAnnotation]; title != "" && isPluginFile(title) {
+			return &manifest.Layers[i], nil
+		}
+	}
+	if len(manifest.Layers) == 1 {
+		return &manifest.Layers[0], nil
+	}
+	return nil, fmt.Errorf("OCI制品中找不到插件层")
+}
+
+// ociClient 最小化的OCI分发协议客户端，支持匿名Bearer令牌认证
+type ociClient struct {
+	http  *http.Client
+	ref   *ociReference
+	token string
+}
+
+// fetchManifest 拉取并校验清单
+func (c *ociClient) fetchManifest() (*ociManifest, error) {
+	url := fmt.Sprintf("https://%s/v2/%s/manifests/%s", c.ref.Registry, c.ref.Repository, c.ref.Reference)
+
+	resp, err := c.get(url, ociManifestMediaType)
+	if err != nil {
+		return nil, fmt.Errorf("拉取OCI清单失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxOCIManifestSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("读取OCI清单失败: %v", err)
+	}
+	if len(data) > maxOCIManifestSize {
+		return nil, fmt.Errorf("OCI清单过大，超过 %d 字节", maxOCIManifestSize)
+	}
+
+	// 按摘要引用时校验清单内容
+	if strings.HasPrefix(c.ref.Reference, "sha256:") {
+		sum := sha256.Sum256(data)
+		if "sha256:"+hex.EncodeToString(sum[:]) != c.ref.Reference {
+			return nil, fmt.Errorf("OCI清单摘要不匹配")
+		}
+	}
+
+	var manifest ociManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("解析OCI清单失败: %v", err)
+	}
+	return &manifest, nil
+}
+
+// downloadBlob 下载层内容到dir中的临时文件并校验摘要，返回临时文件路径
+func (c *ociClient) downloadBlob(layer *ociDescriptor, dir string) (string, error) {
+	if !strings.HasPrefix(layer.Digest, "sha256:") {
+		return "", fmt.Errorf("不支持的摘要算法: %s", layer.Digest)
+	}
+
+	url := fmt.Sprintf("https://%s/v2/%s/blobs/%s", c.ref.Registry, c.ref.Repository, layer.Digest)
+	resp, err := c.get(url, "")
+	if err != nil {
+		return "", fmt.Errorf("下载插件层失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	return stageVerifiedFile(resp.Body, dir, strings.TrimPrefix(layer.Digest, "sha256:"))
+}
+
+// get 发送GET请求，遇到401时按WWW-Authenticate获取匿名令牌后重试
+func (c *ociClient) get(url, accept string) (*http.Response, error) {
+	for attempt := 0; attempt < 2; attempt++ {
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+
+		resp, err := c.http.Do(req)
+		if err != nil {
+			return nil, err
+		}
+
+		if resp.StatusCode == http.StatusUnauthorized && c.token == "" {
+			challenge := resp.Header.Get("WWW-Authenticate")
+			resp.Body.Close()
+			if err := c.authenticate(challenge); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, fmt.Errorf("仓库返回状态码 %d", resp.StatusCode)
+		}
+		return resp, nil
+	}
+	return nil, fmt.Errorf("仓库认证失败")
+}
+
+// authenticate 根据Bearer质询获取匿名访问令牌
+func (c *ociClient) authenticate(challenge string) error {
+	if !strings.HasPrefix(challenge, "Bearer ") {
+		return fmt.Errorf("不支持的仓库认证方式: %s", challenge)
+	}
+
+	params := make(map[string]string)
+	for _, match := range bearerParamPattern.FindAllStringSubmatch(challenge, -1) {
+		params[match[1]] = match[2]
+	}
+	if params["realm"] == "" {
+		return fmt.Errorf("仓库认证质询缺少realm")
+	}
+
+	req, err := http.NewRequest(http.MethodGet, params["realm"], nil)
+	if err != nil {
+		return err
+	}
+	q := req.URL.Query()
+	if params["service"] != "" {
+		q.Set("service", params["service"])
+	}
+	if params["scope"] != "" {
+		q.Set("scope", params["scope"])
+	}
+	req.URL.RawQuery = q.Encode()
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("获取仓库令牌失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("获取仓库令牌失败: 状态码 %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return fmt.Errorf("解析仓库令牌失败: %v", err)
+	}
+
+	c.token = body.Token
+	if c.token == "" {
+		c.token = body.AccessToken
+	}
+	if c.token == "" {
+		return fmt.Errorf("仓库未返回令牌")
+	}
+	return nil
+}
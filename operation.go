@@ -0,0 +1,285 @@
+package plugins
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultInitTimeout 默认插件初始化超时时间
+	defaultInitTimeout = 30 * time.Second
+
+	// operationRetention 已结束操作的保留时间
+	operationRetention = time.Hour
+)
+
+// OperationStage 操作进度阶段
+type OperationStage string
+
+const (
+	StagePending        OperationStage = "pending"
+	StageLoading        OperationStage = "loading"
+	StageInitializing   OperationStage = "initializing"
+	StageWarming        OperationStage = "warming"
+	StageHealthChecking OperationStage = "health_checking"
+	StageCompleted      OperationStage = "completed"
+	StageFailed         OperationStage = "failed"
+	StageCanceled       OperationStage = "canceled"
+)
+
+// Operation 异步管理操作
+type Operation struct {
+	ID         string
+	Plugin     string
+	Stage      OperationStage
+	Error      string
+	StartedAt  time.Time
+	FinishedAt time.Time
+
+	cancel context.CancelFunc
+	mutex  sync.Mutex
+}
+
+// setStage 更新操作阶段，op为nil时忽略
+func (op *Operation) setStage(stage OperationStage) {
+	if op == nil {
+		return
+	}
+	op.mutex.Lock()
+	defer op.mutex.Unlock()
+
+	op.Stage = stage
+}
+
+// finish 结束操作并记录结果
+func (op *Operation) finish(err error, canceled bool) {
+	op.mutex.Lock()
+	defer op.mutex.Unlock()
+
+	op.FinishedAt = time.Now()
+	switch {
+	case err == nil:
+		op.Stage = StageCompleted
+	case canceled:
+		op.Stage = StageCanceled
+		op.Error = err.Error()
+	default:
+		op.Stage = StageFailed
+		op.Error = err.Error()
+	}
+}
+
+// snapshot 返回操作的只读副本
+func (op *Operation) snapshot() *Operation {
+	op.mutex.Lock()
+	defer op.mutex.Unlock()
+
+	return &Operation{
+		ID:         op.ID,
+		Plugin:     op.Plugin,
+		Stage:      op.Stage,
+		Error:      op.Error,
+		StartedAt:  op.StartedAt,
+		FinishedAt: op.FinishedAt,
+	}
+}
+
+// done 操作是否已结束
+func (op *Operation) done() bool {
+	op.mutex.Lock()
+	defer op.mutex.Unlock()
+
+	return !op.FinishedAt.IsZero()
+}
+
+// SetInitTimeout 设置插件初始化超时时间，0表示不限制
+func (m *Manager) SetInitTimeout(timeout time.Duration) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.initTimeout = timeout
+}
+
+// initContext 根据初始化超时时间创建上下文
+func (m *Manager) initContext(parent context.Context) (context.Context, context.CancelFunc) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	return m.initContextLocked(parent)
+}
+
+// initContextLocked 同initContext，调用方需持有m.mutex
+func (m *Manager) initContextLocked(parent context.Context) (context.Context, context.CancelFunc) {
+	if m.initTimeout <= 0 {
+		return context.WithCancel(parent)
+	}
+	return context.WithTimeout(parent, m.initTimeout)
+}
+
+// EnablePluginAsync 异步启用插件，返回操作ID，可通过GetOperation查询进度
+func (m *Manager) EnablePluginAsync(name string) (string, error) {
+	if err := m.checkWritable(); err != nil {
+		return "", err
+	}
+
+	if _, exists := m.GetPlugin(name); !exists {
+		return "", fmt.Errorf("插件不存在: %s", name)
+	}
+
+	id, err := newRandomID()
+	if err != nil {
+		return "", fmt.Errorf("生成操作ID失败: %v", err)
+	}
+
+	ctx, cancel := m.initContext(context.Background())
+	op := &Operation{
+		ID:        id,
+		Plugin:    name,
+		Stage:     StagePending,
+		StartedAt: time.Now(),
+		cancel:    cancel,
+	}
+
+	m.opMutex.Lock()
+	m.pruneOperations()
+	m.operations[id] = op
+	m.opMutex.Unlock()
+
+	go func() {
+		defer cancel()
+
+		op.setStage(StageLoading)
+		err := m.enablePlugin(ctx, name, op)
+		op.finish(err, err != nil && ctx.Err() == context.Canceled)
+	}()
+
+	return id, nil
+}
+
+// GetOperation 获取操作进度
+func (m *Manager) GetOperation(id string) (*Operation, bool) {
+	m.opMutex.Lock()
+	defer m.opMutex.Unlock()
+
+	op, exists := m.operations[id]
+	if !exists {
+		return nil, false
+	}
+	return op.snapshot(), true
+}
+
+// CancelOperation 取消尚未结束的操作
+func (m *Manager) CancelOperation(id string) error {
+	m.opMutex.Lock()
+	op, exists := m.operations[id]
+	m.opMutex.Unlock()
+
+	if !exists {
+		return fmt.Errorf("操作不存在: %s", id)
+	}
+	if op.done() {
+		return fmt.Errorf("操作 %s 已结束", id)
+	}
+
+	op.cancel()
+	return nil
+}
+
+// pruneOperations 清理过期的已结束操作，调用方需持有opMutex
+func (m *Manager) pruneOperations() {
+	for id, op := range m.operations {
+		op.mutex.Lock()
+		expired := !op.FinishedAt.IsZero() && time.Since(op.FinishedAt) > operationRetention
+		op.mutex.Unlock()
+
+		if expired {
+			delete(m.operations, id)
+		}
+	}
+}
+
+// initTracker 记录正在执行的插件初始化，超时或取消后仍在执行的初始化在返回前一直保留记录
+type initTracker struct {
+	running map[string]bool
+	mutex   sync.Mutex
+}
+
+func newInitTracker() *initTracker {
+	return &initTracker{running: make(map[string]bool)}
+}
+
+// check 插件的上一次初始化仍未返回时返回错误
+func (t *initTracker) check(name string) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.running[name] {
+		return errInitRunning(name)
+	}
+	return nil
+}
+
+// begin 登记插件开始初始化，上一次初始化仍未返回时返回错误
+func (t *initTracker) begin(name string) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.running[name] {
+		return errInitRunning(name)
+	}
+	t.running[name] = true
+	return nil
+}
+
+func (t *initTracker) end(name string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	delete(t.running, name)
+}
+
+// errInitRunning 插件超时的初始化仍未返回时拒绝再次初始化的错误
+func errInitRunning(name string) error {
+	return fmt.Errorf("插件 %s 的上一次初始化仍未返回，需等待其返回后再启用", name)
+}
+
+// runInit 在上下文约束下执行插件初始化。超时或取消后Init仍在后台执行，返回前同名插件不能再次初始化或启用，
+// 避免与之后的初始化重叠；若初始化最终成功则关闭插件
+func (m *Manager) runInit(ctx context.Context, name string, p Plugin) error {
+	if err := m.inits.begin(name); err != nil {
+		return err
+	}
+
+	done := make(chan error, 1)
+	go runWithPluginLabels(name, func() {
+		done <- p.Init()
+	})
+
+	select {
+	case err := <-done:
+		m.inits.end(name)
+		return err
+	case <-ctx.Done():
+		go func() {
+			if err := <-done; err == nil {
+				_ = p.Close()
+			}
+			m.inits.end(name)
+			m.logger.Printf("插件 %s 超时的初始化已返回，可以重新启用", name)
+		}()
+		return ctx.Err()
+	}
+}
+
+// newRandomID 生成随机ID
+func newRandomID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
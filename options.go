@@ -0,0 +1,86 @@
+package plugins
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+)
+
+// Option 创建插件管理器的选项
+type Option func(*Manager)
+
+// Logger 管理器的日志输出，*log.Logger满足该接口
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// DispatchSettings 事件分发设置，零值字段保持默认
+type DispatchSettings struct {
+	SyncBudget       time.Duration  // 每个请求所有同步处理的累计时间预算，0表示不限制
+	LatencyPolicy    LatencyPolicy  // 延迟敏感路由策略
+	PressureSource   PressureSource // 宿主压力信号，为nil时不削减异步分发
+	ShedThreshold    float64        // 开始削减异步分发的压力值，需在0到1之间，0使用默认值
+	EventRecordLimit int            // 保留的最近事件记录数量，0使用默认值
+}
+
+// WithPluginDir 指定可写的插件目录
+func WithPluginDir(dir string) Option {
+	return WithPluginDirs(dir)
+}
+
+// WithPluginDirs 指定插件搜索目录，优先级从低到高，最后一个目录是可写的插件目录，空目录被忽略；
+// 与SetPluginDirs不同，目录在首次加载插件时才创建和检查
+func WithPluginDirs(dirs ...string) Option {
+	return func(m *Manager) {
+		var cleaned []string
+		for _, dir := range dirs {
+			if dir != "" {
+				cleaned = append(cleaned, filepath.Clean(dir))
+			}
+		}
+		if len(cleaned) == 0 {
+			return
+		}
+		m.searchDirs = cleaned[:len(cleaned)-1]
+		m.pluginDir = cleaned[len(cleaned)-1]
+	}
+}
+
+// WithStorage 指定管理器使用的存储，代替SetStorage设置的默认存储；存储的可选扩展接口同样通过类型断言检测
+func WithStorage(s PluginStorage) Option {
+	return func(m *Manager) {
+		m.storage = s
+	}
+}
+
+// WithLogger 指定管理器的日志输出，为nil时使用标准库的默认Logger
+func WithLogger(logger Logger) Option {
+	return func(m *Manager) {
+		if logger != nil {
+			m.logger = logger
+		}
+	}
+}
+
+// WithDispatcher 指定事件分发设置
+func WithDispatcher(settings DispatchSettings) Option {
+	return func(m *Manager) {
+		m.budget.limit = settings.SyncBudget
+		m.latencyPolicy = LatencyPolicy{
+			CriticalRoutes: append([]string(nil), settings.LatencyPolicy.CriticalRoutes...),
+			SyncAllowlist:  append([]string(nil), settings.LatencyPolicy.SyncAllowlist...),
+		}
+		if settings.PressureSource != nil {
+			m.shedder.source = settings.PressureSource
+			if settings.ShedThreshold > 0 && settings.ShedThreshold < 1 {
+				m.shedder.threshold = settings.ShedThreshold
+			} else if settings.ShedThreshold != 0 {
+				m.optionWarnings = append(m.optionWarnings,
+					fmt.Sprintf("削减阈值 %v 不在0到1之间，使用默认值 %v", settings.ShedThreshold, defaultShedThreshold))
+			}
+		}
+		if settings.EventRecordLimit > 0 {
+			m.recordLimit = settings.EventRecordLimit
+		}
+	}
+}
@@ -0,0 +1,326 @@
+package plugins
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// PackageExt 插件包扩展名，插件包是包含插件文件、清单、图标、多语言文本和静态资源的zip归档
+	PackageExt = ".splug"
+
+	// packageMetaFile 插件包目录中的安装信息文件，存在时该目录按插件包处理
+	packageMetaFile = ".splug.json"
+
+	// packageAssetsDir 插件包中的静态资源目录
+	packageAssetsDir = "assets"
+
+	// packageLocalesDir 插件包中的多语言文本目录，文件为 <语言>.json
+	packageLocalesDir = "locales"
+
+	// maxPackageSize 插件包解压后的最大总大小
+	maxPackageSize = 512 << 20
+)
+
+// packageMeta 插件包的安装信息
+type packageMeta struct {
+	Source      string    `json:"source"` // 安装时的插件包文件名
+	PluginFile  string    `json:"plugin_file"`
+	InstalledAt time.Time `json:"installed_at"`
+}
+
+// isPackageFile 判断文件是否为插件包
+func isPackageFile(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), PackageExt)
+}
+
+// isPackageDir 判断目录是否为插件包解压后的目录
+func isPackageDir(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, packageMetaFile))
+	return err == nil
+}
+
+// packageDirName 插件文件位于插件包目录中时返回目录名，否则返回空字符串
+func packageDirName(pluginPath string) string {
+	if dir := filepath.Dir(pluginPath); isPackageDir(dir) {
+		return filepath.Base(dir)
+	}
+	return ""
+}
+
+// isPackageResourceDir 判断目录是否为插件包中的静态资源或多语言文本目录，其中的.js等文件不是插件
+func isPackageResourceDir(dir string) bool {
+	switch filepath.Base(dir) {
+	case packageAssetsDir, packageLocalesDir:
+		return isPackageDir(filepath.Dir(dir))
+	}
+	return false
+}
+
+// isSkippedDir 加载和监听插件目录时跳过的目录：隔离目录和插件包中的资源目录，调用方需持有m.mutex
+func (m *Manager) isSkippedDir(dir string) bool {
+	return m.isQuarantineRoot(dir) || isPackageResourceDir(dir)
+}
+
+// installPackage 将插件包解压到插件目录中以插件文件名命名的子目录并加载。
+// 插件包根目录中需有且只有一个插件文件，可以包含目录级清单plugin.json、签名文件、
+// 清单icon字段指向的图标、locales目录下的多语言文本和assets目录下的静态资源，调用方需持有m.mutex
+func (m *Manager) installPackage(srcPath string) (*PluginInfo, error) {
+	r, err := zip.OpenReader(srcPath)
+	if err != nil {
+		return nil, fmt.Errorf("打开插件包失败: %v", err)
+	}
+	defer r.Close()
+
+	pluginFile, err := packagePluginFile(r.File)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(m.pluginDir, 0755); err != nil {
+		return nil, fmt.Errorf("创建插件目录失败: %v", err)
+	}
+	dir := filepath.Join(m.pluginDir, strings.TrimSuffix(pluginFile, filepath.Ext(pluginFile)))
+	if _, err := os.Stat(dir); err == nil {
+		return nil, fmt.Errorf("插件包目录已存在: %s", dir)
+	}
+
+	// 先解压到插件目录中的隐藏临时目录（加载和监听会忽略），完整解压后再改名，
+	// 与目标目录在同一文件系统上，改名是原子的
+	tmp, err := os.MkdirTemp(m.pluginDir, ".splug-*")
+	if err != nil {
+		return nil, fmt.Errorf("创建临时目录失败: %v", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	if err := extractPackage(r.File, tmp, pluginFile); err != nil {
+		return nil, err
+	}
+	meta, err := json.MarshalIndent(packageMeta{
+		Source:      filepath.Base(srcPath),
+		PluginFile:  pluginFile,
+		InstalledAt: time.Now(),
+	}, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("序列化插件包信息失败: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmp, packageMetaFile), meta, 0644); err != nil {
+		return nil, fmt.Errorf("写入插件包信息失败: %v", err)
+	}
+
+	if err := os.Rename(tmp, dir); err != nil {
+		return nil, fmt.Errorf("移动插件包目录失败: %v", err)
+	}
+
+	target := filepath.Join(dir, pluginFile)
+	if err := m.saveChecksum(target); err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+
+	info, err := m.loadPlugin(target)
+	if err != nil {
+		// 安装失败时清理解压的目录和存储记录
+		if info != nil {
+			delete(m.plugins, info.Name)
+			if lister, ok := m.store().(PluginListStorage); ok {
+				_ = lister.DeletePlugin(target)
+			}
+		}
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("加载插件失败: %v", err)
+	}
+
+	m.logger.Printf("已安装插件包: %s -> %s", filepath.Base(srcPath), dir)
+	return info, nil
+}
+
+// packagePluginFile 查找插件包根目录中唯一的插件文件
+func packagePluginFile(files []*zip.File) (string, error) {
+	var found []string
+	for _, f := range files {
+		if !strings.Contains(f.Name, "/") && isPluginFile(f.Name) {
+			found = append(found, f.Name)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return "", fmt.Errorf("插件包根目录中没有插件文件")
+	case 1:
+		return found[0], nil
+	default:
+		return "", fmt.Errorf("插件包根目录中有多个插件文件: %s", strings.Join(found, ", "))
+	}
+}
+
+// extractPackage 将插件包中的文件解压到dir，拒绝绝对路径、跳出目录的路径和符号链接
+func extractPackage(files []*zip.File, dir, pluginFile string) error {
+	var total uint64
+	for _, f := range files {
+		total += f.UncompressedSize64
+	}
+	if total > maxPackageSize {
+		return fmt.Errorf("插件包解压后过大: %d 字节", total)
+	}
+
+	for _, f := range files {
+		name := path.Clean(f.Name)
+		if strings.Contains(f.Name, "\\") || path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			return fmt.Errorf("插件包中的路径无效: %s", f.Name)
+		}
+		if f.Mode()&os.ModeSymlink != 0 {
+			return fmt.Errorf("插件包中不能包含符号链接: %s", f.Name)
+		}
+
+		target := filepath.Join(dir, filepath.FromSlash(name))
+		if f.FileInfo().IsDir() {
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return fmt.Errorf("创建目录失败: %v", err)
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return fmt.Errorf("创建目录失败: %v", err)
+		}
+
+		perm := os.FileMode(0644)
+		if name == pluginFile {
+			perm = 0755
+		}
+		if err := extractPackageFile(f, target, perm); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// extractPackageFile 解压单个文件，实际大小超过声明的大小时返回错误
+func extractPackageFile(f *zip.File, target string, perm os.FileMode) error {
+	in, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("读取插件包文件 %s 失败: %v", f.Name, err)
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return fmt.Errorf("创建文件失败: %v", err)
+	}
+	n, err := io.Copy(out, io.LimitReader(in, int64(f.UncompressedSize64)+1))
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("解压插件包文件 %s 失败: %v", f.Name, err)
+	}
+	if uint64(n) > f.UncompressedSize64 {
+		return fmt.Errorf("插件包文件 %s 的大小与声明不一致", f.Name)
+	}
+	return nil
+}
+
+// packageDir 返回插件的插件包目录，插件不是通过插件包安装时返回空字符串，调用方需持有m.mutex
+func (m *Manager) packageDir(name string) (string, *PluginInfo) {
+	info, exists := m.plugins[name]
+	if !exists {
+		return "", nil
+	}
+	dir := filepath.Dir(info.FilePath)
+	if !isPackageDir(dir) {
+		return "", info
+	}
+	return dir, info
+}
+
+// assetPath 将插件包中的资源路径转换为文件路径，只允许访问assets、locales目录和清单声明的图标
+func (m *Manager) assetPath(name, rel string) (string, bool) {
+	m.mutex.RLock()
+	dir, info := m.packageDir(name)
+	m.mutex.RUnlock()
+	if dir == "" {
+		return "", false
+	}
+
+	rel = strings.TrimPrefix(path.Clean("/"+rel), "/")
+	first, _, _ := strings.Cut(rel, "/")
+	allowed := first == packageAssetsDir || first == packageLocalesDir
+	if info.Manifest != nil && info.Manifest.Icon != "" {
+		allowed = allowed || rel == strings.TrimPrefix(path.Clean("/"+info.Manifest.Icon), "/")
+	}
+	if !allowed {
+		return "", false
+	}
+
+	file := filepath.Join(dir, filepath.FromSlash(rel))
+	stat, err := os.Stat(file)
+	if err != nil || !stat.Mode().IsRegular() {
+		return "", false
+	}
+	return file, true
+}
+
+// AssetHandler 提供插件包中静态资源的HTTP处理器，请求路径为 /<插件名称>/<资源路径>，
+// 如 /demo/assets/index.html、/demo/locales/zh-CN.json、/demo/icon.png（清单icon字段指向的图标）。
+// 宿主需自行去掉挂载前缀，如Gin中使用 gin.WrapH(http.StripPrefix("/plugin-assets", m.AssetHandler()))
+func (m *Manager) AssetHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		name, rel, _ := strings.Cut(strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/"), "/")
+		file, ok := m.assetPath(name, rel)
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		http.ServeFile(w, r, file)
+	})
+}
+
+// PluginStrings 获取插件包中的多语言文本（locales/<语言>.json），没有对应语言时依次尝试主语言（如zh-CN时尝试zh）和en
+func (m *Manager) PluginStrings(name, lang string) (map[string]string, error) {
+	m.mutex.RLock()
+	dir, info := m.packageDir(name)
+	m.mutex.RUnlock()
+	if info == nil {
+		return nil, fmt.Errorf("插件不存在: %s", name)
+	}
+	if dir == "" {
+		return nil, fmt.Errorf("插件 %s 不是通过插件包安装的", name)
+	}
+
+	candidates := []string{lang}
+	if base, _, ok := strings.Cut(lang, "-"); ok {
+		candidates = append(candidates, base)
+	}
+	candidates = append(candidates, "en")
+
+	for _, candidate := range candidates {
+		if candidate == "" || strings.ContainsAny(candidate, `/\.`) {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, packageLocalesDir, candidate+".json"))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("读取插件 %s 的多语言文本失败: %v", name, err)
+		}
+		var strs map[string]string
+		if err := json.Unmarshal(data, &strs); err != nil {
+			return nil, fmt.Errorf("解析插件 %s 的多语言文本失败: %v", name, err)
+		}
+		return strs, nil
+	}
+	return nil, fmt.Errorf("插件 %s 没有 %s 的多语言文本", name, lang)
+}
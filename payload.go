@@ -0,0 +1,130 @@
+package plugins
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compression 事件数据的压缩方式
+type Compression string
+
+const (
+	CompressionNone Compression = ""
+	CompressionGzip Compression = "gzip"
+	CompressionZstd Compression = "zstd"
+)
+
+// PayloadOptions 事件数据的编码选项
+type PayloadOptions struct {
+	Compression Compression // 压缩方式
+	MaxSize     int         // 压缩前的最大字节数，超出部分被截断，0表示不限制
+}
+
+// Payload 编码后的事件数据（请求或响应体），用于事件日志和远程转发
+type Payload struct {
+	Encoding  Compression `json:"encoding,omitempty"`
+	Data      []byte      `json:"data"`
+	Size      int         `json:"size"`                // 截断前的原始字节数
+	Truncated bool        `json:"truncated,omitempty"` // 是否被截断
+}
+
+// 共享的zstd编解码器，EncodeAll和DecodeAll可并发调用
+var (
+	zstdEncoder *zstd.Encoder
+	zstdDecoder *zstd.Decoder
+	zstdInit    sync.Once
+	zstdInitErr error
+)
+
+// EncodePayload 将事件数据序列化为JSON（[]byte和string原样使用），按选项截断并压缩
+func EncodePayload(v interface{}, opts PayloadOptions) (*Payload, error) {
+	var raw []byte
+	switch data := v.(type) {
+	case nil:
+		return nil, nil
+	case []byte:
+		raw = data
+	case string:
+		raw = []byte(data)
+	default:
+		var err error
+		if raw, err = json.Marshal(v); err != nil {
+			return nil, fmt.Errorf("序列化事件数据失败: %v", err)
+		}
+	}
+
+	p := &Payload{Encoding: opts.Compression, Size: len(raw)}
+	if opts.MaxSize > 0 && len(raw) > opts.MaxSize {
+		raw = raw[:opts.MaxSize]
+		p.Truncated = true
+	}
+
+	switch opts.Compression {
+	case CompressionNone:
+		p.Data = append([]byte(nil), raw...)
+	case CompressionGzip:
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		if _, err := zw.Write(raw); err != nil {
+			return nil, fmt.Errorf("压缩事件数据失败: %v", err)
+		}
+		if err := zw.Close(); err != nil {
+			return nil, fmt.Errorf("压缩事件数据失败: %v", err)
+		}
+		p.Data = buf.Bytes()
+	case CompressionZstd:
+		if err := initZstd(); err != nil {
+			return nil, err
+		}
+		p.Data = zstdEncoder.EncodeAll(raw, nil)
+	default:
+		return nil, fmt.Errorf("不支持的压缩方式: %s", opts.Compression)
+	}
+	return p, nil
+}
+
+// Decode 解压事件数据，返回（可能被截断的）原始字节
+func (p *Payload) Decode() ([]byte, error) {
+	switch p.Encoding {
+	case CompressionNone:
+		return p.Data, nil
+	case CompressionGzip:
+		zr, err := gzip.NewReader(bytes.NewReader(p.Data))
+		if err != nil {
+			return nil, fmt.Errorf("解压事件数据失败: %v", err)
+		}
+		defer zr.Close()
+		return io.ReadAll(zr)
+	case CompressionZstd:
+		if err := initZstd(); err != nil {
+			return nil, err
+		}
+		data, err := zstdDecoder.DecodeAll(p.Data, nil)
+		if err != nil {
+			return nil, fmt.Errorf("解压事件数据失败: %v", err)
+		}
+		return data, nil
+	default:
+		return nil, fmt.Errorf("不支持的压缩方式: %s", p.Encoding)
+	}
+}
+
+// initZstd 延迟创建共享的zstd编解码器
+func initZstd() error {
+	zstdInit.Do(func() {
+		if zstdEncoder, zstdInitErr = zstd.NewWriter(nil); zstdInitErr != nil {
+			return
+		}
+		zstdDecoder, zstdInitErr = zstd.NewReader(nil)
+	})
+	if zstdInitErr != nil {
+		return fmt.Errorf("初始化zstd失败: %v", zstdInitErr)
+	}
+	return nil
+}
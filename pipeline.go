@@ -0,0 +1,260 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Transformer 转换阶段能力：插件作为管道的一个阶段处理数据，输出交给下一个阶段
+type Transformer interface {
+	// Transform 处理输入并返回输出
+	Transform(ctx context.Context, input interface{}) (interface{}, error)
+}
+
+// StageErrorPolicy 阶段出错时的处理方式
+type StageErrorPolicy string
+
+const (
+	StageAbort StageErrorPolicy = "abort" // 中止管道（默认）
+	StageSkip  StageErrorPolicy = "skip"  // 跳过该阶段，输入原样交给下一个阶段
+)
+
+// PipelineStage 管道阶段
+type PipelineStage struct {
+	Plugin  string           `json:"plugin"`
+	OnError StageErrorPolicy `json:"on_error,omitempty"`
+	Timeout time.Duration    `json:"timeout,omitempty"` // 阶段超时时间，0表示不限制
+}
+
+// Pipeline 由多个插件串联的处理管道，如 导入 → 去重 → 重命名 → 保存
+type Pipeline struct {
+	Name   string          `json:"name"`
+	Stages []PipelineStage `json:"stages"`
+}
+
+// StageResult 单次运行中一个阶段的结果
+type StageResult struct {
+	Plugin   string
+	Duration time.Duration
+	Error    string
+	Skipped  bool // 出错后按策略跳过
+}
+
+// PipelineRun 管道的一次运行结果
+type PipelineRun struct {
+	Pipeline string
+	Stages   []StageResult
+	Duration time.Duration
+}
+
+// StageStats 管道阶段的累计指标
+type StageStats struct {
+	Plugin    string
+	Runs      int64
+	Errors    int64
+	TotalTime time.Duration
+}
+
+// pipelineRegistry 已登记的管道及指标
+type pipelineRegistry struct {
+	pipelines map[string]Pipeline
+	stats     map[string][]StageStats
+	mutex     sync.Mutex
+}
+
+func newPipelineRegistry() *pipelineRegistry {
+	return &pipelineRegistry{
+		pipelines: make(map[string]Pipeline),
+		stats:     make(map[string][]StageStats),
+	}
+}
+
+// RegisterPipeline 登记管道，同名管道会被替换并重置指标
+func (m *Manager) RegisterPipeline(p Pipeline) error {
+	if err := m.checkWritable(); err != nil {
+		return err
+	}
+
+	if p.Name == "" {
+		return fmt.Errorf("管道名称不能为空")
+	}
+	if len(p.Stages) == 0 {
+		return fmt.Errorf("管道 %s 没有阶段", p.Name)
+	}
+
+	p.Stages = append([]PipelineStage(nil), p.Stages...)
+	stats := make([]StageStats, len(p.Stages))
+	for i, stage := range p.Stages {
+		if stage.Plugin == "" {
+			return fmt.Errorf("管道 %s 的第 %d 个阶段缺少插件名称", p.Name, i+1)
+		}
+		switch stage.OnError {
+		case "":
+			p.Stages[i].OnError = StageAbort
+		case StageAbort, StageSkip:
+		default:
+			return fmt.Errorf("管道 %s 的第 %d 个阶段错误处理方式无效: %s", p.Name, i+1, stage.OnError)
+		}
+		stats[i].Plugin = stage.Plugin
+	}
+
+	m.pipelines.mutex.Lock()
+	defer m.pipelines.mutex.Unlock()
+
+	m.pipelines.pipelines[p.Name] = p
+	m.pipelines.stats[p.Name] = stats
+	return nil
+}
+
+// RemovePipeline 移除管道，维护模式下返回ErrMaintenanceMode（此前无返回值）
+func (m *Manager) RemovePipeline(name string) error {
+	if err := m.checkWritable(); err != nil {
+		return err
+	}
+
+	m.pipelines.mutex.Lock()
+	defer m.pipelines.mutex.Unlock()
+
+	delete(m.pipelines.pipelines, name)
+	delete(m.pipelines.stats, name)
+	return nil
+}
+
+// Pipelines 获取所有已登记的管道
+func (m *Manager) Pipelines() []Pipeline {
+	m.pipelines.mutex.Lock()
+	defer m.pipelines.mutex.Unlock()
+
+	result := make([]Pipeline, 0, len(m.pipelines.pipelines))
+	for _, p := range m.pipelines.pipelines {
+		result = append(result, p)
+	}
+	return result
+}
+
+// PipelineStats 获取管道各阶段的累计指标
+func (m *Manager) PipelineStats(name string) ([]StageStats, error) {
+	m.pipelines.mutex.Lock()
+	defer m.pipelines.mutex.Unlock()
+
+	stats, exists := m.pipelines.stats[name]
+	if !exists {
+		return nil, fmt.Errorf("管道不存在: %s", name)
+	}
+	return append([]StageStats(nil), stats...), nil
+}
+
+// RunPipeline 依次执行管道的各个阶段，前一阶段的输出作为后一阶段的输入，返回最终输出和运行结果
+func (m *Manager) RunPipeline(ctx context.Context, name string, input interface{}) (interface{}, *PipelineRun, error) {
+	m.pipelines.mutex.Lock()
+	p, exists := m.pipelines.pipelines[name]
+	m.pipelines.mutex.Unlock()
+
+	if !exists {
+		return nil, nil, fmt.Errorf("管道不存在: %s", name)
+	}
+
+	run := &PipelineRun{Pipeline: name}
+	start := time.Now()
+	defer func() { run.Duration = time.Since(start) }()
+
+	data := input
+	for i, stage := range p.Stages {
+		if err := ctx.Err(); err != nil {
+			return nil, run, err
+		}
+
+		output, elapsed, err := m.runStage(ctx, stage, data)
+		m.accountStage(name, i, elapsed, err)
+
+		result := StageResult{Plugin: stage.Plugin, Duration: elapsed}
+		if err != nil {
+			result.Error = err.Error()
+			m.reportPluginError(stage.Plugin, fmt.Errorf("管道 %s 阶段失败: %v", name, err))
+
+			if stage.OnError == StageSkip {
+				result.Skipped = true
+				run.Stages = append(run.Stages, result)
+				m.logger.Printf("管道 %s 跳过失败的阶段 %s: %v", name, stage.Plugin, err)
+				continue
+			}
+
+			run.Stages = append(run.Stages, result)
+			return nil, run, fmt.Errorf("管道 %s 第 %d 个阶段 %s 失败: %v", name, i+1, stage.Plugin, err)
+		}
+
+		run.Stages = append(run.Stages, result)
+		data = output
+	}
+
+	return data, run, nil
+}
+
+// runStage 执行单个阶段
+func (m *Manager) runStage(ctx context.Context, stage PipelineStage, input interface{}) (interface{}, time.Duration, error) {
+	m.mutex.RLock()
+	info, exists := m.plugins[stage.Plugin]
+	m.mutex.RUnlock()
+
+	if !exists {
+		return nil, 0, fmt.Errorf("插件不存在: %s", stage.Plugin)
+	}
+	if !info.Enabled || info.dormant {
+		return nil, 0, fmt.Errorf("插件未启用: %s", stage.Plugin)
+	}
+	transformer, ok := info.Plugin.(Transformer)
+	if !ok {
+		return nil, 0, fmt.Errorf("插件 %s 不支持作为管道阶段", stage.Plugin)
+	}
+
+	if stage.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, stage.Timeout)
+		defer cancel()
+	}
+
+	var output interface{}
+	var crashed bool
+	var err error
+	start := time.Now()
+	runWithPluginLabels(stage.Plugin, func() {
+		output, crashed, err = callTransform(ctx, transformer, input)
+	})
+	elapsed := time.Since(start)
+	if crashed {
+		m.markCrashed(info, err.Error())
+	}
+
+	m.recordPluginEvent(stage.Plugin, err)
+	return output, elapsed, err
+}
+
+// callTransform 调用插件的数据转换，插件panic时crashed为true
+func callTransform(ctx context.Context, transformer Transformer, input interface{}) (output interface{}, crashed bool, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			output, crashed, err = nil, true, fmt.Errorf("转换数据时发生panic: %v", r)
+		}
+	}()
+
+	output, err = transformer.Transform(ctx, input)
+	return output, false, err
+}
+
+// accountStage 累计阶段指标
+func (m *Manager) accountStage(pipeline string, index int, elapsed time.Duration, err error) {
+	m.pipelines.mutex.Lock()
+	defer m.pipelines.mutex.Unlock()
+
+	stats, exists := m.pipelines.stats[pipeline]
+	if !exists || index >= len(stats) {
+		return
+	}
+	stats[index].Runs++
+	stats[index].TotalTime += elapsed
+	if err != nil {
+		stats[index].Errors++
+	}
+}
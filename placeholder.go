@@ -0,0 +1,108 @@
+package plugins
+
+import (
+	"regexp"
+)
+
+// 常用宿主设置键，可在插件配置中以 ${host.<键>} 形式引用
+const (
+	HostSettingSiteURL      = "site_url"
+	HostSettingAdminEmail   = "admin_email"
+	HostSettingDefaultProxy = "default_proxy"
+)
+
+// placeholderPattern 匹配配置中的宿主设置占位符
+var placeholderPattern = regexp.MustCompile(`\$\{host\.([A-Za-z0-9_.-]+)\}`)
+
+// SetHostSettings 设置宿主设置，并将解析后的配置重新下发给所有插件；维护模式下返回ErrMaintenanceMode（此前无返回值）
+func (m *Manager) SetHostSettings(settings map[string]string) error {
+	if err := m.checkWritable(); err != nil {
+		return err
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.hostSettings = make(map[string]string, len(settings))
+	for k, v := range settings {
+		m.hostSettings[k] = v
+	}
+
+	// 重新下发配置，使插件与宿主设置保持同步
+	for _, info := range m.plugins {
+		if hasPlaceholders(info.Config) {
+			m.deliverConfig(info.Name, info.Plugin, info.Manifest, info.Config)
+		}
+	}
+	return nil
+}
+
+// GetHostSettings 获取宿主设置
+func (m *Manager) GetHostSettings() map[string]string {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	result := make(map[string]string, len(m.hostSettings))
+	for k, v := range m.hostSettings {
+		result[k] = v
+	}
+	return result
+}
+
+// resolveConfig 返回占位符已替换的配置副本，原配置保持不变，调用方需持有m.mutex
+func (m *Manager) resolveConfig(config map[string]interface{}) map[string]interface{} {
+	if config == nil {
+		return nil
+	}
+	return m.resolveValue(config).(map[string]interface{})
+}
+
+// resolveValue 递归替换配置值中的占位符
+func (m *Manager) resolveValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		return placeholderPattern.ReplaceAllStringFunc(v, func(match string) string {
+			key := placeholderPattern.FindStringSubmatch(match)[1]
+			if setting, ok := m.hostSettings[key]; ok {
+				return setting
+			}
+			m.logger.Printf("未知的宿主设置占位符: %s", match)
+			return match
+		})
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			result[k] = m.resolveValue(item)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = m.resolveValue(item)
+		}
+		return result
+	default:
+		return value
+	}
+}
+
+// hasPlaceholders 判断配置中是否包含占位符
+func hasPlaceholders(value interface{}) bool {
+	switch v := value.(type) {
+	case string:
+		return placeholderPattern.MatchString(v)
+	case map[string]interface{}:
+		for _, item := range v {
+			if hasPlaceholders(item) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			if hasPlaceholders(item) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,300 @@
+package plugins
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// PlanAction 计划动作类型
+type PlanAction string
+
+const (
+	PlanEnable  PlanAction = "enable"
+	PlanDisable PlanAction = "disable"
+	PlanInstall PlanAction = "install"
+)
+
+// PlanItem 单个插件的期望状态
+type PlanItem struct {
+	Name     string                 `json:"name" yaml:"name"`
+	Action   PlanAction             `json:"action" yaml:"action"`
+	Version  string                 `json:"version,omitempty" yaml:"version,omitempty"`   // 期望版本，为空表示不校验
+	Source   string                 `json:"source,omitempty" yaml:"source,omitempty"`     // 安装来源（插件文件路径），install 使用；enable/disable 的插件未安装时从此安装
+	Checksum string                 `json:"checksum,omitempty" yaml:"checksum,omitempty"` // 插件文件的SHA-256，为空表示不校验
+	Config   map[string]interface{} `json:"config,omitempty" yaml:"config,omitempty"`     // 为nil表示不修改配置
+}
+
+// Plan 声明式插件管理计划
+type Plan struct {
+	Items []PlanItem `json:"items" yaml:"items"`
+}
+
+// ApplyPlan 校验并原子地应用管理计划，任一步骤失败时回滚已执行的步骤
+func (m *Manager) ApplyPlan(plan *Plan) error {
+	if err := m.checkWritable(); err != nil {
+		return err
+	}
+
+	m.planMutex.Lock()
+	defer m.planMutex.Unlock()
+
+	if err := m.validatePlan(plan); err != nil {
+		return err
+	}
+
+	// 回滚栈，失败时逆序执行
+	var undo []func()
+	rollback := func() {
+		for i := len(undo) - 1; i >= 0; i-- {
+			undo[i]()
+		}
+	}
+
+	graph, priorities := m.planDependencyGraph(plan)
+	for _, item := range orderPlanItems(plan.Items, graph, priorities) {
+		step, err := m.applyPlanItem(item)
+		// 失败的计划项可能已执行了部分步骤，一并回滚
+		undo = append(undo, step...)
+		if err != nil {
+			rollback()
+			return fmt.Errorf("应用计划失败 %s(%s): %v", item.Name, item.Action, err)
+		}
+	}
+
+	return nil
+}
+
+// validatePlan 在执行前完整校验计划
+func (m *Manager) validatePlan(plan *Plan) error {
+	if plan == nil || len(plan.Items) == 0 {
+		return fmt.Errorf("计划为空")
+	}
+
+	seen := make(map[string]bool)
+	installing := make(map[string]bool)
+	var errs []string
+
+	for _, item := range plan.Items {
+		if item.Name == "" {
+			errs = append(errs, "存在未指定名称的计划项")
+			continue
+		}
+		if seen[item.Name] {
+			errs = append(errs, fmt.Sprintf("插件 %s 在计划中重复出现", item.Name))
+			continue
+		}
+		seen[item.Name] = true
+
+		if item.Action == PlanInstall {
+			installing[item.Name] = true
+		} else if _, exists := m.GetPlugin(item.Name); !exists && item.Source != "" {
+			installing[item.Name] = true
+		}
+	}
+
+	for _, item := range plan.Items {
+		if item.Name == "" {
+			continue
+		}
+
+		info, exists := m.GetPlugin(item.Name)
+
+		switch item.Action {
+		case PlanInstall:
+			if exists {
+				errs = append(errs, fmt.Sprintf("插件 %s 已安装", item.Name))
+			}
+			if item.Source == "" {
+				errs = append(errs, fmt.Sprintf("插件 %s 未指定安装来源", item.Name))
+			} else if _, err := os.Stat(item.Source); err != nil {
+				errs = append(errs, fmt.Sprintf("插件 %s 的安装来源不可用: %v", item.Name, err))
+			}
+		case PlanEnable, PlanDisable:
+			if !exists && !installing[item.Name] {
+				errs = append(errs, fmt.Sprintf("插件不存在: %s", item.Name))
+			}
+			if !exists && installing[item.Name] {
+				if _, err := os.Stat(item.Source); err != nil {
+					errs = append(errs, fmt.Sprintf("插件 %s 的安装来源不可用: %v", item.Name, err))
+				}
+			}
+			if exists && item.Version != "" && info.Version != item.Version {
+				errs = append(errs, fmt.Sprintf("插件 %s 版本不匹配: 期望 %s, 实际 %s", item.Name, item.Version, info.Version))
+			}
+		default:
+			errs = append(errs, fmt.Sprintf("插件 %s 的动作无效: %q", item.Name, item.Action))
+		}
+
+		// 校验已安装的插件文件或安装来源的校验和
+		if item.Checksum != "" {
+			path := item.Source
+			if exists && item.Action != PlanInstall {
+				path = info.FilePath
+			}
+			if path != "" {
+				if sum, err := fileSHA256(path); err != nil {
+					errs = append(errs, fmt.Sprintf("计算插件 %s 的校验和失败: %v", item.Name, err))
+				} else if !strings.EqualFold(sum, strings.TrimPrefix(item.Checksum, "sha256:")) {
+					errs = append(errs, fmt.Sprintf("插件 %s 校验和不匹配: 期望 %s, 实际 %s", item.Name, item.Checksum, sum))
+				}
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("计划校验失败: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// orderPlanItems 按依赖顺序排列计划项：先安装，再禁用，最后启用；
+// 禁用时依赖方先于被依赖的插件，启用时被依赖的插件先于依赖方，无依赖关系时按优先级排序
+func orderPlanItems(items []PlanItem, graph map[string][]string, priorities map[string]int) []PlanItem {
+	rank := map[PlanAction]int{PlanInstall: 0, PlanDisable: 1, PlanEnable: 2}
+
+	order, _ := sortByDependencies(graph, priorities)
+	position := make(map[string]int, len(order))
+	for i, name := range order {
+		position[name] = i
+	}
+
+	ordered := make([]PlanItem, 0, len(items))
+	for r := 0; r <= 2; r++ {
+		start := len(ordered)
+		for _, item := range items {
+			if rank[item.Action] == r {
+				ordered = append(ordered, item)
+			}
+		}
+
+		group := ordered[start:]
+		sort.SliceStable(group, func(i, j int) bool {
+			if r == rank[PlanDisable] {
+				return position[group[i].Name] > position[group[j].Name]
+			}
+			return position[group[i].Name] < position[group[j].Name]
+		})
+	}
+	return ordered
+}
+
+// planDependencyGraph 合并已加载插件和待安装插件清单中的依赖关系及优先级
+func (m *Manager) planDependencyGraph(plan *Plan) (map[string][]string, map[string]int) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	graph, priorities := m.dependencyGraph(), m.priorityMap()
+
+	for _, item := range plan.Items {
+		if _, loaded := m.plugins[item.Name]; item.Action != PlanInstall && (loaded || item.Source == "") {
+			continue
+		}
+		manifest, err := loadManifest(item.Source)
+		if err != nil || manifest == nil {
+			continue
+		}
+		var deps []string
+		for _, dep := range manifest.Dependencies {
+			deps = append(deps, dep.Name)
+		}
+		graph[item.Name] = deps
+		priorities[item.Name] = m.pluginPriority(item.Name, manifest, nil)
+	}
+	return graph, priorities
+}
+
+// applyPlanItem 执行单个计划项，返回对应的回滚步骤
+func (m *Manager) applyPlanItem(item PlanItem) ([]func(), error) {
+	var undo []func()
+
+	// 计划中启用或禁用的插件尚未安装时先从安装来源安装到插件目录
+	_, exists := m.GetPlugin(item.Name)
+	if item.Action == PlanInstall || (!exists && item.Source != "") {
+		info, err := m.InstallPlugin(item.Source)
+		if err != nil {
+			return nil, err
+		}
+		if info.Name != item.Name {
+			m.removeInstalledPlugin(info)
+			return nil, fmt.Errorf("安装来源 %s 提供的插件名称 %s 与计划不符", item.Source, info.Name)
+		}
+		if item.Version != "" && info.Version != item.Version {
+			m.removeInstalledPlugin(info)
+			return nil, fmt.Errorf("版本不匹配: 期望 %s, 实际 %s", item.Version, info.Version)
+		}
+
+		undo = append(undo, func() { m.removeInstalledPlugin(info) })
+	}
+
+	info, _ := m.GetPlugin(item.Name)
+
+	if item.Config != nil {
+		oldConfig := info.Config
+		if err := m.UpdatePluginConfig(item.Name, item.Config); err != nil {
+			return undo, err
+		}
+		undo = append(undo, func() {
+			if err := m.UpdatePluginConfig(item.Name, oldConfig); err != nil {
+				m.logger.Printf("回滚插件 %s 配置失败: %v", item.Name, err)
+			}
+		})
+	}
+
+	switch item.Action {
+	case PlanEnable:
+		if info.Enabled {
+			break
+		}
+		if err := m.EnablePlugin(item.Name); err != nil {
+			return undo, err
+		}
+		undo = append(undo, func() {
+			if err := m.DisablePlugin(item.Name); err != nil {
+				m.logger.Printf("回滚插件 %s 启用状态失败: %v", item.Name, err)
+			}
+		})
+	case PlanDisable:
+		if !info.Enabled {
+			break
+		}
+		if err := m.DisablePlugin(item.Name); err != nil {
+			return undo, err
+		}
+		undo = append(undo, func() {
+			if err := m.EnablePlugin(item.Name); err != nil {
+				m.logger.Printf("回滚插件 %s 禁用状态失败: %v", item.Name, err)
+			}
+		})
+	}
+
+	return undo, nil
+}
+
+// removeInstalledPlugin 关闭并移除计划安装的插件，删除安装到插件目录的文件以及存储中的记录和校验和（用于回滚安装）
+func (m *Manager) removeInstalledPlugin(info *PluginInfo) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.plugins[info.Name] == info {
+		if err := m.unloadPlugin(info.Name); err != nil {
+			m.logger.Printf("移除插件 %s 失败: %v", info.Name, err)
+		}
+	}
+	if lister, ok := m.store().(PluginListStorage); ok {
+		if err := lister.DeletePlugin(info.FilePath); err != nil {
+			m.logger.Printf("删除插件 %s 的存储记录失败: %v", info.Name, err)
+		}
+	}
+	m.forgetChecksum(info.FilePath)
+
+	if packageDirName(info.FilePath) != "" {
+		os.RemoveAll(filepath.Dir(info.FilePath))
+		return
+	}
+	for _, path := range pluginPackageFiles(info.FilePath) {
+		os.Remove(path)
+	}
+}
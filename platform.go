@@ -0,0 +1,80 @@
+package plugins
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+)
+
+// errNativeUnsupported 当前平台不支持Go原生插件时记录在插件状态原因中的错误
+var errNativeUnsupported = fmt.Errorf("当前平台(%s/%s)不支持Go原生插件，请改用独立进程插件(%s)或脚本插件",
+	runtime.GOOS, runtime.GOARCH, subprocessExtensionHint())
+
+// RuntimeSupport 插件运行时在当前平台上的可用性
+type RuntimeSupport struct {
+	Runtime    string   `json:"runtime"`
+	Extensions []string `json:"extensions"`
+	Available  bool     `json:"available"`
+	Reason     string   `json:"reason,omitempty"`
+}
+
+// PlatformReport 当前平台的插件支持情况
+type PlatformReport struct {
+	OS       string           `json:"os"`
+	Arch     string           `json:"arch"`
+	Runtimes []RuntimeSupport `json:"runtimes"`
+}
+
+// PlatformSupport 报告当前平台可用的插件运行时，供界面说明为什么某些插件文件无法加载
+func PlatformSupport() PlatformReport {
+	report := PlatformReport{OS: runtime.GOOS, Arch: runtime.GOARCH}
+
+	native := RuntimeSupport{Runtime: "native", Extensions: []string{goPluginExt}, Available: nativePluginsSupported}
+	if !nativePluginsSupported {
+		native.Reason = errNativeUnsupported.Error()
+	}
+	report.Runtimes = append(report.Runtimes,
+		native,
+		RuntimeSupport{Runtime: "subprocess", Extensions: subprocessExtensions(), Available: true},
+	)
+
+	loaderMutex.RLock()
+	exts := make([]string, 0, len(loaders))
+	for ext := range loaders {
+		exts = append(exts, ext)
+	}
+	loaderMutex.RUnlock()
+	sort.Strings(exts)
+
+	for _, ext := range exts {
+		name := "custom"
+		switch ext {
+		case LuaPluginExt:
+			name = "lua"
+		case JSPluginExt:
+			name = "javascript"
+		case WasmPluginExt:
+			name = "wasm"
+		}
+		report.Runtimes = append(report.Runtimes, RuntimeSupport{Runtime: name, Extensions: []string{ext}, Available: true})
+	}
+	return report
+}
+
+// subprocessExtensions 返回当前平台识别的独立进程插件后缀
+func subprocessExtensions() []string {
+	if runtime.GOOS == "windows" {
+		return []string{SubprocessPluginExt, windowsSubprocessSuffix}
+	}
+	return []string{SubprocessPluginExt}
+}
+
+// subprocessExtensionHint 返回独立进程插件后缀的提示文字
+func subprocessExtensionHint() string {
+	exts := subprocessExtensions()
+	hint := exts[0]
+	for _, ext := range exts[1:] {
+		hint += "/" + ext
+	}
+	return hint
+}
@@ -0,0 +1,149 @@
+package plugins
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// defaultPluginDir 默认插件目录
+	defaultPluginDir = "./plugins"
+
+	// PluginDirEnv 指定插件目录的环境变量，多个目录使用系统路径分隔符分隔，最后一个为可写的插件目录
+	PluginDirEnv = "SUBLINK_PLUGIN_DIR"
+)
+
+// pluginDirsFromEnv 从环境变量获取插件目录列表，未设置时返回默认目录
+func pluginDirsFromEnv() []string {
+	var dirs []string
+	for _, dir := range filepath.SplitList(os.Getenv(PluginDirEnv)) {
+		if dir != "" {
+			dirs = append(dirs, filepath.Clean(dir))
+		}
+	}
+	if len(dirs) == 0 {
+		return []string{defaultPluginDir}
+	}
+	return dirs
+}
+
+// validatePluginDir 校验插件目录：路径不能为空，已存在时必须是目录
+func validatePluginDir(dir string) (string, error) {
+	if dir == "" {
+		return "", fmt.Errorf("插件目录不能为空")
+	}
+
+	dir = filepath.Clean(dir)
+	info, err := os.Stat(dir)
+	if err == nil && !info.IsDir() {
+		return "", fmt.Errorf("插件目录不是目录: %s", dir)
+	}
+	if err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("无法访问插件目录 %s: %v", dir, err)
+	}
+	return dir, nil
+}
+
+// SetPluginDir 设置插件目录，需在LoadPlugins之前调用；目录不存在时会在加载时创建
+func (m *Manager) SetPluginDir(dir string) error {
+	if err := m.checkWritable(); err != nil {
+		return err
+	}
+
+	dir, err := validatePluginDir(dir)
+	if err != nil {
+		return err
+	}
+
+	if m.IsWatching() {
+		return fmt.Errorf("插件目录监听开启时不能修改插件目录")
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if len(m.plugins) > 0 {
+		return fmt.Errorf("插件已加载，不能修改插件目录")
+	}
+
+	m.pluginDir = dir
+	return nil
+}
+
+// SetPluginDirs 设置多个插件搜索目录（如内置插件目录和用户插件目录），需在LoadPlugins之前调用。
+// 同名插件以后面的目录为准；最后一个目录是可写的插件目录，安装和恢复的插件写入该目录
+func (m *Manager) SetPluginDirs(dirs ...string) error {
+	if err := m.checkWritable(); err != nil {
+		return err
+	}
+
+	if len(dirs) == 0 {
+		return fmt.Errorf("插件目录不能为空")
+	}
+
+	cleaned := make([]string, 0, len(dirs))
+	for _, dir := range dirs {
+		dir, err := validatePluginDir(dir)
+		if err != nil {
+			return err
+		}
+		cleaned = append(cleaned, dir)
+	}
+
+	if m.IsWatching() {
+		return fmt.Errorf("插件目录监听开启时不能修改插件目录")
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if len(m.plugins) > 0 {
+		return fmt.Errorf("插件已加载，不能修改插件目录")
+	}
+
+	m.searchDirs = cleaned[:len(cleaned)-1]
+	m.pluginDir = cleaned[len(cleaned)-1]
+	return nil
+}
+
+// GetPluginDir 获取可写的插件目录
+func (m *Manager) GetPluginDir() string {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	return m.pluginDir
+}
+
+// GetPluginDirs 按优先级从低到高获取所有插件搜索目录
+func (m *Manager) GetPluginDirs() []string {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	return m.pluginDirs()
+}
+
+// pluginDirs 按优先级从低到高返回所有插件搜索目录，调用方需持有m.mutex
+func (m *Manager) pluginDirs() []string {
+	dirs := make([]string, 0, len(m.searchDirs)+1)
+	dirs = append(dirs, m.searchDirs...)
+	return append(dirs, m.pluginDir)
+}
+
+// dirPriority 返回插件文件所在搜索目录的优先级，数值越大优先级越高，不在任何搜索目录中时返回-1，调用方需持有m.mutex
+func (m *Manager) dirPriority(pluginPath string) int {
+	priority, longest := -1, -1
+	clean := filepath.Clean(pluginPath)
+	for i, dir := range m.pluginDirs() {
+		dir = filepath.Clean(dir)
+		if clean != dir && !strings.HasPrefix(clean, dir+string(filepath.Separator)) {
+			continue
+		}
+		// 目录嵌套时以最深的目录为准
+		if len(dir) >= longest {
+			priority, longest = i, len(dir)
+		}
+	}
+	return priority
+}
@@ -0,0 +1,158 @@
+package pluginstest
+
+import (
+	"sync"
+
+	plugins "github.com/ZeroDeng01/sublinkPro-plugins"
+	"github.com/gin-gonic/gin"
+)
+
+// Event 模拟插件收到的一次事件
+type Event struct {
+	Type         plugins.EventType
+	Method       string
+	Path         string
+	StatusCode   int
+	RequestBody  interface{}
+	ResponseBody interface{}
+}
+
+// MockPlugin 记录收到的配置和事件的模拟插件，未设置的字段使用默认值：
+// 版本 1.0.0、订阅所有路径（prefix:/）以及api_success和api_error事件
+type MockPlugin struct {
+	PluginName    string
+	PluginVersion string
+	APIs          []string
+	Events        []plugins.EventType
+	Defaults      map[string]interface{}
+
+	// OnEvent 收到事件时调用（可选），返回的错误作为OnAPIEvent的结果
+	OnEvent func(ctx *gin.Context, event Event) error
+
+	config   map[string]interface{}
+	received []Event
+	inited   bool
+	closed   bool
+	mutex    sync.Mutex
+}
+
+// NewMockPlugin 创建指定名称的模拟插件
+func NewMockPlugin(name string) *MockPlugin {
+	return &MockPlugin{PluginName: name}
+}
+
+func (p *MockPlugin) Name() string {
+	return p.PluginName
+}
+
+func (p *MockPlugin) Version() string {
+	if p.PluginVersion == "" {
+		return "1.0.0"
+	}
+	return p.PluginVersion
+}
+
+func (p *MockPlugin) Description() string {
+	return "pluginstest模拟插件"
+}
+
+func (p *MockPlugin) DefaultConfig() map[string]interface{} {
+	result := make(map[string]interface{}, len(p.Defaults))
+	for k, v := range p.Defaults {
+		result[k] = v
+	}
+	return result
+}
+
+func (p *MockPlugin) SetConfig(config map[string]interface{}) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.config = config
+}
+
+func (p *MockPlugin) Init() error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.inited = true
+	p.closed = false
+	return nil
+}
+
+func (p *MockPlugin) Close() error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.closed = true
+	return nil
+}
+
+func (p *MockPlugin) OnAPIEvent(ctx *gin.Context, event plugins.EventType, path string, statusCode int, requestBody interface{}, responseBody interface{}) error {
+	received := Event{
+		Type:         event,
+		Path:         path,
+		StatusCode:   statusCode,
+		RequestBody:  requestBody,
+		ResponseBody: responseBody,
+	}
+	if ctx != nil && ctx.Request != nil {
+		received.Method = ctx.Request.Method
+	}
+
+	p.mutex.Lock()
+	p.received = append(p.received, received)
+	onEvent := p.OnEvent
+	p.mutex.Unlock()
+
+	if onEvent != nil {
+		return onEvent(ctx, received)
+	}
+	return nil
+}
+
+func (p *MockPlugin) InterestedAPIs() []string {
+	if len(p.APIs) == 0 {
+		return []string{plugins.PrefixMatch + "/"}
+	}
+	return p.APIs
+}
+
+func (p *MockPlugin) InterestedEvents() []plugins.EventType {
+	if len(p.Events) == 0 {
+		return []plugins.EventType{plugins.EventAPISuccess, plugins.EventAPIError}
+	}
+	return p.Events
+}
+
+// Received 获取已收到的事件
+func (p *MockPlugin) Received() []Event {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return append([]Event(nil), p.received...)
+}
+
+// Reset 清空已收到的事件
+func (p *MockPlugin) Reset() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.received = nil
+}
+
+// Config 获取最近一次收到的配置
+func (p *MockPlugin) Config() map[string]interface{} {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return p.config
+}
+
+// Inited 插件是否已初始化且未关闭
+func (p *MockPlugin) Inited() bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return p.inited && !p.closed
+}
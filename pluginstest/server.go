@@ -0,0 +1,246 @@
+// Package pluginstest 提供宿主的端到端测试工具：在临时插件目录和内存存储上启动带插件中间件的Gin服务，
+// 加载模拟插件或真实插件文件，无需部署即可测试路由和插件处理的结果。
+// 每个测试服务使用独立的插件管理器，测试可以并行执行
+package pluginstest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	plugins "github.com/ZeroDeng01/sublinkPro-plugins"
+	"github.com/gin-gonic/gin"
+)
+
+// mockExt 模拟插件文件的扩展名，文件内容为插件名称，加载时返回登记的插件实例
+const mockExt = ".pluginstest"
+
+// defaultWaitTimeout 等待异步事件处理完成的默认超时时间
+const defaultWaitTimeout = 5 * time.Second
+
+var (
+	mockPlugins  = make(map[string]plugins.Plugin) // 模拟插件文件路径 -> 插件实例
+	mockMutex    sync.RWMutex
+	registerOnce sync.Once
+)
+
+// loadMock 加载模拟插件文件
+func loadMock(path string) (plugins.Plugin, error) {
+	mockMutex.RLock()
+	defer mockMutex.RUnlock()
+
+	instance, exists := mockPlugins[filepath.Clean(path)]
+	if !exists {
+		return nil, fmt.Errorf("未登记的模拟插件: %s", path)
+	}
+	return instance, nil
+}
+
+// Options 测试服务的选项
+type Options struct {
+	// Plugins 以模拟插件文件加载的插件实例，可以是MockPlugin或宿主在测试中直接构造的插件
+	Plugins []plugins.Plugin
+
+	// Files 复制到插件目录后加载的真实插件文件，同名的清单和签名文件一并复制
+	Files []string
+
+	// Config 插件名称 -> 加载后设置的配置
+	Config map[string]map[string]interface{}
+
+	// Routes 注册宿主的业务路由（可选），在插件中间件之后注册
+	Routes func(engine *gin.Engine)
+
+	// ManagementRoutes 是否注册插件管理路由
+	ManagementRoutes bool
+
+	// Storage 使用的存储（可选），为空时使用新的MemoryStorage
+	Storage plugins.PluginStorage
+
+	// WaitTimeout Do等待异步事件处理完成的超时时间，默认5秒
+	WaitTimeout time.Duration
+}
+
+// Server 测试服务
+type Server struct {
+	Engine  *gin.Engine
+	Manager *plugins.Manager
+	Storage plugins.PluginStorage
+	Dir     string // 临时插件目录
+
+	t           testing.TB
+	waitTimeout time.Duration
+}
+
+// NewTestServer 启动测试服务：创建使用临时插件目录和内存存储的插件管理器，以全部启用的策略加载插件，
+// 并创建使用插件中间件的Gin引擎。测试结束时卸载所有插件
+func NewTestServer(t testing.TB, opts Options) *Server {
+	t.Helper()
+
+	registerOnce.Do(func() {
+		if err := plugins.RegisterLoader(mockExt, plugins.PluginLoaderFunc(loadMock)); err != nil {
+			panic(err)
+		}
+	})
+
+	store := opts.Storage
+	if store == nil {
+		store = NewMemoryStorage()
+	}
+	waitTimeout := opts.WaitTimeout
+	if waitTimeout <= 0 {
+		waitTimeout = defaultWaitTimeout
+	}
+
+	dir := t.TempDir()
+	m := plugins.NewManager(plugins.WithPluginDir(dir), plugins.WithStorage(store))
+	if err := m.SetStartupPolicy(plugins.StartupEnableAll); err != nil {
+		t.Fatalf("设置启动策略失败: %v", err)
+	}
+
+	var mockPaths []string
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), waitTimeout)
+		defer cancel()
+		if err := m.WaitIdle(ctx); err != nil {
+			t.Errorf("等待插件事件处理完成失败: %v", err)
+		}
+		for name := range m.GetAllPlugins() {
+			if err := m.UnloadPlugin(name); err != nil {
+				t.Errorf("卸载插件 %s 失败: %v", name, err)
+			}
+		}
+
+		mockMutex.Lock()
+		for _, path := range mockPaths {
+			delete(mockPlugins, path)
+		}
+		mockMutex.Unlock()
+	})
+
+	for _, instance := range opts.Plugins {
+		path := filepath.Join(dir, instance.Name()+mockExt)
+		if err := os.WriteFile(path, []byte(instance.Name()), 0644); err != nil {
+			t.Fatalf("写入模拟插件文件失败: %v", err)
+		}
+		mockMutex.Lock()
+		mockPlugins[path] = instance
+		mockMutex.Unlock()
+		mockPaths = append(mockPaths, path)
+	}
+	for _, file := range opts.Files {
+		if err := copyPluginFile(file, dir); err != nil {
+			t.Fatalf("复制插件文件失败: %v", err)
+		}
+	}
+
+	if err := m.LoadPlugins(context.Background()); err != nil {
+		t.Fatalf("加载插件失败: %v", err)
+	}
+	for name, config := range opts.Config {
+		if err := m.UpdatePluginConfig(name, config); err != nil {
+			t.Fatalf("设置插件 %s 的配置失败: %v", name, err)
+		}
+	}
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(m.Middleware())
+	if opts.Routes != nil {
+		opts.Routes(engine)
+	}
+	if opts.ManagementRoutes {
+		m.RegisterGinRoutes(engine)
+	}
+
+	return &Server{
+		Engine:      engine,
+		Manager:     m,
+		Storage:     store,
+		Dir:         dir,
+		t:           t,
+		waitTimeout: waitTimeout,
+	}
+}
+
+// Do 处理请求并等待插件的异步事件处理完成，返回响应记录
+func (s *Server) Do(req *http.Request) *httptest.ResponseRecorder {
+	s.t.Helper()
+
+	recorder := httptest.NewRecorder()
+	s.Engine.ServeHTTP(recorder, req)
+	s.Wait()
+	return recorder
+}
+
+// Request 构造请求并调用Do，body为nil时请求没有请求体
+func (s *Server) Request(method, target string, body io.Reader) *httptest.ResponseRecorder {
+	s.t.Helper()
+
+	req := httptest.NewRequest(method, target, body)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return s.Do(req)
+}
+
+// Wait 等待插件的异步事件处理完成，超时时测试失败
+func (s *Server) Wait() {
+	s.t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.waitTimeout)
+	defer cancel()
+	if err := s.Manager.WaitIdle(ctx); err != nil {
+		s.t.Fatalf("等待插件事件处理完成失败: %v", err)
+	}
+}
+
+// copyPluginFile 将插件文件及其清单（<文件名>.plugin.json）和签名（<文件>.sig）复制到插件目录
+func copyPluginFile(src, dir string) error {
+	target := filepath.Join(dir, filepath.Base(src))
+	if err := copyFile(src, target); err != nil {
+		return err
+	}
+	sidecars := map[string]string{
+		strings.TrimSuffix(src, filepath.Ext(src)) + ".plugin.json": strings.TrimSuffix(target, filepath.Ext(target)) + ".plugin.json",
+		src + ".sig": target + ".sig",
+	}
+	for from, to := range sidecars {
+		if _, err := os.Stat(from); err != nil {
+			continue
+		}
+		if err := copyFile(from, to); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	stat, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, stat.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
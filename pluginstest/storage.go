@@ -0,0 +1,115 @@
+package pluginstest
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	plugins "github.com/ZeroDeng01/sublinkPro-plugins"
+)
+
+// MemoryStorage 内存中的插件存储，实现PluginStorage以及插件列表、状态和数据的扩展接口
+type MemoryStorage struct {
+	records map[string]*plugins.PluginStorageInfo // 插件路径 -> 插件记录
+	data    map[string]map[string]string          // 插件名称 -> KV数据
+	mutex   sync.RWMutex
+}
+
+// NewMemoryStorage 创建内存存储
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{
+		records: make(map[string]*plugins.PluginStorageInfo),
+		data:    make(map[string]map[string]string),
+	}
+}
+
+func (s *MemoryStorage) GetPlugin(path string) (*plugins.PluginStorageInfo, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	record, exists := s.records[path]
+	if !exists {
+		return nil, nil
+	}
+	copied := *record
+	return &copied, nil
+}
+
+func (s *MemoryStorage) SavePlugin(name, path string, enabled bool, config map[string]interface{}) error {
+	configJSON, err := json.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("序列化插件配置失败: %v", err)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	record, exists := s.records[path]
+	if !exists {
+		record = &plugins.PluginStorageInfo{Path: path}
+		s.records[path] = record
+	}
+	record.Name = name
+	record.Enabled = enabled
+	record.Config = string(configJSON)
+	return nil
+}
+
+func (s *MemoryStorage) SavePluginState(path string, state plugins.PluginState, reason string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	record, exists := s.records[path]
+	if !exists {
+		record = &plugins.PluginStorageInfo{Path: path}
+		s.records[path] = record
+	}
+	record.State = string(state)
+	record.StateReason = reason
+	return nil
+}
+
+func (s *MemoryStorage) ListPlugins() ([]*plugins.PluginStorageInfo, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	result := make([]*plugins.PluginStorageInfo, 0, len(s.records))
+	for _, record := range s.records {
+		copied := *record
+		result = append(result, &copied)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Path < result[j].Path })
+	return result, nil
+}
+
+func (s *MemoryStorage) DeletePlugin(path string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.records, path)
+	return nil
+}
+
+func (s *MemoryStorage) ExportPluginData(name string) (map[string]string, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	result := make(map[string]string, len(s.data[name]))
+	for k, v := range s.data[name] {
+		result[k] = v
+	}
+	return result, nil
+}
+
+func (s *MemoryStorage) ImportPluginData(name string, data map[string]string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	copied := make(map[string]string, len(data))
+	for k, v := range data {
+		copied[k] = v
+	}
+	s.data[name] = copied
+	return nil
+}
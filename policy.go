@@ -0,0 +1,147 @@
+package plugins
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// ErrPluginNotAllowed 插件被允许/禁止列表策略拒绝
+var ErrPluginNotAllowed = errors.New("插件被策略禁止")
+
+// PluginPolicy 插件允许/禁止列表，条目为插件名称或插件文件的SHA-256（可带 sha256: 前缀）。
+// 允许列表为空表示不限制；同时命中时以禁止列表为准
+type PluginPolicy struct {
+	AllowedPlugins []string `json:"allowed_plugins" yaml:"allowed_plugins"`
+	BlockedPlugins []string `json:"blocked_plugins" yaml:"blocked_plugins"`
+}
+
+// pluginPolicy 生效中的插件策略
+type pluginPolicy struct {
+	policy PluginPolicy
+	mutex  sync.RWMutex
+}
+
+// SetPluginPolicy 设置插件允许/禁止列表，已启用但不再被允许的插件会被禁用
+func (m *Manager) SetPluginPolicy(policy PluginPolicy) error {
+	if err := m.checkWritable(); err != nil {
+		return err
+	}
+
+	m.policy.mutex.Lock()
+	m.policy.policy = PluginPolicy{
+		AllowedPlugins: normalizePolicyEntries(policy.AllowedPlugins),
+		BlockedPlugins: normalizePolicyEntries(policy.BlockedPlugins),
+	}
+	m.policy.mutex.Unlock()
+
+	m.mutex.RLock()
+	var denied []string
+	for name, info := range m.plugins {
+		if info.Enabled && m.checkPolicy(name, info.FilePath) != nil {
+			denied = append(denied, name)
+		}
+	}
+	m.mutex.RUnlock()
+
+	for _, name := range denied {
+		if err := m.DisablePlugin(name); err != nil {
+			m.logger.Printf("禁用被策略禁止的插件 %s 失败: %v", name, err)
+			continue
+		}
+		m.logger.Printf("插件 %s 被策略禁止，已禁用", name)
+	}
+	return nil
+}
+
+// GetPluginPolicy 获取插件允许/禁止列表
+func (m *Manager) GetPluginPolicy() PluginPolicy {
+	m.policy.mutex.RLock()
+	defer m.policy.mutex.RUnlock()
+
+	return PluginPolicy{
+		AllowedPlugins: append([]string(nil), m.policy.policy.AllowedPlugins...),
+		BlockedPlugins: append([]string(nil), m.policy.policy.BlockedPlugins...),
+	}
+}
+
+// checkPolicy 按允许/禁止列表检查插件，name为空时只按文件哈希检查禁止列表（用于打开插件文件之前）
+func (m *Manager) checkPolicy(name, pluginPath string) error {
+	m.policy.mutex.RLock()
+	policy := m.policy.policy
+	m.policy.mutex.RUnlock()
+
+	if len(policy.AllowedPlugins) == 0 && len(policy.BlockedPlugins) == 0 {
+		return nil
+	}
+
+	var sum string
+	if hasHashEntry(policy.AllowedPlugins) || hasHashEntry(policy.BlockedPlugins) {
+		var err error
+		if sum, err = fileSHA256(pluginPath); err != nil {
+			return fmt.Errorf("计算插件文件校验和失败: %v", err)
+		}
+	}
+
+	if policyMatches(policy.BlockedPlugins, name, sum) {
+		return fmt.Errorf("%w: %s 在禁止列表中", ErrPluginNotAllowed, pluginLabel(name, pluginPath))
+	}
+	if name != "" && len(policy.AllowedPlugins) > 0 && !policyMatches(policy.AllowedPlugins, name, sum) {
+		return fmt.Errorf("%w: %s 不在允许列表中", ErrPluginNotAllowed, pluginLabel(name, pluginPath))
+	}
+	return nil
+}
+
+// normalizePolicyEntries 去掉哈希条目的 sha256: 前缀并统一为小写
+func normalizePolicyEntries(entries []string) []string {
+	result := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if hash := strings.TrimPrefix(strings.ToLower(entry), "sha256:"); isHashEntry(hash) {
+			entry = hash
+		}
+		result = append(result, entry)
+	}
+	return result
+}
+
+// policyMatches 判断名称或文件哈希是否命中列表
+func policyMatches(entries []string, name, sum string) bool {
+	for _, entry := range entries {
+		if (name != "" && entry == name) || (sum != "" && entry == sum) {
+			return true
+		}
+	}
+	return false
+}
+
+// isHashEntry 判断条目是否为SHA-256十六进制字符串
+func isHashEntry(entry string) bool {
+	if len(entry) != 64 {
+		return false
+	}
+	_, err := hex.DecodeString(entry)
+	return err == nil
+}
+
+func hasHashEntry(entries []string) bool {
+	for _, entry := range entries {
+		if isHashEntry(entry) {
+			return true
+		}
+	}
+	return false
+}
+
+// pluginLabel 错误信息中的插件标识
+func pluginLabel(name, pluginPath string) string {
+	if name != "" {
+		return name
+	}
+	return pluginPath
+}
@@ -0,0 +1,99 @@
+package plugins
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Prioritizer 声明优先级的插件实现此接口（可选实现），优先级高的插件先初始化、先接收事件；
+// 清单中的priority优先于此接口，管理员通过SetPluginPriority设置的优先级最高
+type Prioritizer interface {
+	Priority() int
+}
+
+// pluginPriority 计算插件的优先级：管理员设置 > 清单 > Prioritizer > 0，调用方需持有m.mutex
+func (m *Manager) pluginPriority(name string, manifest *PluginManifest, p Plugin) int {
+	if priority, exists := m.priorities[name]; exists {
+		return priority
+	}
+	if manifest != nil && manifest.Priority != nil {
+		return *manifest.Priority
+	}
+	if prioritizer, ok := p.(Prioritizer); ok {
+		return prioritizer.Priority()
+	}
+	return 0
+}
+
+// SetPluginPriority 设置插件优先级，覆盖清单和插件自身声明的优先级，插件重新加载后仍然有效；
+// 新的优先级立即影响事件分发顺序
+func (m *Manager) SetPluginPriority(name string, priority int) error {
+	if err := m.checkWritable(); err != nil {
+		return err
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	info, exists := m.plugins[name]
+	if !exists {
+		return fmt.Errorf("插件不存在: %s", name)
+	}
+
+	m.priorities[name] = priority
+	info.Priority = priority
+	return nil
+}
+
+// ClearPluginPriority 清除管理员设置的插件优先级，恢复为清单或插件自身声明的优先级
+func (m *Manager) ClearPluginPriority(name string) error {
+	if err := m.checkWritable(); err != nil {
+		return err
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	delete(m.priorities, name)
+	if info, exists := m.plugins[name]; exists {
+		info.Priority = m.pluginPriority(name, info.Manifest, info.Plugin)
+	}
+	return nil
+}
+
+// priorityMap 返回已加载插件的优先级，调用方需持有m.mutex
+func (m *Manager) priorityMap() map[string]int {
+	priorities := make(map[string]int, len(m.plugins))
+	for name, info := range m.plugins {
+		priorities[name] = info.Priority
+	}
+	return priorities
+}
+
+// sortByPriority 按优先级从高到低排序插件，优先级相同时按名称排序
+func sortByPriority(infos []*PluginInfo) {
+	sort.SliceStable(infos, func(i, j int) bool {
+		if infos[i].Priority != infos[j].Priority {
+			return infos[i].Priority > infos[j].Priority
+		}
+		return infos[i].Name < infos[j].Name
+	})
+}
+
+// sortPathsByPriority 按清单或管理员设置的优先级从高到低排序同一目录中的插件文件，
+// 打开插件文件前无法得到Prioritizer声明的优先级，调用方需持有m.mutex
+func (m *Manager) sortPathsByPriority(paths []string) {
+	priorities := make(map[string]int, len(paths))
+	for _, path := range paths {
+		name := artifactName(path)
+		manifest, _ := loadManifest(path)
+		if manifest != nil && manifest.Name != "" {
+			name = manifest.Name
+		}
+		priorities[path] = m.pluginPriority(name, manifest, nil)
+	}
+
+	sort.SliceStable(paths, func(i, j int) bool {
+		return priorities[paths[i]] > priorities[paths[j]]
+	})
+}
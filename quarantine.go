@@ -0,0 +1,290 @@
+package plugins
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// quarantineDirName 插件目录中存放加载失败的插件文件的子目录，加载和监听时跳过
+	quarantineDirName = "quarantine"
+
+	// quarantineMetaSuffix 隔离记录文件的后缀，与被隔离的插件文件放在一起
+	quarantineMetaSuffix = ".quarantine.json"
+)
+
+// QuarantineRecord 加载失败的插件文件的隔离记录
+type QuarantineRecord struct {
+	FileName      string    `json:"file_name"`
+	Name          string    `json:"name,omitempty"` // 清单中声明的插件名称，清单无法解析时为空
+	OriginalPath  string    `json:"original_path"`
+	Reason        string    `json:"reason"`
+	Moved         bool      `json:"moved"` // 是否已移入隔离目录，只读搜索目录中的文件只记录不移动
+	QuarantinedAt time.Time `json:"quarantined_at"`
+}
+
+// QuarantineStorage 隔离记录存储扩展接口（可选实现），实现后宿主可以在管理界面中展示插件无法使用的原因
+type QuarantineStorage interface {
+	// SaveQuarantineRecord 保存隔离记录，文件名相同时覆盖
+	SaveQuarantineRecord(record QuarantineRecord) error
+
+	// DeleteQuarantineRecord 删除隔离记录
+	DeleteQuarantineRecord(fileName string) error
+}
+
+// loadFailure 插件文件本身有问题导致的加载失败（校验和不一致、清单无效、文件格式损坏或缺少导出符号），加载插件目录时会被隔离。
+// 签名策略为跳过时的签名错误、进程启动失败等可能是暂时的问题，不属于文件本身的问题，不会被隔离
+type loadFailure struct {
+	err error
+}
+
+func (e *loadFailure) Error() string { return e.err.Error() }
+func (e *loadFailure) Unwrap() error { return e.err }
+
+// fileFailure 将错误标记为插件文件本身的问题，err为nil时返回nil
+func fileFailure(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &loadFailure{err: err}
+}
+
+// malformedBinaryErrors plugin.Open返回的表示文件格式损坏的错误片段
+var malformedBinaryErrors = []string{
+	"invalid ELF header",
+	"file too short",
+	"wrong ELF class",
+	"not a dynamic executable",
+	"only ET_DYN and ET_EXEC can be loaded",
+	"not a mach-o file",
+}
+
+// isMalformedBinary 判断plugin.Open的错误是否由文件格式损坏导致
+func isMalformedBinary(err error) bool {
+	msg := err.Error()
+	for _, fragment := range malformedBinaryErrors {
+		if strings.Contains(msg, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+// isFileFailure 判断加载错误是否由插件文件本身的问题导致
+func isFileFailure(err error) bool {
+	var failure *loadFailure
+	return errors.As(err, &failure)
+}
+
+// quarantineRoot 隔离目录，位于可写的插件目录中，调用方需持有m.mutex
+func (m *Manager) quarantineRoot() string {
+	return filepath.Join(m.pluginDir, quarantineDirName)
+}
+
+// isQuarantineRoot 判断目录是否为隔离目录，调用方需持有m.mutex
+func (m *Manager) isQuarantineRoot(dir string) bool {
+	return filepath.Clean(dir) == filepath.Clean(m.quarantineRoot())
+}
+
+// quarantineFile 将加载失败的插件文件及其清单、签名移入隔离目录，并记录失败原因；
+// 不在可写插件目录中的文件只记录原因，调用方需持有m.mutex
+func (m *Manager) quarantineFile(pluginPath string, reason error) {
+	root := m.quarantineRoot()
+	if err := os.MkdirAll(root, 0755); err != nil {
+		m.logger.Printf("创建隔离目录失败: %v", err)
+		return
+	}
+
+	record := QuarantineRecord{
+		FileName:      filepath.Base(pluginPath),
+		OriginalPath:  pluginPath,
+		Reason:        reason.Error(),
+		QuarantinedAt: time.Now(),
+	}
+	if manifest, err := loadManifest(pluginPath); err == nil && manifest != nil {
+		record.Name = manifest.Name
+	}
+
+	if m.isWritableDirFile(pluginPath) {
+		for _, src := range pluginPackageFiles(pluginPath) {
+			if err := moveFile(src, filepath.Join(root, filepath.Base(src))); err != nil {
+				m.logger.Printf("移动插件文件到隔离目录失败: %v", err)
+				break
+			}
+			record.Moved = true
+		}
+	}
+
+	if err := m.saveQuarantineRecord(record); err != nil {
+		m.logger.Printf("保存插件 %s 的隔离记录失败: %v", record.FileName, err)
+		return
+	}
+	if record.Moved {
+		m.logger.Printf("插件文件 %s 加载失败，已移入隔离目录: %v", record.FileName, reason)
+	} else {
+		m.logger.Printf("插件文件 %s 加载失败，已记录隔离原因: %v", record.FileName, reason)
+	}
+}
+
+// isWritableDirFile 判断文件是否位于可写的插件目录中（隔离目录除外），调用方需持有m.mutex
+func (m *Manager) isWritableDirFile(pluginPath string) bool {
+	dir := filepath.Clean(m.pluginDir) + string(filepath.Separator)
+	clean := filepath.Clean(pluginPath)
+	return strings.HasPrefix(clean, dir) && !strings.HasPrefix(clean, filepath.Clean(m.quarantineRoot())+string(filepath.Separator))
+}
+
+// saveQuarantineRecord 写入隔离记录文件，存储实现QuarantineStorage时同时写入存储，调用方需持有m.mutex
+func (m *Manager) saveQuarantineRecord(record QuarantineRecord) error {
+	data, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化隔离记录失败: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(m.quarantineRoot(), record.FileName+quarantineMetaSuffix), data, 0644); err != nil {
+		return fmt.Errorf("写入隔离记录失败: %v", err)
+	}
+
+	if quarantineStorage, ok := m.store().(QuarantineStorage); ok {
+		if err := quarantineStorage.SaveQuarantineRecord(record); err != nil {
+			return fmt.Errorf("保存隔离记录到存储失败: %v", err)
+		}
+	}
+	return nil
+}
+
+// removeQuarantineRecord 删除隔离记录文件及存储中的记录，调用方需持有m.mutex
+func (m *Manager) removeQuarantineRecord(fileName string) {
+	if err := os.Remove(filepath.Join(m.quarantineRoot(), fileName+quarantineMetaSuffix)); err != nil && !os.IsNotExist(err) {
+		m.logger.Printf("删除隔离记录失败: %v", err)
+	}
+	if quarantineStorage, ok := m.store().(QuarantineStorage); ok {
+		if err := quarantineStorage.DeleteQuarantineRecord(fileName); err != nil {
+			m.logger.Printf("删除存储中的隔离记录失败: %v", err)
+		}
+	}
+}
+
+// QuarantinedFiles 列出因加载失败被隔离的插件文件，按隔离时间从新到旧排列
+func (m *Manager) QuarantinedFiles() ([]QuarantineRecord, error) {
+	m.mutex.RLock()
+	root := m.quarantineRoot()
+	m.mutex.RUnlock()
+
+	return readQuarantineRecords(root, m.logger)
+}
+
+// readQuarantineRecords 读取隔离目录中的隔离记录
+func readQuarantineRecords(root string, logger Logger) ([]QuarantineRecord, error) {
+	entries, err := os.ReadDir(root)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取隔离目录失败: %v", err)
+	}
+
+	var records []QuarantineRecord
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), quarantineMetaSuffix) {
+			continue
+		}
+		record, err := readQuarantineRecord(filepath.Join(root, entry.Name()))
+		if err != nil {
+			logger.Printf("读取隔离记录 %s 失败: %v", entry.Name(), err)
+			continue
+		}
+		records = append(records, *record)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].QuarantinedAt.After(records[j].QuarantinedAt)
+	})
+	return records, nil
+}
+
+// readQuarantineRecord 读取单个隔离记录文件
+func readQuarantineRecord(path string) (*QuarantineRecord, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var record QuarantineRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// RestoreQuarantinedFile 将被隔离的插件文件移回原位置并重新加载，加载仍然失败时重新隔离并更新原因。
+// 因校验和不一致被隔离的文件需先通过SetAllowModifiedPlugins允许加载，或重新安装
+func (m *Manager) RestoreQuarantinedFile(fileName string) (*PluginInfo, error) {
+	if err := m.checkWritable(); err != nil {
+		return nil, err
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	root := m.quarantineRoot()
+	record, err := readQuarantineRecord(filepath.Join(root, filepath.Base(fileName)+quarantineMetaSuffix))
+	if err != nil {
+		return nil, fmt.Errorf("找不到插件文件 %s 的隔离记录: %v", fileName, err)
+	}
+
+	if record.Moved {
+		if _, err := os.Stat(record.OriginalPath); err == nil {
+			return nil, fmt.Errorf("插件文件已存在: %s", record.OriginalPath)
+		}
+		if err := os.MkdirAll(filepath.Dir(record.OriginalPath), 0755); err != nil {
+			return nil, fmt.Errorf("创建插件目录失败: %v", err)
+		}
+		dir := filepath.Dir(record.OriginalPath)
+		for _, src := range pluginPackageFiles(filepath.Join(root, record.FileName)) {
+			if err := moveFile(src, filepath.Join(dir, filepath.Base(src))); err != nil {
+				return nil, err
+			}
+		}
+	}
+	m.removeQuarantineRecord(record.FileName)
+
+	info, err := m.loadPlugin(record.OriginalPath)
+	if err != nil {
+		if isFileFailure(err) {
+			m.quarantineFile(record.OriginalPath, err)
+		}
+		return info, fmt.Errorf("加载插件失败: %v", err)
+	}
+
+	m.logger.Printf("已从隔离目录恢复插件: %s", info.Name)
+	return info, nil
+}
+
+// DeleteQuarantinedFile 删除被隔离的插件文件及其隔离记录，未移入隔离目录的文件只删除记录
+func (m *Manager) DeleteQuarantinedFile(fileName string) error {
+	if err := m.checkWritable(); err != nil {
+		return err
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	root := m.quarantineRoot()
+	record, err := readQuarantineRecord(filepath.Join(root, filepath.Base(fileName)+quarantineMetaSuffix))
+	if err != nil {
+		return fmt.Errorf("找不到插件文件 %s 的隔离记录: %v", fileName, err)
+	}
+
+	if record.Moved {
+		for _, path := range pluginPackageFiles(filepath.Join(root, record.FileName)) {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("删除被隔离的插件文件失败: %v", err)
+			}
+		}
+	}
+	m.removeQuarantineRecord(record.FileName)
+	return nil
+}
@@ -0,0 +1,97 @@
+package plugins
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Readiness 插件运行时自报的就绪状态
+type Readiness string
+
+const (
+	ReadinessReady      Readiness = "ready"      // 正常
+	ReadinessDegraded   Readiness = "degraded"   // 降级，如依赖的外部服务不可用
+	ReadinessRecovering Readiness = "recovering" // 正在恢复
+)
+
+// PluginReadiness 插件的就绪状态
+type PluginReadiness struct {
+	Readiness Readiness
+	Reason    string
+	Since     time.Time
+}
+
+// readinessTracker 插件就绪状态，独立于m.mutex，插件在Init中上报时不会死锁
+type readinessTracker struct {
+	states map[string]PluginReadiness
+	mutex  sync.RWMutex
+}
+
+func newReadinessTracker() *readinessTracker {
+	return &readinessTracker{states: make(map[string]PluginReadiness)}
+}
+
+// ReportReadiness 上报插件就绪状态，降级的插件不参与同步处理
+func (h *pluginHost) ReportReadiness(readiness Readiness, reason string) error {
+	return h.m.setReadiness(h.name, readiness, reason)
+}
+
+// setReadiness 更新插件就绪状态
+func (m *Manager) setReadiness(name string, readiness Readiness, reason string) error {
+	switch readiness {
+	case ReadinessReady, ReadinessDegraded, ReadinessRecovering:
+	default:
+		return fmt.Errorf("未知的就绪状态: %s", readiness)
+	}
+
+	m.readiness.mutex.Lock()
+	defer m.readiness.mutex.Unlock()
+
+	current, exists := m.readiness.states[name]
+	if exists && current.Readiness == readiness && current.Reason == reason {
+		return nil
+	}
+
+	m.readiness.states[name] = PluginReadiness{Readiness: readiness, Reason: reason, Since: time.Now()}
+	if readiness != ReadinessReady || exists {
+		m.logger.Printf("插件 %s 上报状态: %s %s", name, readiness, reason)
+	}
+	return nil
+}
+
+// clearReadiness 插件关闭后清除就绪状态
+func (m *Manager) clearReadiness(name string) {
+	m.readiness.mutex.Lock()
+	defer m.readiness.mutex.Unlock()
+
+	delete(m.readiness.states, name)
+}
+
+// GetPluginReadiness 获取插件就绪状态，插件未上报时视为就绪
+func (m *Manager) GetPluginReadiness(name string) PluginReadiness {
+	m.readiness.mutex.RLock()
+	defer m.readiness.mutex.RUnlock()
+
+	if state, exists := m.readiness.states[name]; exists {
+		return state
+	}
+	return PluginReadiness{Readiness: ReadinessReady}
+}
+
+// AllPluginReadiness 获取所有上报过状态的插件的就绪状态
+func (m *Manager) AllPluginReadiness() map[string]PluginReadiness {
+	m.readiness.mutex.RLock()
+	defer m.readiness.mutex.RUnlock()
+
+	result := make(map[string]PluginReadiness, len(m.readiness.states))
+	for name, state := range m.readiness.states {
+		result[name] = state
+	}
+	return result
+}
+
+// isDegraded 插件是否处于降级状态
+func (m *Manager) isDegraded(name string) bool {
+	return m.GetPluginReadiness(name).Readiness == ReadinessDegraded
+}
@@ -0,0 +1,267 @@
+package plugins
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// ReconcileReport Reconcile的结果
+type ReconcileReport struct {
+	Enabled       []string  `json:"enabled,omitempty"`        // 按存储启用的插件
+	Disabled      []string  `json:"disabled,omitempty"`       // 按存储禁用的插件
+	ConfigUpdated []string  `json:"config_updated,omitempty"` // 重新读取配置的插件
+	Unloaded      []string  `json:"unloaded,omitempty"`       // 文件已删除而卸载的插件
+	Purged        []string  `json:"purged,omitempty"`         // 删除的插件记录（文件路径）
+	Errors        []string  `json:"errors,omitempty"`
+	StartedAt     time.Time `json:"started_at"`
+	Duration      string    `json:"duration"`
+}
+
+// Changed 是否有任何变化
+func (r *ReconcileReport) Changed() bool {
+	return len(r.Enabled)+len(r.Disabled)+len(r.ConfigUpdated)+len(r.Unloaded)+len(r.Purged) > 0
+}
+
+// reconciler 定时同步的状态
+type reconciler struct {
+	stop  chan struct{} // 定时同步未开启时为nil
+	mutex sync.Mutex
+	run   sync.Mutex // 同一时间只执行一次同步
+}
+
+func newReconciler() *reconciler {
+	return &reconciler{}
+}
+
+// reconcileTarget 同步开始时记录的插件状态
+type reconcileTarget struct {
+	name    string
+	path    string
+	state   PluginState
+	enabled bool
+	config  map[string]interface{}
+}
+
+// Reconcile 对比内存中的插件与存储记录和插件文件并使两者一致：存储中启用状态被外部修改的插件按存储启用或禁用，
+// 存储中配置变化的插件重新读取配置，文件已删除的插件卸载并删除记录，同时清理文件已丢失的插件记录。
+// 只处理已启用和已禁用的插件，隔离、不兼容、等待批准等状态的插件保持不变；单个插件失败不影响其他插件
+func (m *Manager) Reconcile(ctx context.Context) (*ReconcileReport, error) {
+	if err := m.checkWritable(); err != nil {
+		return nil, err
+	}
+
+	m.reconciler.run.Lock()
+	defer m.reconciler.run.Unlock()
+
+	report := &ReconcileReport{StartedAt: time.Now()}
+	defer func() {
+		report.Duration = time.Since(report.StartedAt).String()
+	}()
+
+	m.mutex.RLock()
+	targets := make([]reconcileTarget, 0, len(m.plugins))
+	for _, info := range m.plugins {
+		if info.dormant || info.lazy || info.enabling {
+			continue
+		}
+		targets = append(targets, reconcileTarget{
+			name:    info.Name,
+			path:    info.FilePath,
+			state:   info.State,
+			enabled: info.Enabled,
+			config:  info.Config,
+		})
+	}
+	m.mutex.RUnlock()
+
+	for _, target := range targets {
+		if err := ctx.Err(); err != nil {
+			return report, fmt.Errorf("同步插件状态已取消: %w", err)
+		}
+		if err := m.reconcilePlugin(target, report); err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("%s: %v", target.name, err))
+		}
+	}
+
+	if err := m.purgeMissingRecords(report); err != nil {
+		report.Errors = append(report.Errors, err.Error())
+	}
+
+	if report.Changed() {
+		m.logger.Printf("同步插件状态: 启用 %d，禁用 %d，更新配置 %d，卸载 %d，删除记录 %d",
+			len(report.Enabled), len(report.Disabled), len(report.ConfigUpdated), len(report.Unloaded), len(report.Purged))
+	}
+	if len(report.Errors) > 0 {
+		return report, fmt.Errorf("同步插件状态时有 %d 个错误", len(report.Errors))
+	}
+	return report, nil
+}
+
+// reconcilePlugin 同步单个插件
+func (m *Manager) reconcilePlugin(target reconcileTarget, report *ReconcileReport) error {
+	// 文件已删除：卸载插件并删除记录
+	if _, err := os.Stat(target.path); os.IsNotExist(err) {
+		if err := m.UnloadPlugin(target.name); err != nil {
+			return fmt.Errorf("卸载文件已删除的插件失败: %v", err)
+		}
+		report.Unloaded = append(report.Unloaded, target.name)
+		if lister, ok := m.store().(PluginListStorage); ok {
+			if err := lister.DeletePlugin(target.path); err != nil {
+				return fmt.Errorf("删除插件记录失败: %v", err)
+			}
+			report.Purged = append(report.Purged, target.path)
+		}
+		return nil
+	}
+
+	record, err := m.store().GetPlugin(target.path)
+	if err != nil {
+		return fmt.Errorf("读取插件记录失败: %v", err)
+	}
+	if record == nil {
+		return nil
+	}
+
+	// 配置变化
+	if record.Config != "" {
+		var config map[string]interface{}
+		if err := json.Unmarshal([]byte(record.Config), &config); err != nil {
+			return fmt.Errorf("解析插件配置失败: %v", err)
+		}
+		if !sameConfig(target.config, config) {
+			if err := m.UpdatePluginConfig(target.name, config); err != nil {
+				return fmt.Errorf("更新插件配置失败: %v", err)
+			}
+			report.ConfigUpdated = append(report.ConfigUpdated, target.name)
+		}
+	}
+
+	// 启用状态变化，只在已启用和已禁用之间切换
+	if target.state != StateEnabled && target.state != StateDisabled {
+		return nil
+	}
+	if record.State != "" && record.State != string(StateEnabled) && record.State != string(StateDisabled) {
+		return nil
+	}
+	switch {
+	case record.Enabled && !target.enabled:
+		if err := m.EnablePlugin(target.name); err != nil {
+			return fmt.Errorf("启用插件失败: %v", err)
+		}
+		report.Enabled = append(report.Enabled, target.name)
+	case !record.Enabled && target.enabled:
+		if err := m.DisablePlugin(target.name); err != nil {
+			return fmt.Errorf("禁用插件失败: %v", err)
+		}
+		report.Disabled = append(report.Disabled, target.name)
+	}
+	return nil
+}
+
+// purgeMissingRecords 删除文件已丢失且未加载的插件记录
+func (m *Manager) purgeMissingRecords(report *ReconcileReport) error {
+	lister, ok := m.store().(PluginListStorage)
+	if !ok {
+		return nil
+	}
+
+	records, err := lister.ListPlugins()
+	if err != nil {
+		return fmt.Errorf("读取插件记录失败: %v", err)
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	loaded := make(map[string]bool, len(m.plugins))
+	for _, info := range m.plugins {
+		loaded[info.FilePath] = true
+	}
+
+	var errs []error
+	for _, record := range records {
+		if loaded[record.Path] {
+			continue
+		}
+		if _, err := os.Stat(record.Path); !os.IsNotExist(err) {
+			continue
+		}
+		if err := lister.DeletePlugin(record.Path); err != nil {
+			errs = append(errs, fmt.Errorf("删除插件记录 %s 失败: %v", record.Path, err))
+			continue
+		}
+		delete(m.missing, record.Path)
+		report.Purged = append(report.Purged, record.Path)
+	}
+	return errors.Join(errs...)
+}
+
+// sameConfig 按JSON编码比较配置，忽略数值类型等解码差异
+func sameConfig(a, b map[string]interface{}) bool {
+	encodedA, errA := json.Marshal(a)
+	encodedB, errB := json.Marshal(b)
+	if errA != nil || errB != nil {
+		return false
+	}
+	return bytes.Equal(encodedA, encodedB)
+}
+
+// StartReconciler 按间隔定时执行Reconcile，interval必须大于0
+func (m *Manager) StartReconciler(interval time.Duration) error {
+	if err := m.checkWritable(); err != nil {
+		return err
+	}
+
+	if interval <= 0 {
+		return fmt.Errorf("同步间隔必须大于0")
+	}
+
+	m.reconciler.mutex.Lock()
+	defer m.reconciler.mutex.Unlock()
+
+	if m.reconciler.stop != nil {
+		return fmt.Errorf("定时同步已开启")
+	}
+	stop := make(chan struct{})
+	m.reconciler.stop = stop
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				if m.IsMaintenanceMode() || m.IsShuttingDown() {
+					continue
+				}
+				if _, err := m.Reconcile(context.Background()); err != nil {
+					m.logger.Printf("定时同步插件状态失败: %v", err)
+				}
+			}
+		}
+	}()
+
+	m.logger.Printf("已开启插件状态定时同步，间隔 %v", interval)
+	return nil
+}
+
+// StopReconciler 停止定时同步，正在执行的同步会继续完成
+func (m *Manager) StopReconciler() {
+	m.reconciler.mutex.Lock()
+	defer m.reconciler.mutex.Unlock()
+
+	if m.reconciler.stop == nil {
+		return
+	}
+	close(m.reconciler.stop)
+	m.reconciler.stop = nil
+	m.logger.Printf("已停止插件状态定时同步")
+}
@@ -0,0 +1,136 @@
+package plugins
+
+import (
+	"fmt"
+)
+
+// ReloadPlugin 重新加载插件：关闭当前实例，重新读取插件文件，从存储恢复配置，原先启用的插件会被重新启用。
+// 注意：Go运行时会缓存已打开的插件，同一路径重复打开得到的仍是旧代码；
+// 要加载新编译的代码，请使用ReloadPluginFrom指定新的版本化文件路径（且插件需以不同的pluginpath编译）
+func (m *Manager) ReloadPlugin(name string) error {
+	info, exists := m.GetPlugin(name)
+	if !exists {
+		return fmt.Errorf("插件不存在: %s", name)
+	}
+	return m.ReloadPluginFrom(name, info.FilePath)
+}
+
+// ReloadPluginFrom 从指定的插件文件重新加载插件，加载失败时恢复原实例。
+// 版本变化时原版本的插件文件和配置会被保留，可通过RollbackPlugin回退
+func (m *Manager) ReloadPluginFrom(name, pluginPath string) error {
+	if err := m.checkWritable(); err != nil {
+		return err
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	_, err := m.reloadPlugin(name, pluginPath, nil, nil)
+	return err
+}
+
+// reloadPlugin 从指定的插件文件重新加载插件，config为nil时沿用原配置，data不为nil时在加载前导入插件KV数据，
+// 返回原插件信息，调用方需持有m.mutex
+func (m *Manager) reloadPlugin(name, pluginPath string, config map[string]interface{}, data map[string]string) (*PluginInfo, error) {
+	old, exists := m.plugins[name]
+	if !exists {
+		return nil, fmt.Errorf("插件不存在: %s", name)
+	}
+	wasEnabled := old.Enabled
+	if config == nil {
+		config = old.Config
+	}
+
+	// 加载新文件前记录原版本的配置和数据，版本变化时保留
+	kept := m.versionSnapshot(old)
+	dataStorage, _ := m.store().(PluginDataStorage)
+	restoreData := func() {}
+	if dataStorage != nil && data != nil && kept.Data != nil {
+		if err := dataStorage.ImportPluginData(name, data); err != nil {
+			return nil, fmt.Errorf("导入插件 %s 数据失败: %v", name, err)
+		}
+		restoreData = func() {
+			if err := dataStorage.ImportPluginData(name, kept.Data); err != nil {
+				m.logger.Printf("恢复插件 %s 数据失败: %v", name, err)
+			}
+		}
+	}
+
+	// 关闭当前实例
+	if wasEnabled {
+		if err := old.Plugin.Close(); err != nil {
+			m.logger.Printf("关闭插件 %s 失败: %v", name, err)
+		}
+		m.checkLeaks(name)
+	}
+	delete(m.plugins, name)
+
+	// 将当前状态写入新路径的存储记录，loadPlugin会据此恢复配置和启用状态
+	if err := m.store().SavePlugin(old.Name, pluginPath, wasEnabled, config); err != nil {
+		restoreData()
+		m.restorePlugin(old, wasEnabled)
+		return nil, fmt.Errorf("更新插件状态到存储失败: %v", err)
+	}
+
+	info, err := m.loadPlugin(pluginPath)
+	if err == nil && info.Name != name {
+		delete(m.plugins, info.Name)
+		if info.Enabled {
+			_ = info.Plugin.Close()
+		}
+		err = fmt.Errorf("新插件文件的名称 %s 与 %s 不一致", info.Name, name)
+	}
+	if err != nil {
+		if info != nil && info.Name == name {
+			delete(m.plugins, name)
+		}
+		restoreData()
+		m.restorePlugin(old, wasEnabled)
+		return nil, fmt.Errorf("重新加载插件 %s 失败: %v", name, err)
+	}
+
+	// 路径变化时删除旧路径的存储记录
+	if pluginPath != old.FilePath {
+		if lister, ok := m.store().(PluginListStorage); ok {
+			if err := lister.DeletePlugin(old.FilePath); err != nil {
+				m.logger.Printf("删除旧插件记录失败: %v", err)
+			}
+		}
+	}
+
+	if info.Version != old.Version {
+		// 原地覆盖的插件文件已是新版本，只能保留路径不同的旧文件
+		if pluginPath != old.FilePath {
+			if err := m.keepVersion(old.FilePath, kept); err != nil {
+				m.logger.Printf("保留插件 %s v%s 失败: %v", name, old.Version, err)
+			}
+		}
+		m.notifyStateChange(name, old.State, info.State, fmt.Sprintf("升级: v%s -> v%s", old.Version, info.Version))
+	}
+
+	m.logger.Printf("已重新加载插件: %s v%s -> v%s", name, old.Version, info.Version)
+	return old, nil
+}
+
+// restorePlugin 重新加载失败时恢复原插件实例，调用方需持有m.mutex
+func (m *Manager) restorePlugin(old *PluginInfo, wasEnabled bool) {
+	m.plugins[old.Name] = old
+
+	if err := m.store().SavePlugin(old.Name, old.FilePath, wasEnabled, old.Config); err != nil {
+		m.logger.Printf("恢复插件 %s 存储记录失败: %v", old.Name, err)
+	}
+	m.deliverConfig(old.Name, old.Plugin, old.Manifest, old.Config)
+
+	if !wasEnabled {
+		return
+	}
+
+	var initErr error
+	runWithPluginLabels(old.Name, func() {
+		initErr = old.Plugin.Init()
+	})
+	if initErr != nil {
+		_ = m.setState(old, StateInitFailed, fmt.Sprintf("恢复时初始化失败: %v", initErr))
+		m.logger.Printf("恢复插件 %s 失败: %v", old.Name, initErr)
+	}
+}
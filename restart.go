@@ -0,0 +1,131 @@
+package plugins
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// RestartOption 重启插件子系统的选项。存储、日志等NewManager的选项在事件分发期间修改会产生数据竞争，重启只能更换插件目录
+type RestartOption func(*restartSettings)
+
+// restartSettings 重启时应用的设置
+type restartSettings struct {
+	dirs []string // 新的插件搜索目录，为nil时不更换
+}
+
+// RestartWithPluginDirs 重启时更换插件搜索目录，含义与WithPluginDirs相同
+func RestartWithPluginDirs(dirs ...string) RestartOption {
+	return func(s *restartSettings) {
+		s.dirs = append([]string{}, dirs...)
+	}
+}
+
+// Restart 重新加载整个插件子系统，相当于Shutdown后再LoadPlugins，用于在不重启宿主的情况下应用插件目录的变化，
+// opts（如RestartWithPluginDirs）在准备新插件前于m.mutex下应用。
+// 先在旧插件继续处理事件的同时扫描并准备插件文件，准备完成后暂停事件分发、等待异步处理完成并调用旧插件的Flush，
+// 再关闭旧插件、登记并初始化新插件，事件分发只会看到旧的或新的插件集合。准备失败或ctx在此之前取消时旧插件保持不变，插件目录恢复原值
+func (m *Manager) Restart(ctx context.Context, opts ...RestartOption) error {
+	if err := m.checkWritable(); err != nil {
+		return err
+	}
+	if m.IsShuttingDown() {
+		return fmt.Errorf("插件管理器正在关闭，不能重启")
+	}
+	if !m.restarting.CompareAndSwap(false, true) {
+		return fmt.Errorf("插件子系统正在重启")
+	}
+	defer m.restarting.Store(false)
+
+	start := time.Now()
+
+	var settings restartSettings
+	for _, opt := range opts {
+		opt(&settings)
+	}
+
+	m.mutex.Lock()
+	oldDir, oldSearchDirs := m.pluginDir, m.searchDirs
+	if settings.dirs != nil {
+		WithPluginDirs(settings.dirs...)(m)
+	}
+	err := m.ensurePluginDir()
+	m.mutex.Unlock()
+	if err != nil {
+		m.restoreDirs(oldDir, oldSearchDirs)
+		return err
+	}
+
+	// 准备新的插件集合，旧插件继续处理事件
+	m.mutex.RLock()
+	prepared, err := m.preparePluginDirs(ctx)
+	m.mutex.RUnlock()
+	if err == nil {
+		err = ctx.Err()
+	}
+	if err != nil {
+		m.restoreDirs(oldDir, oldSearchDirs)
+		return fmt.Errorf("准备插件失败，保持当前插件: %w", err)
+	}
+
+	// 插件目录可能已变化，监听器按新的目录重新开启
+	m.watchMutex.Lock()
+	var debounce time.Duration
+	if m.watcher != nil {
+		debounce = m.watcher.debounce
+	}
+	m.watchMutex.Unlock()
+	if debounce > 0 {
+		m.StopWatcher()
+	}
+
+	// 暂停分发新事件，等待旧插件的异步处理完成后写出数据
+	m.dispatchGate.Lock()
+	defer m.dispatchGate.Unlock()
+
+	var errs []error
+	m.shutdown.mutex.Lock()
+	flushTimeout := m.shutdown.timeouts[PhaseFlush]
+	m.shutdown.mutex.Unlock()
+	flushCtx, cancel := context.WithTimeout(ctx, flushTimeout)
+	if err := waitContext(flushCtx, &m.inflight); err != nil {
+		errs = append(errs, fmt.Errorf("等待异步处理完成: %v", err))
+	}
+	if err := m.flushPlugins(flushCtx); err != nil {
+		errs = append(errs, err)
+	}
+	cancel()
+
+	// 替换插件集合，此后ctx取消不再中断，新插件的初始化受各自的初始化超时时间约束
+	swapCtx := context.WithoutCancel(ctx)
+	m.mutex.Lock()
+	if err := m.closeRunning(swapCtx); err != nil {
+		errs = append(errs, err)
+	}
+	m.plugins = make(map[string]*PluginInfo)
+	m.resetNameConflicts()
+	if err := m.registerPrepared(swapCtx, prepared); err != nil {
+		errs = append(errs, err)
+	}
+	m.detectMissingPlugins()
+	count := len(m.plugins)
+	m.mutex.Unlock()
+
+	if debounce > 0 {
+		if err := m.StartWatcher(debounce); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	m.logger.Printf("插件子系统已重启，加载 %d 个插件，耗时 %v", count, time.Since(start))
+	return errors.Join(errs...)
+}
+
+// restoreDirs 准备失败时恢复重启前的插件目录
+func (m *Manager) restoreDirs(pluginDir string, searchDirs []string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.pluginDir, m.searchDirs = pluginDir, searchDirs
+}
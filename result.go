@@ -0,0 +1,227 @@
+package plugins
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultEventRecordLimit 默认保留的事件记录数量
+const defaultEventRecordLimit = 1000
+
+// EventResult 事件处理的结构化结果
+type EventResult struct {
+	Annotations map[string]string  // 注释信息
+	Metrics     map[string]float64 // 指标
+	Actions     []string           // 执行的动作
+}
+
+// ResultHandler 返回结构化结果的事件处理接口（可选实现），实现后将代替OnAPIEvent被调用
+type ResultHandler interface {
+	OnAPIEventResult(ctx *gin.Context, event EventType, path string, statusCode int, requestBody interface{}, responseBody interface{}) (*EventResult, error)
+}
+
+// EventRecordObserver 事件记录观察接口（可选实现），事件的所有处理结果汇总后回调，适用于审计和报表插件
+type EventRecordObserver interface {
+	OnEventRecord(record EventRecord)
+}
+
+// PluginResult 单个插件对事件的处理结果
+type PluginResult struct {
+	Plugin   string
+	Result   *EventResult
+	Error    string
+	Duration time.Duration
+}
+
+// EventRecord 事件记录，汇总所有插件的处理结果
+type EventRecord struct {
+	ID         string // 事件ID，重试和重放时保持不变
+	Event      EventType
+	Path       string
+	StatusCode int
+	Time       time.Time
+	Results    []PluginResult
+	Completed  bool // 所有插件是否均已处理完成
+
+	RequestBody  *Payload `json:",omitempty"` // 请求体，仅在事件日志开启记录请求体时保存
+	ResponseBody *Payload `json:",omitempty"` // 响应体，仅在事件日志开启记录请求体时保存
+}
+
+// eventRecord 处理中的事件记录
+type eventRecord struct {
+	EventRecord
+
+	mutex   sync.Mutex
+	pending sync.WaitGroup
+}
+
+// addResult 追加插件处理结果
+func (r *eventRecord) addResult(result PluginResult) {
+	r.mutex.Lock()
+	r.Results = append(r.Results, result)
+	r.mutex.Unlock()
+
+	r.pending.Done()
+}
+
+// snapshot 返回记录的只读副本
+func (r *eventRecord) snapshot() EventRecord {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return EventRecord{
+		ID:         r.ID,
+		Event:      r.Event,
+		Path:       r.Path,
+		StatusCode: r.StatusCode,
+		Time:       r.Time,
+		Results:    append([]PluginResult(nil), r.Results...),
+		Completed:  r.Completed,
+
+		RequestBody:  r.RequestBody,
+		ResponseBody: r.ResponseBody,
+	}
+}
+
+// newEventRecord 创建事件记录并加入最近记录列表，handlers为待处理的插件数量
+func (m *Manager) newEventRecord(id string, event EventType, path string, statusCode int, handlers int) *eventRecord {
+	record := &eventRecord{EventRecord: EventRecord{
+		ID:         id,
+		Event:      event,
+		Path:       path,
+		StatusCode: statusCode,
+		Time:       time.Now(),
+	}}
+	record.pending.Add(handlers)
+
+	m.recordMutex.Lock()
+	m.records = append(m.records, record)
+	if over := len(m.records) - m.recordLimit; over > 0 {
+		m.records = m.records[over:]
+	}
+	m.recordMutex.Unlock()
+
+	// 所有插件处理完成后通知观察者
+	go func() {
+		record.pending.Wait()
+
+		record.mutex.Lock()
+		record.Completed = true
+		record.mutex.Unlock()
+
+		snapshot := record.snapshot()
+		m.appendJournal(snapshot)
+		m.notifyRecordObservers(snapshot)
+	}()
+
+	return record
+}
+
+// invokeHandler 调用插件处理事件并将结果汇总到事件记录
+func (m *Manager) invokeHandler(ctx *gin.Context, record *eventRecord, info *PluginInfo, requestBody interface{}, responseBody interface{}) {
+	release, ok := m.acquireSlot(info.Name)
+	if !ok {
+		record.addResult(PluginResult{Plugin: info.Name, Error: errConcurrencyLimit.Error()})
+		return
+	}
+	defer release()
+
+	start := time.Now()
+
+	result, crashed, err := callHandler(ctx, record, info.Plugin, requestBody, responseBody)
+	if crashed {
+		m.markCrashed(info, err.Error())
+	}
+
+	pr := PluginResult{Plugin: info.Name, Result: result, Duration: time.Since(start)}
+	m.recordPluginEvent(info.Name, err)
+	m.recordLatency(info.Name, pr.Duration, err != nil)
+	if err != nil {
+		pr.Error = err.Error()
+		m.reportPluginError(info.Name, err)
+	}
+	if !crashed {
+		if reason, tripped := m.breakers.record(info.Name, err != nil, time.Now()); tripped {
+			goUnlabeled(func() { m.tripBreaker(info, reason) })
+		}
+	}
+	record.addResult(pr)
+}
+
+// callHandler 调用插件的事件处理，插件panic时crashed为true
+func callHandler(ctx *gin.Context, record *eventRecord, plugin Plugin, requestBody interface{}, responseBody interface{}) (result *EventResult, crashed bool, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			result, crashed, err = nil, true, fmt.Errorf("处理事件时发生panic: %v", r)
+		}
+	}()
+
+	if handler, ok := plugin.(IdempotentHandler); ok {
+		result, err = handler.OnAPIEventWithID(ctx, record.ID, record.Event, record.Path, record.StatusCode, requestBody, responseBody)
+	} else if handler, ok := plugin.(ResultHandler); ok {
+		result, err = handler.OnAPIEventResult(ctx, record.Event, record.Path, record.StatusCode, requestBody, responseBody)
+	} else {
+		err = plugin.OnAPIEvent(ctx, record.Event, record.Path, record.StatusCode, requestBody, responseBody)
+	}
+	return result, false, err
+}
+
+// notifyRecordObservers 将汇总后的事件记录发送给观察者插件
+func (m *Manager) notifyRecordObservers(record EventRecord) {
+	m.mutex.RLock()
+	var observers []*PluginInfo
+	for _, info := range m.plugins {
+		if !info.Enabled {
+			continue
+		}
+		if _, ok := info.Plugin.(EventRecordObserver); ok {
+			observers = append(observers, info)
+		}
+	}
+	m.mutex.RUnlock()
+
+	for _, info := range observers {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					m.logger.Printf("插件 %s 处理事件记录时发生panic: %v", info.Name, r)
+				}
+			}()
+			info.Plugin.(EventRecordObserver).OnEventRecord(record)
+		}()
+	}
+}
+
+// SetEventRecordLimit 设置保留的最近事件记录数量
+func (m *Manager) SetEventRecordLimit(limit int) {
+	m.recordMutex.Lock()
+	defer m.recordMutex.Unlock()
+
+	if limit <= 0 {
+		limit = defaultEventRecordLimit
+	}
+	m.recordLimit = limit
+	if over := len(m.records) - limit; over > 0 {
+		m.records = m.records[over:]
+	}
+}
+
+// RecentEventRecords 获取最近的事件记录（按时间先后），limit<=0表示全部
+func (m *Manager) RecentEventRecords(limit int) []EventRecord {
+	m.recordMutex.Lock()
+	records := m.records
+	if limit > 0 && len(records) > limit {
+		records = records[len(records)-limit:]
+	}
+	records = append([]*eventRecord(nil), records...)
+	m.recordMutex.Unlock()
+
+	result := make([]EventRecord, 0, len(records))
+	for _, record := range records {
+		result = append(result, record.snapshot())
+	}
+	return result
+}
@@ -0,0 +1,136 @@
+package plugins
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// RouteRefPrefix InterestedAPIs中以此开头的条目表示按路由名称订阅，如 route:subscription.fetch
+const RouteRefPrefix = "route:"
+
+// 宿主的常用路由名称
+const (
+	RouteSubscriptionFetch = "subscription.fetch" // 客户端获取订阅
+	RouteSubscriptionList  = "subscription.list"  // 订阅列表
+	RouteNodeList          = "node.list"          // 节点列表
+	RouteLogin             = "auth.login"         // 用户登录
+)
+
+// RouteCatalog 宿主的命名路由表，插件通过路由名称获取路径，宿主修改路径后插件无需改动
+type RouteCatalog struct {
+	routes map[string]string
+}
+
+// Path 获取路由路径，未登记时返回空字符串
+func (c RouteCatalog) Path(name string) string {
+	return c.routes[name]
+}
+
+// Names 获取所有已登记的路由名称
+func (c RouteCatalog) Names() []string {
+	names := make([]string, 0, len(c.routes))
+	for name := range c.routes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SubscriptionFetch 客户端获取订阅的路由
+func (c RouteCatalog) SubscriptionFetch() string { return c.Path(RouteSubscriptionFetch) }
+
+// SubscriptionList 订阅列表的路由
+func (c RouteCatalog) SubscriptionList() string { return c.Path(RouteSubscriptionList) }
+
+// NodeList 节点列表的路由
+func (c RouteCatalog) NodeList() string { return c.Path(RouteNodeList) }
+
+// Login 用户登录的路由
+func (c RouteCatalog) Login() string { return c.Path(RouteLogin) }
+
+// RouteRef 生成按路由名称订阅的InterestedAPIs条目
+func RouteRef(name string) string {
+	return RouteRefPrefix + name
+}
+
+// SetRouteCatalog 登记宿主的命名路由表（名称到路径），插件可通过HostAPI.Routes获取，
+// 或在InterestedAPIs中使用 route:<名称> 订阅
+func (m *Manager) SetRouteCatalog(routes map[string]string) {
+	catalog := make(map[string]string, len(routes))
+	for name, path := range routes {
+		catalog[name] = path
+	}
+
+	m.mutex.Lock()
+	m.routeCatalog = RouteCatalog{routes: catalog}
+	m.mutex.Unlock()
+
+	for name, warnings := range m.SubscriptionWarnings() {
+		for _, warning := range warnings {
+			m.logger.Printf("插件 %s 订阅检查: %s", name, warning)
+		}
+	}
+}
+
+// Routes 获取宿主的命名路由表
+func (m *Manager) Routes() RouteCatalog {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	return m.routeCatalog
+}
+
+func (h *pluginHost) Routes() RouteCatalog {
+	return h.m.Routes()
+}
+
+// apiMatches 判断请求是否匹配插件订阅的条目：前缀条目按路径前缀匹配，
+// 路由名称条目按路由匹配，路由中的 :param 段匹配任意值，*wildcard 段匹配剩余路径，调用方需持有m.mutex
+func (m *Manager) apiMatches(plugin, method, path, api string) bool {
+	matcher, guide, err := m.matchers.compile(plugin, api)
+	if guide {
+		if err != nil {
+			m.logger.Printf("插件 %s 的订阅条目无效，不会匹配任何请求: %v", plugin, err)
+		} else {
+			m.logger.Printf("插件 %s 订阅的 %q 使用旧的裸路径格式，已按 %q 处理，建议在插件中改用新格式", plugin, api, matcher.canonical())
+		}
+	}
+	if err != nil {
+		return false
+	}
+	return m.matches(matcher, method, path)
+}
+
+// routeMatchesPath 判断请求路径是否匹配路由
+func routeMatchesPath(route, path string) bool {
+	routeSegs := strings.Split(strings.Trim(route, "/"), "/")
+	pathSegs := strings.Split(strings.Trim(path, "/"), "/")
+
+	for i, rs := range routeSegs {
+		if strings.HasPrefix(rs, "*") {
+			return true
+		}
+		if i >= len(pathSegs) {
+			return false
+		}
+		if strings.HasPrefix(rs, ":") {
+			continue
+		}
+		if rs != pathSegs[i] {
+			return false
+		}
+	}
+	return len(pathSegs) == len(routeSegs)
+}
+
+// routeRefWarning 检查按路由名称订阅的路由是否已登记，调用方需持有m.mutex
+func (m *Manager) routeRefWarning(name string) string {
+	if len(m.routeCatalog.routes) == 0 {
+		return ""
+	}
+	if m.routeCatalog.Path(name) == "" {
+		return fmt.Sprintf("订阅的路由名称 %q 未在宿主路由表中登记", name)
+	}
+	return ""
+}
@@ -0,0 +1,161 @@
+package plugins
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterRoutes 登记宿主的路由表，并重新校验所有插件订阅的API路径
+func (m *Manager) RegisterRoutes(paths []string) {
+	m.mutex.Lock()
+	m.routes = append([]string(nil), paths...)
+	m.mutex.Unlock()
+
+	for name, warnings := range m.SubscriptionWarnings() {
+		for _, warning := range warnings {
+			m.logger.Printf("插件 %s 订阅检查: %s", name, warning)
+		}
+	}
+}
+
+// RegisterGinRoutes 从Gin引擎登记宿主的路由表
+func (m *Manager) RegisterGinRoutes(engine *gin.Engine) {
+	var paths []string
+	for _, route := range engine.Routes() {
+		paths = append(paths, route.Path)
+	}
+	m.RegisterRoutes(paths)
+}
+
+// SubscriptionWarnings 返回订阅了不存在路由的插件及警告信息，宿主未登记路由表时不检查
+func (m *Manager) SubscriptionWarnings() map[string][]string {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	result := make(map[string][]string)
+	for name, info := range m.plugins {
+		if warnings := m.subscriptionWarnings(info); len(warnings) > 0 {
+			result[name] = warnings
+		}
+	}
+	return result
+}
+
+// subscriptionWarnings 编译并检查单个插件订阅的API路径，调用方需持有m.mutex
+func (m *Manager) subscriptionWarnings(info *PluginInfo) []string {
+	var warnings []string
+	for _, api := range interestedAPIs(info.Plugin) {
+		matcher, err := m.matchers.precompile(api)
+		if err != nil {
+			warnings = append(warnings, err.Error())
+			continue
+		}
+		switch matcher.kind {
+		case matchRoute:
+			if warning := m.routeRefWarning(matcher.pattern); warning != "" {
+				warnings = append(warnings, warning)
+			}
+			continue
+		case matchAll:
+			continue
+		}
+		if matcher.legacy && strings.ContainsAny(matcher.pattern, "*?[") {
+			warnings = append(warnings, fmt.Sprintf("订阅的路径 %q 按前缀匹配，其中的通配符不生效，按通配符匹配请使用 %s", api, GlobMatch+matcher.pattern))
+		}
+		if len(m.routes) == 0 {
+			continue
+		}
+
+		matched := false
+		for _, route := range m.routes {
+			if matcherMayMatchRoute(matcher, route) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			warnings = append(warnings, fmt.Sprintf("订阅的路径 %q 不匹配任何已登记的路由", api))
+		}
+	}
+	return warnings
+}
+
+// matcherMayMatchRoute 判断订阅条目是否可能匹配路由
+func matcherMayMatchRoute(matcher *apiMatcher, route string) bool {
+	switch matcher.kind {
+	case matchGlob:
+		return globMatchesRoute(matcher.segments, splitPath(route))
+	case matchRegex:
+		static, dynamic := route, false
+		if i := strings.IndexAny(route, ":*"); i >= 0 {
+			static, dynamic = route[:i], true
+		}
+		if !dynamic {
+			return matcher.regex.MatchString(route)
+		}
+		// 含参数的路由无法枚举实际路径，只比较正则表达式的字面前缀与路由的静态部分
+		prefix, _ := matcher.regex.LiteralPrefix()
+		return strings.HasPrefix(prefix, static) || strings.HasPrefix(static, prefix)
+	default:
+		return prefixMatchesRoute(matcher.pattern, route)
+	}
+}
+
+// globMatchesRoute 逐段比较通配符和路由，路由中的 :param 段匹配任意路径段，*wildcard 段匹配剩余的所有路径段
+func globMatchesRoute(pattern, route []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := len(route); i >= 0; i-- {
+				if globMatchesRoute(pattern[1:], route[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(route) == 0 {
+			return false
+		}
+		if strings.HasPrefix(route[0], "*") {
+			return true
+		}
+		if !strings.HasPrefix(route[0], ":") {
+			if ok, _ := path.Match(pattern[0], route[0]); !ok {
+				return false
+			}
+		}
+		pattern, route = pattern[1:], route[1:]
+	}
+	return len(route) == 0 || (len(route) == 1 && strings.HasPrefix(route[0], "*"))
+}
+
+// prefixMatchesRoute 判断路径前缀是否可能匹配路由，路由中的 :param 和 *wildcard 段匹配任意值
+func prefixMatchesRoute(prefix, route string) bool {
+	prefixSegs := strings.Split(strings.Trim(prefix, "/"), "/")
+	routeSegs := strings.Split(strings.Trim(route, "/"), "/")
+
+	for i, ps := range prefixSegs {
+		if i >= len(routeSegs) {
+			return false
+		}
+		rs := routeSegs[i]
+
+		if strings.HasPrefix(rs, "*") {
+			return true
+		}
+		if strings.HasPrefix(rs, ":") {
+			continue
+		}
+
+		// 前缀的最后一段可以只是路由段的一部分
+		if i == len(prefixSegs)-1 {
+			return strings.HasPrefix(rs, ps)
+		}
+		if rs != ps {
+			return false
+		}
+	}
+	return true
+}
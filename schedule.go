@@ -0,0 +1,195 @@
+package plugins
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// ScheduledEvent 延迟投递的事件，请求和响应数据需可JSON序列化以便持久化；
+// 投递时没有对应的HTTP请求，插件收到的ctx为nil
+type ScheduledEvent struct {
+	ID           string      `json:"id"`
+	Event        EventType   `json:"event"`
+	Path         string      `json:"path"`
+	StatusCode   int         `json:"status_code"`
+	RequestBody  interface{} `json:"request_body,omitempty"`
+	ResponseBody interface{} `json:"response_body,omitempty"`
+	DueAt        time.Time   `json:"due_at"`
+	Source       string      `json:"source,omitempty"` // 发起的插件名称，宿主发起时为空
+}
+
+// ScheduledEventStorage 延迟事件存储扩展接口（可选实现），实现后延迟事件在重启后继续投递
+type ScheduledEventStorage interface {
+	// SaveScheduledEvent 保存延迟事件
+	SaveScheduledEvent(event ScheduledEvent) error
+
+	// DeleteScheduledEvent 删除已投递或取消的延迟事件
+	DeleteScheduledEvent(id string) error
+
+	// ListScheduledEvents 列出所有未投递的延迟事件
+	ListScheduledEvents() ([]ScheduledEvent, error)
+}
+
+// eventScheduler 延迟事件调度
+type eventScheduler struct {
+	events   map[string]ScheduledEvent
+	timers   map[string]*time.Timer
+	restored bool
+	mutex    trackedMutex
+}
+
+func newEventScheduler(locks *lockTracker) *eventScheduler {
+	return &eventScheduler{
+		events: make(map[string]ScheduledEvent),
+		timers: make(map[string]*time.Timer),
+		mutex:  trackedMutex{name: "scheduler", tracker: locks},
+	}
+}
+
+// EmitAfter 在d之后投递事件，返回延迟事件ID
+func (m *Manager) EmitAfter(d time.Duration, event ScheduledEvent) (string, error) {
+	if event.Event == "" {
+		return "", fmt.Errorf("事件类型不能为空")
+	}
+	if d < 0 {
+		d = 0
+	}
+
+	id, err := newRandomID()
+	if err != nil {
+		return "", fmt.Errorf("生成事件ID失败: %v", err)
+	}
+	event.ID = id
+	event.DueAt = time.Now().Add(d)
+
+	if scheduleStorage, ok := m.store().(ScheduledEventStorage); ok {
+		if err := scheduleStorage.SaveScheduledEvent(event); err != nil {
+			return "", fmt.Errorf("保存延迟事件失败: %v", err)
+		}
+	}
+
+	m.scheduleEvent(event)
+	return id, nil
+}
+
+// EmitAfter 插件发起延迟事件
+func (h *pluginHost) EmitAfter(d time.Duration, event ScheduledEvent) (string, error) {
+	event.Source = h.name
+	return h.m.EmitAfter(d, event)
+}
+
+// CancelScheduledEvent 取消尚未投递的延迟事件
+func (m *Manager) CancelScheduledEvent(id string) error {
+	if err := m.checkWritable(); err != nil {
+		return err
+	}
+
+	m.scheduler.mutex.Lock()
+	timer, exists := m.scheduler.timers[id]
+	if exists {
+		timer.Stop()
+		delete(m.scheduler.timers, id)
+		delete(m.scheduler.events, id)
+	}
+	m.scheduler.mutex.Unlock()
+
+	if !exists {
+		return fmt.Errorf("延迟事件不存在: %s", id)
+	}
+
+	if scheduleStorage, ok := m.store().(ScheduledEventStorage); ok {
+		if err := scheduleStorage.DeleteScheduledEvent(id); err != nil {
+			return fmt.Errorf("删除延迟事件失败: %v", err)
+		}
+	}
+	return nil
+}
+
+// ScheduledEvents 按投递时间列出尚未投递的延迟事件
+func (m *Manager) ScheduledEvents() []ScheduledEvent {
+	m.scheduler.mutex.Lock()
+	defer m.scheduler.mutex.Unlock()
+
+	events := make([]ScheduledEvent, 0, len(m.scheduler.events))
+	for _, event := range m.scheduler.events {
+		events = append(events, event)
+	}
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].DueAt.Before(events[j].DueAt)
+	})
+	return events
+}
+
+// restoreScheduledEvents 从存储恢复未投递的延迟事件，已过期的事件立即投递，只在首次加载插件时执行
+func (m *Manager) restoreScheduledEvents() {
+	scheduleStorage, ok := m.store().(ScheduledEventStorage)
+	if !ok {
+		return
+	}
+
+	m.scheduler.mutex.Lock()
+	if m.scheduler.restored {
+		m.scheduler.mutex.Unlock()
+		return
+	}
+	m.scheduler.restored = true
+	m.scheduler.mutex.Unlock()
+
+	events, err := scheduleStorage.ListScheduledEvents()
+	if err != nil {
+		m.logger.Printf("读取延迟事件失败: %v", err)
+		return
+	}
+
+	for _, event := range events {
+		m.scheduleEvent(event)
+	}
+	if len(events) > 0 {
+		m.logger.Printf("已恢复 %d 个延迟事件", len(events))
+	}
+}
+
+// scheduleEvent 为延迟事件设置定时器
+func (m *Manager) scheduleEvent(event ScheduledEvent) {
+	m.scheduler.mutex.Lock()
+	defer m.scheduler.mutex.Unlock()
+
+	if _, exists := m.scheduler.timers[event.ID]; exists {
+		return
+	}
+
+	// 关闭期间不再设置定时器，存储中的延迟事件在下次启动时恢复
+	if m.shuttingDown.Load() {
+		return
+	}
+
+	m.scheduler.events[event.ID] = event
+	m.scheduler.timers[event.ID] = time.AfterFunc(time.Until(event.DueAt), func() {
+		m.deliverScheduledEvent(event)
+	})
+}
+
+// deliverScheduledEvent 投递到期的延迟事件
+func (m *Manager) deliverScheduledEvent(event ScheduledEvent) {
+	m.scheduler.mutex.Lock()
+	_, pending := m.scheduler.timers[event.ID]
+	delete(m.scheduler.timers, event.ID)
+	delete(m.scheduler.events, event.ID)
+	m.scheduler.mutex.Unlock()
+
+	// 已被取消
+	if !pending {
+		return
+	}
+
+	// 先删除存储记录，避免重启后重复投递
+	if scheduleStorage, ok := m.store().(ScheduledEventStorage); ok {
+		if err := scheduleStorage.DeleteScheduledEvent(event.ID); err != nil {
+			m.logger.Printf("删除延迟事件 %s 失败: %v", event.ID, err)
+		}
+	}
+
+	// 以延迟事件ID作为事件ID，重启后重复投递时插件可据此去重
+	m.triggerEvent(nil, event.ID, event.Event, event.Path, event.StatusCode, event.RequestBody, event.ResponseBody)
+}
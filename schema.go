@@ -0,0 +1,287 @@
+package plugins
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// jsonSchemaDialect 生成的JSON Schema版本
+const jsonSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// schemaTypes 公开JSON Schema的事件和管理API对象，键为$defs中的名称
+var schemaTypes = map[string]reflect.Type{
+	"EventRecord":     reflect.TypeOf(EventRecord{}),
+	"ScheduledEvent":  reflect.TypeOf(ScheduledEvent{}),
+	"Notification":    reflect.TypeOf(Notification{}),
+	"Notice":          reflect.TypeOf(Notice{}),
+	"PluginManifest":  reflect.TypeOf(PluginManifest{}),
+	"PluginInfo":      reflect.TypeOf(PluginInfo{}),
+	"PluginReadiness": reflect.TypeOf(PluginReadiness{}),
+	"Operation":       reflect.TypeOf(Operation{}),
+	"LoadReport":      reflect.TypeOf(LoadReport{}),
+	"ConfigDiff":      reflect.TypeOf(ConfigDiff{}),
+	"ArchivedPlugin":  reflect.TypeOf(ArchivedPlugin{}),
+	"GroupHealth":     reflect.TypeOf(GroupHealth{}),
+	"PluginPolicy":    reflect.TypeOf(PluginPolicy{}),
+
+	// 宿主业务事件的数据
+	"NodeEvent":         reflect.TypeOf(NodeEvent{}),
+	"SubscriptionEvent": reflect.TypeOf(SubscriptionEvent{}),
+	"UserLoginEvent":    reflect.TypeOf(UserLoginEvent{}),
+	"TemplateEvent":     reflect.TypeOf(TemplateEvent{}),
+	"CustomEventType":   reflect.TypeOf(CustomEventType{}),
+
+	// 独立进程插件的gRPC消息，以JSON编码
+	"SubprocessDescribeResponse": reflect.TypeOf(rpcDescribeResponse{}),
+	"SubprocessConfigRequest":    reflect.TypeOf(rpcConfigRequest{}),
+	"SubprocessEventRequest":     reflect.TypeOf(rpcEventRequest{}),
+}
+
+// schemaEnums 字符串枚举类型的取值
+var schemaEnums = map[reflect.Type][]string{
+	reflect.TypeOf(EventType("")):        {string(EventAPISuccess), string(EventAPIError), string(EventAPIBefore), string(EventAPIAfter), string(EventNodeCreated), string(EventNodeUpdated), string(EventNodeDeleted), string(EventSubscriptionGenerated), string(EventUserLogin), string(EventTemplateChanged)},
+	reflect.TypeOf(PluginState("")):      {string(StateDiscovered), string(StateLoaded), string(StateEnabled), string(StateDisabled), string(StateInitFailed), string(StateCrashed), string(StateQuarantined), string(StateIncompatible), string(StatePendingApproval), string(StateWarming)},
+	reflect.TypeOf(Readiness("")):        {string(ReadinessReady), string(ReadinessDegraded), string(ReadinessRecovering)},
+	reflect.TypeOf(Severity("")):         {string(SeverityInfo), string(SeverityWarning), string(SeverityError), string(SeverityCritical)},
+	reflect.TypeOf(LoadDecision("")):     {string(LoadWouldLoad), string(LoadSkip), string(LoadReject)},
+	reflect.TypeOf(ConfigChangeType("")): {string(ConfigAdded), string(ConfigRemoved), string(ConfigChanged)},
+	reflect.TypeOf(OperationStage("")):   {string(StagePending), string(StageLoading), string(StageInitializing), string(StageWarming), string(StageHealthChecking), string(StageCompleted), string(StageFailed), string(StageCanceled)},
+	reflect.TypeOf(Compression("")):      {string(CompressionNone), string(CompressionGzip), string(CompressionZstd)},
+	reflect.TypeOf(GroupStatus("")):      {string(GroupHealthy), string(GroupDegraded), string(GroupDown)},
+	reflect.TypeOf(TemplateAction("")):   {string(TemplateCreated), string(TemplateUpdated), string(TemplateDeleted)},
+}
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+	rawJSONType  = reflect.TypeOf(json.RawMessage(nil))
+)
+
+// Schema 返回所有事件和管理API对象的JSON Schema文档，各对象定义在$defs中，供其他语言的插件作者生成代码
+func Schema() ([]byte, error) {
+	gen := &schemaGenerator{defs: make(map[string]interface{})}
+	for name, t := range schemaTypes {
+		gen.define(name, t)
+	}
+
+	doc := map[string]interface{}{
+		"$schema":     jsonSchemaDialect,
+		"$id":         fmt.Sprintf("sublink-plugins/v%d", SubprocessProtocolVersion),
+		"title":       "SublinkPro plugin contract",
+		"description": "事件载荷和插件管理API对象，时长字段以纳秒表示",
+		"$defs":       gen.defs,
+	}
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("生成JSON Schema失败: %v", err)
+	}
+	return data, nil
+}
+
+// SchemaHandler 返回输出JSON Schema文档的gin处理函数，路由由宿主自行注册
+func SchemaHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		data, err := Schema()
+		if err != nil {
+			c.String(http.StatusInternalServerError, err.Error())
+			return
+		}
+		c.Data(http.StatusOK, "application/schema+json", data)
+	}
+}
+
+// ProtoDefinition 返回独立进程插件gRPC协议的protobuf定义。消息以JSON编码传输，字段名与proto字段名一致
+func ProtoDefinition() string {
+	return subprocessProto
+}
+
+// schemaGenerator 基于反射生成JSON Schema，结构体按json标签生成属性
+type schemaGenerator struct {
+	defs  map[string]interface{}
+	names map[reflect.Type]string
+}
+
+// define 在$defs中登记结构体定义
+func (g *schemaGenerator) define(name string, t reflect.Type) {
+	if g.names == nil {
+		g.names = make(map[reflect.Type]string)
+	}
+	if _, exists := g.names[t]; exists {
+		return
+	}
+	g.names[t] = name
+	g.defs[name] = g.structSchema(t)
+}
+
+// schemaFor 生成类型的JSON Schema，命名结构体引用$defs中的定义
+func (g *schemaGenerator) schemaFor(t reflect.Type) map[string]interface{} {
+	switch t {
+	case timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case durationType:
+		return map[string]interface{}{"type": "integer", "description": "纳秒"}
+	case rawJSONType:
+		return map[string]interface{}{}
+	}
+
+	if values, ok := schemaEnums[t]; ok {
+		return map[string]interface{}{"type": "string", "enum": values}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return g.schemaFor(t.Elem())
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "contentEncoding": "base64"}
+		}
+		return map[string]interface{}{"type": "array", "items": g.schemaFor(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": g.schemaFor(t.Elem())}
+	case reflect.Struct:
+		name := t.Name()
+		if name == "" {
+			return g.structSchema(t)
+		}
+		if existing, ok := g.names[t]; ok {
+			name = existing
+		} else {
+			g.define(name, t)
+		}
+		return map[string]interface{}{"$ref": "#/$defs/" + name}
+	}
+
+	// interface{}等任意值
+	return map[string]interface{}{}
+}
+
+// structSchema 按encoding/json的规则生成结构体的属性，嵌入的结构体字段展开到外层
+func (g *schemaGenerator) structSchema(t reflect.Type) map[string]interface{} {
+	properties := make(map[string]interface{})
+	var required []string
+
+	var collect func(t reflect.Type)
+	collect = func(t reflect.Type) {
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if field.Anonymous && field.Type.Kind() == reflect.Struct && field.Tag.Get("json") == "" {
+				collect(field.Type)
+				continue
+			}
+			if !field.IsExported() || skipSchemaField(field.Type) {
+				continue
+			}
+
+			name, omitEmpty, skip := jsonFieldName(field)
+			if skip {
+				continue
+			}
+			properties[name] = g.schemaFor(field.Type)
+			if !omitEmpty && field.Type.Kind() != reflect.Ptr && field.Type.Kind() != reflect.Interface {
+				required = append(required, name)
+			}
+		}
+	}
+	collect(t)
+
+	schema := map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// skipSchemaField 判断字段是否无法序列化为JSON，如插件实例、函数和通道
+func skipSchemaField(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Func, reflect.Chan:
+		return true
+	case reflect.Interface:
+		return t.NumMethod() > 0
+	}
+	return false
+}
+
+// jsonFieldName 按json标签解析字段名
+func jsonFieldName(field reflect.StructField) (name string, omitEmpty bool, skip bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false, true
+	}
+
+	parts := strings.Split(tag, ",")
+	name = parts[0]
+	if name == "" {
+		name = field.Name
+	}
+	for _, opt := range parts[1:] {
+		if opt == "omitempty" {
+			omitEmpty = true
+		}
+	}
+	return name, omitEmpty, false
+}
+
+// subprocessProto 独立进程插件gRPC协议的protobuf定义，需与subprocess_rpc.go中的消息保持一致
+const subprocessProto = `syntax = "proto3";
+
+package sublink.plugin.v1;
+
+import "google/protobuf/struct.proto";
+
+// 插件进程启动后在stdout输出一行握手信息：sublink-plugin|1|tcp|127.0.0.1:<port>
+// 宿主通过环境变量SUBLINK_PLUGIN_MAGIC_COOKIE确认插件由宿主启动，stdin关闭表示宿主已退出
+// 消息以JSON编码（gRPC content-subtype为json），字段名与下面的proto字段名一致
+service Plugin {
+  rpc Describe(Empty) returns (DescribeResponse);
+  rpc SetConfig(ConfigRequest) returns (Empty);
+  rpc Init(Empty) returns (Empty);
+  rpc OnAPIEvent(EventRequest) returns (Empty);
+  rpc Healthy(Empty) returns (Empty);
+  rpc Close(Empty) returns (Empty);
+}
+
+message Empty {}
+
+message DescribeResponse {
+  int32 protocol_version = 1;
+  string name = 2;
+  string version = 3;
+  string description = 4;
+  google.protobuf.Struct default_config = 5;
+  repeated string interested_apis = 6;
+  repeated string interested_events = 7;
+}
+
+message ConfigRequest {
+  google.protobuf.Struct config = 1;
+}
+
+message EventRequest {
+  string event = 1;
+  string path = 2;
+  int32 status_code = 3;
+  google.protobuf.Value request_body = 4;
+  google.protobuf.Value response_body = 5;
+  string event_id = 6; // 事件ID，重试和重放时保持不变
+}
+`
@@ -0,0 +1,162 @@
+package plugins
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultShedThreshold 默认开始削减异步分发的压力值
+	defaultShedThreshold = 0.7
+
+	// pressureSampleInterval 读取宿主压力信号的最小间隔，避免每个事件都调用压力源
+	pressureSampleInterval = time.Second
+)
+
+// errLoadShed 宿主压力过高削减异步分发时记录在事件结果中的错误
+var errLoadShed = fmt.Errorf("宿主负载过高，已跳过异步处理")
+
+// PressureSource 宿主提供的压力信号，返回0（空闲）到1（满载）之间的值，
+// 可以综合CPU、内存和请求延迟等指标计算
+type PressureSource interface {
+	Pressure() float64
+}
+
+// PressureFunc 函数形式的压力信号
+type PressureFunc func() float64
+
+// Pressure 实现PressureSource接口
+func (f PressureFunc) Pressure() float64 {
+	return f()
+}
+
+// loadShedder 按宿主压力削减异步事件分发，优先级低的插件先被削减
+type loadShedder struct {
+	source    PressureSource
+	threshold float64 // 压力达到此值时开始削减
+
+	pressure  float64            // 最近一次读取的压力值
+	sampledAt time.Time          // 最近一次读取压力的时间
+	ranks     map[string]float64 // 插件在已启用插件中的优先级排位，0为最低
+	slot      float64            // 每个插件占用的排位宽度
+	shed      map[string]int64   // 每个插件被削减的事件数
+	mutex     sync.Mutex
+}
+
+func newLoadShedder() *loadShedder {
+	return &loadShedder{threshold: defaultShedThreshold, slot: 1, shed: make(map[string]int64)}
+}
+
+// SetPressureSource 设置宿主压力信号，source为nil时停止削减。压力超过threshold后按比例削减异步分发：
+// 压力从threshold升到1的过程中，按优先级从低到高依次削减插件，每个插件被跳过的事件比例从0逐步升到100%，
+// 压力为1时跳过所有异步分发。
+// 同步分发不受影响（由同步时间预算约束）。threshold需在0到1之间，为0时使用默认值0.7
+func (m *Manager) SetPressureSource(source PressureSource, threshold float64) error {
+	if threshold == 0 {
+		threshold = defaultShedThreshold
+	}
+	if threshold <= 0 || threshold >= 1 {
+		return fmt.Errorf("削减阈值必须在0到1之间")
+	}
+
+	m.shedder.mutex.Lock()
+	defer m.shedder.mutex.Unlock()
+
+	m.shedder.source = source
+	m.shedder.threshold = threshold
+	m.shedder.pressure = 0
+	m.shedder.sampledAt = time.Time{}
+	m.shedder.ranks, m.shedder.slot = nil, 1
+	return nil
+}
+
+// LoadPressure 获取最近一次读取的宿主压力值和当前削减比例，未设置压力信号时均为0
+func (m *Manager) LoadPressure() (pressure float64, shedRatio float64) {
+	m.shedder.mutex.Lock()
+	defer m.shedder.mutex.Unlock()
+
+	return m.shedder.pressure, m.shedder.ratio()
+}
+
+// ShedStats 获取每个插件因宿主压力被跳过的异步事件数
+func (m *Manager) ShedStats() map[string]int64 {
+	m.shedder.mutex.Lock()
+	defer m.shedder.mutex.Unlock()
+
+	result := make(map[string]int64, len(m.shedder.shed))
+	for name, n := range m.shedder.shed {
+		result[name] = n
+	}
+	return result
+}
+
+// ratio 当前削减比例，调用方需持有s.mutex
+func (s *loadShedder) ratio() float64 {
+	if s.source == nil || s.pressure <= s.threshold {
+		return 0
+	}
+	if s.pressure >= 1 {
+		return 1
+	}
+	return (s.pressure - s.threshold) / (1 - s.threshold)
+}
+
+// shouldShed 判断是否跳过插件的异步分发并计数，不能在持有m.mutex时调用
+func (m *Manager) shouldShed(name string) bool {
+	s := m.shedder
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.source == nil {
+		return false
+	}
+
+	// 按间隔读取压力信号，需要削减时重新计算插件的优先级排位
+	if now := time.Now(); now.Sub(s.sampledAt) >= pressureSampleInterval {
+		s.sampledAt = now
+		s.pressure = s.source.Pressure()
+		s.ranks, s.slot = nil, 1
+		if s.ratio() > 0 {
+			s.ranks = m.priorityRanks()
+			if len(s.ranks) > 0 {
+				s.slot = 1 / float64(len(s.ranks))
+			}
+		}
+	}
+
+	ratio := s.ratio()
+	if ratio <= 0 {
+		return false
+	}
+
+	// 削减比例越过插件的排位后，该插件被跳过的概率在一个排位宽度内从0升到1；
+	// 排位之后才启用的插件按最低优先级处理
+	probability := (ratio - s.ranks[name]) / s.slot
+	if probability <= 0 || (probability < 1 && rand.Float64() >= probability) {
+		return false
+	}
+	s.shed[name]++
+	return true
+}
+
+// priorityRanks 计算已启用插件按优先级从低到高的排位，取值在[0, 1)之间
+func (m *Manager) priorityRanks() map[string]float64 {
+	m.mutex.RLock()
+	var infos []*PluginInfo
+	for _, info := range m.plugins {
+		if info.Enabled {
+			infos = append(infos, info)
+		}
+	}
+	sortByPriority(infos)
+	m.mutex.RUnlock()
+
+	// sortByPriority按优先级从高到低排列，倒序计算排位
+	ranks := make(map[string]float64, len(infos))
+	for i, info := range infos {
+		ranks[info.Name] = float64(len(infos)-1-i) / float64(len(infos))
+	}
+	return ranks
+}
@@ -0,0 +1,318 @@
+package plugins
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ShutdownPhase 关闭阶段，按 stop_intake → flush → close → storage_flush 的顺序执行
+type ShutdownPhase string
+
+const (
+	PhaseStopIntake   ShutdownPhase = "stop_intake"   // 停止接收新事件，停止目录监听和延迟事件定时器
+	PhaseFlush        ShutdownPhase = "flush"         // 等待异步处理完成，调用插件的Flush
+	PhaseClose        ShutdownPhase = "close"         // 关闭所有插件
+	PhaseStorageFlush ShutdownPhase = "storage_flush" // 将计数器写入存储，调用存储的Flush
+)
+
+// shutdownPhases 关闭阶段的执行顺序
+var shutdownPhases = []ShutdownPhase{PhaseStopIntake, PhaseFlush, PhaseClose, PhaseStorageFlush}
+
+// defaultShutdownTimeouts 各关闭阶段的默认超时时间
+var defaultShutdownTimeouts = map[ShutdownPhase]time.Duration{
+	PhaseStopIntake:   5 * time.Second,
+	PhaseFlush:        10 * time.Second,
+	PhaseClose:        10 * time.Second,
+	PhaseStorageFlush: 5 * time.Second,
+}
+
+// Flusher 需要在关闭前写出缓冲数据的插件实现此接口（可选实现），在flush阶段调用；
+// 存储实现此接口时在storage_flush阶段调用
+type Flusher interface {
+	Flush(ctx context.Context) error
+}
+
+// defaultPluginCloseTimeout 关闭阶段单个插件Close的默认超时时间
+const defaultPluginCloseTimeout = 3 * time.Second
+
+// shutdownSettings 关闭阶段的超时时间和宿主登记的钩子
+type shutdownSettings struct {
+	timeouts     map[ShutdownPhase]time.Duration
+	closeTimeout time.Duration // 单个插件Close的超时时间
+	hooks        map[ShutdownPhase][]func(ctx context.Context) error
+	mutex        sync.Mutex
+}
+
+func newShutdownSettings() *shutdownSettings {
+	timeouts := make(map[ShutdownPhase]time.Duration, len(defaultShutdownTimeouts))
+	for phase, timeout := range defaultShutdownTimeouts {
+		timeouts[phase] = timeout
+	}
+	return &shutdownSettings{
+		timeouts:     timeouts,
+		closeTimeout: defaultPluginCloseTimeout,
+		hooks:        make(map[ShutdownPhase][]func(ctx context.Context) error),
+	}
+}
+
+// SetShutdownTimeout 设置关闭阶段的超时时间，超时后不再等待该阶段剩余的工作，直接进入下一阶段
+func (m *Manager) SetShutdownTimeout(phase ShutdownPhase, timeout time.Duration) error {
+	if _, exists := defaultShutdownTimeouts[phase]; !exists {
+		return fmt.Errorf("未知的关闭阶段: %s", phase)
+	}
+	if timeout <= 0 {
+		return fmt.Errorf("关闭阶段超时时间必须大于0")
+	}
+
+	m.shutdown.mutex.Lock()
+	defer m.shutdown.mutex.Unlock()
+
+	m.shutdown.timeouts[phase] = timeout
+	return nil
+}
+
+// SetPluginCloseTimeout 设置关闭阶段单个插件Close的超时时间，超时后不再等待该插件，继续关闭下一个插件
+func (m *Manager) SetPluginCloseTimeout(timeout time.Duration) error {
+	if timeout <= 0 {
+		return fmt.Errorf("插件关闭超时时间必须大于0")
+	}
+
+	m.shutdown.mutex.Lock()
+	defer m.shutdown.mutex.Unlock()
+
+	m.shutdown.closeTimeout = timeout
+	return nil
+}
+
+// OnShutdown 登记宿主的关闭钩子，在对应阶段的内置工作完成后按登记顺序执行，共享该阶段的超时时间
+func (m *Manager) OnShutdown(phase ShutdownPhase, hook func(ctx context.Context) error) error {
+	if _, exists := defaultShutdownTimeouts[phase]; !exists {
+		return fmt.Errorf("未知的关闭阶段: %s", phase)
+	}
+
+	m.shutdown.mutex.Lock()
+	defer m.shutdown.mutex.Unlock()
+
+	m.shutdown.hooks[phase] = append(m.shutdown.hooks[phase], hook)
+	return nil
+}
+
+// IsShuttingDown 是否正在关闭，关闭期间不再分发新事件
+func (m *Manager) IsShuttingDown() bool {
+	return m.shuttingDown.Load()
+}
+
+// WaitIdle 等待已分发的异步事件处理全部完成，ctx取消时返回错误；常用于测试中检查插件处理的结果
+func (m *Manager) WaitIdle(ctx context.Context) error {
+	return waitContext(ctx, &m.inflight)
+}
+
+// Shutdown 按阶段关闭插件管理器：停止接收事件、等待异步处理并调用插件Flush、关闭插件、写入存储。
+// 每个阶段受各自的超时时间和ctx约束，超时或出错不影响后续阶段；返回所有阶段的错误，全部完成时返回nil
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.shuttingDown.Store(true)
+	defer m.shuttingDown.Store(false)
+
+	var errs []error
+	for _, phase := range shutdownPhases {
+		m.shutdown.mutex.Lock()
+		timeout := m.shutdown.timeouts[phase]
+		hooks := append([]func(ctx context.Context) error{}, m.shutdown.hooks[phase]...)
+		m.shutdown.mutex.Unlock()
+
+		start := time.Now()
+		phaseCtx, cancel := context.WithTimeout(ctx, timeout)
+		err := m.runShutdownPhase(phaseCtx, phase)
+		for _, hook := range hooks {
+			if phaseCtx.Err() != nil {
+				break
+			}
+			if hookErr := hook(phaseCtx); hookErr != nil {
+				err = errors.Join(err, fmt.Errorf("关闭钩子失败: %v", hookErr))
+			}
+		}
+		if ctx.Err() != nil {
+			err = errors.Join(err, fmt.Errorf("已取消: %v", ctx.Err()))
+		} else if phaseCtx.Err() != nil {
+			err = errors.Join(err, fmt.Errorf("超时（%v）", timeout))
+		}
+		cancel()
+
+		if err != nil {
+			m.logger.Printf("关闭阶段 %s 未完成: %v", phase, err)
+			errs = append(errs, fmt.Errorf("关闭阶段 %s: %w", phase, err))
+		}
+		m.logger.Printf("关闭阶段 %s 完成，耗时 %v", phase, time.Since(start))
+	}
+	return errors.Join(errs...)
+}
+
+// runShutdownPhase 执行关闭阶段的内置工作
+func (m *Manager) runShutdownPhase(ctx context.Context, phase ShutdownPhase) error {
+	switch phase {
+	case PhaseStopIntake:
+		m.StopWatcher()
+		m.StopReconciler()
+		m.StopHealthChecks()
+		m.stopScheduledTimers()
+		return nil
+
+	case PhaseFlush:
+		if err := waitContext(ctx, &m.inflight); err != nil {
+			return fmt.Errorf("等待异步处理完成: %v", err)
+		}
+		return m.flushPlugins(ctx)
+
+	case PhaseClose:
+		return m.closePlugins(ctx)
+
+	case PhaseStorageFlush:
+		m.FlushStats()
+		if flusher, ok := m.store().(Flusher); ok {
+			if err := flusher.Flush(ctx); err != nil {
+				return fmt.Errorf("写入存储失败: %v", err)
+			}
+		}
+		return nil
+	}
+	return nil
+}
+
+// flushPlugins 并发调用已启用插件的Flush
+func (m *Manager) flushPlugins(ctx context.Context) error {
+	m.mutex.RLock()
+	var flushers []*PluginInfo
+	for _, info := range m.plugins {
+		if _, ok := info.Plugin.(Flusher); ok && info.Enabled && !info.dormant {
+			flushers = append(flushers, info)
+		}
+	}
+	m.mutex.RUnlock()
+
+	errs := make([]error, len(flushers))
+	var wg sync.WaitGroup
+	for i, info := range flushers {
+		wg.Add(1)
+		go func(i int, info *PluginInfo) {
+			defer wg.Done()
+			runWithPluginLabels(info.Name, func() {
+				if err := info.Plugin.(Flusher).Flush(ctx); err != nil {
+					errs[i] = fmt.Errorf("插件 %s 写出数据失败: %v", info.Name, err)
+				}
+			})
+		}(i, info)
+	}
+
+	if err := waitContext(ctx, &wg); err != nil {
+		return err
+	}
+	return errors.Join(errs...)
+}
+
+// closePlugins 按启用顺序的逆序依次关闭运行中的插件并清空插件列表：后启用的插件（如依赖其他插件的插件）先关闭，
+// 同时启用的按优先级从低到高关闭。每个插件的Close受单独的超时时间约束，阶段超时后剩余的插件不再关闭
+func (m *Manager) closePlugins(ctx context.Context) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	err := m.closeRunning(ctx)
+	m.plugins = make(map[string]*PluginInfo)
+	return err
+}
+
+// closeRunning 按关闭顺序依次关闭运行中的插件，不修改插件列表，调用方需持有m.mutex
+func (m *Manager) closeRunning(ctx context.Context) error {
+	m.shutdown.mutex.Lock()
+	closeTimeout := m.shutdown.closeTimeout
+	m.shutdown.mutex.Unlock()
+
+	var running []*PluginInfo
+	for _, info := range m.plugins {
+		if info.Enabled && !info.dormant {
+			running = append(running, info)
+		}
+	}
+	sort.Slice(running, func(i, j int) bool {
+		a, b := running[i], running[j]
+		if a.enabledSeq != b.enabledSeq {
+			return a.enabledSeq > b.enabledSeq
+		}
+		if a.Priority != b.Priority {
+			return a.Priority < b.Priority
+		}
+		return a.Name > b.Name
+	})
+
+	var errs []error
+	for i, info := range running {
+		if ctx.Err() != nil {
+			for _, rest := range running[i:] {
+				errs = append(errs, fmt.Errorf("插件 %s 未关闭: %v", rest.Name, ctx.Err()))
+			}
+			break
+		}
+		if err := m.closePlugin(ctx, info, closeTimeout); err != nil {
+			m.logger.Printf("关闭插件失败 %s: %v", info.Name, err)
+			errs = append(errs, fmt.Errorf("关闭插件 %s 失败: %w", info.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// closePlugin 在超时时间内关闭单个插件，超时后不再等待
+func (m *Manager) closePlugin(ctx context.Context, info *PluginInfo, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("关闭时发生panic: %v", r)
+			}
+		}()
+		runWithPluginLabels(info.Name, func() {
+			done <- info.Plugin.Close()
+		})
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("关闭超时: %v", ctx.Err())
+	}
+}
+
+// stopScheduledTimers 停止延迟事件定时器，存储中的延迟事件在下次启动时恢复
+func (m *Manager) stopScheduledTimers() {
+	m.scheduler.mutex.Lock()
+	defer m.scheduler.mutex.Unlock()
+
+	for id, timer := range m.scheduler.timers {
+		timer.Stop()
+		delete(m.scheduler.timers, id)
+		delete(m.scheduler.events, id)
+	}
+	m.scheduler.restored = false
+}
+
+// waitContext 等待WaitGroup完成或ctx结束
+func waitContext(ctx context.Context, wg *sync.WaitGroup) error {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
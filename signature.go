@@ -0,0 +1,139 @@
+package plugins
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// signatureSuffix 插件签名文件后缀，签名文件与插件文件放在一起，如 foo.so.sig
+const signatureSuffix = ".sig"
+
+// SignaturePolicy 插件签名校验策略
+type SignaturePolicy string
+
+const (
+	SignatureDisabled   SignaturePolicy = "disabled"   // 不校验签名（默认）
+	SignatureSkip       SignaturePolicy = "skip"       // 跳过未签名或签名无效的插件
+	SignatureQuarantine SignaturePolicy = "quarantine" // 登记为隔离状态但不打开插件文件，便于在界面中展示
+)
+
+// signatureVerifier 插件签名校验配置
+type signatureVerifier struct {
+	policy SignaturePolicy
+	keys   []ed25519.PublicKey
+	mutex  sync.RWMutex
+}
+
+// SetSignaturePolicy 设置插件签名校验策略及受信任的Ed25519公钥
+func (m *Manager) SetSignaturePolicy(policy SignaturePolicy, trustedKeys ...ed25519.PublicKey) error {
+	if err := m.checkWritable(); err != nil {
+		return err
+	}
+
+	switch policy {
+	case SignatureDisabled, SignatureSkip, SignatureQuarantine:
+	default:
+		return fmt.Errorf("未知的签名校验策略: %s", policy)
+	}
+
+	for i, key := range trustedKeys {
+		if len(key) != ed25519.PublicKeySize {
+			return fmt.Errorf("第 %d 个公钥长度无效", i+1)
+		}
+	}
+	if policy != SignatureDisabled && len(trustedKeys) == 0 {
+		return fmt.Errorf("启用签名校验时至少需要一个受信任的公钥")
+	}
+
+	m.signature.mutex.Lock()
+	defer m.signature.mutex.Unlock()
+
+	m.signature.policy = policy
+	m.signature.keys = append([]ed25519.PublicKey(nil), trustedKeys...)
+	return nil
+}
+
+// GetSignaturePolicy 获取插件签名校验策略
+func (m *Manager) GetSignaturePolicy() SignaturePolicy {
+	m.signature.mutex.RLock()
+	defer m.signature.mutex.RUnlock()
+
+	if m.signature.policy == "" {
+		return SignatureDisabled
+	}
+	return m.signature.policy
+}
+
+// signatureError 签名校验失败
+type signatureError struct {
+	err error
+}
+
+func (e *signatureError) Error() string {
+	return fmt.Sprintf("签名校验失败: %v", e.err)
+}
+
+func (e *signatureError) Unwrap() error {
+	return e.err
+}
+
+// isSignatureError 判断错误是否由签名校验失败引起
+func isSignatureError(err error) bool {
+	var sigErr *signatureError
+	return errors.As(err, &sigErr)
+}
+
+// verifySignature 使用受信任的公钥校验插件文件的分离签名，未启用签名校验时直接通过
+func (m *Manager) verifySignature(pluginPath string) error {
+	m.signature.mutex.RLock()
+	policy := m.signature.policy
+	keys := m.signature.keys
+	m.signature.mutex.RUnlock()
+
+	if policy == "" || policy == SignatureDisabled {
+		return nil
+	}
+
+	sigData, err := os.ReadFile(pluginPath + signatureSuffix)
+	if os.IsNotExist(err) {
+		return &signatureError{fmt.Errorf("插件未签名")}
+	}
+	if err != nil {
+		return &signatureError{fmt.Errorf("读取签名文件失败: %v", err)}
+	}
+
+	sig, err := decodeSignature(sigData)
+	if err != nil {
+		return &signatureError{err}
+	}
+
+	data, err := os.ReadFile(pluginPath)
+	if err != nil {
+		return &signatureError{fmt.Errorf("读取插件文件失败: %v", err)}
+	}
+
+	for _, key := range keys {
+		if ed25519.Verify(key, data, sig) {
+			return nil
+		}
+	}
+	return &signatureError{fmt.Errorf("插件签名无效或不是由受信任的公钥签发")}
+}
+
+// decodeSignature 解析签名文件，支持原始64字节或Base64编码
+func decodeSignature(data []byte) ([]byte, error) {
+	if len(data) == ed25519.SignatureSize {
+		return data, nil
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(sig) != ed25519.SignatureSize {
+		return nil, fmt.Errorf("签名文件格式无效")
+	}
+	return sig, nil
+}
@@ -0,0 +1,394 @@
+package plugins
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// snapshotManifestName 快照清单在归档中的文件名
+	snapshotManifestName = "snapshot.json"
+
+	// snapshotFilesDir 插件文件在归档中的目录
+	snapshotFilesDir = "files/"
+
+	// maxSnapshotSize 恢复时快照解压后的最大总大小
+	maxSnapshotSize = 1 << 30
+)
+
+// SnapshotPlugin 快照中的单个插件记录
+type SnapshotPlugin struct {
+	Name    string                 `json:"name"`
+	Version string                 `json:"version"`
+	File    string                 `json:"file"`   // 插件文件相对于其搜索目录的路径，以/分隔
+	SHA256  string                 `json:"sha256"` // 插件文件校验和
+	Enabled bool                   `json:"enabled"`
+	Config  map[string]interface{} `json:"config"`
+	Data    map[string]string      `json:"data,omitempty"` // 插件KV数据
+
+	// Extra 随插件文件一起恢复的文件：清单、签名，插件包目录中的其他文件
+	Extra []SnapshotFile `json:"extra,omitempty"`
+}
+
+// SnapshotFile 快照中随插件文件一起恢复的文件
+type SnapshotFile struct {
+	Path   string `json:"path"` // 相对于搜索目录的路径，以/分隔
+	SHA256 string `json:"sha256"`
+}
+
+// snapshotSource 写入快照的文件在归档中的路径和来源
+type snapshotSource struct {
+	name   string
+	source string
+}
+
+// SnapshotManifest 快照清单
+type SnapshotManifest struct {
+	CreatedAt time.Time        `json:"created_at"`
+	Plugins   []SnapshotPlugin `json:"plugins"`
+}
+
+// Snapshot 将插件子系统（插件文件及其清单、签名和插件包中的其他文件，启用状态、配置、KV数据）打包写入w，格式为tar.gz
+func (m *Manager) Snapshot(w io.Writer) error {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	manifest := SnapshotManifest{CreatedAt: time.Now()}
+	dataStorage, _ := m.store().(PluginDataStorage)
+	seen := make(map[string]string)
+	var sources []snapshotSource
+
+	for _, info := range m.plugins {
+		sum, err := fileSHA256(info.FilePath)
+		if err != nil {
+			return fmt.Errorf("计算插件 %s 校验和失败: %v", info.Name, err)
+		}
+
+		// 按相对于搜索目录的路径记录，不同子目录中的同名文件不会互相覆盖
+		root := m.searchDirOf(info.FilePath)
+		file := snapshotRelPath(root, info.FilePath)
+		extra, err := snapshotExtraFiles(root, info.FilePath)
+		if err != nil {
+			return fmt.Errorf("读取插件 %s 的附属文件失败: %v", info.Name, err)
+		}
+		for _, src := range append([]snapshotSource{{name: file, source: info.FilePath}}, extra...) {
+			if other, exists := seen[src.name]; exists {
+				return fmt.Errorf("插件 %s 与 %s 的文件路径相同: %s", info.Name, other, src.name)
+			}
+			seen[src.name] = info.Name
+			sources = append(sources, src)
+		}
+
+		item := SnapshotPlugin{
+			Name:    info.Name,
+			Version: info.Version,
+			File:    file,
+			SHA256:  sum,
+			Enabled: info.Enabled,
+			Config:  info.Config,
+		}
+		for _, src := range extra {
+			sum, err := fileSHA256(src.source)
+			if err != nil {
+				return fmt.Errorf("计算插件 %s 附属文件校验和失败: %v", info.Name, err)
+			}
+			item.Extra = append(item.Extra, SnapshotFile{Path: src.name, SHA256: sum})
+		}
+
+		if dataStorage != nil {
+			data, err := dataStorage.ExportPluginData(info.Name)
+			if err != nil {
+				return fmt.Errorf("导出插件 %s 数据失败: %v", info.Name, err)
+			}
+			item.Data = data
+		}
+
+		manifest.Plugins = append(manifest.Plugins, item)
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化快照清单失败: %v", err)
+	}
+	if err := writeTarEntry(tw, snapshotManifestName, manifestData); err != nil {
+		return err
+	}
+
+	for _, src := range sources {
+		data, err := os.ReadFile(src.source)
+		if err != nil {
+			return fmt.Errorf("读取插件文件失败 %s: %v", src.source, err)
+		}
+		if err := writeTarEntry(tw, snapshotFilesDir+src.name, data); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("写入快照失败: %v", err)
+	}
+	return gz.Close()
+}
+
+// Restore 从Snapshot生成的归档中恢复插件子系统
+func (m *Manager) Restore(r io.Reader) error {
+	if err := m.checkWritable(); err != nil {
+		return err
+	}
+
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return fmt.Errorf("读取快照失败: %v", err)
+	}
+	defer gz.Close()
+
+	// 先完整读入归档，校验通过后再落盘
+	var manifest *SnapshotManifest
+	files := make(map[string][]byte)
+	var total int64
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("读取快照失败: %v", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		total += hdr.Size
+		if total > maxSnapshotSize {
+			return fmt.Errorf("快照解压后过大，超过 %d 字节", maxSnapshotSize)
+		}
+		data, err := io.ReadAll(io.LimitReader(tr, hdr.Size))
+		if err != nil {
+			return fmt.Errorf("读取快照条目 %s 失败: %v", hdr.Name, err)
+		}
+
+		if hdr.Name == snapshotManifestName {
+			manifest = &SnapshotManifest{}
+			if err := json.Unmarshal(data, manifest); err != nil {
+				return fmt.Errorf("解析快照清单失败: %v", err)
+			}
+			continue
+		}
+		if name, ok := strings.CutPrefix(hdr.Name, snapshotFilesDir); ok {
+			files[name] = data
+		}
+	}
+
+	if manifest == nil {
+		return fmt.Errorf("快照中缺少清单文件")
+	}
+
+	restored := make(map[string]bool)
+	for _, item := range manifest.Plugins {
+		for _, file := range append([]SnapshotFile{{Path: item.File, SHA256: item.SHA256}}, item.Extra...) {
+			if !isSnapshotFilePath(file.Path) || restored[file.Path] {
+				return fmt.Errorf("快照中插件 %s 的文件路径无效或重复: %s", item.Name, file.Path)
+			}
+			restored[file.Path] = true
+
+			data, ok := files[file.Path]
+			if !ok {
+				return fmt.Errorf("快照中缺少插件 %s 的文件 %s", item.Name, file.Path)
+			}
+			sum := sha256.Sum256(data)
+			if hex.EncodeToString(sum[:]) != file.SHA256 {
+				return fmt.Errorf("插件 %s 的文件 %s 校验和不匹配", item.Name, file.Path)
+			}
+		}
+	}
+
+	m.mutex.RLock()
+	pluginDir := m.pluginDir
+	m.mutex.RUnlock()
+
+	dataStorage, _ := m.store().(PluginDataStorage)
+
+	for _, item := range manifest.Plugins {
+		pluginPath := filepath.Join(pluginDir, filepath.FromSlash(item.File))
+
+		if _, loaded := m.GetPlugin(item.Name); !loaded {
+			if err := m.restoreSnapshotFiles(item, pluginDir, files); err != nil {
+				return err
+			}
+		}
+
+		if dataStorage != nil && item.Data != nil {
+			if err := dataStorage.ImportPluginData(item.Name, item.Data); err != nil {
+				return fmt.Errorf("导入插件 %s 数据失败: %v", item.Name, err)
+			}
+		}
+
+		if err := m.restoreSnapshotPlugin(item, pluginPath); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// restoreSnapshotPlugin 将单个插件恢复到快照中的启用状态与配置
+func (m *Manager) restoreSnapshotPlugin(item SnapshotPlugin, pluginPath string) error {
+	if _, loaded := m.GetPlugin(item.Name); !loaded {
+		// 先写入存储，loadPlugin会按存储中的状态初始化插件
+		if err := m.store().SavePlugin(item.Name, pluginPath, item.Enabled, item.Config); err != nil {
+			return fmt.Errorf("写入插件 %s 存储记录失败: %v", item.Name, err)
+		}
+
+		m.mutex.Lock()
+		_, err := m.loadPlugin(pluginPath)
+		m.mutex.Unlock()
+		if err != nil {
+			return fmt.Errorf("加载插件 %s 失败: %v", item.Name, err)
+		}
+		return nil
+	}
+
+	if err := m.UpdatePluginConfig(item.Name, item.Config); err != nil {
+		return err
+	}
+
+	if item.Enabled {
+		return m.EnablePlugin(item.Name)
+	}
+	return m.DisablePlugin(item.Name)
+}
+
+// restoreSnapshotFiles 将插件文件及附属文件写入插件目录，附属文件先于插件文件写入，
+// 写入后校验清单和签名，校验失败时删除写入的文件
+func (m *Manager) restoreSnapshotFiles(item SnapshotPlugin, pluginDir string, files map[string][]byte) error {
+	pluginPath := filepath.Join(pluginDir, filepath.FromSlash(item.File))
+
+	var written []string
+	cleanup := func() {
+		for _, path := range written {
+			os.Remove(path)
+		}
+	}
+	for _, file := range append(item.Extra, SnapshotFile{Path: item.File}) {
+		target := filepath.Join(pluginDir, filepath.FromSlash(file.Path))
+		perm := os.FileMode(0644)
+		if target == pluginPath {
+			perm = 0755
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			cleanup()
+			return fmt.Errorf("创建插件目录失败: %v", err)
+		}
+		if err := os.WriteFile(target, files[file.Path], perm); err != nil {
+			cleanup()
+			return fmt.Errorf("写入插件文件失败 %s: %v", target, err)
+		}
+		written = append(written, target)
+	}
+
+	if _, err := loadManifest(pluginPath); err != nil {
+		cleanup()
+		return fmt.Errorf("恢复的插件 %s 清单无效: %v", item.Name, err)
+	}
+	if err := m.verifySignature(pluginPath); err != nil {
+		cleanup()
+		return fmt.Errorf("恢复的插件 %s 签名校验失败: %v", item.Name, err)
+	}
+	if err := m.saveChecksum(pluginPath); err != nil {
+		cleanup()
+		return err
+	}
+	return nil
+}
+
+// snapshotRelPath 文件相对于搜索目录的路径，以/分隔，不在搜索目录中时使用文件名
+func snapshotRelPath(root, file string) string {
+	if rel, err := filepath.Rel(root, file); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	return filepath.Base(file)
+}
+
+// snapshotExtraFiles 返回随插件文件一起写入快照的附属文件：插件包目录中的其他文件；
+// 不在插件包中时为插件使用的清单（目录级或共用清单按插件专属清单恢复，不影响恢复目录中的其他插件）和签名文件
+func snapshotExtraFiles(root, pluginPath string) ([]snapshotSource, error) {
+	if packageDirName(pluginPath) != "" {
+		var extra []snapshotSource
+		err := filepath.WalkDir(filepath.Dir(pluginPath), func(path string, d os.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.Type().IsRegular() || path == pluginPath {
+				return nil
+			}
+			extra = append(extra, snapshotSource{name: snapshotRelPath(root, path), source: path})
+			return nil
+		})
+		return extra, err
+	}
+
+	file := snapshotRelPath(root, pluginPath)
+	var extra []snapshotSource
+	if manifest := manifestPath(pluginPath); manifest != "" {
+		name := strings.TrimSuffix(file, path.Ext(file)) + ".plugin.json"
+		extra = append(extra, snapshotSource{name: name, source: manifest})
+	}
+	if _, err := os.Stat(pluginPath + signatureSuffix); err == nil {
+		extra = append(extra, snapshotSource{name: file + signatureSuffix, source: pluginPath + signatureSuffix})
+	}
+	return extra, nil
+}
+
+// isSnapshotFilePath 判断快照清单中的插件文件路径是否为插件目录内的相对路径
+func isSnapshotFilePath(name string) bool {
+	clean := path.Clean(name)
+	return name != "" && clean == name && !strings.Contains(name, "\\") && !path.IsAbs(clean) &&
+		clean != "." && clean != ".." && !strings.HasPrefix(clean, "../")
+}
+
+// fileSHA256 计算文件的SHA-256校验和
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// writeTarEntry 向tar归档写入单个文件
+func writeTarEntry(tw *tar.Writer, name string, data []byte) error {
+	hdr := &tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    int64(len(data)),
+		ModTime: time.Now(),
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return fmt.Errorf("写入快照条目 %s 失败: %v", name, err)
+	}
+	if _, err := tw.Write(data); err != nil {
+		return fmt.Errorf("写入快照条目 %s 失败: %v", name, err)
+	}
+	return nil
+}
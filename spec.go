@@ -0,0 +1,56 @@
+package plugins
+
+import (
+	"fmt"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ExportSpec 以YAML导出当前已安装插件的声明式描述：名称、版本、插件文件路径、校验和、启用状态和配置。
+// 导出结果可以用ParseSpec解析后交给ApplyPlan，在其他环境中重建相同的插件状态，或放入版本库审阅配置变更；
+// 目标环境中未安装的插件会从source安装，因此source需在目标环境中可访问
+func (m *Manager) ExportSpec() ([]byte, error) {
+	m.mutex.RLock()
+	items := make([]PlanItem, 0, len(m.plugins))
+	for _, info := range m.plugins {
+		action := PlanDisable
+		if info.Enabled || info.awaitingDeps {
+			action = PlanEnable
+		}
+		items = append(items, PlanItem{
+			Name:    info.Name,
+			Action:  action,
+			Version: info.Version,
+			Source:  info.FilePath,
+			Config:  info.Config,
+		})
+	}
+	m.mutex.RUnlock()
+
+	// 计算校验和需要读取文件，不持有m.mutex
+	for i := range items {
+		sum, err := fileSHA256(items[i].Source)
+		if err != nil {
+			m.logger.Printf("计算插件 %s 的校验和失败: %v", items[i].Name, err)
+			continue
+		}
+		items[i].Checksum = sum
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Name < items[j].Name })
+
+	data, err := yaml.Marshal(&Plan{Items: items})
+	if err != nil {
+		return nil, fmt.Errorf("序列化插件描述失败: %v", err)
+	}
+	return data, nil
+}
+
+// ParseSpec 解析ExportSpec导出的YAML（也接受JSON），返回可交给ApplyPlan的计划
+func ParseSpec(data []byte) (*Plan, error) {
+	var plan Plan
+	if err := yaml.Unmarshal(data, &plan); err != nil {
+		return nil, fmt.Errorf("解析插件描述失败: %v", err)
+	}
+	return &plan, nil
+}
@@ -0,0 +1,57 @@
+package plugins
+
+import "fmt"
+
+// StartupPolicy 加载插件时决定启用状态的策略
+type StartupPolicy string
+
+const (
+	StartupRespectStorage  StartupPolicy = "respect_storage"  // 按存储中的启用状态，新插件默认禁用（默认）
+	StartupEnableAll       StartupPolicy = "enable_all"       // 启用所有发现的插件，用于首次部署
+	StartupRequireApproval StartupPolicy = "require_approval" // 所有插件加载后等待管理员批准，用于加固环境
+)
+
+// SetStartupPolicy 设置加载插件时的启用策略，需在LoadPlugins之前调用。
+// 隔离、不兼容等状态不受策略影响；等待批准的插件通过EnablePlugin批准
+func (m *Manager) SetStartupPolicy(policy StartupPolicy) error {
+	if err := m.checkWritable(); err != nil {
+		return err
+	}
+
+	switch policy {
+	case StartupRespectStorage, StartupEnableAll, StartupRequireApproval:
+	default:
+		return fmt.Errorf("不支持的启动策略: %s", policy)
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.startupPolicy = policy
+	return nil
+}
+
+// GetStartupPolicy 获取加载插件时的启用策略
+func (m *Manager) GetStartupPolicy() StartupPolicy {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	return m.startupPolicy
+}
+
+// startupState 根据启动策略和存储记录推导插件加载时的状态，reason为空时沿用存储中的状态原因
+func startupState(policy StartupPolicy, record *PluginStorageInfo) (state PluginState, reason string) {
+	state = initialState(record)
+
+	switch policy {
+	case StartupEnableAll:
+		if state == StateDisabled {
+			state = StateEnabled
+		}
+	case StartupRequireApproval:
+		if state == StateEnabled || state == StateDisabled {
+			state, reason = StatePendingApproval, "启动策略要求管理员批准"
+		}
+	}
+	return state, reason
+}
@@ -0,0 +1,306 @@
+package plugins
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// PluginState 插件状态，PluginInfo.Enabled与State == StateEnabled保持一致
+type PluginState string
+
+const (
+	StateDiscovered      PluginState = "discovered"       // 已发现插件文件，尚未打开
+	StateLoaded          PluginState = "loaded"           // 已打开插件文件，尚未初始化
+	StateEnabled         PluginState = "enabled"          // 已启用
+	StateDisabled        PluginState = "disabled"         // 被用户禁用
+	StateInitFailed      PluginState = "init_failed"      // 初始化、预热或升级迁移失败，需重新启用
+	StateCrashed         PluginState = "crashed"          // 处理事件或执行管道阶段时发生panic，已关闭，需重新启用
+	StateQuarantined     PluginState = "quarantined"      // 因错误被隔离
+	StateIncompatible    PluginState = "incompatible"     // 与宿主不兼容
+	StatePendingApproval PluginState = "pending_approval" // 等待管理员批准
+	StateWarming         PluginState = "warming"          // 预热中
+)
+
+// stateTransitions 允许的状态迁移，discovered和loaded只出现在加载过程中，插件登记后处于其他状态
+var stateTransitions = map[PluginState][]PluginState{
+	StateDiscovered:      {StateLoaded, StateEnabled, StateDisabled, StateInitFailed, StateQuarantined, StateIncompatible, StatePendingApproval},
+	StateLoaded:          {StateEnabled, StateDisabled, StateInitFailed, StateQuarantined, StateIncompatible, StatePendingApproval},
+	StateEnabled:         {StateDisabled, StateInitFailed, StateCrashed, StateQuarantined, StateIncompatible},
+	StateDisabled:        {StateEnabled, StateInitFailed, StateQuarantined, StateIncompatible, StatePendingApproval, StateWarming},
+	StateInitFailed:      {StateEnabled, StateDisabled, StateQuarantined, StateIncompatible, StatePendingApproval, StateWarming},
+	StateCrashed:         {StateEnabled, StateDisabled, StateInitFailed, StateQuarantined, StateIncompatible, StatePendingApproval, StateWarming},
+	StateQuarantined:     {StateEnabled, StateDisabled, StateInitFailed, StateIncompatible, StateWarming},
+	StateIncompatible:    {StateDisabled},
+	StatePendingApproval: {StateEnabled, StateDisabled, StateInitFailed, StateQuarantined, StateIncompatible, StateWarming},
+	StateWarming:         {StateEnabled, StateDisabled, StateInitFailed, StateQuarantined},
+}
+
+// maxStateHistory 每个插件保留的状态迁移记录数量
+const maxStateHistory = 20
+
+// StateTransition 插件状态迁移记录
+type StateTransition struct {
+	From   PluginState `json:"from,omitempty"` // 插件首次被发现时为空
+	To     PluginState `json:"to"`
+	Reason string      `json:"reason,omitempty"`
+	Time   time.Time   `json:"time"`
+}
+
+// canTransition 判断状态迁移是否合法
+func canTransition(from, to PluginState) bool {
+	if from == to {
+		return true
+	}
+	for _, s := range stateTransitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// setState 设置插件状态并同步Enabled字段与存储，调用方需持有m.mutex
+func (m *Manager) setState(info *PluginInfo, state PluginState, reason string) error {
+	if info.State != "" && !canTransition(info.State, state) {
+		return fmt.Errorf("插件 %s 不能从状态 %s 切换到 %s", info.Name, info.State, state)
+	}
+
+	oldState := info.State
+	if state == StateEnabled && oldState != StateEnabled {
+		info.enabledSeq = m.enableSeq.Add(1)
+		m.breakers.reset(info.Name)
+	}
+	if oldState != state || info.StateReason != reason {
+		m.recordTransition(info, oldState, state, reason)
+	}
+	info.State = state
+	info.StateReason = reason
+	info.Enabled = state == StateEnabled
+	if oldState != "" && oldState != state {
+		m.notifyStateChange(info.Name, oldState, state, reason)
+	}
+
+	if stateStorage, ok := m.store().(PluginStateStorage); ok {
+		if err := stateStorage.SavePluginState(info.FilePath, state, reason); err != nil {
+			m.logger.Printf("保存插件 %s 状态到存储失败: %v", info.Name, err)
+		}
+	}
+	return nil
+}
+
+// recordTransition 记录状态迁移并更新迁移时间，调用方需持有m.mutex
+func (m *Manager) recordTransition(info *PluginInfo, from, to PluginState, reason string) {
+	now := time.Now()
+	info.StateChangedAt = now
+	info.history = append(info.history, StateTransition{From: from, To: to, Reason: reason, Time: now})
+	if over := len(info.history) - maxStateHistory; over > 0 {
+		info.history = append([]StateTransition(nil), info.history[over:]...)
+	}
+}
+
+// registerState 记录新登记插件的加载过程：discovered、打开插件文件时的loaded，以及登记时的状态，调用方需持有m.mutex
+func (m *Manager) registerState(info *PluginInfo, opened bool) {
+	m.recordTransition(info, "", StateDiscovered, "")
+	last := StateDiscovered
+	if opened {
+		m.recordTransition(info, last, StateLoaded, "")
+		last = StateLoaded
+	}
+	m.recordTransition(info, last, info.State, info.StateReason)
+}
+
+// PluginStateHistory 获取插件最近的状态迁移记录（按时间先后），用于在界面中解释插件未运行的原因
+func (m *Manager) PluginStateHistory(name string) ([]StateTransition, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	info, exists := m.plugins[name]
+	if !exists {
+		return nil, fmt.Errorf("插件不存在: %s", name)
+	}
+	return append([]StateTransition(nil), info.history...), nil
+}
+
+// GetPluginState 获取插件状态及原因
+func (m *Manager) GetPluginState(name string) (PluginState, string, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	info, exists := m.plugins[name]
+	if !exists {
+		return "", "", fmt.Errorf("插件不存在: %s", name)
+	}
+	return info.State, info.StateReason, nil
+}
+
+// QuarantinePlugin 隔离插件：关闭插件并记录原因，需管理员重新启用
+func (m *Manager) QuarantinePlugin(name, reason string) error {
+	if err := m.checkWritable(); err != nil {
+		return err
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	info, exists := m.plugins[name]
+	if !exists {
+		return fmt.Errorf("插件不存在: %s", name)
+	}
+
+	wasEnabled := info.Enabled
+	if err := m.setState(info, StateQuarantined, reason); err != nil {
+		return err
+	}
+
+	if wasEnabled {
+		if err := info.Plugin.Close(); err != nil {
+			m.logger.Printf("关闭插件 %s 失败: %v", name, err)
+		}
+		m.checkLeaks(name)
+		m.disableDependents(name)
+	}
+
+	if err := m.store().SavePlugin(info.Name, info.FilePath, false, info.Config); err != nil {
+		m.logger.Printf("更新插件状态到存储失败: %v", err)
+	}
+
+	m.logger.Printf("插件 %s 已被隔离: %s", name, reason)
+	return nil
+}
+
+// markCrashed 插件处理事件或执行管道阶段时发生panic，关闭插件并标记为crashed，需管理员重新启用。
+// 插件已被重新加载或已不在运行时不做处理，调用方不能持有m.mutex
+func (m *Manager) markCrashed(info *PluginInfo, reason string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	current, exists := m.plugins[info.Name]
+	if !exists || current != info || !info.Enabled {
+		return
+	}
+	if err := m.setState(info, StateCrashed, reason); err != nil {
+		m.logger.Printf("标记插件 %s 崩溃失败: %v", info.Name, err)
+		return
+	}
+
+	if err := info.Plugin.Close(); err != nil {
+		m.logger.Printf("关闭插件 %s 失败: %v", info.Name, err)
+	}
+	m.checkLeaks(info.Name)
+	m.disableDependents(info.Name)
+
+	if err := m.store().SavePlugin(info.Name, info.FilePath, false, info.Config); err != nil {
+		m.logger.Printf("更新插件状态到存储失败: %v", err)
+	}
+	m.logger.Printf("插件 %s 已崩溃并被关闭: %s", info.Name, reason)
+}
+
+// RequireApproval 将插件标记为等待管理员批准，批准方式为调用EnablePlugin
+func (m *Manager) RequireApproval(name, reason string) error {
+	if err := m.checkWritable(); err != nil {
+		return err
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	info, exists := m.plugins[name]
+	if !exists {
+		return fmt.Errorf("插件不存在: %s", name)
+	}
+	if info.Enabled {
+		return fmt.Errorf("插件 %s 已启用，请先禁用", name)
+	}
+
+	return m.setState(info, StatePendingApproval, reason)
+}
+
+// initialState 根据存储记录推导插件加载时的状态
+func initialState(record *PluginStorageInfo) PluginState {
+	if record == nil {
+		return StateDisabled
+	}
+
+	switch PluginState(record.State) {
+	case StateQuarantined, StateIncompatible, StatePendingApproval, StateInitFailed, StateCrashed:
+		return PluginState(record.State)
+	}
+
+	if record.Enabled {
+		return StateEnabled
+	}
+	return StateDisabled
+}
+
+// StateChangeHandler 插件状态变化回调，插件升级时reason为 "升级: v旧版本 -> v新版本"，此时新旧状态可能相同
+type StateChangeHandler func(name string, oldState, newState PluginState, reason string)
+
+// stateChange 等待通知的状态变化
+type stateChange struct {
+	name     string
+	oldState PluginState
+	newState PluginState
+	reason   string
+}
+
+// stateObservers 插件状态变化回调，回调在单独的goroutine中按发生顺序执行
+type stateObservers struct {
+	handlers   []StateChangeHandler
+	pending    []stateChange
+	delivering bool
+	mutex      sync.Mutex
+}
+
+func newStateObservers() *stateObservers {
+	return &stateObservers{}
+}
+
+// OnPluginStateChange 注册插件状态变化回调，插件被启用、禁用、隔离或升级时调用，
+// 宿主可以借此刷新界面缓存或发出自己的事件。回调在管理器锁之外异步执行，可以调用管理器的方法；
+// 插件首次加载时的初始状态不触发回调
+func (m *Manager) OnPluginStateChange(handler StateChangeHandler) {
+	m.stateObservers.mutex.Lock()
+	defer m.stateObservers.mutex.Unlock()
+
+	m.stateObservers.handlers = append(m.stateObservers.handlers, handler)
+}
+
+// notifyStateChange 将状态变化加入待通知队列，没有注册回调时直接丢弃
+func (m *Manager) notifyStateChange(name string, oldState, newState PluginState, reason string) {
+	o := m.stateObservers
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	if len(o.handlers) == 0 {
+		return
+	}
+	o.pending = append(o.pending, stateChange{name: name, oldState: oldState, newState: newState, reason: reason})
+	if !o.delivering {
+		o.delivering = true
+		goUnlabeled(m.deliverStateChanges)
+	}
+}
+
+// deliverStateChanges 依次执行待通知的状态变化回调，队列为空时退出
+func (m *Manager) deliverStateChanges() {
+	o := m.stateObservers
+	for {
+		o.mutex.Lock()
+		if len(o.pending) == 0 {
+			o.delivering = false
+			o.mutex.Unlock()
+			return
+		}
+		changes := o.pending
+		o.pending = nil
+		handlers := append([]StateChangeHandler{}, o.handlers...)
+		o.mutex.Unlock()
+
+		for _, change := range changes {
+			for _, handler := range handlers {
+				handler(change.name, change.oldState, change.newState, change.reason)
+			}
+		}
+	}
+}
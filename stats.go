@@ -0,0 +1,138 @@
+package plugins
+
+import (
+	"sync"
+	"time"
+)
+
+// statsFlushInterval 插件计数器写入存储的最小间隔
+const statsFlushInterval = 30 * time.Second
+
+// PluginStats 插件的持久化计数器
+type PluginStats struct {
+	EventsHandled int64     // 处理的事件数
+	Errors        int64     // 处理失败的事件数
+	LastActivity  time.Time // 最近一次处理事件的时间
+	LastError     string    // 最近一次错误
+	LastErrorAt   time.Time // 最近一次错误的时间
+}
+
+// PluginStatsStorage 插件计数器存储扩展接口（可选实现），用于在重启后保留计数
+type PluginStatsStorage interface {
+	// LoadPluginStats 读取插件计数器，不存在时返回nil
+	LoadPluginStats(name string) (*PluginStats, error)
+
+	// SavePluginStats 保存插件计数器
+	SavePluginStats(name string, stats PluginStats) error
+}
+
+// statsTracker 插件计数器
+type statsTracker struct {
+	stats     map[string]*PluginStats
+	dirty     map[string]bool
+	lastFlush time.Time
+	mutex     sync.Mutex
+}
+
+func newStatsTracker() *statsTracker {
+	return &statsTracker{
+		stats:     make(map[string]*PluginStats),
+		dirty:     make(map[string]bool),
+		lastFlush: time.Now(),
+	}
+}
+
+// get 获取插件计数器，首次访问时从存储中恢复，调用方需持有t.mutex
+func (t *statsTracker) get(store PluginStorage, logger Logger, name string) *PluginStats {
+	if stats, exists := t.stats[name]; exists {
+		return stats
+	}
+
+	stats := &PluginStats{}
+	if statsStorage, ok := store.(PluginStatsStorage); ok {
+		saved, err := statsStorage.LoadPluginStats(name)
+		if err != nil {
+			logger.Printf("读取插件 %s 计数器失败: %v", name, err)
+		} else if saved != nil {
+			stats = saved
+		}
+	}
+	t.stats[name] = stats
+	return stats
+}
+
+// recordPluginEvent 记录插件处理了一次事件
+func (m *Manager) recordPluginEvent(name string, err error) {
+	t := m.stats
+	now := time.Now()
+
+	t.mutex.Lock()
+	stats := t.get(m.store(), m.logger, name)
+	stats.EventsHandled++
+	stats.LastActivity = now
+	if err != nil {
+		stats.Errors++
+		stats.LastError = err.Error()
+		stats.LastErrorAt = now
+	}
+	t.dirty[name] = true
+
+	flush := now.Sub(t.lastFlush) >= statsFlushInterval
+	if flush {
+		t.lastFlush = now
+	}
+	t.mutex.Unlock()
+
+	if flush {
+		goUnlabeled(m.FlushStats)
+	}
+}
+
+// GetPluginStats 获取插件计数器
+func (m *Manager) GetPluginStats(name string) PluginStats {
+	m.stats.mutex.Lock()
+	defer m.stats.mutex.Unlock()
+
+	return *m.stats.get(m.store(), m.logger, name)
+}
+
+// AllPluginStats 获取所有已加载插件的计数器
+func (m *Manager) AllPluginStats() map[string]PluginStats {
+	m.mutex.RLock()
+	names := make([]string, 0, len(m.plugins))
+	for name := range m.plugins {
+		names = append(names, name)
+	}
+	m.mutex.RUnlock()
+
+	m.stats.mutex.Lock()
+	defer m.stats.mutex.Unlock()
+
+	result := make(map[string]PluginStats, len(names))
+	for _, name := range names {
+		result[name] = *m.stats.get(m.store(), m.logger, name)
+	}
+	return result
+}
+
+// FlushStats 将变化的插件计数器写入存储
+func (m *Manager) FlushStats() {
+	statsStorage, ok := m.store().(PluginStatsStorage)
+	if !ok {
+		return
+	}
+
+	m.stats.mutex.Lock()
+	pending := make(map[string]PluginStats, len(m.stats.dirty))
+	for name := range m.stats.dirty {
+		pending[name] = *m.stats.stats[name]
+	}
+	m.stats.dirty = make(map[string]bool)
+	m.stats.mutex.Unlock()
+
+	for name, stats := range pending {
+		if err := statsStorage.SavePluginStats(name, stats); err != nil {
+			m.logger.Printf("保存插件 %s 计数器失败: %v", name, err)
+		}
+	}
+}
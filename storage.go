@@ -11,10 +11,12 @@ type PluginStorage interface {
 
 // PluginStorageInfo 插件存储信息
 type PluginStorageInfo struct {
-	Name    string
-	Path    string
-	Enabled bool
-	Config  string // JSON格式的配置
+	Name        string
+	Path        string
+	Enabled     bool
+	Config      string // JSON格式的配置
+	State       string // 插件状态，为空时根据Enabled推导
+	StateReason string // 状态原因
 }
 
 // DefaultStorage 默认的存储实现（空实现）
@@ -31,12 +33,44 @@ func (d *DefaultStorage) SavePlugin(name, path string, enabled bool, config map[
 // 全局存储实例
 var storage PluginStorage = &DefaultStorage{}
 
-// SetStorage 设置存储实现
+// SetStorage 设置默认的存储实现，未通过WithStorage指定存储的管理器使用该存储
 func SetStorage(s PluginStorage) {
 	storage = s
 }
 
-// GetStorage 获取存储实现
+// GetStorage 获取默认的存储实现
 func GetStorage() PluginStorage {
 	return storage
 }
+
+// store 获取管理器使用的存储，未指定时使用默认存储
+func (m *Manager) store() PluginStorage {
+	if m.storage != nil {
+		return m.storage
+	}
+	return storage
+}
+
+// PluginStateStorage 插件状态存储扩展接口（可选实现）
+type PluginStateStorage interface {
+	// SavePluginState 保存插件状态及原因
+	SavePluginState(path string, state PluginState, reason string) error
+}
+
+// PluginDataStorage 插件数据存储扩展接口（可选实现），用于快照与恢复插件的KV数据
+type PluginDataStorage interface {
+	// ExportPluginData 导出插件的全部KV数据
+	ExportPluginData(name string) (map[string]string, error)
+
+	// ImportPluginData 导入插件的KV数据（覆盖已有数据）
+	ImportPluginData(name string, data map[string]string) error
+}
+
+// PluginListStorage 插件列表存储扩展接口（可选实现），用于发现已丢失文件的插件记录
+type PluginListStorage interface {
+	// ListPlugins 列出所有插件记录
+	ListPlugins() ([]*PluginStorageInfo, error)
+
+	// DeletePlugin 删除插件记录
+	DeletePlugin(path string) error
+}
@@ -0,0 +1,443 @@
+package plugins
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+// SubprocessPluginExt 独立进程插件的文件扩展名，插件是通过ServeSubprocess提供gRPC服务的可执行文件
+const SubprocessPluginExt = ".plugin"
+
+// windowsSubprocessSuffix Windows上独立进程插件的文件后缀，保留.exe以便系统识别为可执行文件
+const windowsSubprocessSuffix = SubprocessPluginExt + ".exe"
+
+const (
+	subprocessStartTimeout = 10 * time.Second // 等待插件进程握手的超时时间
+	subprocessCallTimeout  = 30 * time.Second // 单次调用插件进程的超时时间
+	subprocessStopTimeout  = 5 * time.Second  // 等待插件进程退出的超时时间
+)
+
+// isSubprocessPlugin 判断文件是否为独立进程插件，Windows上同时识别 .plugin.exe
+func isSubprocessPlugin(path string) bool {
+	if runtime.GOOS == "windows" && strings.HasSuffix(strings.ToLower(path), windowsSubprocessSuffix) {
+		return true
+	}
+	return strings.EqualFold(filepath.Ext(path), SubprocessPluginExt)
+}
+
+// subprocessPluginStem 返回去掉独立进程插件后缀的文件名
+func subprocessPluginStem(path string) string {
+	base := filepath.Base(path)
+	if strings.HasSuffix(strings.ToLower(base), windowsSubprocessSuffix) {
+		return base[:len(base)-len(windowsSubprocessSuffix)]
+	}
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+// openSubprocess 启动独立进程插件读取元数据后停止进程，进程在插件初始化时重新启动
+func (m *Manager) openSubprocess(pluginPath string) (*openedPlugin, error) {
+	manifest, err := loadManifest(pluginPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// 插件进程的环境变量按清单中的插件名称读取，没有清单时使用文件名
+	name := subprocessPluginStem(pluginPath)
+	if manifest != nil && manifest.Name != "" {
+		name = manifest.Name
+	}
+	environ, err := m.pluginEnviron(name)
+	if err != nil {
+		return nil, err
+	}
+
+	proc, err := startPluginProcess(pluginPath, environ, m.logger)
+	if err != nil {
+		return nil, err
+	}
+	defer proc.stop()
+
+	var meta rpcDescribeResponse
+	if err := proc.call("Describe", &rpcEmpty{}, &meta); err != nil {
+		return nil, fmt.Errorf("读取插件元数据失败: %v", err)
+	}
+	if meta.ProtocolVersion != SubprocessProtocolVersion {
+		return nil, fmt.Errorf("插件协议版本不兼容: 插件 %d，宿主 %d", meta.ProtocolVersion, SubprocessProtocolVersion)
+	}
+	if meta.Name == "" {
+		return nil, fmt.Errorf("插件名称不能为空")
+	}
+
+	// 每次启动插件进程时重新读取环境变量，使SetPluginEnv在下次启动时生效
+	instance := &subprocessPlugin{
+		path:    pluginPath,
+		environ: func() ([]string, error) { return m.pluginEnviron(name) },
+		logger:  m.logger,
+		meta:    meta,
+	}
+	m.injectHostAPI(instance, manifest)
+
+	return &openedPlugin{
+		instance:   instance,
+		apiVersion: 1,
+		manifest:   manifest,
+		build:      readBuildMeta(nil, pluginPath),
+	}, nil
+}
+
+// subprocessPlugin 独立进程插件在宿主一侧的代理，插件进程崩溃不会影响宿主，之后的调用返回错误直到重新启用
+type subprocessPlugin struct {
+	path    string
+	environ func() ([]string, error) // 读取插件进程当前的环境变量
+	logger  Logger
+	meta    rpcDescribeResponse
+	config  map[string]interface{}
+	proc    *pluginProcess // 运行中的插件进程，未初始化时为nil
+	mutex   sync.Mutex
+}
+
+func (p *subprocessPlugin) Name() string {
+	return p.meta.Name
+}
+
+func (p *subprocessPlugin) Version() string {
+	return p.meta.Version
+}
+
+func (p *subprocessPlugin) Description() string {
+	return p.meta.Description
+}
+
+func (p *subprocessPlugin) DefaultConfig() map[string]interface{} {
+	config := make(map[string]interface{}, len(p.meta.DefaultConfig))
+	for k, v := range p.meta.DefaultConfig {
+		config[k] = v
+	}
+	return config
+}
+
+func (p *subprocessPlugin) InterestedAPIs() []string {
+	return p.meta.InterestedAPIs
+}
+
+func (p *subprocessPlugin) InterestedEvents() []EventType {
+	return p.meta.InterestedEvents
+}
+
+// SetConfig 保存配置，插件进程运行中时同步到插件进程
+func (p *subprocessPlugin) SetConfig(config map[string]interface{}) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.config = config
+	if p.proc == nil {
+		return
+	}
+	if err := p.proc.call("SetConfig", &rpcConfigRequest{Config: config}, &rpcEmpty{}); err != nil {
+		p.logger.Printf("设置插件 %s 配置失败: %v", p.meta.Name, err)
+	}
+}
+
+// Init 启动插件进程并初始化插件，插件进程已退出时重新启动
+func (p *subprocessPlugin) Init() error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.proc != nil {
+		p.proc.stop()
+		p.proc = nil
+	}
+
+	environ, err := p.environ()
+	if err != nil {
+		return fmt.Errorf("读取插件环境变量失败: %v", err)
+	}
+	proc, err := startPluginProcess(p.path, environ, p.logger)
+	if err != nil {
+		return err
+	}
+
+	if p.config != nil {
+		if err := proc.call("SetConfig", &rpcConfigRequest{Config: p.config}, &rpcEmpty{}); err != nil {
+			proc.stop()
+			return fmt.Errorf("设置插件配置失败: %v", err)
+		}
+	}
+	if err := proc.call("Init", &rpcEmpty{}, &rpcEmpty{}); err != nil {
+		proc.stop()
+		return err
+	}
+
+	p.proc = proc
+	return nil
+}
+
+// Close 关闭插件并停止插件进程
+func (p *subprocessPlugin) Close() error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.proc == nil {
+		return nil
+	}
+
+	err := p.proc.call("Close", &rpcEmpty{}, &rpcEmpty{})
+	p.proc.stop()
+	p.proc = nil
+	return err
+}
+
+// OnAPIEvent 将事件转发给插件进程，gin.Context不会传递给插件进程
+func (p *subprocessPlugin) OnAPIEvent(ctx *gin.Context, event EventType, path string, statusCode int, requestBody interface{}, responseBody interface{}) error {
+	return p.forwardEvent(&rpcEventRequest{Event: event, Path: path, StatusCode: statusCode}, requestBody, responseBody)
+}
+
+// OnAPIEventWithID 将事件连同事件ID转发给插件进程
+func (p *subprocessPlugin) OnAPIEventWithID(ctx *gin.Context, eventID string, event EventType, path string, statusCode int, requestBody interface{}, responseBody interface{}) (*EventResult, error) {
+	req := &rpcEventRequest{EventID: eventID, Event: event, Path: path, StatusCode: statusCode}
+	return nil, p.forwardEvent(req, requestBody, responseBody)
+}
+
+// forwardEvent 编码请求体和响应体后将事件发送给插件进程
+func (p *subprocessPlugin) forwardEvent(req *rpcEventRequest, requestBody interface{}, responseBody interface{}) error {
+
+	var err error
+	if req.RequestBody, err = encodeRawBody(requestBody); err != nil {
+		return err
+	}
+	if req.ResponseBody, err = encodeRawBody(responseBody); err != nil {
+		return err
+	}
+
+	proc, err := p.running()
+	if err != nil {
+		return err
+	}
+	return proc.call("OnAPIEvent", req, &rpcEmpty{})
+}
+
+// Healthy 检查插件进程是否存活，插件实现了HealthChecker时同时调用其健康检查
+func (p *subprocessPlugin) Healthy(ctx context.Context) error {
+	proc, err := p.running()
+	if err != nil {
+		return err
+	}
+	return proc.callContext(ctx, "Healthy", &rpcEmpty{}, &rpcEmpty{})
+}
+
+// running 返回运行中的插件进程
+func (p *subprocessPlugin) running() (*pluginProcess, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.proc == nil {
+		return nil, fmt.Errorf("插件进程未运行")
+	}
+	if err := p.proc.exitError(); err != nil {
+		return nil, err
+	}
+	return p.proc, nil
+}
+
+// encodeRawBody 将请求体或响应体编码为JSON
+func encodeRawBody(body interface{}) (json.RawMessage, error) {
+	if body == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("序列化事件数据失败: %v", err)
+	}
+	return data, nil
+}
+
+// pluginProcess 运行中的插件进程
+type pluginProcess struct {
+	name     string
+	cmd      *exec.Cmd
+	stdin    io.WriteCloser
+	conn     *grpc.ClientConn
+	exited   chan struct{}
+	waitErr  error
+	stopping atomic.Bool
+}
+
+// startPluginProcess 启动插件进程，读取握手行后建立gRPC连接
+func startPluginProcess(pluginPath string, environ []string, logger Logger) (*pluginProcess, error) {
+	// 工作目录切换到插件目录，相对路径需先转为绝对路径
+	absPath, err := filepath.Abs(pluginPath)
+	if err != nil {
+		return nil, fmt.Errorf("解析插件路径失败: %v", err)
+	}
+	cmd := exec.Command(absPath)
+	cmd.Dir = filepath.Dir(absPath)
+	cmd.Env = append(os.Environ(), subprocessMagicEnv+"="+subprocessMagicValue)
+	cmd.Env = append(cmd.Env, environ...)
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("启动插件进程失败: %v", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("启动插件进程失败: %v", err)
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, fmt.Errorf("启动插件进程失败: %v", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("启动插件进程失败: %v", err)
+	}
+
+	proc := &pluginProcess{
+		name:   filepath.Base(pluginPath),
+		cmd:    cmd,
+		stdin:  stdin,
+		exited: make(chan struct{}),
+	}
+	go forwardOutput(logger, proc.name, stderr)
+
+	handshake := make(chan string, 1)
+	reader := bufio.NewReader(stdout)
+	go func() {
+		line, _ := reader.ReadString('\n')
+		handshake <- strings.TrimSpace(line)
+		forwardOutput(logger, proc.name, reader)
+	}()
+
+	go func() {
+		proc.waitErr = cmd.Wait()
+		close(proc.exited)
+		if !proc.stopping.Load() {
+			logger.Printf("插件进程 %s 意外退出: %v", proc.name, proc.waitErr)
+		}
+	}()
+
+	var line string
+	select {
+	case line = <-handshake:
+	case <-proc.exited:
+		return nil, fmt.Errorf("插件进程在握手前退出: %v", proc.waitErr)
+	case <-time.After(subprocessStartTimeout):
+		proc.stop()
+		return nil, fmt.Errorf("等待插件进程握手超时")
+	}
+
+	addr, err := parseHandshake(line)
+	if err != nil {
+		proc.stop()
+		return nil, err
+	}
+
+	conn, err := grpc.NewClient(addr,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(grpc.ForceCodec(jsonCodec{})),
+	)
+	if err != nil {
+		proc.stop()
+		return nil, fmt.Errorf("连接插件进程失败: %v", err)
+	}
+	proc.conn = conn
+	return proc, nil
+}
+
+// parseHandshake 解析握手行，返回插件进程的监听地址
+func parseHandshake(line string) (string, error) {
+	parts := strings.Split(line, "|")
+	if len(parts) != 4 || parts[0] != subprocessHandshakePrefix {
+		return "", fmt.Errorf("无效的插件握手信息: %q", line)
+	}
+
+	version, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return "", fmt.Errorf("无效的插件协议版本: %q", parts[1])
+	}
+	if version != SubprocessProtocolVersion {
+		return "", fmt.Errorf("插件协议版本不兼容: 插件 %d，宿主 %d", version, SubprocessProtocolVersion)
+	}
+	if parts[2] != "tcp" {
+		return "", fmt.Errorf("不支持的插件连接方式: %s", parts[2])
+	}
+	return parts[3], nil
+}
+
+// forwardOutput 将插件进程的输出逐行转发到日志
+func forwardOutput(logger Logger, name string, r io.Reader) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		logger.Printf("[%s] %s", name, scanner.Text())
+	}
+}
+
+// call 以默认超时调用插件进程
+func (p *pluginProcess) call(method string, req interface{}, resp interface{}) error {
+	ctx, cancel := context.WithTimeout(context.Background(), subprocessCallTimeout)
+	defer cancel()
+
+	return p.callContext(ctx, method, req, resp)
+}
+
+// callContext 调用插件进程，插件返回的错误还原为普通错误
+func (p *pluginProcess) callContext(ctx context.Context, method string, req interface{}, resp interface{}) error {
+	if err := p.exitError(); err != nil {
+		return err
+	}
+
+	err := p.conn.Invoke(ctx, subprocessMethodPath(method), req, resp)
+	if err == nil {
+		return nil
+	}
+	if exitErr := p.exitError(); exitErr != nil {
+		return exitErr
+	}
+	if s, ok := status.FromError(err); ok {
+		return errors.New(s.Message())
+	}
+	return err
+}
+
+// exitError 插件进程已退出时返回错误
+func (p *pluginProcess) exitError() error {
+	select {
+	case <-p.exited:
+		return fmt.Errorf("插件进程已退出: %v", p.waitErr)
+	default:
+		return nil
+	}
+}
+
+// stop 关闭连接和stdin通知插件进程退出，超时后强制结束
+func (p *pluginProcess) stop() {
+	p.stopping.Store(true)
+	if p.conn != nil {
+		_ = p.conn.Close()
+	}
+	_ = p.stdin.Close()
+
+	select {
+	case <-p.exited:
+	case <-time.After(subprocessStopTimeout):
+		_ = p.cmd.Process.Kill()
+		<-p.exited
+	}
+}
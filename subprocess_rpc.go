@@ -0,0 +1,114 @@
+package plugins
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"google.golang.org/grpc"
+)
+
+const (
+	// SubprocessProtocolVersion 独立进程插件的gRPC协议版本，宿主与插件版本不一致时拒绝加载
+	SubprocessProtocolVersion = 1
+
+	// subprocessServiceName 独立进程插件的gRPC服务名，随协议版本变化
+	subprocessServiceName = "sublink.plugin.v1.Plugin"
+
+	// subprocessCodecName 独立进程插件使用的消息编码
+	subprocessCodecName = "json"
+)
+
+// jsonCodec 以JSON编码gRPC消息，插件无需依赖protobuf生成代码
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+func (jsonCodec) Name() string {
+	return subprocessCodecName
+}
+
+// rpcEmpty 空消息
+type rpcEmpty struct{}
+
+// rpcDescribeResponse 插件元数据
+type rpcDescribeResponse struct {
+	ProtocolVersion  int                    `json:"protocol_version"`
+	Name             string                 `json:"name"`
+	Version          string                 `json:"version"`
+	Description      string                 `json:"description"`
+	DefaultConfig    map[string]interface{} `json:"default_config"`
+	InterestedAPIs   []string               `json:"interested_apis"`
+	InterestedEvents []EventType            `json:"interested_events"`
+}
+
+// rpcConfigRequest 插件配置
+type rpcConfigRequest struct {
+	Config map[string]interface{} `json:"config"`
+}
+
+// rpcEventRequest API事件，请求体和响应体以JSON传递
+type rpcEventRequest struct {
+	EventID      string          `json:"event_id,omitempty"`
+	Event        EventType       `json:"event"`
+	Path         string          `json:"path"`
+	StatusCode   int             `json:"status_code"`
+	RequestBody  json.RawMessage `json:"request_body,omitempty"`
+	ResponseBody json.RawMessage `json:"response_body,omitempty"`
+}
+
+// subprocessServer 插件进程一侧的gRPC服务实现
+type subprocessServer interface {
+	describe(ctx context.Context, req *rpcEmpty) (*rpcDescribeResponse, error)
+	setConfig(ctx context.Context, req *rpcConfigRequest) (*rpcEmpty, error)
+	initPlugin(ctx context.Context, req *rpcEmpty) (*rpcEmpty, error)
+	onAPIEvent(ctx context.Context, req *rpcEventRequest) (*rpcEmpty, error)
+	healthy(ctx context.Context, req *rpcEmpty) (*rpcEmpty, error)
+	close(ctx context.Context, req *rpcEmpty) (*rpcEmpty, error)
+}
+
+// subprocessMethod 生成gRPC一元方法描述
+func subprocessMethod[Req any, Resp any](name string, call func(subprocessServer, context.Context, *Req) (*Resp, error)) grpc.MethodDesc {
+	return grpc.MethodDesc{
+		MethodName: name,
+		Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+			req := new(Req)
+			if err := dec(req); err != nil {
+				return nil, err
+			}
+			if interceptor == nil {
+				return call(srv.(subprocessServer), ctx, req)
+			}
+			info := &grpc.UnaryServerInfo{Server: srv, FullMethod: subprocessMethodPath(name)}
+			return interceptor(ctx, req, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+				return call(srv.(subprocessServer), ctx, req.(*Req))
+			})
+		},
+	}
+}
+
+// subprocessMethodPath 返回gRPC方法的完整路径
+func subprocessMethodPath(name string) string {
+	return fmt.Sprintf("/%s/%s", subprocessServiceName, name)
+}
+
+// subprocessServiceDesc 独立进程插件的gRPC服务描述
+var subprocessServiceDesc = grpc.ServiceDesc{
+	ServiceName: subprocessServiceName,
+	HandlerType: (*subprocessServer)(nil),
+	Methods: []grpc.MethodDesc{
+		subprocessMethod("Describe", subprocessServer.describe),
+		subprocessMethod("SetConfig", subprocessServer.setConfig),
+		subprocessMethod("Init", subprocessServer.initPlugin),
+		subprocessMethod("OnAPIEvent", subprocessServer.onAPIEvent),
+		subprocessMethod("Healthy", subprocessServer.healthy),
+		subprocessMethod("Close", subprocessServer.close),
+	},
+	Metadata: "sublink/plugin/v1",
+}
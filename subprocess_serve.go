@@ -0,0 +1,169 @@
+package plugins
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+
+	"google.golang.org/grpc"
+)
+
+const (
+	// subprocessMagicEnv 宿主启动插件进程时设置的环境变量，用于确认插件由宿主启动
+	subprocessMagicEnv = "SUBLINK_PLUGIN_MAGIC_COOKIE"
+
+	// subprocessMagicValue subprocessMagicEnv的值
+	subprocessMagicValue = "7c1e5b0e-sublink-plugin"
+
+	// subprocessHandshakePrefix 插件进程输出的握手行前缀，握手行格式为 前缀|协议版本|网络|地址
+	subprocessHandshakePrefix = "sublink-plugin"
+)
+
+// ServeSubprocess 在独立进程插件的main函数中调用，通过gRPC向宿主提供插件，宿主关闭插件或退出后返回。
+// 插件可执行文件以 .plugin 为扩展名放入插件目录，不需要与宿主使用相同的Go版本和依赖版本
+func ServeSubprocess(p Plugin) error {
+	if os.Getenv(subprocessMagicEnv) != subprocessMagicValue {
+		return fmt.Errorf("该程序是SublinkPro插件，需由宿主进程启动")
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return fmt.Errorf("监听端口失败: %v", err)
+	}
+
+	srv := &pluginServer{plugin: p, done: make(chan struct{})}
+	server := grpc.NewServer(grpc.ForceServerCodec(jsonCodec{}))
+	server.RegisterService(&subprocessServiceDesc, srv)
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.Serve(listener)
+	}()
+
+	// 宿主通过stdout读取握手行，之后的输出转发到宿主日志
+	fmt.Fprintf(os.Stdout, "%s|%d|tcp|%s\n", subprocessHandshakePrefix, SubprocessProtocolVersion, listener.Addr())
+
+	// 宿主退出时stdin关闭，插件随之退出
+	hostGone := make(chan struct{})
+	go func() {
+		_, _ = io.Copy(io.Discard, os.Stdin)
+		close(hostGone)
+	}()
+
+	select {
+	case <-srv.done:
+		server.GracefulStop()
+		return nil
+	case <-hostGone:
+		server.Stop()
+		return srv.shutdown()
+	case err := <-serveErr:
+		_ = srv.shutdown()
+		return err
+	}
+}
+
+// pluginServer 插件进程一侧的gRPC服务
+type pluginServer struct {
+	plugin      Plugin
+	initialized bool
+	done        chan struct{}
+	closeOnce   sync.Once
+	mutex       sync.Mutex
+}
+
+func (s *pluginServer) describe(ctx context.Context, req *rpcEmpty) (*rpcDescribeResponse, error) {
+	return &rpcDescribeResponse{
+		ProtocolVersion:  SubprocessProtocolVersion,
+		Name:             s.plugin.Name(),
+		Version:          s.plugin.Version(),
+		Description:      s.plugin.Description(),
+		DefaultConfig:    s.plugin.DefaultConfig(),
+		InterestedAPIs:   interestedAPIs(s.plugin),
+		InterestedEvents: s.plugin.InterestedEvents(),
+	}, nil
+}
+
+func (s *pluginServer) setConfig(ctx context.Context, req *rpcConfigRequest) (*rpcEmpty, error) {
+	s.plugin.SetConfig(req.Config)
+	return &rpcEmpty{}, nil
+}
+
+func (s *pluginServer) initPlugin(ctx context.Context, req *rpcEmpty) (*rpcEmpty, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if err := s.plugin.Init(); err != nil {
+		return nil, err
+	}
+	s.initialized = true
+	return &rpcEmpty{}, nil
+}
+
+func (s *pluginServer) onAPIEvent(ctx context.Context, req *rpcEventRequest) (*rpcEmpty, error) {
+	requestBody, err := decodeRawBody(req.RequestBody)
+	if err != nil {
+		return nil, err
+	}
+	responseBody, err := decodeRawBody(req.ResponseBody)
+	if err != nil {
+		return nil, err
+	}
+
+	// gin.Context无法跨进程传递，独立进程插件收到的ctx为nil
+	if handler, ok := s.plugin.(IdempotentHandler); ok && req.EventID != "" {
+		_, err = handler.OnAPIEventWithID(nil, req.EventID, req.Event, req.Path, req.StatusCode, requestBody, responseBody)
+	} else {
+		err = s.plugin.OnAPIEvent(nil, req.Event, req.Path, req.StatusCode, requestBody, responseBody)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rpcEmpty{}, nil
+}
+
+func (s *pluginServer) healthy(ctx context.Context, req *rpcEmpty) (*rpcEmpty, error) {
+	if checker, ok := s.plugin.(HealthChecker); ok {
+		if err := checker.Healthy(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return &rpcEmpty{}, nil
+}
+
+func (s *pluginServer) close(ctx context.Context, req *rpcEmpty) (*rpcEmpty, error) {
+	err := s.shutdown()
+	s.closeOnce.Do(func() { close(s.done) })
+	if err != nil {
+		return nil, err
+	}
+	return &rpcEmpty{}, nil
+}
+
+// shutdown 关闭已初始化的插件
+func (s *pluginServer) shutdown() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if !s.initialized {
+		return nil
+	}
+	s.initialized = false
+	return s.plugin.Close()
+}
+
+// decodeRawBody 解码以JSON传递的请求体或响应体
+func decodeRawBody(raw json.RawMessage) (interface{}, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	var body interface{}
+	if err := json.Unmarshal(raw, &body); err != nil {
+		return nil, fmt.Errorf("解析事件数据失败: %v", err)
+	}
+	return body, nil
+}
@@ -0,0 +1,249 @@
+package plugins
+
+import (
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// defaultTapBuffer 事件镜像通道的默认缓冲大小
+	defaultTapBuffer = 64
+
+	// defaultTapBodyLimit 镜像事件中请求体和响应体的默认最大字节数
+	defaultTapBodyLimit = 64 << 10
+)
+
+// TapFilter 事件镜像的过滤条件，零值字段不限制
+type TapFilter struct {
+	Plugin     string      // 只镜像分发给该插件的事件
+	Events     []EventType // 事件类型
+	Method     string      // 请求方法
+	PathPrefix string      // 请求路径前缀
+	StatusCode int         // 响应状态码
+	SampleRate float64     // 采样比例，不在(0,1]之间时镜像全部匹配的事件
+	Buffer     int         // 通道缓冲大小，默认64；读取不及时时丢弃事件
+	BodyLimit  int         // 请求体和响应体的最大字节数，超出部分被截断，默认64KiB
+}
+
+// Event 镜像的事件，请求体和响应体是分发给插件的解码后数据的JSON
+type Event struct {
+	ID           string    `json:"id"`
+	Event        EventType `json:"event"`
+	Method       string    `json:"method,omitempty"`
+	Path         string    `json:"path"`
+	StatusCode   int       `json:"status_code"`
+	Time         time.Time `json:"time"`
+	Plugins      []string  `json:"plugins"` // 接收事件的插件，按分发顺序
+	RequestBody  string    `json:"request_body,omitempty"`
+	ResponseBody string    `json:"response_body,omitempty"`
+	Truncated    bool      `json:"truncated,omitempty"` // 请求体或响应体是否被截断
+}
+
+// eventTap 单个事件镜像
+type eventTap struct {
+	filter  TapFilter
+	ch      chan Event
+	dropped atomic.Uint64
+}
+
+// tapRegistry 已开启的事件镜像
+type tapRegistry struct {
+	taps  map[<-chan Event]*eventTap
+	count atomic.Int32
+	mutex sync.RWMutex
+}
+
+func newTapRegistry() *tapRegistry {
+	return &tapRegistry{taps: make(map[<-chan Event]*eventTap)}
+}
+
+// Tap 开启事件镜像，分发给插件的事件按过滤条件和采样比例复制到返回的通道，用于在生产环境中查看插件实际收到的数据而无需修改插件。
+// 通道读取不及时时丢弃事件而不阻塞分发；不再使用时需调用Untap关闭通道
+func (m *Manager) Tap(filter TapFilter) <-chan Event {
+	if filter.Buffer <= 0 {
+		filter.Buffer = defaultTapBuffer
+	}
+	if filter.BodyLimit <= 0 {
+		filter.BodyLimit = defaultTapBodyLimit
+	}
+	filter.Events = append([]EventType(nil), filter.Events...)
+
+	tap := &eventTap{filter: filter, ch: make(chan Event, filter.Buffer)}
+
+	m.taps.mutex.Lock()
+	defer m.taps.mutex.Unlock()
+
+	m.taps.taps[tap.ch] = tap
+	m.taps.count.Add(1)
+	return tap.ch
+}
+
+// Untap 关闭事件镜像及其通道，返回因读取不及时丢弃的事件数
+func (m *Manager) Untap(ch <-chan Event) uint64 {
+	m.taps.mutex.Lock()
+	defer m.taps.mutex.Unlock()
+
+	tap, exists := m.taps.taps[ch]
+	if !exists {
+		return 0
+	}
+	delete(m.taps.taps, ch)
+	m.taps.count.Add(-1)
+	close(tap.ch)
+	return tap.dropped.Load()
+}
+
+// mirrorEvent 将分发的事件复制到匹配的事件镜像，没有开启镜像时直接返回
+func (m *Manager) mirrorEvent(record *eventRecord, method string, requestBody, responseBody interface{}, targets ...[]*PluginInfo) {
+	if m.taps.count.Load() == 0 {
+		return
+	}
+
+	var plugins []string
+	for _, list := range targets {
+		for _, info := range list {
+			plugins = append(plugins, info.Name)
+		}
+	}
+
+	m.taps.mutex.RLock()
+	defer m.taps.mutex.RUnlock()
+
+	for _, tap := range m.taps.taps {
+		if !tap.matches(record, method, plugins) {
+			continue
+		}
+		event := Event{
+			ID:         record.ID,
+			Event:      record.Event,
+			Method:     method,
+			Path:       record.Path,
+			StatusCode: record.StatusCode,
+			Time:       record.Time,
+			Plugins:    plugins,
+		}
+		event.RequestBody, event.Truncated = m.tapBody(requestBody, tap.filter.BodyLimit)
+		var truncated bool
+		event.ResponseBody, truncated = m.tapBody(responseBody, tap.filter.BodyLimit)
+		event.Truncated = event.Truncated || truncated
+
+		select {
+		case tap.ch <- event:
+		default:
+			tap.dropped.Add(1)
+		}
+	}
+}
+
+// matches 判断事件是否匹配镜像的过滤条件和采样比例
+func (t *eventTap) matches(record *eventRecord, method string, plugins []string) bool {
+	f := t.filter
+	if f.Plugin != "" {
+		found := false
+		for _, name := range plugins {
+			if name == f.Plugin {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(f.Events) > 0 {
+		found := false
+		for _, event := range f.Events {
+			if event == record.Event {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if f.Method != "" && !strings.EqualFold(f.Method, method) {
+		return false
+	}
+	if f.PathPrefix != "" && !strings.HasPrefix(record.Path, f.PathPrefix) {
+		return false
+	}
+	if f.StatusCode != 0 && f.StatusCode != record.StatusCode {
+		return false
+	}
+	if f.SampleRate > 0 && f.SampleRate < 1 && rand.Float64() >= f.SampleRate {
+		return false
+	}
+	return true
+}
+
+// tapBody 将请求体或响应体编码为截断后的JSON文本
+func (m *Manager) tapBody(body interface{}, limit int) (string, bool) {
+	payload, err := EncodePayload(body, PayloadOptions{MaxSize: limit})
+	if err != nil {
+		m.logger.Printf("镜像事件数据失败: %v", err)
+		return "", false
+	}
+	if payload == nil {
+		return "", false
+	}
+	return string(payload.Data), payload.Truncated
+}
+
+// TapHandler 返回以Server-Sent Events输出事件镜像的Gin处理函数，路由由宿主自行注册并负责鉴权，
+// 如 admin.GET("/api/plugins/tap", manager.TapHandler())。
+// 查询参数 plugin、event（可重复）、method、path（路径前缀）、status、sample 对应TapFilter的字段，客户端断开时关闭镜像
+func (m *Manager) TapHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filter := TapFilter{
+			Plugin:     c.Query("plugin"),
+			Method:     c.Query("method"),
+			PathPrefix: c.Query("path"),
+		}
+		for _, event := range c.QueryArray("event") {
+			filter.Events = append(filter.Events, EventType(event))
+		}
+		if status := c.Query("status"); status != "" {
+			code, err := strconv.Atoi(status)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "status必须是整数"})
+				return
+			}
+			filter.StatusCode = code
+		}
+		if sample := c.Query("sample"); sample != "" {
+			rate, err := strconv.ParseFloat(sample, 64)
+			if err != nil || rate <= 0 || rate > 1 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "sample必须在0到1之间"})
+				return
+			}
+			filter.SampleRate = rate
+		}
+
+		ch := m.Tap(filter)
+		defer m.Untap(ch)
+
+		c.Header("Cache-Control", "no-cache")
+		c.Header("X-Accel-Buffering", "no")
+		c.Stream(func(w io.Writer) bool {
+			select {
+			case <-c.Request.Context().Done():
+				return false
+			case event, ok := <-ch:
+				if !ok {
+					return false
+				}
+				c.SSEvent("event", event)
+				return true
+			}
+		})
+	}
+}
@@ -0,0 +1,307 @@
+package plugins
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"reflect"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+	"unicode/utf8"
+)
+
+// ErrTemplateLimit 模板或渲染结果超出限制
+var ErrTemplateLimit = errors.New("模板超出限制")
+
+// TemplateFormat 模板格式
+type TemplateFormat string
+
+const (
+	TemplateText TemplateFormat = "text" // 纯文本，使用text/template，不做转义
+	TemplateHTML TemplateFormat = "html" // HTML，使用html/template，按上下文自动转义
+)
+
+// TemplateLimits 模板渲染限制，零值字段使用默认值
+type TemplateLimits struct {
+	MaxSourceSize int           // 模板源码的最大字节数
+	MaxOutputSize int           // 渲染结果的最大字节数
+	Timeout       time.Duration // 单次渲染的最长时间
+}
+
+// DefaultTemplateLimits 默认模板渲染限制
+var DefaultTemplateLimits = TemplateLimits{
+	MaxSourceSize: 64 << 10,
+	MaxOutputSize: 1 << 20,
+	Timeout:       2 * time.Second,
+}
+
+// templateSettings 模板渲染限制及宿主登记的辅助函数
+type templateSettings struct {
+	limits TemplateLimits
+	funcs  map[string]interface{}
+	mutex  sync.RWMutex
+}
+
+func newTemplateSettings() *templateSettings {
+	return &templateSettings{limits: DefaultTemplateLimits, funcs: make(map[string]interface{})}
+}
+
+// SetTemplateLimits 设置插件模板渲染的限制，零值字段使用默认值
+func (m *Manager) SetTemplateLimits(limits TemplateLimits) error {
+	if limits.MaxSourceSize < 0 || limits.MaxOutputSize < 0 || limits.Timeout < 0 {
+		return fmt.Errorf("模板渲染限制不能为负数")
+	}
+	if limits.MaxSourceSize == 0 {
+		limits.MaxSourceSize = DefaultTemplateLimits.MaxSourceSize
+	}
+	if limits.MaxOutputSize == 0 {
+		limits.MaxOutputSize = DefaultTemplateLimits.MaxOutputSize
+	}
+	if limits.Timeout == 0 {
+		limits.Timeout = DefaultTemplateLimits.Timeout
+	}
+
+	m.templates.mutex.Lock()
+	defer m.templates.mutex.Unlock()
+
+	m.templates.limits = limits
+	return nil
+}
+
+// RegisterTemplateFunc 登记宿主提供给插件模板的辅助函数，函数需返回一个值或一个值和error；
+// 不能覆盖内置辅助函数，fn为nil表示移除
+func (m *Manager) RegisterTemplateFunc(name string, fn interface{}) error {
+	if _, builtin := builtinTemplateFuncs(systemClock{})[name]; builtin {
+		return fmt.Errorf("模板函数 %s 与内置辅助函数同名", name)
+	}
+
+	m.templates.mutex.Lock()
+	defer m.templates.mutex.Unlock()
+
+	if fn == nil {
+		delete(m.templates.funcs, name)
+		return nil
+	}
+	if err := checkTemplateFunc(name, fn); err != nil {
+		return err
+	}
+	m.templates.funcs[name] = fn
+	return nil
+}
+
+// checkTemplateFunc 检查函数名称和签名能否用于模板
+func checkTemplateFunc(name string, fn interface{}) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("模板函数 %s 无效: %v", name, r)
+		}
+	}()
+	template.New("").Funcs(template.FuncMap{name: fn})
+	return nil
+}
+
+// RenderTemplate 以宿主的辅助函数渲染模板，受模板大小、输出大小和渲染时间限制
+func (h *pluginHost) RenderTemplate(format TemplateFormat, text string, data interface{}) (string, error) {
+	return h.m.renderTemplate(format, h.name, text, data, h.Clock())
+}
+
+// ValidateTemplate 检查模板能否解析
+func (h *pluginHost) ValidateTemplate(format TemplateFormat, text string) error {
+	_, _, err := h.m.parseTemplate(format, h.name, text, h.Clock())
+	return err
+}
+
+// parseTemplate 按格式解析模板，返回执行函数和渲染限制
+func (m *Manager) parseTemplate(format TemplateFormat, name, text string, clock Clock) (func(*limitedBuffer, interface{}) error, TemplateLimits, error) {
+	m.templates.mutex.RLock()
+	limits := m.templates.limits
+	funcs := builtinTemplateFuncs(clock)
+	for fname, fn := range m.templates.funcs {
+		funcs[fname] = fn
+	}
+	m.templates.mutex.RUnlock()
+
+	if len(text) > limits.MaxSourceSize {
+		return nil, limits, fmt.Errorf("%w: 模板大小 %d 字节超过 %d 字节", ErrTemplateLimit, len(text), limits.MaxSourceSize)
+	}
+
+	switch format {
+	case TemplateText, "":
+		tmpl, err := template.New(name).Option("missingkey=zero").Funcs(funcs).Parse(text)
+		if err != nil {
+			return nil, limits, fmt.Errorf("解析模板失败: %v", err)
+		}
+		return func(buf *limitedBuffer, data interface{}) error { return tmpl.Execute(buf, data) }, limits, nil
+	case TemplateHTML:
+		tmpl, err := htmltemplate.New(name).Option("missingkey=zero").Funcs(funcs).Parse(text)
+		if err != nil {
+			return nil, limits, fmt.Errorf("解析模板失败: %v", err)
+		}
+		return func(buf *limitedBuffer, data interface{}) error { return tmpl.Execute(buf, data) }, limits, nil
+	default:
+		return nil, limits, fmt.Errorf("不支持的模板格式: %s", format)
+	}
+}
+
+// renderTemplate 解析并在限制内渲染模板，超时后不再等待渲染结果
+func (m *Manager) renderTemplate(format TemplateFormat, name, text string, data interface{}, clock Clock) (string, error) {
+	execute, limits, err := m.parseTemplate(format, name, text, clock)
+	if err != nil {
+		return "", err
+	}
+
+	buf := &limitedBuffer{limit: limits.MaxOutputSize, deadline: time.Now().Add(limits.Timeout)}
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("渲染模板时发生panic: %v", r)
+			}
+		}()
+		done <- execute(buf, data)
+	}()
+
+	timer := time.NewTimer(limits.Timeout)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		if errors.Is(err, ErrTemplateLimit) {
+			return "", err
+		}
+		if err != nil {
+			return "", fmt.Errorf("渲染模板失败: %v", err)
+		}
+		return buf.String(), nil
+	case <-timer.C:
+		return "", fmt.Errorf("%w: 渲染超过 %v", ErrTemplateLimit, limits.Timeout)
+	}
+}
+
+// limitedBuffer 限制写入大小和时间的缓冲区，超出后写入返回错误使模板执行终止
+type limitedBuffer struct {
+	buf      bytes.Buffer
+	limit    int
+	deadline time.Time
+	mutex    sync.Mutex
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if time.Now().After(b.deadline) {
+		return 0, fmt.Errorf("%w: 渲染超时", ErrTemplateLimit)
+	}
+	if b.buf.Len()+len(p) > b.limit {
+		return 0, fmt.Errorf("%w: 渲染结果超过 %d 字节", ErrTemplateLimit, b.limit)
+	}
+	return b.buf.Write(p)
+}
+
+func (b *limitedBuffer) String() string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buf.String()
+}
+
+// builtinTemplateFuncs 内置辅助函数，now使用插件的时钟
+func builtinTemplateFuncs(clock Clock) map[string]interface{} {
+	return map[string]interface{}{
+		"upper":      strings.ToUpper,
+		"lower":      strings.ToLower,
+		"trim":       strings.TrimSpace,
+		"replace":    func(old, repl, s string) string { return strings.ReplaceAll(s, old, repl) },
+		"contains":   func(substr, s string) bool { return strings.Contains(s, substr) },
+		"hasPrefix":  func(prefix, s string) bool { return strings.HasPrefix(s, prefix) },
+		"hasSuffix":  func(suffix, s string) bool { return strings.HasSuffix(s, suffix) },
+		"join":       templateJoin,
+		"default":    templateDefault,
+		"truncate":   templateTruncate,
+		"now":        clock.Now,
+		"formatTime": func(layout string, t time.Time) string { return t.Format(layout) },
+		"formatBytes": func(v interface{}) (string, error) {
+			n, err := templateNumber(v)
+			if err != nil {
+				return "", err
+			}
+			return formatBytes(n), nil
+		},
+		"json": func(v interface{}) (string, error) {
+			data, err := json.Marshal(v)
+			return string(data), err
+		},
+	}
+}
+
+// templateJoin 以sep连接列表元素，用法 {{.Tags | join ", "}}
+func templateJoin(sep string, list interface{}) (string, error) {
+	v := reflect.ValueOf(list)
+	if !v.IsValid() {
+		return "", nil
+	}
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return "", fmt.Errorf("join的参数不是列表: %T", list)
+	}
+	parts := make([]string, v.Len())
+	for i := range parts {
+		parts[i] = fmt.Sprint(v.Index(i).Interface())
+	}
+	return strings.Join(parts, sep), nil
+}
+
+// templateDefault 值为空时使用默认值，用法 {{.Name | default "未命名"}}
+func templateDefault(def, value interface{}) interface{} {
+	v := reflect.ValueOf(value)
+	if !v.IsValid() || v.IsZero() {
+		return def
+	}
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map:
+		if v.Len() == 0 {
+			return def
+		}
+	}
+	return value
+}
+
+// templateTruncate 截断到n个字符，超出时以…结尾
+func templateTruncate(n int, s string) string {
+	if n <= 0 || utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:n]) + "…"
+}
+
+// templateNumber 将模板中的数值转换为float64
+func templateNumber(v interface{}) (float64, error) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), nil
+	default:
+		return 0, fmt.Errorf("不是数值: %T", v)
+	}
+}
+
+// formatBytes 以1024为单位格式化字节数，如 1.5 GiB
+func formatBytes(n float64) string {
+	units := []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB"}
+	i := 0
+	for n >= 1024 && i < len(units)-1 {
+		n /= 1024
+		i++
+	}
+	if i == 0 {
+		return fmt.Sprintf("%.0f %s", n, units[i])
+	}
+	return fmt.Sprintf("%.2f %s", n, units[i])
+}
@@ -0,0 +1,95 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Upgrader 需要在版本变化时迁移配置或KV数据的插件实现此接口（可选实现）。
+// 插件版本与上次启用时记录的版本不同时，在Init之前调用，fromVersion为上次启用时的版本；
+// 首次启用的插件不调用，与Init共用初始化超时时间。返回错误或超时时插件进入init_failed状态，记录的版本保持不变，修复后重新启用会再次调用
+type Upgrader interface {
+	Upgrade(fromVersion string) error
+}
+
+// PluginVersionStorage 插件版本存储扩展接口（可选实现），实现后重启后仍能发现插件版本变化；
+// 未实现时版本只记录在内存中，只能发现运行期间的重新加载
+type PluginVersionStorage interface {
+	// GetPluginVersion 获取插件上次启用时的版本，没有记录时返回空字符串
+	GetPluginVersion(name string) (string, error)
+
+	// SavePluginVersion 保存插件当前启用的版本
+	SavePluginVersion(name string, version string) error
+}
+
+// versionStore 内存中的插件版本记录
+type versionStore struct {
+	versions map[string]string
+	mutex    sync.Mutex
+}
+
+func newVersionStore() *versionStore {
+	return &versionStore{versions: make(map[string]string)}
+}
+
+// storedVersion 获取插件上次启用时记录的版本
+func (m *Manager) storedVersion(name string) (string, error) {
+	if versionStorage, ok := m.store().(PluginVersionStorage); ok {
+		return versionStorage.GetPluginVersion(name)
+	}
+
+	m.versions.mutex.Lock()
+	defer m.versions.mutex.Unlock()
+
+	return m.versions.versions[name], nil
+}
+
+// saveVersion 记录插件当前启用的版本
+func (m *Manager) saveVersion(name, version string) error {
+	if versionStorage, ok := m.store().(PluginVersionStorage); ok {
+		return versionStorage.SavePluginVersion(name, version)
+	}
+
+	m.versions.mutex.Lock()
+	defer m.versions.mutex.Unlock()
+
+	m.versions.versions[name] = version
+	return nil
+}
+
+// runUpgrade 插件版本与记录的版本不同时调用插件的Upgrade，成功后记录新版本，在Init之前调用；
+// ctx结束时不再等待Upgrade返回并返回错误，记录的版本保持不变
+func (m *Manager) runUpgrade(ctx context.Context, name string, p Plugin) error {
+	from, err := m.storedVersion(name)
+	if err != nil {
+		return fmt.Errorf("读取插件 %s 记录的版本失败: %v", name, err)
+	}
+	to := p.Version()
+	if from == to {
+		return nil
+	}
+
+	if upgrader, ok := p.(Upgrader); ok && from != "" {
+		done := make(chan error, 1)
+		go runWithPluginLabels(name, func() {
+			done <- upgrader.Upgrade(from)
+		})
+
+		var upgradeErr error
+		select {
+		case upgradeErr = <-done:
+		case <-ctx.Done():
+			upgradeErr = fmt.Errorf("超时或已取消: %v", ctx.Err())
+		}
+		if upgradeErr != nil {
+			return fmt.Errorf("升级数据失败（v%s -> v%s）: %v", from, to, upgradeErr)
+		}
+		m.logger.Printf("插件 %s 已完成数据升级: v%s -> v%s", name, from, to)
+	}
+
+	if err := m.saveVersion(name, to); err != nil {
+		m.logger.Printf("保存插件 %s 的版本失败: %v", name, err)
+	}
+	return nil
+}
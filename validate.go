@@ -0,0 +1,171 @@
+package plugins
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ValidationCheck 单项校验结果
+type ValidationCheck struct {
+	Name    string `json:"name"`
+	Passed  bool   `json:"passed"`
+	Message string `json:"message,omitempty"`
+}
+
+// ValidationReport 插件文件或清单的校验报告
+type ValidationReport struct {
+	Path          string                 `json:"path"`
+	Valid         bool                   `json:"valid"` // 所有校验项均通过
+	Name          string                 `json:"name,omitempty"`
+	Version       string                 `json:"version,omitempty"`
+	Description   string                 `json:"description,omitempty"`
+	APIVersion    int                    `json:"api_version,omitempty"`
+	Build         *BuildInfo             `json:"build,omitempty"`
+	Capabilities  []Capability           `json:"capabilities,omitempty"`
+	DefaultConfig map[string]interface{} `json:"default_config,omitempty"`
+	Manifest      *PluginManifest        `json:"manifest,omitempty"`
+	Checks        []ValidationCheck      `json:"checks"`
+	Warnings      []string               `json:"warnings,omitempty"` // 不影响加载但需要注意的问题
+}
+
+// check 记录一项校验结果，返回是否通过
+func (r *ValidationReport) check(name string, err error) bool {
+	if err != nil {
+		r.Checks = append(r.Checks, ValidationCheck{Name: name, Message: err.Error()})
+		r.Valid = false
+		return false
+	}
+	r.Checks = append(r.Checks, ValidationCheck{Name: name, Passed: true})
+	return true
+}
+
+// ValidatePlugin 预演校验插件文件或插件清单（<名称>.plugin.json 或 plugin.json），不登记插件、不写入存储。
+// 插件文件会被打开以检查导出符号、读取元数据并用清单中的config_schema校验默认配置，
+// 校验完成后关闭插件实例；Go原生插件一经打开无法从进程中卸载，请只校验可信来源的文件。
+// 返回的错误只表示无法进行校验，校验不通过的原因记录在报告中
+func (m *Manager) ValidatePlugin(path string) (*ValidationReport, error) {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("无法访问插件文件: %v", err)
+	}
+	if !stat.Mode().IsRegular() {
+		return nil, fmt.Errorf("插件文件不是普通文件: %s", path)
+	}
+
+	report := &ValidationReport{Path: path, Valid: true}
+	if isManifestFile(path) {
+		m.validateManifestFile(report, path)
+		return report, nil
+	}
+	if !isPluginFile(path) {
+		return nil, fmt.Errorf("不支持的插件文件类型: %s", path)
+	}
+
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	if !report.check("policy", m.checkPolicy("", path)) {
+		return report, nil
+	}
+
+	manifest, err := loadManifest(path)
+	report.Manifest = manifest
+	if !report.check("manifest", err) {
+		return report, nil
+	}
+	if !report.check("host_version", m.checkHostVersion(manifest)) {
+		return report, nil
+	}
+
+	// 打开插件文件：校验签名、检查导出符号和接口版本
+	opened, err := m.openPlugin(path)
+	if !report.check("open", err) {
+		return report, nil
+	}
+	instance := opened.instance
+	defer instance.Close()
+
+	report.Name = instance.Name()
+	report.Version = instance.Version()
+	report.Description = instance.Description()
+	report.APIVersion = opened.apiVersion
+	report.Build = opened.build
+	report.Capabilities = detectCapabilities(instance)
+	report.DefaultConfig = instance.DefaultConfig()
+
+	var metadataErr error
+	if report.Name == "" {
+		metadataErr = fmt.Errorf("插件名称为空")
+	} else if report.Version == "" {
+		metadataErr = fmt.Errorf("插件版本为空")
+	}
+	if report.check("metadata", metadataErr) {
+		report.check("policy_name", m.checkPolicy(report.Name, path))
+	}
+
+	var consistencyErr error
+	if manifest != nil && manifest.Name != "" && manifest.Name != report.Name {
+		consistencyErr = fmt.Errorf("插件名称 %s 与清单 %s 不一致", report.Name, manifest.Name)
+	} else if manifest != nil && manifest.Version != "" && manifest.Version != report.Version {
+		consistencyErr = fmt.Errorf("插件版本 %s 与清单 %s 不一致", report.Version, manifest.Version)
+	}
+	report.check("manifest_consistency", consistencyErr)
+	report.check("default_config", manifest.validateConfig(report.DefaultConfig))
+
+	// 与已加载插件的关系只作为提示
+	if existing, exists := m.plugins[report.Name]; exists {
+		report.Warnings = append(report.Warnings, fmt.Sprintf("已加载同名插件 v%s（%s）", existing.Version, existing.FilePath))
+	}
+	candidate := &PluginInfo{Name: report.Name, Version: report.Version, Plugin: instance, Manifest: manifest}
+	for _, unmet := range m.unmetDependencies(candidate) {
+		report.Warnings = append(report.Warnings, fmt.Sprintf("依赖未满足: %s", unmet))
+	}
+	report.Warnings = append(report.Warnings, m.subscriptionWarnings(candidate)...)
+
+	return report, nil
+}
+
+// validateManifestFile 校验单独上传的插件清单
+func (m *Manager) validateManifestFile(report *ValidationReport, path string) {
+	manifest, err := parseManifestFile(path)
+	if !report.check("manifest", err) {
+		return
+	}
+	report.Manifest = manifest
+	report.Name = manifest.Name
+	report.Version = manifest.Version
+	report.Description = manifest.Description
+
+	_, schemaErr := manifest.configSchema()
+	report.check("config_schema", schemaErr)
+	report.check("host_version", m.checkHostVersion(manifest))
+	if manifest.Name != "" {
+		report.check("policy_name", m.checkPolicy(manifest.Name, path))
+	}
+}
+
+// parseManifestFile 读取并校验指定路径的插件清单
+func parseManifestFile(path string) (*PluginManifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取插件清单失败: %v", err)
+	}
+
+	var manifest PluginManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("解析插件清单失败: %v", err)
+	}
+	if err := manifest.validate(); err != nil {
+		return nil, fmt.Errorf("插件清单无效: %v", err)
+	}
+	return &manifest, nil
+}
+
+// isManifestFile 判断文件是否为插件清单
+func isManifestFile(path string) bool {
+	base := filepath.Base(path)
+	return base == "plugin.json" || strings.HasSuffix(base, ".plugin.json")
+}
@@ -0,0 +1,281 @@
+package plugins
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// keptVersionMetaSuffix 保留版本的信息文件后缀，与保留的插件文件放在一起
+	keptVersionMetaSuffix = ".version.json"
+
+	// defaultKeptVersions 每个插件默认保留的历史版本数量
+	defaultKeptVersions = 3
+)
+
+// KeptVersion 升级时保留的插件历史版本
+type KeptVersion struct {
+	Name     string                 `json:"name"`
+	Version  string                 `json:"version"`
+	FileName string                 `json:"file_name"` // 保留的插件文件名，形如 <名称>@<版本>.so
+	Enabled  bool                   `json:"enabled"`
+	Config   map[string]interface{} `json:"config"`
+	Data     map[string]string      `json:"data,omitempty"` // 插件KV数据，存储支持时导出
+	KeptAt   time.Time              `json:"kept_at"`
+}
+
+// SetKeptVersions 设置每个插件保留的历史版本数量，n<=0时使用默认的3个，超出的旧版本在下次升级时删除
+func (m *Manager) SetKeptVersions(n int) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.keptVersions = n
+}
+
+// versionsRoot 历史版本目录，与归档目录一样放在插件目录之外，避免被加载和监听，调用方需持有m.mutex
+func (m *Manager) versionsRoot() string {
+	return filepath.Clean(m.pluginDir) + ".versions"
+}
+
+// keptVersionLimit 每个插件保留的历史版本数量，调用方需持有m.mutex
+func (m *Manager) keptVersionLimit() int {
+	if m.keptVersions > 0 {
+		return m.keptVersions
+	}
+	return defaultKeptVersions
+}
+
+// versionedFileName 生成带版本号的插件文件名，如 demo.so 在版本1.2.0时为 demo@1.2.0.so
+func versionedFileName(fileName, version string) string {
+	ext := filepath.Ext(fileName)
+	if strings.HasSuffix(strings.ToLower(fileName), windowsSubprocessSuffix) {
+		ext = fileName[len(fileName)-len(windowsSubprocessSuffix):]
+	}
+	stem := strings.TrimSuffix(fileName, ext)
+	if i := strings.LastIndex(stem, "@"); i > 0 {
+		stem = stem[:i]
+	}
+
+	// 版本号来自插件自身，替换掉不能出现在文件名中的字符
+	version = strings.Map(func(r rune) rune {
+		switch {
+		case r >= '0' && r <= '9', r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r == '.', r == '-', r == '+', r == '_':
+			return r
+		}
+		return '_'
+	}, version)
+	return stem + "@" + version + ext
+}
+
+// copyPluginPackage 将插件文件及其清单、签名复制为target，清单复制为target的专属清单
+func copyPluginPackage(src, target string) error {
+	if err := copyFile(src, target, 0755); err != nil {
+		return err
+	}
+	if manifest := manifestPath(src); manifest != "" {
+		if err := copyFile(manifest, sidecarManifestPath(target), 0644); err != nil {
+			return err
+		}
+	}
+	if _, err := os.Stat(src + signatureSuffix); err == nil {
+		if err := copyFile(src+signatureSuffix, target+signatureSuffix, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// versionSnapshot 记录插件当前版本的配置和数据，调用方需持有m.mutex
+func (m *Manager) versionSnapshot(info *PluginInfo) KeptVersion {
+	kept := KeptVersion{
+		Name:     info.Name,
+		Version:  info.Version,
+		FileName: versionedFileName(filepath.Base(info.FilePath), info.Version),
+		Enabled:  info.Enabled,
+		Config:   info.Config,
+	}
+	if dataStorage, ok := m.store().(PluginDataStorage); ok {
+		data, err := dataStorage.ExportPluginData(info.Name)
+		if err != nil {
+			m.logger.Printf("导出插件 %s 数据失败: %v", info.Name, err)
+		}
+		kept.Data = data
+	}
+	return kept
+}
+
+// keepVersion 将升级前的插件文件、清单、签名及versionSnapshot记录的配置和数据复制到历史版本目录，
+// 并删除超出保留数量的旧版本，调用方需持有m.mutex
+func (m *Manager) keepVersion(pluginPath string, kept KeptVersion) error {
+	dir := filepath.Join(m.versionsRoot(), kept.Name)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("创建历史版本目录失败: %v", err)
+	}
+	kept.KeptAt = time.Now()
+
+	if err := copyPluginPackage(pluginPath, filepath.Join(dir, kept.FileName)); err != nil {
+		return err
+	}
+
+	meta, err := json.MarshalIndent(kept, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化版本信息失败: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, kept.FileName+keptVersionMetaSuffix), meta, 0644); err != nil {
+		return fmt.Errorf("写入版本信息失败: %v", err)
+	}
+
+	versions, err := readKeptVersions(dir, m.logger)
+	if err != nil {
+		return err
+	}
+	for _, old := range versions[min(len(versions), m.keptVersionLimit()):] {
+		removeKeptVersion(dir, old, m.logger)
+	}
+
+	m.logger.Printf("已保留插件 %s v%s", kept.Name, kept.Version)
+	return nil
+}
+
+// PluginVersions 列出插件保留的历史版本，按保留时间从新到旧排列
+func (m *Manager) PluginVersions(name string) ([]KeptVersion, error) {
+	m.mutex.RLock()
+	dir := filepath.Join(m.versionsRoot(), name)
+	m.mutex.RUnlock()
+
+	return readKeptVersions(dir, m.logger)
+}
+
+// readKeptVersions 读取插件历史版本目录中的版本信息，按保留时间从新到旧排列
+func readKeptVersions(dir string, logger Logger) ([]KeptVersion, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取历史版本目录失败: %v", err)
+	}
+
+	var versions []KeptVersion
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), keptVersionMetaSuffix) {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			logger.Printf("读取版本信息 %s 失败: %v", entry.Name(), err)
+			continue
+		}
+		var kept KeptVersion
+		if err := json.Unmarshal(data, &kept); err != nil {
+			logger.Printf("解析版本信息 %s 失败: %v", entry.Name(), err)
+			continue
+		}
+		versions = append(versions, kept)
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].KeptAt.After(versions[j].KeptAt)
+	})
+	return versions, nil
+}
+
+// removeKeptVersion 删除保留的历史版本文件及其版本信息
+func removeKeptVersion(dir string, kept KeptVersion, logger Logger) {
+	paths := append(pluginPackageFiles(filepath.Join(dir, kept.FileName)), filepath.Join(dir, kept.FileName+keptVersionMetaSuffix))
+	for _, path := range paths {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			logger.Printf("删除插件 %s 的历史版本 v%s 失败: %v", kept.Name, kept.Version, err)
+		}
+	}
+}
+
+// RollbackPlugin 将插件回退到保留的历史版本：历史版本的文件以 <名称>@<版本> 命名放回插件目录，
+// 恢复该版本的配置和数据后重新加载，回退前的版本同样会被保留。回退后不会对插件调用Upgrade。
+// 注意：Go运行时无法卸载已打开的原生插件，回退到本进程中已打开过的原生插件版本会失败，需重启宿主
+func (m *Manager) RollbackPlugin(name, version string) (*PluginInfo, error) {
+	if err := m.checkWritable(); err != nil {
+		return nil, err
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	current, exists := m.plugins[name]
+	if !exists {
+		return nil, fmt.Errorf("插件不存在: %s", name)
+	}
+	if current.Version == version {
+		return nil, fmt.Errorf("插件 %s 当前已是 v%s", name, version)
+	}
+
+	dir := filepath.Join(m.versionsRoot(), name)
+	versions, err := readKeptVersions(dir, m.logger)
+	if err != nil {
+		return nil, err
+	}
+	var kept *KeptVersion
+	for i := range versions {
+		if versions[i].Version == version {
+			kept = &versions[i]
+			break
+		}
+	}
+	if kept == nil {
+		return nil, fmt.Errorf("插件 %s 没有保留 v%s", name, version)
+	}
+
+	if err := os.MkdirAll(m.pluginDir, 0755); err != nil {
+		return nil, fmt.Errorf("创建插件目录失败: %v", err)
+	}
+	target := filepath.Join(m.pluginDir, kept.FileName)
+	if _, err := os.Stat(target); err == nil {
+		return nil, fmt.Errorf("插件文件已存在: %s", target)
+	}
+
+	// 先复制回插件目录，回退失败时删除，历史版本保持不变
+	if err := copyPluginPackage(filepath.Join(dir, kept.FileName), target); err != nil {
+		return nil, err
+	}
+	cleanup := func() {
+		for _, path := range pluginPackageFiles(target) {
+			os.Remove(path)
+		}
+	}
+	if err := m.saveChecksum(target); err != nil {
+		cleanup()
+		return nil, err
+	}
+
+	// 回退的是旧版本，记录其版本号避免重新加载时把降级当作升级调用Upgrade
+	if err := m.saveVersion(name, kept.Version); err != nil {
+		m.logger.Printf("保存插件 %s 的版本失败: %v", name, err)
+	}
+
+	old, err := m.reloadPlugin(name, target, kept.Config, kept.Data)
+	if err != nil {
+		cleanup()
+		if err := m.saveVersion(name, current.Version); err != nil {
+			m.logger.Printf("保存插件 %s 的版本失败: %v", name, err)
+		}
+		return nil, fmt.Errorf("回退插件 %s 到 v%s 失败: %v", name, version, err)
+	}
+
+	// 回退前的版本已被保留时从插件目录中移除，避免再次加载时出现同名插件
+	keptFile := filepath.Join(dir, versionedFileName(filepath.Base(old.FilePath), old.Version))
+	if _, err := os.Stat(keptFile); err == nil && old.FilePath != target && m.isWritableDirFile(old.FilePath) {
+		for _, path := range pluginPackageFiles(old.FilePath) {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				m.logger.Printf("删除插件 %s 回退前的文件失败: %v", name, err)
+			}
+		}
+	}
+
+	info := m.plugins[name]
+	m.logger.Printf("已将插件 %s 从 v%s 回退到 v%s", name, old.Version, info.Version)
+	return info, nil
+}
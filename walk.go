@@ -0,0 +1,113 @@
+package plugins
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ignoredSuffixes 临时文件、备份文件和未上传完成的文件后缀，如 demo.so.tmp、demo.so.part
+var ignoredSuffixes = []string{".tmp", ".temp", ".bak", ".swp", ".part", ".partial", ".crdownload", ".download", ".uploading"}
+
+// SetFollowSymlinks 设置遍历插件目录时是否进入指向目录的符号链接，默认不进入；
+// 指向插件文件的符号链接始终按插件文件加载。重新加载插件或重新开启目录监听后生效
+func (m *Manager) SetFollowSymlinks(follow bool) {
+	m.followSymlinks.Store(follow)
+}
+
+// isIgnoredName 判断插件目录中的文件或子目录是否应被忽略：隐藏文件和目录（如.git）、
+// 编辑器备份文件（demo.so~、#demo.so#）以及临时和未上传完成的文件
+func isIgnoredName(name string) bool {
+	if strings.HasPrefix(name, ".") || strings.HasSuffix(name, "~") {
+		return true
+	}
+	if strings.HasPrefix(name, "#") && strings.HasSuffix(name, "#") {
+		return true
+	}
+	lower := strings.ToLower(name)
+	for _, suffix := range ignoredSuffixes {
+		if strings.HasSuffix(lower, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// isPartialFile 判断插件文件是否为空文件，上传或复制刚开始时文件为空，写入完成后由目录监听加载
+func isPartialFile(path string) bool {
+	stat, err := os.Stat(path)
+	return err == nil && stat.Mode().IsRegular() && stat.Size() == 0
+}
+
+// walkOptions 遍历插件目录的选项
+type walkOptions struct {
+	quarantine     string // 隔离目录
+	followSymlinks bool   // 是否进入指向目录的符号链接
+}
+
+// walkOptions 获取当前的遍历选项，调用方需持有m.mutex
+func (m *Manager) walkOptions() walkOptions {
+	return walkOptions{quarantine: filepath.Clean(m.quarantineRoot()), followSymlinks: m.followSymlinks.Load()}
+}
+
+// walkPluginTree 按名称顺序遍历插件搜索目录，对每个遍历到的目录调用onDir，对每个插件文件调用onFile（可以为nil）。
+// 跳过被忽略的文件和目录、隔离目录、插件包中的资源目录和空文件；进入符号链接目录时避免重复遍历同一目录
+func walkPluginTree(root string, opts walkOptions, onDir func(dir string) error, onFile func(path string) error) error {
+	visited := make(map[string]bool)
+
+	var walk func(dir string) error
+	walk = func(dir string) error {
+		if real, err := filepath.EvalSymlinks(dir); err == nil {
+			if visited[real] {
+				return nil
+			}
+			visited[real] = true
+		}
+		if onDir != nil {
+			if err := onDir(dir); err != nil {
+				return err
+			}
+		}
+
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if isIgnoredName(entry.Name()) {
+				continue
+			}
+			path := filepath.Join(dir, entry.Name())
+
+			isDir := entry.IsDir()
+			if entry.Type()&os.ModeSymlink != 0 {
+				stat, err := os.Stat(path)
+				if err != nil {
+					continue // 失效的符号链接
+				}
+				if stat.IsDir() && !opts.followSymlinks {
+					continue
+				}
+				isDir = stat.IsDir()
+			}
+
+			if isDir {
+				if filepath.Clean(path) == opts.quarantine || isPackageResourceDir(path) {
+					continue
+				}
+				if err := walk(path); err != nil {
+					return err
+				}
+				continue
+			}
+			if onFile == nil || !isPluginFile(path) || isPartialFile(path) {
+				continue
+			}
+			if err := onFile(path); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return walk(root)
+}
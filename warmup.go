@@ -0,0 +1,43 @@
+package plugins
+
+import (
+	"context"
+	"time"
+)
+
+// defaultWarmupTimeout 默认插件预热超时时间
+const defaultWarmupTimeout = 30 * time.Second
+
+// Warmer 预热接口（可选实现），在Init之后、插件标记为就绪之前调用，用于预热缓存或与远端握手
+type Warmer interface {
+	Warmup(ctx context.Context) error
+}
+
+// SetWarmupTimeout 设置插件预热超时时间，0表示不限制
+func (m *Manager) SetWarmupTimeout(timeout time.Duration) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.warmupTimeout = timeout
+}
+
+// runWarmup 在超时约束下执行插件预热
+func runWarmup(ctx context.Context, timeout time.Duration, name string, w Warmer) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	done := make(chan error, 1)
+	go runWithPluginLabels(name, func() {
+		done <- w.Warmup(ctx)
+	})
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
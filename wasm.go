@@ -0,0 +1,331 @@
+package plugins
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+)
+
+// WasmPluginExt WASM插件的文件扩展名
+const WasmPluginExt = ".wasm"
+
+// wasmCallTimeout 单次调用WASM插件导出函数的超时时间
+const wasmCallTimeout = 5 * time.Second
+
+// wasmHostModule 宿主函数所在的导入模块名称
+const wasmHostModule = "sublink"
+
+func init() {
+	loaders[WasmPluginExt] = builtinLoader(loadWasmPlugin)
+}
+
+// loadWasmPlugin 加载WASM插件，由wazero执行，支持wasip1编译的模块（如 GOOS=wasip1 -buildmode=c-shared 或TinyGo）。
+// 插件与宿主之间以JSON交换数据，参数以(指针, 长度)传入，返回值为 指针<<32|长度 的i64，0表示无返回值：
+//
+//	导出 memory                                  线性内存
+//	导出 sublink_alloc(size i32) i32             分配size字节，供宿主写入参数
+//	导出 sublink_free(ptr i32, size i32)         可选，宿主用完参数和返回值后调用
+//	导出 sublink_describe() i64                  返回插件描述：{"name","version","description","apis","events","default_config"}
+//	导出 sublink_init(ptr i32, len i32) i64      可选，启用时调用，参数为 {"config": {...}}
+//	导出 sublink_on_event(ptr i32, len i32) i64  参数为 {"type","path","status_code","request_body","response_body","config"}
+//	导出 sublink_close() i64                     可选，禁用时调用
+//	导入 sublink.log(ptr i32, len i32)           输出日志
+//
+// sublink_init、sublink_on_event和sublink_close的返回值非空时为错误信息。描述中未声明events时接收所有内置的API事件和宿主业务事件。
+// 模块不支持并发调用，所有调用串行执行，每次调用的执行时间受wasmCallTimeout限制；
+// 超时的调用会终止模块实例，之后的调用返回错误，重新启用插件时创建新的实例
+func loadWasmPlugin(path string, logger Logger) (Plugin, error) {
+	code, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取WASM模块失败: %v", err)
+	}
+
+	ctx := context.Background()
+	runtime := wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfig().WithCloseOnContextDone(true))
+	p := &wasmPlugin{runtime: runtime}
+	if err := p.prepare(ctx, code, logger); err != nil {
+		_ = runtime.Close(ctx)
+		return nil, err
+	}
+
+	out, err := p.call("sublink_describe", nil)
+	if err != nil {
+		_ = runtime.Close(ctx)
+		return nil, err
+	}
+	var desc struct {
+		Name          string                 `json:"name"`
+		Version       string                 `json:"version"`
+		Description   string                 `json:"description"`
+		APIs          []string               `json:"apis"`
+		Events        []EventType            `json:"events"`
+		DefaultConfig map[string]interface{} `json:"default_config"`
+	}
+	if out == nil {
+		_ = runtime.Close(ctx)
+		return nil, fmt.Errorf("WASM模块未导出sublink_describe函数或未返回描述: %s", path)
+	}
+	if err := json.Unmarshal(out, &desc); err != nil {
+		_ = runtime.Close(ctx)
+		return nil, fmt.Errorf("解析WASM插件描述失败: %v", err)
+	}
+
+	p.name = desc.Name
+	if p.name == "" {
+		p.name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	p.version = desc.Version
+	p.description = desc.Description
+	p.apis = desc.APIs
+	p.events = desc.Events
+	if p.events == nil {
+		p.events = builtinEvents()
+	}
+	p.defaultConfig = desc.DefaultConfig
+	return p, nil
+}
+
+// wasmPlugin WASM插件，模块实例不支持并发调用，所有调用串行执行
+type wasmPlugin struct {
+	name          string
+	version       string
+	description   string
+	apis          []string
+	events        []EventType
+	defaultConfig map[string]interface{}
+	config        map[string]interface{}
+
+	runtime  wazero.Runtime
+	compiled wazero.CompiledModule
+	module   api.Module // 当前的模块实例，禁用或被终止后为nil
+	mutex    sync.Mutex
+}
+
+// prepare 登记宿主函数、编译模块并创建第一个实例
+func (p *wasmPlugin) prepare(ctx context.Context, code []byte, logger Logger) error {
+	if _, err := wasi_snapshot_preview1.Instantiate(ctx, p.runtime); err != nil {
+		return fmt.Errorf("初始化WASI失败: %v", err)
+	}
+
+	_, err := p.runtime.NewHostModuleBuilder(wasmHostModule).
+		NewFunctionBuilder().
+		WithFunc(func(ctx context.Context, mod api.Module, ptr, size uint32) {
+			if data, ok := mod.Memory().Read(ptr, size); ok {
+				logger.Printf("[wasm] %s", data)
+			}
+		}).
+		Export("log").
+		Instantiate(ctx)
+	if err != nil {
+		return fmt.Errorf("登记宿主函数失败: %v", err)
+	}
+
+	if p.compiled, err = p.runtime.CompileModule(ctx, code); err != nil {
+		return fmt.Errorf("编译WASM模块失败: %v", err)
+	}
+	for _, name := range []string{"sublink_alloc", "sublink_on_event"} {
+		if _, ok := p.compiled.ExportedFunctions()[name]; !ok {
+			return fmt.Errorf("WASM模块未导出%s函数", name)
+		}
+	}
+	if _, ok := p.compiled.ExportedMemories()["memory"]; !ok {
+		return fmt.Errorf("WASM模块未导出memory")
+	}
+	return p.instantiate(ctx)
+}
+
+// instantiate 创建模块实例，wasip1 reactor模块的_initialize在创建时执行
+func (p *wasmPlugin) instantiate(ctx context.Context) error {
+	config := wazero.NewModuleConfig().
+		WithName("").
+		WithStartFunctions("_initialize").
+		WithSysWalltime().
+		WithSysNanotime().
+		WithRandSource(rand.Reader)
+
+	module, err := p.runtime.InstantiateModule(ctx, p.compiled, config)
+	if err != nil {
+		return fmt.Errorf("创建WASM模块实例失败: %v", err)
+	}
+	p.module = module
+	return nil
+}
+
+func (p *wasmPlugin) Name() string {
+	return p.name
+}
+
+func (p *wasmPlugin) Version() string {
+	return p.version
+}
+
+func (p *wasmPlugin) Description() string {
+	return p.description
+}
+
+func (p *wasmPlugin) DefaultConfig() map[string]interface{} {
+	config := make(map[string]interface{}, len(p.defaultConfig))
+	for k, v := range p.defaultConfig {
+		config[k] = v
+	}
+	return config
+}
+
+func (p *wasmPlugin) SetConfig(config map[string]interface{}) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.config = config
+}
+
+func (p *wasmPlugin) Init() error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	// 禁用或超时后重新启用时创建新的实例
+	if p.module == nil {
+		if err := p.instantiate(context.Background()); err != nil {
+			return err
+		}
+	}
+	return p.invoke("sublink_init", map[string]interface{}{"config": p.config})
+}
+
+func (p *wasmPlugin) Close() error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.module == nil {
+		return nil
+	}
+	err := p.invoke("sublink_close", nil)
+	if p.module != nil {
+		_ = p.module.Close(context.Background())
+		p.module = nil
+	}
+	return err
+}
+
+func (p *wasmPlugin) OnAPIEvent(ctx *gin.Context, event EventType, path string, statusCode int, requestBody interface{}, responseBody interface{}) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return p.invoke("sublink_on_event", map[string]interface{}{
+		"type":          string(event),
+		"path":          path,
+		"status_code":   statusCode,
+		"request_body":  requestBody,
+		"response_body": responseBody,
+		"config":        p.config,
+	})
+}
+
+func (p *wasmPlugin) InterestedAPIs() []string {
+	return p.apis
+}
+
+func (p *wasmPlugin) InterestedEvents() []EventType {
+	return p.events
+}
+
+// invoke 以JSON参数调用导出函数，函数未导出时忽略，返回值非空时作为错误信息，调用方需持有p.mutex
+func (p *wasmPlugin) invoke(name string, args interface{}) error {
+	var input []byte
+	if args != nil {
+		var err error
+		if input, err = json.Marshal(args); err != nil {
+			return fmt.Errorf("序列化%s的参数失败: %v", name, err)
+		}
+	}
+
+	out, err := p.call(name, input)
+	if err != nil {
+		return err
+	}
+	if out != nil {
+		return fmt.Errorf("%s", out)
+	}
+	return nil
+}
+
+// call 在超时限制内调用导出函数并读取返回值，input为nil时不传参数，函数未导出时返回nil。
+// 调用超时或模块退出时实例被关闭，调用方需持有p.mutex（加载时除外）
+func (p *wasmPlugin) call(name string, input []byte) ([]byte, error) {
+	if p.module == nil {
+		return nil, fmt.Errorf("WASM模块实例已终止，需重新启用插件")
+	}
+	fn := p.module.ExportedFunction(name)
+	if fn == nil {
+		return nil, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), wasmCallTimeout)
+	defer cancel()
+
+	var params []uint64
+	if input != nil {
+		ptr, err := p.write(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+		defer p.free(ctx, ptr, uint32(len(input)))
+		params = []uint64{uint64(ptr), uint64(len(input))}
+	}
+
+	results, err := fn.Call(ctx, params...)
+	if err != nil {
+		if p.module.IsClosed() {
+			p.module = nil
+		}
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("调用%s执行超时（%v）", name, wasmCallTimeout)
+		}
+		return nil, fmt.Errorf("调用%s失败: %v", name, err)
+	}
+	if len(results) == 0 || results[0] == 0 {
+		return nil, nil
+	}
+
+	ptr, size := uint32(results[0]>>32), uint32(results[0])
+	data, ok := p.module.Memory().Read(ptr, size)
+	if !ok {
+		return nil, fmt.Errorf("%s的返回值超出内存范围", name)
+	}
+	out := append([]byte(nil), data...)
+	p.free(ctx, ptr, size)
+	return out, nil
+}
+
+// write 在模块内存中分配空间并写入数据
+func (p *wasmPlugin) write(ctx context.Context, data []byte) (uint32, error) {
+	results, err := p.module.ExportedFunction("sublink_alloc").Call(ctx, uint64(len(data)))
+	if err != nil {
+		return 0, fmt.Errorf("分配WASM内存失败: %v", err)
+	}
+	ptr := uint32(results[0])
+	if !p.module.Memory().Write(ptr, data) {
+		return 0, fmt.Errorf("写入WASM内存失败: 地址超出内存范围")
+	}
+	return ptr, nil
+}
+
+// free 释放sublink_alloc分配的内存或返回值，模块未导出sublink_free时忽略
+func (p *wasmPlugin) free(ctx context.Context, ptr, size uint32) {
+	if p.module == nil {
+		return
+	}
+	if fn := p.module.ExportedFunction("sublink_free"); fn != nil {
+		_, _ = fn.Call(ctx, uint64(ptr), uint64(size))
+	}
+}
@@ -0,0 +1,221 @@
+package plugins
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// defaultWatchDebounce 默认的文件变化防抖时间，避免在文件复制过程中加载不完整的插件
+const defaultWatchDebounce = 2 * time.Second
+
+// pluginWatcher 插件目录监听器
+type pluginWatcher struct {
+	watcher  *fsnotify.Watcher
+	debounce time.Duration
+	timers   map[string]*time.Timer
+	mutex    sync.Mutex
+	done     chan struct{}
+}
+
+// StartWatcher 开始监听插件目录，自动加载新增、重新加载变化、卸载删除的插件文件；debounce<=0时使用默认值
+func (m *Manager) StartWatcher(debounce time.Duration) error {
+	if err := m.checkWritable(); err != nil {
+		return err
+	}
+
+	m.watchMutex.Lock()
+	defer m.watchMutex.Unlock()
+
+	if m.watcher != nil {
+		return fmt.Errorf("插件目录监听已开启")
+	}
+	if debounce <= 0 {
+		debounce = defaultWatchDebounce
+	}
+
+	fw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("创建文件监听器失败: %v", err)
+	}
+
+	// fsnotify不会递归监听，需要逐个添加子目录
+	m.mutex.RLock()
+	dirs, opts := m.pluginDirs(), m.walkOptions()
+	m.mutex.RUnlock()
+	for _, dir := range dirs {
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			continue
+		}
+		// 隔离目录、插件包中的资源目录和被忽略的目录不监听
+		err = walkPluginTree(dir, opts, fw.Add, nil)
+		if err != nil {
+			fw.Close()
+			return fmt.Errorf("监听插件目录失败: %v", err)
+		}
+	}
+
+	w := &pluginWatcher{
+		watcher:  fw,
+		debounce: debounce,
+		timers:   make(map[string]*time.Timer),
+		done:     make(chan struct{}),
+	}
+	m.watcher = w
+
+	go m.runWatcher(w)
+
+	m.logger.Printf("开始监听插件目录: %s", strings.Join(dirs, ", "))
+	return nil
+}
+
+// StopWatcher 停止监听插件目录
+func (m *Manager) StopWatcher() {
+	m.watchMutex.Lock()
+	defer m.watchMutex.Unlock()
+
+	w := m.watcher
+	if w == nil {
+		return
+	}
+	m.watcher = nil
+
+	close(w.done)
+	w.watcher.Close()
+
+	w.mutex.Lock()
+	for _, timer := range w.timers {
+		timer.Stop()
+	}
+	w.mutex.Unlock()
+
+	m.logger.Printf("已停止监听插件目录")
+}
+
+// IsWatching 是否正在监听插件目录
+func (m *Manager) IsWatching() bool {
+	m.watchMutex.Lock()
+	defer m.watchMutex.Unlock()
+
+	return m.watcher != nil
+}
+
+// runWatcher 处理文件变化事件
+func (m *Manager) runWatcher(w *pluginWatcher) {
+	for {
+		select {
+		case <-w.done:
+			return
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			m.logger.Printf("插件目录监听出错: %v", err)
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+
+			// 新建的子目录加入监听
+			if event.Has(fsnotify.Create) {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					m.mutex.RLock()
+					skipped := m.isSkippedDir(event.Name) || isIgnoredName(filepath.Base(event.Name))
+					m.mutex.RUnlock()
+					if link, err := os.Lstat(event.Name); err == nil && link.Mode()&os.ModeSymlink != 0 && !m.followSymlinks.Load() {
+						skipped = true
+					}
+					if !skipped {
+						_ = w.watcher.Add(event.Name)
+					}
+					continue
+				}
+			}
+
+			if !isPluginFile(event.Name) || isIgnoredName(filepath.Base(event.Name)) {
+				continue
+			}
+			w.schedule(event.Name, func() { m.handleFileChange(event.Name) })
+		}
+	}
+}
+
+// schedule 防抖：同一文件在防抖时间内的多次变化只处理最后一次
+func (w *pluginWatcher) schedule(path string, fn func()) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if timer, exists := w.timers[path]; exists {
+		timer.Stop()
+	}
+	w.timers[path] = time.AfterFunc(w.debounce, func() {
+		w.mutex.Lock()
+		delete(w.timers, path)
+		w.mutex.Unlock()
+
+		fn()
+	})
+}
+
+// handleFileChange 根据文件当前状态加载、重新加载或卸载插件
+func (m *Manager) handleFileChange(path string) {
+	if m.IsMaintenanceMode() {
+		m.logger.Printf("维护模式下忽略插件文件变化: %s", path)
+		return
+	}
+
+	name, loaded := m.pluginNameByPath(path)
+	_, statErr := os.Stat(path)
+
+	switch {
+	case os.IsNotExist(statErr) && loaded:
+		if err := m.UnloadPlugin(name); err != nil {
+			m.logger.Printf("自动卸载插件 %s 失败: %v", name, err)
+		}
+	case statErr == nil && loaded:
+		if err := m.ReloadPlugin(name); err != nil {
+			m.logger.Printf("自动重新加载插件 %s 失败: %v", name, err)
+		}
+	case statErr == nil && isPartialFile(path):
+		// 文件还在写入，写入完成后的变化事件会再次触发加载
+	case statErr == nil:
+		m.mutex.Lock()
+		if reason := m.filterReason(m.searchDirOf(path), path); reason != "" {
+			m.mutex.Unlock()
+			m.logger.Printf("插件文件 %s 被加载过滤规则跳过: %s", path, reason)
+			return
+		}
+		if reason := artifactSkipReason(path); reason != "" {
+			m.mutex.Unlock()
+			m.logger.Printf("跳过插件文件 %s: %s", path, reason)
+			return
+		}
+		_, err := m.loadPlugin(path)
+		if err != nil && isFileFailure(err) {
+			m.quarantineFile(path, err)
+		}
+		m.mutex.Unlock()
+		if err != nil {
+			m.logger.Printf("自动加载插件失败 %s: %v", path, err)
+		}
+	}
+}
+
+// pluginNameByPath 根据文件路径查找已加载的插件名称
+func (m *Manager) pluginNameByPath(path string) (string, bool) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	clean := filepath.Clean(path)
+	for name, info := range m.plugins {
+		if filepath.Clean(info.FilePath) == clean {
+			return name, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,208 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultWidgetTimeout 单个仪表盘组件获取数据的默认超时时间
+const defaultWidgetTimeout = 2 * time.Second
+
+// WidgetKind 仪表盘组件的数据类型
+type WidgetKind string
+
+const (
+	WidgetStat   WidgetKind = "stat"   // 单个统计值，如今日转换次数
+	WidgetSeries WidgetKind = "series" // 一条或多条时间序列，如每小时请求数
+)
+
+// Widget 插件提供的仪表盘组件，Data在每次请求仪表盘数据时调用，应在ctx取消前返回
+type Widget struct {
+	ID    string // 组件标识，同一插件内唯一
+	Title string
+	Kind  WidgetKind
+	Data  func(ctx context.Context) (*WidgetData, error)
+}
+
+// WidgetProvider 仪表盘组件能力：插件在宿主首页展示自己的统计数据
+type WidgetProvider interface {
+	// Widgets 返回插件提供的仪表盘组件
+	Widgets() []Widget
+}
+
+// WidgetData 组件数据，按组件类型填写Stat或Series
+type WidgetData struct {
+	Stat   *WidgetStatValue   `json:"stat,omitempty"`
+	Series []WidgetSeriesData `json:"series,omitempty"`
+}
+
+// WidgetStatValue 统计值
+type WidgetStatValue struct {
+	Value float64  `json:"value"`
+	Unit  string   `json:"unit,omitempty"`
+	Delta *float64 `json:"delta,omitempty"` // 与上一周期相比的变化量（可选）
+}
+
+// WidgetSeriesData 时间序列
+type WidgetSeriesData struct {
+	Name   string              `json:"name"`
+	Points []WidgetSeriesPoint `json:"points"`
+}
+
+// WidgetSeriesPoint 时间序列中的数据点
+type WidgetSeriesPoint struct {
+	Time  time.Time `json:"time"`
+	Value float64   `json:"value"`
+}
+
+// DashboardWidget 汇总后的仪表盘组件数据，获取失败时Data为nil、Error为原因
+type DashboardWidget struct {
+	Plugin    string      `json:"plugin"`
+	ID        string      `json:"id"`
+	Title     string      `json:"title"`
+	Kind      WidgetKind  `json:"kind"`
+	Data      *WidgetData `json:"data,omitempty"`
+	Error     string      `json:"error,omitempty"`
+	UpdatedAt time.Time   `json:"updated_at"`
+}
+
+// SetWidgetTimeout 设置单个仪表盘组件获取数据的超时时间，d<=0时恢复默认值
+func (m *Manager) SetWidgetTimeout(d time.Duration) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if d <= 0 {
+		d = defaultWidgetTimeout
+	}
+	m.widgetTimeout = d
+}
+
+// DashboardWidgets 并发获取所有已启用插件的仪表盘组件数据，按插件优先级和插件给出的顺序排列。
+// 单个组件超时、出错或panic只影响该组件
+func (m *Manager) DashboardWidgets(ctx context.Context) []DashboardWidget {
+	m.mutex.RLock()
+	var providers []*PluginInfo
+	for _, info := range m.plugins {
+		if !info.Enabled {
+			continue
+		}
+		if _, ok := info.Plugin.(WidgetProvider); ok {
+			providers = append(providers, info)
+		}
+	}
+	sortByPriority(providers)
+	timeout := m.widgetTimeout
+	m.mutex.RUnlock()
+
+	result := make([]DashboardWidget, 0)
+	var fetchers []func()
+	for _, info := range providers {
+		for _, widget := range m.pluginWidgets(info) {
+			i := len(result)
+			result = append(result, DashboardWidget{
+				Plugin: info.Name,
+				ID:     widget.ID,
+				Title:  widget.Title,
+				Kind:   widget.Kind,
+			})
+			fetchers = append(fetchers, func() {
+				data, err := fetchWidget(ctx, timeout, widget)
+				result[i].Data, result[i].UpdatedAt = data, time.Now()
+				if err != nil {
+					result[i].Data, result[i].Error = nil, err.Error()
+				}
+			})
+		}
+	}
+
+	var wg sync.WaitGroup
+	for _, fetch := range fetchers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fetch()
+		}()
+	}
+	wg.Wait()
+	return result
+}
+
+// DashboardHandler 返回输出仪表盘组件数据的Gin处理函数，路由由宿主自行注册，
+// 如 engine.GET("/api/dashboard/widgets", manager.DashboardHandler())
+func (m *Manager) DashboardHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"widgets": m.DashboardWidgets(c.Request.Context())})
+	}
+}
+
+// pluginWidgets 获取插件声明的组件，跳过标识为空或重复的组件
+func (m *Manager) pluginWidgets(info *PluginInfo) (widgets []Widget) {
+	defer func() {
+		if r := recover(); r != nil {
+			m.logger.Printf("插件 %s 获取仪表盘组件时发生panic: %v", info.Name, r)
+			widgets = nil
+		}
+	}()
+
+	seen := make(map[string]bool)
+	for _, widget := range info.Plugin.(WidgetProvider).Widgets() {
+		if widget.ID == "" || seen[widget.ID] || widget.Data == nil {
+			m.logger.Printf("插件 %s 的仪表盘组件 %q 无效或重复，已忽略", info.Name, widget.ID)
+			continue
+		}
+		seen[widget.ID] = true
+		widgets = append(widgets, widget)
+	}
+	return widgets
+}
+
+// fetchWidget 在超时时间内获取组件数据，并检查数据与组件类型一致
+func fetchWidget(ctx context.Context, timeout time.Duration, widget Widget) (*WidgetData, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	type outcome struct {
+		data *WidgetData
+		err  error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- outcome{err: fmt.Errorf("获取组件数据时发生panic: %v", r)}
+			}
+		}()
+		data, err := widget.Data(ctx)
+		done <- outcome{data: data, err: err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("获取组件数据超时: %v", ctx.Err())
+	case out := <-done:
+		if out.err != nil {
+			return nil, out.err
+		}
+		if out.data == nil {
+			return nil, fmt.Errorf("组件没有返回数据")
+		}
+		switch widget.Kind {
+		case WidgetStat:
+			if out.data.Stat == nil {
+				return nil, fmt.Errorf("统计组件没有返回统计值")
+			}
+		case WidgetSeries:
+			if out.data.Series == nil {
+				return nil, fmt.Errorf("时间序列组件没有返回时间序列")
+			}
+		default:
+			return nil, fmt.Errorf("不支持的组件类型: %s", widget.Kind)
+		}
+		return out.data, nil
+	}
+}
@@ -0,0 +1,212 @@
+package plugins
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// 延迟直方图：第i个区间的上界为 latencyBase * 2^(i/latencyBinsPerDouble)，最后一个区间收纳所有更长的耗时
+const (
+	latencyBase          = 100 * time.Microsecond
+	latencyBinsPerDouble = 4
+	latencyBins          = 80 // 约覆盖到100秒
+)
+
+// statsWindowSpec 滚动窗口的时长和桶数，窗口按桶滚动，统计结果精确到一个桶的时长
+type statsWindowSpec struct {
+	window  time.Duration
+	buckets int
+}
+
+// statsWindows 维护的滚动窗口：1分钟、5分钟和1小时
+var statsWindows = []statsWindowSpec{
+	{window: time.Minute, buckets: 12},
+	{window: 5 * time.Minute, buckets: 10},
+	{window: time.Hour, buckets: 12},
+}
+
+// WindowStats 插件在滚动时间窗口内的处理统计
+type WindowStats struct {
+	Window    time.Duration // 窗口时长
+	Count     int64         // 处理的事件数
+	Errors    int64         // 处理失败的事件数
+	ErrorRate float64       // 失败比例，没有事件时为0
+	P50       time.Duration // 耗时中位数（近似值）
+	P90       time.Duration
+	P99       time.Duration
+	Max       time.Duration
+}
+
+// windowBucket 一个时间桶内的计数和耗时直方图
+type windowBucket struct {
+	start  int64 // 桶的起始时间（Unix纳秒，按桶时长对齐）
+	count  int64
+	errors int64
+	max    time.Duration
+	hist   [latencyBins]uint32
+}
+
+// windowRing 单个窗口的环形桶
+type windowRing struct {
+	span    time.Duration
+	buckets []windowBucket
+}
+
+// pluginWindows 单个插件所有窗口的统计
+type pluginWindows struct {
+	rings []*windowRing
+}
+
+// windowTracker 插件滚动窗口统计，只保存在内存中
+type windowTracker struct {
+	plugins map[string]*pluginWindows
+	mutex   sync.Mutex
+}
+
+func newWindowTracker() *windowTracker {
+	return &windowTracker{plugins: make(map[string]*pluginWindows)}
+}
+
+// recordLatency 记录插件一次事件处理的耗时和结果
+func (m *Manager) recordLatency(name string, elapsed time.Duration, failed bool) {
+	now := time.Now()
+
+	t := m.windows
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	windows, exists := t.plugins[name]
+	if !exists {
+		windows = &pluginWindows{}
+		for _, spec := range statsWindows {
+			windows.rings = append(windows.rings, &windowRing{
+				span:    spec.window / time.Duration(spec.buckets),
+				buckets: make([]windowBucket, spec.buckets),
+			})
+		}
+		t.plugins[name] = windows
+	}
+
+	bin := latencyBin(elapsed)
+	for _, ring := range windows.rings {
+		b := ring.bucket(now)
+		b.count++
+		if failed {
+			b.errors++
+		}
+		if elapsed > b.max {
+			b.max = elapsed
+		}
+		b.hist[bin]++
+	}
+}
+
+// PluginWindowStats 获取插件在1分钟、5分钟和1小时滚动窗口内的耗时分位数和错误率
+func (m *Manager) PluginWindowStats(name string) []WindowStats {
+	now := time.Now()
+
+	m.windows.mutex.Lock()
+	defer m.windows.mutex.Unlock()
+
+	windows := m.windows.plugins[name]
+	result := make([]WindowStats, 0, len(statsWindows))
+	for i, spec := range statsWindows {
+		stats := WindowStats{Window: spec.window}
+		if windows != nil {
+			stats = windows.rings[i].snapshot(now, spec.window)
+		}
+		result = append(result, stats)
+	}
+	return result
+}
+
+// AllPluginWindowStats 获取所有已加载插件的滚动窗口统计
+func (m *Manager) AllPluginWindowStats() map[string][]WindowStats {
+	m.mutex.RLock()
+	names := make([]string, 0, len(m.plugins))
+	for name := range m.plugins {
+		names = append(names, name)
+	}
+	m.mutex.RUnlock()
+
+	result := make(map[string][]WindowStats, len(names))
+	for _, name := range names {
+		result[name] = m.PluginWindowStats(name)
+	}
+	return result
+}
+
+// bucket 返回当前时间所在的桶，桶已过期时清空重用
+func (r *windowRing) bucket(now time.Time) *windowBucket {
+	start := now.UnixNano() / int64(r.span) * int64(r.span)
+	b := &r.buckets[(start/int64(r.span))%int64(len(r.buckets))]
+	if b.start != start {
+		*b = windowBucket{start: start}
+	}
+	return b
+}
+
+// snapshot 合并窗口内的桶并计算分位数
+func (r *windowRing) snapshot(now time.Time, window time.Duration) WindowStats {
+	stats := WindowStats{Window: window}
+	oldest := now.Add(-window).UnixNano()
+
+	var hist [latencyBins]uint64
+	for i := range r.buckets {
+		b := &r.buckets[i]
+		if b.count == 0 || b.start+int64(r.span) <= oldest {
+			continue
+		}
+		stats.Count += b.count
+		stats.Errors += b.errors
+		if b.max > stats.Max {
+			stats.Max = b.max
+		}
+		for j, n := range b.hist {
+			hist[j] += uint64(n)
+		}
+	}
+
+	if stats.Count == 0 {
+		return stats
+	}
+	stats.ErrorRate = float64(stats.Errors) / float64(stats.Count)
+	stats.P50 = percentile(hist[:], stats.Count, 0.50, stats.Max)
+	stats.P90 = percentile(hist[:], stats.Count, 0.90, stats.Max)
+	stats.P99 = percentile(hist[:], stats.Count, 0.99, stats.Max)
+	return stats
+}
+
+// percentile 按直方图估算分位数，取所在区间的上界，不超过最大耗时
+func percentile(hist []uint64, count int64, q float64, max time.Duration) time.Duration {
+	rank := uint64(math.Ceil(q * float64(count)))
+	var cumulative uint64
+	for i, n := range hist {
+		cumulative += n
+		if cumulative >= rank {
+			if bound := latencyBound(i); bound < max {
+				return bound
+			}
+			return max
+		}
+	}
+	return max
+}
+
+// latencyBin 返回耗时所在的直方图区间
+func latencyBin(d time.Duration) int {
+	if d <= latencyBase {
+		return 0
+	}
+	bin := int(math.Ceil(latencyBinsPerDouble * math.Log2(float64(d)/float64(latencyBase))))
+	if bin >= latencyBins {
+		return latencyBins - 1
+	}
+	return bin
+}
+
+// latencyBound 返回直方图区间的上界
+func latencyBound(bin int) time.Duration {
+	return time.Duration(float64(latencyBase) * math.Pow(2, float64(bin)/latencyBinsPerDouble))
+}